@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"gateman.io/application/constants"
 	"gateman.io/infrastructure/logger"
 	server_response "gateman.io/infrastructure/serverResponse"
 )
@@ -43,6 +44,10 @@ func ErrorProcessingPayload(ctx interface{}, deviceID *string) {
 	server_response.Responder.Respond(ctx, http.StatusBadRequest, "Abnormal payload passed 🤨", nil, nil, nil, deviceID)
 }
 
+func PayloadTooLargeError(ctx interface{}, deviceID *string) {
+	server_response.Responder.Respond(ctx, http.StatusRequestEntityTooLarge, "Payload too large 🫨", nil, nil, nil, deviceID)
+}
+
 func FatalServerError(ctx interface{}, err error, deviceID string) {
 	// logger.MetricMonitor.ReportError(err, nil, nil, nil)
 	server_response.Responder.Respond(ctx, http.StatusInternalServerError,
@@ -73,6 +78,11 @@ func UnsupportedUserAgent(ctx interface{}, deviceID string) {
 		"unsupported user agent 👮🏻‍♂️", nil, nil, nil, &deviceID)
 }
 
+func EncryptionKeyExpired(ctx interface{}, deviceID string) {
+	server_response.Responder.Respond(ctx, http.StatusUnauthorized,
+		"encryption key has expired. initiate key exchange protocol again.", nil, nil, &constants.ENCRYPTION_KEY_EXPIRED, &deviceID)
+}
+
 func MalformedHeader(ctx interface{}, deviceID *string) {
 	// logger.MetricMonitor.ReportError(errors.New("unspported user agent"), []logger.LoggerOptions{
 	// 	{Key: "ctx",
@@ -15,8 +15,13 @@ var ACCOUNT_EXISTS_EMAIL_OR_PHONE_UNVERIFIED uint = 9140 // take the user to the
 var FREE_TIER_ACCOUNT_LIMIT_HIT uint = 5243              // display a page telling the user the limit has been hit
 var SET_APP_PIN uint = 1433                              // display a page telling the user the limit has been hit
 var VERIFY_WORKSPACE_MEMBER_EMAIL uint = 1937            // display a page telling the user the limit has been hit
+var APP_MFA_REQUIRED uint = 2381                         // ask the user for a code from their authenticator app or a recovery code
+var SET_UP_APP_MFA uint = 2391                           // take the user to the authenticator set up page for the app
+var WORKSPACE_MFA_REQUIRED uint = 2401                   // ask the member for a code from their authenticator app or a recovery code
+var SET_UP_WORKSPACE_MFA uint = 2411                     // take the member to the authenticator set up page using the returned otpAccessToken
+var LOOKUP_QUOTA_EXCEEDED uint = 5253                    // the app has used the lookups its plan includes this month
 
-var AVAILABLE_REQUIRED_DATA_POINTS = []string{"BVN", "NIN", "FirstName", "LastName", "Gender", "MiddleName", "DOB", "Image", "Email", "Phone", "LoginLocale"} // include "address" later
+var AVAILABLE_REQUIRED_DATA_POINTS = []string{"BVN", "NIN", "Document", "NINFace", "BVNFace", "FirstName", "LastName", "Gender", "MiddleName", "DOB", "Image", "Email", "Phone", "LoginLocale"} // include "address" later
 var CUSTOM_FIELD_TYPES = []string{"long_text", "short_text", "switch", "dropdown", "number", "secret", "pin", "date"}
 
 var MAX_ORGANISATIONS_CREATED int64 = 20
@@ -27,3 +32,14 @@ var FREE_TIER_MAU_LIMIT int64 = 10_000
 var PAID_TIER_FREE_MAU_LIMIT int64 = 40_000
 var ESSENTIAL_TIER_MAU_PRICE int64 = 20_00
 var PREMIUM_TIER_MAU_PRICE int64 = 12_00
+
+// monthly lookups of the public api included in each plan. Paid plans are billed the overage price for every lookup
+// past them with their next charge, Free apps are stopped. Premium government id lookups are unlimited.
+var FREE_TIER_ID_LOOKUP_LIMIT int64 = 50
+var ESSENTIAL_TIER_ID_LOOKUP_LIMIT int64 = 1_000
+var FREE_TIER_BIOMETRIC_LOOKUP_LIMIT int64 = 100
+var ESSENTIAL_TIER_BIOMETRIC_LOOKUP_LIMIT int64 = 2_000
+var PREMIUM_TIER_BIOMETRIC_LOOKUP_LIMIT int64 = 10_000
+var ESSENTIAL_TIER_ID_LOOKUP_PRICE int64 = 100_00
+var ESSENTIAL_TIER_BIOMETRIC_LOOKUP_PRICE int64 = 20_00
+var PREMIUM_TIER_BIOMETRIC_LOOKUP_PRICE int64 = 15_00
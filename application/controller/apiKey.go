@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/controller/dto"
+	"gateman.io/application/interfaces"
+	"gateman.io/application/repository"
+	services "gateman.io/application/services/application"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/logger"
+	server_response "gateman.io/infrastructure/serverResponse"
+	"gateman.io/infrastructure/validator"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func CreateAppAPIKey(ctx *interfaces.ApplicationContext[dto.CreateAPIKeyDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	if ctx.Body.ExpiresAt != nil && ctx.Body.ExpiresAt.Before(time.Now()) {
+		apperrors.ClientError(ctx.Ctx, "expiresAt must be in the future", nil, nil, ctx.DeviceID)
+		return
+	}
+	app := fetchAPIKeyApp(ctx.Ctx, ctx.GetStringParameter("id"), ctx.GetStringContextData("WorkspaceID"), ctx.DeviceID)
+	if app == nil {
+		return
+	}
+	apiKey, plainKey, err := services.CreateAPIKey(app, ctx.Body.Label, ctx.Body.Scopes, ctx.Body.Sandbox, ctx.Body.ExpiresAt, ctx.GetStringContextData("UserID"), nil)
+	if err != nil {
+		handleAPIKeyError(ctx.Ctx, err, app.AppID, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusCreated, "API key created. This will only be displayed once", map[string]any{
+		"apiKey":  plainKey,
+		"details": apiKey,
+	}, nil, nil, &ctx.DeviceID)
+}
+
+func FetchAppAPIKeys(ctx *interfaces.ApplicationContext[any]) {
+	app := fetchAPIKeyApp(ctx.Ctx, ctx.GetStringParameter("id"), ctx.GetStringContextData("WorkspaceID"), ctx.DeviceID)
+	if app == nil {
+		return
+	}
+	apiKeyRepo := repository.APIKeyRepo()
+	apiKeys, err := apiKeyRepo.FindMany(map[string]interface{}{
+		"appID":       app.AppID,
+		"workspaceID": app.WorkspaceID,
+	}, options.Find().SetSort(map[string]any{
+		"createdAt": -1,
+	}))
+	if err != nil {
+		logger.Error("an error occured while fetching app api keys", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "appID",
+			Data: app.AppID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "API keys fetched", apiKeys, nil, nil, &ctx.DeviceID)
+}
+
+// Issues a replacement for a key. The old key keeps working for the overlap so integrations can be switched without downtime.
+func RotateAppAPIKey(ctx *interfaces.ApplicationContext[dto.RotateAPIKeyDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	app := fetchAPIKeyApp(ctx.Ctx, ctx.GetStringParameter("id"), ctx.GetStringContextData("WorkspaceID"), ctx.DeviceID)
+	if app == nil {
+		return
+	}
+	apiKey, err := services.FindAppAPIKey(app.AppID, app.WorkspaceID, ctx.GetStringParameter("keyID"))
+	if err != nil {
+		handleAPIKeyError(ctx.Ctx, err, app.AppID, ctx.DeviceID)
+		return
+	}
+	overlapHours := uint16(24)
+	if ctx.Body.OverlapHours != nil {
+		overlapHours = *ctx.Body.OverlapHours
+	}
+	newKey, plainKey, err := services.RotateAPIKey(app, apiKey, time.Hour*time.Duration(overlapHours), ctx.GetStringContextData("UserID"))
+	if err != nil {
+		handleAPIKeyError(ctx.Ctx, err, app.AppID, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusCreated, fmt.Sprintf("API key rotated. The old key will stop working in %d hours. This will only be displayed once", overlapHours), map[string]any{
+		"apiKey":  plainKey,
+		"details": newKey,
+	}, nil, nil, &ctx.DeviceID)
+}
+
+func RevokeAppAPIKey(ctx *interfaces.ApplicationContext[any]) {
+	app := fetchAPIKeyApp(ctx.Ctx, ctx.GetStringParameter("id"), ctx.GetStringContextData("WorkspaceID"), ctx.DeviceID)
+	if app == nil {
+		return
+	}
+	apiKey, err := services.FindAppAPIKey(app.AppID, app.WorkspaceID, ctx.GetStringParameter("keyID"))
+	if err == nil {
+		err = services.RevokeAPIKey(apiKey)
+	}
+	if err != nil {
+		handleAPIKeyError(ctx.Ctx, err, app.AppID, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "API key revoked", nil, nil, nil, &ctx.DeviceID)
+}
+
+func fetchAPIKeyApp(ctx any, id string, workspaceID string, deviceID string) *entities.Application {
+	appRepo := repository.ApplicationRepo()
+	app, err := appRepo.FindOneByFilter(map[string]interface{}{
+		"_id":         id,
+		"workspaceID": workspaceID,
+	}, options.FindOne().SetProjection(map[string]any{
+		"appID":       1,
+		"workspaceID": 1,
+	}))
+	if err != nil {
+		logger.Error("an error occured while fetching app for api keys", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "id",
+			Data: id,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return nil
+	}
+	if app == nil {
+		apperrors.NotFoundError(ctx, "Invalid app id provided. App not found", &deviceID)
+		return nil
+	}
+	return app
+}
+
+func handleAPIKeyError(ctx any, err error, appID string, deviceID string) {
+	switch {
+	case errors.Is(err, services.ErrAPIKeyNotFound):
+		apperrors.NotFoundError(ctx, "API key not found", &deviceID)
+	case errors.Is(err, services.ErrAPIKeyAlreadyRevoked):
+		apperrors.ClientError(ctx, "This API key has already been revoked", nil, nil, deviceID)
+	case errors.Is(err, services.ErrAPIKeyInactive):
+		apperrors.ClientError(ctx, "Only active api keys can be rotated", nil, nil, deviceID)
+	case errors.Is(err, services.ErrAPIKeyLimitReached):
+		apperrors.ClientError(ctx, fmt.Sprintf("An app can only have %d active api keys. Revoke unused keys before creating another", services.MaxActiveAPIKeys), nil, nil, deviceID)
+	default:
+		logger.Error("an error occured while managing app api keys", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "appID",
+			Data: appID,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+	}
+}
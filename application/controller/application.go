@@ -29,7 +29,6 @@ import (
 	queue_tasks "gateman.io/infrastructure/message_queue/tasks"
 	mq_types "gateman.io/infrastructure/message_queue/types"
 	server_response "gateman.io/infrastructure/serverResponse"
-	"gateman.io/infrastructure/totp"
 	"gateman.io/infrastructure/validator"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -158,6 +157,7 @@ func DeleteApplication(ctx *interfaces.ApplicationContext[any]) {
 	appRepo.UpdatePartialByID(ctx.GetStringParameter("id"), map[string]any{
 		"disabled": true,
 	})
+	services.PurgeAPIKeyCache(deleted.AppID, services.APIKeyCachePurgeAppDeleted)
 	deleteAppPayload, err := json.Marshal(queue_tasks.DeleteAppPayload{
 		ID:          ctx.GetStringParameter("id"),
 		WorkspaceID: ctx.GetStringContextData("WorkspaceID"),
@@ -252,11 +252,13 @@ func UpdateApplication(ctx *interfaces.ApplicationContext[dto.UpdateApplications
 		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
 		return
 	}
+	services.PurgeAPIKeyCacheByID(ctx.GetStringParameter("id"), services.APIKeyCachePurgeAppUpdated)
 	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "app updated", nil, nil, nil, &ctx.DeviceID)
 }
 
 func RefreshAppAPIKey(ctx *interfaces.ApplicationContext[any]) {
-	apiKey, _ := cryptography.EncryptData([]byte(utils.GenerateUULDString()), nil)
+	generatedAPIKey, _ := utils.GenerateRandomHexKey(84)
+	apiKey := &generatedAPIKey
 	hashedAPIKey, _ := cryptography.CryptoHahser.HashString(string(*apiKey), nil)
 	appRepo := repository.ApplicationRepo()
 	app, err := appRepo.UpdatePartialByID(ctx.GetStringParameter("id"), map[string]any{
@@ -275,6 +277,7 @@ func RefreshAppAPIKey(ctx *interfaces.ApplicationContext[any]) {
 		apperrors.NotFoundError(ctx.Ctx, "Invalid app id provided. App not found", &ctx.DeviceID)
 		return
 	}
+	services.PurgeAPIKeyCacheByID(ctx.GetStringParameter("id"), services.APIKeyCachePurgeKeyRotated)
 	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "API key updated. This will only be displayed once", apiKey, nil, nil, &ctx.DeviceID)
 }
 
@@ -286,12 +289,19 @@ func UpdateWhiteListedIPs(ctx *interfaces.ApplicationContext[dto.UpdateWhitelist
 	}
 	invalidIP := utils.ValidateIPAddresses(ctx.Body.IPs)
 	if invalidIP {
-		apperrors.ClientError(ctx.Ctx, "please enter only valid IP addresses", nil, nil, ctx.DeviceID)
+		apperrors.ClientError(ctx.Ctx, "please enter only valid IP addresses or CIDR ranges", nil, nil, ctx.DeviceID)
 		return
 	}
+	field := "whiteListedIPs"
+	if ctx.Body.Sandbox {
+		field = "sandboxWhiteListedIPs"
+	}
 	appRepo := repository.ApplicationRepo()
-	app, err := appRepo.UpdatePartialByID(ctx.GetStringParameter("id"), map[string]any{
-		"whiteListedIPs": utils.MakeStringArrayUnique(ctx.Body.IPs),
+	app, err := appRepo.UpdatePartialByFilter(map[string]interface{}{
+		"_id":         ctx.GetStringParameter("id"),
+		"workspaceID": ctx.GetStringContextData("WorkspaceID"),
+	}, map[string]any{
+		field: utils.MakeStringArrayUnique(ctx.Body.IPs),
 	})
 	if err != nil {
 		logger.Error("an error occured while updating whitelisted ips", logger.LoggerOptions{
@@ -302,32 +312,18 @@ func UpdateWhiteListedIPs(ctx *interfaces.ApplicationContext[dto.UpdateWhitelist
 		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
 		return
 	}
-	if app == 0 {
+	if !app {
 		apperrors.NotFoundError(ctx.Ctx, "Invalid app id provided. App not found", &ctx.DeviceID)
 		return
 	}
+	services.PurgeAPIKeyCacheByID(ctx.GetStringParameter("id"), services.APIKeyCachePurgeIPsUpdated)
 	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "IP Whitelist updated", nil, nil, nil, &ctx.DeviceID)
 }
 
+// Rotates the app signing key. The replaced key keeps verifying tokens for the overlap of the app's rotation policy.
 func RefreshAppSigningKey(ctx *interfaces.ApplicationContext[any]) {
-	appSigningKey := utils.GenerateUULDString()
-	appSigningKey = fmt.Sprintf("%s%s", appSigningKey, "-g8man")
-	encryptedAppSigningKey, _ := cryptography.EncryptData([]byte(appSigningKey), nil)
-	appRepo := repository.ApplicationRepo()
-	app, err := appRepo.UpdatePartialByID(ctx.GetStringParameter("id"), map[string]any{
-		"appSigningKey": *encryptedAppSigningKey,
-	})
-	if err != nil {
-		logger.Error("an error occured while updating app signing key", logger.LoggerOptions{
-			Key: "params", Data: ctx.Param,
-		}, logger.LoggerOptions{
-			Key: "payload", Data: ctx.Body,
-		})
-		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
-		return
-	}
-	if app == 0 {
-		apperrors.NotFoundError(ctx.Ctx, "Invalid app id provided. App not found", &ctx.DeviceID)
+	_, appSigningKey, _ := rotateAppSigningKeys(ctx.Ctx, ctx.GetStringParameter("id"), ctx.GetStringContextData("WorkspaceID"), false, nil, ctx.DeviceID)
+	if appSigningKey == nil {
 		return
 	}
 	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "App Signing Key updated. This will only be displayed once", appSigningKey, nil, nil, &ctx.DeviceID)
@@ -407,7 +403,8 @@ func ToggleMFAProtectionSetting(ctx *interfaces.ApplicationContext[dto.ToggleMFA
 }
 
 func RefreshSandboxAppAPIKey(ctx *interfaces.ApplicationContext[any]) {
-	apiKey, _ := cryptography.EncryptData([]byte(utils.GenerateUULDString()), nil)
+	generatedAPIKey, _ := utils.GenerateRandomHexKey(84)
+	apiKey := &generatedAPIKey
 	hashedAPIKey, _ := cryptography.CryptoHahser.HashString(string(*apiKey), nil)
 	appRepo := repository.ApplicationRepo()
 	app, err := appRepo.UpdatePartialByID(ctx.GetStringParameter("id"), map[string]any{
@@ -426,28 +423,14 @@ func RefreshSandboxAppAPIKey(ctx *interfaces.ApplicationContext[any]) {
 		apperrors.NotFoundError(ctx.Ctx, "Invalid app id provided. App not found", &ctx.DeviceID)
 		return
 	}
+	services.PurgeAPIKeyCacheByID(ctx.GetStringParameter("id"), services.APIKeyCachePurgeKeyRotated)
 	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Sandbox API key updated. This will only be displayed once", apiKey, nil, nil, &ctx.DeviceID)
 }
 
+// Rotates the app signing key. The replaced key keeps verifying tokens for the overlap of the app's rotation policy.
 func RefreshSandboxAppSigningKey(ctx *interfaces.ApplicationContext[any]) {
-	appSigningKey := utils.GenerateUULDString()
-	appSigningKey = fmt.Sprintf("sandbox-%s-g8man", appSigningKey)
-	encryptedAppSigningKey, _ := cryptography.EncryptData([]byte(appSigningKey), nil)
-	appRepo := repository.ApplicationRepo()
-	app, err := appRepo.UpdatePartialByID(ctx.GetStringParameter("id"), map[string]any{
-		"sandBoxAppSigningKey": *encryptedAppSigningKey,
-	})
-	if err != nil {
-		logger.Error("an error occured while updating app signing key", logger.LoggerOptions{
-			Key: "params", Data: ctx.Param,
-		}, logger.LoggerOptions{
-			Key: "payload", Data: ctx.Body,
-		})
-		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
-		return
-	}
-	if app == 0 {
-		apperrors.NotFoundError(ctx.Ctx, "Invalid app id provided. App not found", &ctx.DeviceID)
+	_, appSigningKey, _ := rotateAppSigningKeys(ctx.Ctx, ctx.GetStringParameter("id"), ctx.GetStringContextData("WorkspaceID"), true, nil, ctx.DeviceID)
+	if appSigningKey == nil {
 		return
 	}
 	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Sandbox App Signing Key updated. This will only be displayed once", appSigningKey, nil, nil, &ctx.DeviceID)
@@ -519,41 +502,27 @@ func ApplicationSignUp(ctx *interfaces.ApplicationContext[dto.ApplicationSignUpD
 		"userID": ctx.GetStringContextData("UserID"),
 		"appID":  ctx.Body.AppID,
 	})
-	var responseCode *uint
+	responseCode, ok := services.AuthoriseAppUserSignIn(ctx.Ctx, app, appUserExists, ctx.Body.Pin, ctx.Body.MFACode, ctx.Body.RecoveryCode, ctx.DeviceID)
+	if !ok {
+		return
+	}
 	if appUserExists != nil {
-		if appUserExists.Blocked {
-			apperrors.AuthenticationError(ctx.Ctx, "Your access to this application has been restricted", ctx.DeviceID)
-			return
-		}
-		if app.PinProtected {
-			if appUserExists.Pin == nil {
-				responseCode = &constants.SET_APP_PIN
-			}
-			pinMatch := cryptography.CryptoHahser.VerifyHashData(*appUserExists.Pin, *ctx.Body.Pin)
-			if !pinMatch {
-				apperrors.AuthenticationError(ctx.Ctx, "Incorrect pin", ctx.DeviceID)
-				return
-			}
-		}
 		eligible, msg, payload, requestedFields := services.ProcessUserSignUp(app, user, ctx.Keys["ip"].(string))
 		if eligible {
 			block, err := services.CheckMonthlyLimit(ctx.Ctx, app.ID, appUserExists.ID, ctx.DeviceID)
 			if err != nil || block {
 				return
 			}
-			payload, err := services.GenerateAuthTokens(payload, app, ctx.UserAgent, ctx.DeviceID, ctx.GetStringContextData("UserID"), requestedFields)
+			payload, err := services.GenerateAuthTokens(payload, app, ctx.UserAgent, ctx.DeviceID, ctx.GetStringContextData("UserID"), requestedFields, nil, nil)
 			if err != nil {
 				apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
 				return
 			}
 			server_response.Responder.Respond(ctx.Ctx, http.StatusOK, msg, payload, nil, responseCode, &ctx.DeviceID)
+			return
 		}
 		server_response.Responder.Respond(ctx.Ctx, http.StatusBadRequest, msg, payload, nil, nil, &ctx.DeviceID)
 	} else {
-		if app.PinProtected && ctx.Body.Pin == nil {
-			apperrors.ClientError(ctx.Ctx, "Provide your login pin", nil, nil, ctx.DeviceID)
-			return
-		}
 		eligible, msg, payload, requestedFields := services.ProcessUserSignUp(app, user, ctx.Keys["ip"].(string))
 		if eligible {
 			var pin []byte
@@ -566,11 +535,15 @@ func ApplicationSignUp(ctx *interfaces.ApplicationContext[dto.ApplicationSignUpD
 				WorkspaceID: app.WorkspaceID,
 				Pin:         utils.GetStringPointer(string(pin)),
 			})
+			// new users of apps that require MFA set it up before their first sign in
+			if !services.VerifyAppUserMFA(ctx.Ctx, app, appUserExists, ctx.Body.MFACode, ctx.Body.RecoveryCode, ctx.DeviceID) {
+				return
+			}
 			block, err := services.CheckMonthlyLimit(ctx.Ctx, app.ID, appUserExists.ID, ctx.DeviceID)
 			if err != nil || block {
 				return
 			}
-			payload, err := services.GenerateAuthTokens(payload, app, ctx.UserAgent, ctx.DeviceID, ctx.GetStringContextData("UserID"), requestedFields)
+			payload, err := services.GenerateAuthTokens(payload, app, ctx.UserAgent, ctx.DeviceID, ctx.GetStringContextData("UserID"), requestedFields, nil, nil)
 			if err != nil {
 				apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
 			}
@@ -789,28 +762,3 @@ func GetAppMetrics(ctx *interfaces.ApplicationContext[dto.FetchAppMetrics]) {
 	appMetrics["disabled"] = app.Disabled
 	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "metrics fetched", appMetrics, nil, nil, &ctx.DeviceID)
 }
-
-func SetUpMFA(ctx *interfaces.ApplicationContext[any]) {
-	appUserRepo := repository.AppUserRepo()
-	user, _ := appUserRepo.FindByID(ctx.GetStringContextData("UserID"))
-	if user == nil {
-		apperrors.NotFoundError(ctx.Ctx, "User not found", &ctx.DeviceID)
-		return
-	}
-	if user.AuthenticatorSecret != nil {
-		apperrors.ClientError(ctx.Ctx, "MFA has already been set up on this account", nil, nil, ctx.DeviceID)
-		return
-	}
-	_, url, err := totp.TOTPService.GenerateSecret(ctx.GetStringContextData("UserID"))
-	if err != nil {
-		logger.Error("an error occured while trying to generate TOTP code", logger.LoggerOptions{
-			Key: "err", Data: err,
-		}, logger.LoggerOptions{
-			Key:  "userID",
-			Data: ctx.GetStringContextData("UserID"),
-		})
-		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
-		return
-	}
-	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "mfa secret generated", url, nil, nil, &ctx.DeviceID)
-}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
@@ -35,8 +34,9 @@ import (
 )
 
 func KeyExchange(ctx *interfaces.ApplicationContext[dto.KeyExchangeDTO]) {
-	serverPublicKey, _, _ := auth_usecases.InitiateKeyExchange(ctx.Ctx, ctx.DeviceID, ctx.Body.ClientPublicKey)
+	serverPublicKey, _, err := auth_usecases.InitiateKeyExchange(ctx.Ctx, ctx.DeviceID, ctx.Body.ClientPublicKey)
 	if serverPublicKey == nil {
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
 		return
 	}
 	server_response.Responder.UnEncryptedRespond(ctx.Ctx, http.StatusCreated, "key exchanged", hex.EncodeToString(serverPublicKey), nil, nil)
@@ -129,8 +129,7 @@ func VerifyUserAccount(ctx *interfaces.ApplicationContext[any]) {
 	}
 
 	hashedAccessToken, _ := cryptography.CryptoHahser.HashString(*token, nil)
-	hashedDeviceID, _ := cryptography.CryptoHahser.HashString(ctx.DeviceID, []byte(os.Getenv("HASH_FIXED_SALT")))
-	cache.Cache.CreateEntry(fmt.Sprintf("%s-access", string(hashedDeviceID)), hashedAccessToken, time.Minute*10)
+	cache.Cache.CreateEntry(auth.DeviceSessionKey(ctx.DeviceID, "access"), hashedAccessToken, time.Minute*10)
 	url, err := fileupload.FileUploader.GeneratedSignedURL(fmt.Sprintf("%s/%s", profile.ID, "accountimage"), types.SignedURLPermission{
 		Write: true,
 	}, time.Minute*10)
@@ -209,6 +208,7 @@ func VerifyWorkspaceAccount(ctx *interfaces.ApplicationContext[any]) {
 		superAdmin.Devices = append(superAdmin.Devices, entities.Device{
 			ID:                ctx.DeviceID,
 			Name:              ctx.DeviceName,
+			UserAgent:         ctx.UserAgent,
 			LastLogin:         time.Now(),
 			LastLoginLocation: fmt.Sprintf("%s, %s - (%f, %f)", strings.ToUpper(ipLookupRes.City), strings.ToUpper(ipLookupRes.CountryCode), ipLookupRes.Longitude, ipLookupRes.Latitude),
 			Verified:          true,
@@ -218,6 +218,7 @@ func VerifyWorkspaceAccount(ctx *interfaces.ApplicationContext[any]) {
 		superAdmin.Devices = append(superAdmin.Devices, entities.Device{
 			ID:                savedDevice.ID,
 			Name:              savedDevice.Name,
+			UserAgent:         ctx.UserAgent,
 			LastLogin:         time.Now(),
 			LastLoginLocation: fmt.Sprintf("%s, %s - (%f, %f)", strings.ToUpper(ipLookupRes.City), strings.ToUpper(ipLookupRes.CountryCode), ipLookupRes.Longitude, ipLookupRes.Latitude),
 			Verified:          true,
@@ -278,9 +279,8 @@ func VerifyWorkspaceAccount(ctx *interfaces.ApplicationContext[any]) {
 	}
 	hashedAccessToken, _ := cryptography.CryptoHahser.HashString(*accessToken, nil)
 	hashedRefreshToken, _ := cryptography.CryptoHahser.HashString(*refreshToken, nil)
-	hashedDeviceID, _ := cryptography.CryptoHahser.HashString(ctx.DeviceID, []byte(os.Getenv("HASH_FIXED_SALT")))
-	cache.Cache.CreateEntry(fmt.Sprintf("%s-workspace-access", string(hashedDeviceID)), hashedAccessToken, time.Hour*24)       // token should last for 10 mins
-	cache.Cache.CreateEntry(fmt.Sprintf("%s-workspace-refresh", string(hashedDeviceID)), hashedRefreshToken, time.Hour*24*180) // token should last for 100 days
+	cache.Cache.CreateEntry(auth.DeviceSessionKey(ctx.DeviceID, "workspace-access"), hashedAccessToken, time.Hour*24)       // token should last for 10 mins
+	cache.Cache.CreateEntry(auth.DeviceSessionKey(ctx.DeviceID, "workspace-refresh"), hashedRefreshToken, time.Hour*24*180) // token should last for 100 days
 	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "email verified", map[string]any{
 		"workspaceAccessToken":  accessToken,
 		"workspaceRefreshToken": refreshToken,
@@ -489,45 +489,53 @@ func VeirfyDeviceImage(ctx *interfaces.ApplicationContext[dto.VerifyDeviceDTO])
 		apperrors.ClientError(ctx.Ctx, "Face mismatch", nil, nil, ctx.DeviceID)
 		return
 	}
-	var savedDevice entities.Device
-	for i, device := range account.Devices {
-		if device.ID == ctx.DeviceID {
-			savedDevice = account.Devices[i]
+	err = fileupload.FileUploader.DeleteFile(fmt.Sprintf("%s/%s", account.ID, ctx.DeviceID))
+	if err != nil {
+		logger.Error("an error occured while trying to clear user device image", logger.LoggerOptions{
+			Key:  "filePath",
+			Data: fmt.Sprintf("%s/%s", ctx.GetStringContextData("UserID"), "accountimage"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	signInVerifiedDevice(ctx.Ctx, account, entities.Device{
+		ID:        ctx.DeviceID,
+		Name:      ctx.DeviceName,
+		UserAgent: ctx.UserAgent,
+		LastLogin: time.Now(),
+	})
+}
+
+// Marks the device as verified once the user has proven who they are on it and signs them in.
+// device is only saved as is when it is not already on the account.
+func signInVerifiedDevice(ctx any, account *entities.User, device entities.Device) {
+	deviceID := device.ID
+	for i, savedDevice := range account.Devices {
+		if savedDevice.ID == deviceID {
+			device = savedDevice
 			account.Devices = append(account.Devices[:i], account.Devices[i+1:]...)
 			break
 		}
 	}
-	account.Devices = append(account.Devices, entities.Device{
-		ID:        savedDevice.ID,
-		Name:      savedDevice.Name,
-		LastLogin: savedDevice.LastLogin,
-		Verified:  true,
-	})
-	_, err = userRepo.UpdatePartialByID(account.ID, map[string]any{
+	device.Verified = true
+	account.Devices = append(account.Devices, device)
+	userRepo := repository.UserRepo()
+	_, err := userRepo.UpdatePartialByID(account.ID, map[string]any{
 		"devices": account.Devices,
 	})
 
 	if err != nil {
-		logger.Error("something went wrong when updating image status", logger.LoggerOptions{
+		logger.Error("something went wrong when updating device status", logger.LoggerOptions{
 			Key:  "error",
 			Data: err,
 		})
-		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		apperrors.UnknownError(ctx, err, nil, deviceID)
 		return
 	}
 	var phone *string
 	if account.Phone != nil {
 		phone = utils.GetStringPointer(fmt.Sprintf("%s%s", account.Phone.Prefix, account.Phone.LocalNumber))
 	}
-	err = fileupload.FileUploader.DeleteFile(fmt.Sprintf("%s/%s", account.ID, ctx.DeviceID))
-	if err != nil {
-		logger.Error("an error occured while trying to clear user device image", logger.LoggerOptions{
-			Key:  "filePath",
-			Data: fmt.Sprintf("%s/%s", ctx.GetStringContextData("UserID"), "accountimage"),
-		})
-		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
-		return
-	}
 
 	accessToken, err := auth.GenerateAuthToken(auth.ClaimsData{
 		UserID:          account.ID,
@@ -535,13 +543,13 @@ func VeirfyDeviceImage(ctx *interfaces.ApplicationContext[dto.VerifyDeviceDTO])
 		Email:           account.Email,
 		VerifiedAccount: account.VerifiedAccount,
 		PhoneNum:        phone,
-		DeviceID:        ctx.DeviceID,
+		DeviceID:        deviceID,
 		TokenType:       auth.AccessToken,
 		IssuedAt:        time.Now().Unix(),
 		ExpiresAt:       time.Now().Add(time.Hour * 1).Unix(), //lasts for 1 hr
 	})
 	if err != nil {
-		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		apperrors.UnknownError(ctx, err, nil, deviceID)
 		return
 	}
 	refreshToken, err := auth.GenerateAuthToken(auth.ClaimsData{
@@ -551,24 +559,23 @@ func VeirfyDeviceImage(ctx *interfaces.ApplicationContext[dto.VerifyDeviceDTO])
 		VerifiedAccount: account.VerifiedAccount,
 		TokenType:       auth.RefreshToken,
 		PhoneNum:        phone,
-		DeviceID:        ctx.DeviceID,
+		DeviceID:        deviceID,
 		IssuedAt:        time.Now().Unix(),
 		ExpiresAt:       time.Now().Add(time.Hour * 24 * 180).Unix(), //lasts for 180 days
 	})
 
 	if err != nil {
-		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		apperrors.UnknownError(ctx, err, nil, deviceID)
 		return
 	}
 	hashedAccessToken, _ := cryptography.CryptoHahser.HashString(*accessToken, nil)
 	hashedRefreshToken, _ := cryptography.CryptoHahser.HashString(*refreshToken, nil)
-	hashedDeviceID, _ := cryptography.CryptoHahser.HashString(ctx.DeviceID, []byte(os.Getenv("HASH_FIXED_SALT")))
-	cache.Cache.CreateEntry(fmt.Sprintf("%s-access", string(hashedDeviceID)), hashedAccessToken, time.Hour*1)        // token should last for 10 mins
-	cache.Cache.CreateEntry(fmt.Sprintf("%s-refresh", string(hashedDeviceID)), hashedRefreshToken, time.Hour*24*180) // token should last for 10 mins
-	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "device verified", map[string]any{
+	cache.Cache.CreateEntry(auth.DeviceSessionKey(deviceID, "access"), hashedAccessToken, time.Hour*1)        // token should last for 10 mins
+	cache.Cache.CreateEntry(auth.DeviceSessionKey(deviceID, "refresh"), hashedRefreshToken, time.Hour*24*180) // token should last for 10 mins
+	server_response.Responder.Respond(ctx, http.StatusOK, "device verified", map[string]any{
 		"accessToken":  accessToken,
 		"refreshToken": refreshToken,
-	}, nil, nil, &ctx.DeviceID)
+	}, nil, nil, &deviceID)
 }
 
 func RefreshToken(ctx *interfaces.ApplicationContext[any]) {
@@ -607,6 +614,7 @@ func RefreshToken(ctx *interfaces.ApplicationContext[any]) {
 		DeviceID:        ctx.DeviceID,
 		IssuedAt:        time.Now().Unix(),
 		ExpiresAt:       time.Now().Add(time.Hour * 24 * 180).Unix(), //lasts for 180 days
+		Family:          refreshTokenFamily(ctx),
 	})
 
 	if err != nil {
@@ -615,9 +623,8 @@ func RefreshToken(ctx *interfaces.ApplicationContext[any]) {
 	}
 	hashedAccessToken, _ := cryptography.CryptoHahser.HashString(*accessToken, nil)
 	hashedRefreshToken, _ := cryptography.CryptoHahser.HashString(*refreshToken, nil)
-	hashedDeviceID, _ := cryptography.CryptoHahser.HashString(ctx.DeviceID, []byte(os.Getenv("HASH_FIXED_SALT")))
-	cache.Cache.CreateEntry(fmt.Sprintf("%s-access", string(hashedDeviceID)), hashedAccessToken, time.Hour*24)       // token should last for 10 mins
-	cache.Cache.CreateEntry(fmt.Sprintf("%s-refresh", string(hashedDeviceID)), hashedRefreshToken, time.Hour*24*180) // token should last for 100 days
+	cache.Cache.CreateEntry(auth.DeviceSessionKey(ctx.DeviceID, "access"), hashedAccessToken, time.Hour*24)       // token should last for 10 mins
+	cache.Cache.CreateEntry(auth.DeviceSessionKey(ctx.DeviceID, "refresh"), hashedRefreshToken, time.Hour*24*180) // token should last for 100 days
 	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "token refreshed", map[string]any{
 		"accessToken":  accessToken,
 		"refreshToken": refreshToken,
@@ -656,6 +663,7 @@ func WorkspaceRefreshToken(ctx *interfaces.ApplicationContext[any]) {
 		DeviceID:        ctx.DeviceID,
 		IssuedAt:        time.Now().Unix(),
 		ExpiresAt:       time.Now().Add(time.Hour * 24 * 180).Unix(), //lasts for 180 days
+		Family:          refreshTokenFamily(ctx),
 	})
 
 	if err != nil {
@@ -664,11 +672,17 @@ func WorkspaceRefreshToken(ctx *interfaces.ApplicationContext[any]) {
 	}
 	hashedAccessToken, _ := cryptography.CryptoHahser.HashString(*accessToken, nil)
 	hashedRefreshToken, _ := cryptography.CryptoHahser.HashString(*refreshToken, nil)
-	hashedDeviceID, _ := cryptography.CryptoHahser.HashString(ctx.DeviceID, []byte(os.Getenv("HASH_FIXED_SALT")))
-	cache.Cache.CreateEntry(fmt.Sprintf("%s-workspace-access", string(hashedDeviceID)), hashedAccessToken, time.Hour*24)       // token should last for 10 mins
-	cache.Cache.CreateEntry(fmt.Sprintf("%s-workspace-refresh", string(hashedDeviceID)), hashedRefreshToken, time.Hour*24*180) // token should last for 100 days
+	cache.Cache.CreateEntry(auth.DeviceSessionKey(ctx.DeviceID, "workspace-access"), hashedAccessToken, time.Hour*24)       // token should last for 10 mins
+	cache.Cache.CreateEntry(auth.DeviceSessionKey(ctx.DeviceID, "workspace-refresh"), hashedRefreshToken, time.Hour*24*180) // token should last for 100 days
 	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "token refreshed", map[string]any{
 		"workspaceAccessToken":  accessToken,
 		"workspaceRefreshToken": refreshToken,
 	}, nil, nil, &ctx.DeviceID)
 }
+
+// the family rotated by the refresh token middleware. nil for tokens issued before families were introduced
+func refreshTokenFamily(ctx *interfaces.ApplicationContext[any]) *auth.RefreshTokenFamily {
+	data, _ := ctx.GetContextData("RefreshTokenFamily")
+	family, _ := data.(*auth.RefreshTokenFamily)
+	return family
+}
@@ -8,10 +8,12 @@ import (
 	"gateman.io/application/controller/devAPI/dto"
 	"gateman.io/application/interfaces"
 	"gateman.io/application/repository"
+	services "gateman.io/application/services/application"
 	fileupload "gateman.io/infrastructure/file_upload"
 	"gateman.io/infrastructure/file_upload/types"
 	"gateman.io/infrastructure/logger"
 	server_response "gateman.io/infrastructure/serverResponse"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func APIFetchAppDetails(ctx *interfaces.ApplicationContext[dto.FetchAppDTO]) {
@@ -122,3 +124,40 @@ func APIUnblockAccounts(ctx *interfaces.ApplicationContext[dto.BlockAccountsDTO]
 	}
 	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "users unblocked", nil, nil, nil, &ctx.DeviceID)
 }
+
+func APIFetchAppJWKS(ctx *interfaces.ApplicationContext[any]) {
+	appRepo := repository.ApplicationRepo()
+	app, err := appRepo.FindOneByFilter(map[string]interface{}{
+		"appID": ctx.GetStringParameter("appID"),
+	}, options.FindOne().SetProjection(map[string]any{
+		"tokenSigningKey":        1,
+		"sandboxTokenSigningKey": 1,
+		"retiredSigningKeys":     1,
+		"disabled":               1,
+	}))
+	if err != nil {
+		logger.Error("an error occured while fetching app for jwks", logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if app == nil || app.Disabled {
+		apperrors.NotFoundError(ctx.Ctx, "app not found", &ctx.DeviceID)
+		return
+	}
+	jwks, err := services.AppJWKS(app)
+	if err != nil {
+		logger.Error("an error occured while building app jwks", logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key:  "appID",
+			Data: app.ID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.RawRespond(ctx.Ctx, http.StatusOK, jwks)
+}
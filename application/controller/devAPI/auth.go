@@ -1,13 +1,26 @@
 package public_controller
 
 import (
+	"net/http"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/controller/devAPI/dto"
 	"gateman.io/application/interfaces"
+	application_usecase "gateman.io/application/usecases/application"
+	server_response "gateman.io/infrastructure/serverResponse"
+	"gateman.io/infrastructure/validator"
 )
 
-func APIVerifyAuthToken(ctx *interfaces.ApplicationContext[any]) {
-	//	 err := application_usecase.VerifyAuthTokenUseCase(ctx.Ctx, ctx.Param["token"].(string),ctx.Param["appID"].(string) )
-	//		if err != nil {
-	//			return
-	//		}
-	//		server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "token verified", nil, nil, nil)
+func APIVerifyAuthToken(ctx *interfaces.ApplicationContext[dto.IntrospectTokenDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	introspection, err := application_usecase.VerifyAuthTokenUseCase(ctx.Ctx, ctx.Body.Token, ctx.GetStringContextData("AppID"), ctx.Body.DeviceID)
+	if err != nil {
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.RawRespond(ctx.Ctx, http.StatusOK, introspection)
 }
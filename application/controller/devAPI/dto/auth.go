@@ -0,0 +1,7 @@
+package dto
+
+type IntrospectTokenDTO struct {
+	Token         string  `json:"token" form:"token" validate:"required"`
+	TokenTypeHint *string `json:"token_type_hint" form:"token_type_hint"`
+	DeviceID      *string `json:"deviceID" form:"deviceID"` // when set the token must have been issued to this device
+}
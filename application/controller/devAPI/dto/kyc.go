@@ -0,0 +1,6 @@
+package dto
+
+type SubmitKYCJobDTO struct {
+	IDs       []string `json:"ids" validate:"required,min=1,max=100"`
+	Reference *string  `json:"reference" validate:"omitempty,max=100"`
+}
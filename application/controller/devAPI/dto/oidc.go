@@ -0,0 +1,21 @@
+package dto
+
+type OIDCAuthorizeDTO struct {
+	ClientID            string  `form:"client_id"`
+	RedirectURI         string  `form:"redirect_uri"`
+	ResponseType        string  `form:"response_type"`
+	Scope               string  `form:"scope"`
+	State               *string `form:"state"`
+	Nonce               *string `form:"nonce"`
+	CodeChallenge       string  `form:"code_challenge"`
+	CodeChallengeMethod string  `form:"code_challenge_method"`
+}
+
+type OIDCTokenDTO struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	ClientID     string `form:"client_id"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+}
@@ -1,19 +1,75 @@
 package public_controller
 
 import (
+	"errors"
 	"net/http"
 
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/constants"
 	"gateman.io/application/interfaces"
-	identityverification "gateman.io/infrastructure/identity_verification"
+	metering_services "gateman.io/application/services/metering"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/logger"
 	server_response "gateman.io/infrastructure/serverResponse"
 )
 
+// Looks id up if the plan of the app allows another lookup of lookupType this month and records the lookup when it was billable.
+// Returns false when a response has already been sent.
+func meteredIdentityLookup(ctx *interfaces.ApplicationContext[any], lookupType entities.MeteredLookupType, id string) (any, bool) {
+	appID := ctx.GetStringContextData("AppID")
+	sandbox := ctx.GetBoolContextData("SandboxEnv")
+	reservation, err := metering_services.ReserveLookups(appID, lookupType, 1, sandbox)
+	if errors.Is(err, metering_services.ErrLookupQuotaExceeded) {
+		apperrors.CustomError(ctx.Ctx, err.Error(), &constants.LOOKUP_QUOTA_EXCEEDED, ctx.DeviceID)
+		return nil, false
+	}
+	if err != nil {
+		logger.Error("an error occured while checking the lookup quota of an app", logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key:  "appID",
+			Data: appID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return nil, false
+	}
+	record, source, err := metering_services.LookupIdentity(lookupType, id)
+	// the lookup has been made, a ledger failure is logged instead of failing the request
+	var ledgerErr error
+	if metering_services.Billable(err) {
+		ledgerErr = metering_services.RecordLookup(entities.MeteredLookup{
+			AppID:       appID,
+			WorkspaceID: ctx.GetStringContextData("WorkspaceID"),
+			Type:        lookupType,
+			Source:      source,
+			Sandbox:     sandbox,
+			Plan:        reservation.Plan,
+		})
+	} else {
+		ledgerErr = metering_services.ReleaseLookups(appID, reservation, reservation.Count)
+	}
+	if ledgerErr != nil {
+		logger.Error("an error occured while recording a metered lookup", logger.LoggerOptions{
+			Key:  "err",
+			Data: ledgerErr,
+		}, logger.LoggerOptions{
+			Key:  "appID",
+			Data: appID,
+		})
+	}
+	return record, true
+}
+
 func APIFetchNINDetails(ctx *interfaces.ApplicationContext[any]) {
 	if ctx.Param["nin"] == nil {
 		server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "id is required", nil, nil, nil, &ctx.DeviceID)
 		return
 	}
-	fetchedNIN, _ := identityverification.IdentityVerifier.FetchNINDetails(ctx.Param["nin"].(string))
+	fetchedNIN, ok := meteredIdentityLookup(ctx, entities.NINLookup, ctx.Param["nin"].(string))
+	if !ok {
+		return
+	}
 	if fetchedNIN == nil {
 		server_response.Responder.Respond(ctx.Ctx, http.StatusNotFound, "NIN details not found", nil, nil, nil, &ctx.DeviceID)
 		return
@@ -26,7 +82,10 @@ func APIFetchBVNDetails(ctx *interfaces.ApplicationContext[any]) {
 		server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "bvn is required", nil, nil, nil, &ctx.DeviceID)
 		return
 	}
-	fetchedBVN, _ := identityverification.IdentityVerifier.FetchBVNDetails(ctx.Param["bvn"].(string))
+	fetchedBVN, ok := meteredIdentityLookup(ctx, entities.BVNLookup, ctx.Param["bvn"].(string))
+	if !ok {
+		return
+	}
 	if fetchedBVN == nil {
 		server_response.Responder.Respond(ctx.Ctx, http.StatusNotFound, "BVN details not found", nil, nil, nil, &ctx.DeviceID)
 		return
@@ -39,7 +98,10 @@ func APIFetchVotersCardDetails(ctx *interfaces.ApplicationContext[any]) {
 		server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "voters id is required", nil, nil, nil, &ctx.DeviceID)
 		return
 	}
-	fetchedVoterID, _ := identityverification.IdentityVerifier.FetchVoterIDDetails(ctx.Param["votersID"].(string))
+	fetchedVoterID, ok := meteredIdentityLookup(ctx, entities.VotersCardLookup, ctx.Param["votersID"].(string))
+	if !ok {
+		return
+	}
 	if fetchedVoterID == nil {
 		server_response.Responder.Respond(ctx.Ctx, http.StatusNotFound, "Voter ID details not found", nil, nil, nil, &ctx.DeviceID)
 		return
@@ -52,7 +114,10 @@ func APIFetchDriversLicenseDetails(ctx *interfaces.ApplicationContext[any]) {
 		server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "drivers license is required", nil, nil, nil, &ctx.DeviceID)
 		return
 	}
-	fetchedDriversID, _ := identityverification.IdentityVerifier.FetchDriverIDDetails(ctx.Param["driversLicense"].(string))
+	fetchedDriversID, ok := meteredIdentityLookup(ctx, entities.DriversLicenseLookup, ctx.Param["driversLicense"].(string))
+	if !ok {
+		return
+	}
 	if fetchedDriversID == nil {
 		server_response.Responder.Respond(ctx.Ctx, http.StatusNotFound, "Driver's License details not found", nil, nil, nil, &ctx.DeviceID)
 		return
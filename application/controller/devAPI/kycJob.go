@@ -0,0 +1,112 @@
+package public_controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/constants"
+	"gateman.io/application/controller/devAPI/dto"
+	"gateman.io/application/interfaces"
+	kycjob_services "gateman.io/application/services/kycjob"
+	metering_services "gateman.io/application/services/metering"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/logger"
+	messagequeue "gateman.io/infrastructure/message_queue"
+	queue_tasks "gateman.io/infrastructure/message_queue/tasks"
+	mq_types "gateman.io/infrastructure/message_queue/types"
+	server_response "gateman.io/infrastructure/serverResponse"
+	"gateman.io/infrastructure/validator"
+)
+
+func APISubmitKYCJob(ctx *interfaces.ApplicationContext[dto.SubmitKYCJobDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	check := entities.KYCJobCheck(ctx.GetStringParameter("check"))
+	ids, err := kycjob_services.NormaliseIDs(check, ctx.Body.IDs)
+	if err != nil {
+		apperrors.ClientError(ctx.Ctx, err.Error(), nil, nil, ctx.DeviceID)
+		return
+	}
+	// every id of the job has to fit in the quota, lookups are billed as the items are looked up
+	reservation, err := metering_services.ReserveLookups(ctx.GetStringContextData("AppID"), entities.MeteredLookupType(check), len(ids), ctx.GetBoolContextData("SandboxEnv"))
+	if errors.Is(err, metering_services.ErrLookupQuotaExceeded) {
+		apperrors.CustomError(ctx.Ctx, err.Error(), &constants.LOOKUP_QUOTA_EXCEEDED, ctx.DeviceID)
+		return
+	}
+	if err != nil {
+		logger.Error("an error occured while checking the lookup quota of an app", logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key:  "appID",
+			Data: ctx.GetStringContextData("AppID"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	job, err := kycjob_services.SubmitJob(ctx.GetStringContextData("AppID"), ctx.GetStringContextData("WorkspaceID"), ctx.GetBoolContextData("SandboxEnv"), check, ids, ctx.Body.Reference, reservation)
+	if err != nil {
+		metering_services.ReleaseLookups(ctx.GetStringContextData("AppID"), reservation, reservation.Count)
+		logger.Error("an error occured while submitting kyc job", logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key:  "appID",
+			Data: ctx.GetStringContextData("AppID"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	for _, item := range job.Items {
+		lookupPayload, err := json.Marshal(queue_tasks.KYCJobLookupPayload{
+			JobID: job.ID,
+			Index: item.Index,
+			BasePayload: mq_types.BasePayload{
+				RetryInterval: kycjob_services.LookupRetryInterval,
+			},
+		})
+		if err != nil {
+			logger.Error("error marshalling payload for kyc job lookup queue")
+			apperrors.ErrorProcessingPayload(ctx.Ctx, &ctx.DeviceID)
+			return
+		}
+		messagequeue.TaskQueue.Enqueue(mq_types.QueueTask{
+			Payload:  lookupPayload,
+			Name:     queue_tasks.HandleKYCJobLookupTaskName,
+			Priority: mq_types.Medium,
+			MaxRetry: kycjob_services.LookupMaxRetry,
+		})
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusAccepted, "kyc job submitted", map[string]any{
+		"id":        job.ID,
+		"status":    job.Status,
+		"ids":       len(job.Items),
+		"expiresAt": job.ExpiresAt,
+	}, nil, nil, &ctx.DeviceID)
+}
+
+func APIFetchKYCJob(ctx *interfaces.ApplicationContext[any]) {
+	check := entities.KYCJobCheck(ctx.GetStringParameter("check"))
+	job, err := kycjob_services.FetchJob(ctx.GetStringContextData("AppID"), check, ctx.GetStringParameter("id"))
+	if err != nil {
+		logger.Error("an error occured while fetching kyc job", logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key:  "jobID",
+			Data: ctx.GetStringParameter("id"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if job == nil {
+		apperrors.NotFoundError(ctx.Ctx, "kyc job not found", &ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "kyc job fetched", job, nil, nil, &ctx.DeviceID)
+}
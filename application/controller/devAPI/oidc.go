@@ -0,0 +1,334 @@
+package public_controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/controller/devAPI/dto"
+	"gateman.io/application/interfaces"
+	"gateman.io/application/repository"
+	services "gateman.io/application/services/application"
+	security_services "gateman.io/application/services/security"
+	application_usecase "gateman.io/application/usecases/application"
+	"gateman.io/application/utils"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/auth"
+	"gateman.io/infrastructure/logger"
+	server_response "gateman.io/infrastructure/serverResponse"
+)
+
+func APIOIDCDiscovery(ctx *interfaces.ApplicationContext[any]) {
+	app := fetchOIDCClient(ctx.Ctx, ctx.GetStringParameter("appID"), ctx.DeviceID)
+	if app == nil {
+		return
+	}
+	signingKey, err := services.AppTokenSigningKey(app)
+	if err != nil {
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	issuer := services.OIDCIssuer(app)
+	server_response.Responder.RawRespond(ctx.Ctx, http.StatusOK, map[string]any{
+		"issuer":                                issuer,
+		"authorization_endpoint":                fmt.Sprintf("%s/authorize", issuer),
+		"token_endpoint":                        fmt.Sprintf("%s/token", issuer),
+		"userinfo_endpoint":                     fmt.Sprintf("%s/userinfo", issuer),
+		"jwks_uri":                              fmt.Sprintf("%s/api/public/v1/app/%s/.well-known/jwks.json", os.Getenv("API_URL"), app.AppID),
+		"introspection_endpoint":                fmt.Sprintf("%s/api/public/v1/app/token/introspect", os.Getenv("API_URL")),
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{signingKey.Algorithm},
+		"scopes_supported":                      services.OIDCSupportedScopes(app),
+		"claims_supported":                      services.OIDCSupportedClaims(app),
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"none"},
+	})
+}
+
+func APIOIDCAuthorize(ctx *interfaces.ApplicationContext[dto.OIDCAuthorizeDTO]) {
+	app := fetchOIDCClient(ctx.Ctx, ctx.GetStringParameter("appID"), ctx.DeviceID)
+	if app == nil {
+		return
+	}
+	// errors are only sent back to the client once the redirect uri is known to be registered
+	if ctx.Body.ClientID != app.AppID {
+		apperrors.ClientError(ctx.Ctx, "invalid client_id", nil, nil, ctx.DeviceID)
+		return
+	}
+	if app.RedirectURIs == nil || !utils.HasItemString(app.RedirectURIs, ctx.Body.RedirectURI) {
+		apperrors.ClientError(ctx.Ctx, "redirect_uri is not registered for this app", nil, nil, ctx.DeviceID)
+		return
+	}
+	if ctx.Body.ResponseType != "code" {
+		redirectOIDCError(ctx.Ctx, ctx.Body.RedirectURI, ctx.Body.State, "unsupported_response_type", "only the code response type is supported")
+		return
+	}
+	if ctx.Body.CodeChallenge == "" || ctx.Body.CodeChallengeMethod != "S256" {
+		redirectOIDCError(ctx.Ctx, ctx.Body.RedirectURI, ctx.Body.State, "invalid_request", "a S256 code_challenge is required")
+		return
+	}
+	scopes := services.ParseScopes(ctx.Body.Scope)
+	supportedScopes := services.OIDCSupportedScopes(app)
+	if !utils.HasItemString(&scopes, "openid") {
+		redirectOIDCError(ctx.Ctx, ctx.Body.RedirectURI, ctx.Body.State, "invalid_scope", "the openid scope is required")
+		return
+	}
+	for _, scope := range scopes {
+		if !utils.HasItemString(&supportedScopes, scope) {
+			redirectOIDCError(ctx.Ctx, ctx.Body.RedirectURI, ctx.Body.State, "invalid_scope", fmt.Sprintf("%s is not a supported scope", scope))
+			return
+		}
+	}
+	requestID, err := services.SaveOIDCAuthorizationRequest(services.OIDCAuthorizationRequest{
+		AppID:         app.AppID,
+		RedirectURI:   ctx.Body.RedirectURI,
+		Scopes:        utils.MakeStringArrayUnique(scopes),
+		State:         ctx.Body.State,
+		Nonce:         ctx.Body.Nonce,
+		CodeChallenge: ctx.Body.CodeChallenge,
+	})
+	if err != nil {
+		logger.Error("an error occured while saving oidc authorization request", logger.LoggerOptions{
+			Key: "err", Data: err,
+		})
+		redirectOIDCError(ctx.Ctx, ctx.Body.RedirectURI, ctx.Body.State, "server_error", "the authorization request could not be saved")
+		return
+	}
+	// the client signs the user in and completes the request through the app oidc authorize route
+	server_response.Responder.Redirect(ctx.Ctx, fmt.Sprintf("%s/app/authenticate/%s?oidcRequest=%s", os.Getenv("CLIENT_URL"), app.ID, *requestID))
+}
+
+func APIOIDCToken(ctx *interfaces.ApplicationContext[dto.OIDCTokenDTO]) {
+	appID := ctx.GetStringParameter("appID")
+	if ctx.Body.ClientID != appID {
+		oidcTokenError(ctx.Ctx, http.StatusUnauthorized, "invalid_client", "client_id does not match")
+		return
+	}
+	switch ctx.Body.GrantType {
+	case "authorization_code":
+		exchangeOIDCAuthorizationCode(ctx, appID)
+	case "refresh_token":
+		refreshOIDCTokens(ctx, appID)
+	default:
+		oidcTokenError(ctx.Ctx, http.StatusBadRequest, "unsupported_grant_type", "only the authorization_code and refresh_token grants are supported")
+	}
+}
+
+func exchangeOIDCAuthorizationCode(ctx *interfaces.ApplicationContext[dto.OIDCTokenDTO], appID string) {
+	authCode := services.RedeemOIDCAuthorizationCode(ctx.Body.Code)
+	if authCode == nil || authCode.AppID != appID {
+		oidcTokenError(ctx.Ctx, http.StatusBadRequest, "invalid_grant", "the authorization code is invalid or has expired")
+		return
+	}
+	if authCode.RedirectURI != ctx.Body.RedirectURI {
+		oidcTokenError(ctx.Ctx, http.StatusBadRequest, "invalid_grant", "redirect_uri does not match the authorization request")
+		return
+	}
+	if !services.VerifyPKCEChallenge(ctx.Body.CodeVerifier, authCode.CodeChallenge) {
+		oidcTokenError(ctx.Ctx, http.StatusBadRequest, "invalid_grant", "code_verifier does not match the code challenge")
+		return
+	}
+	app := fetchOIDCTokenClient(ctx.Ctx, appID)
+	if app == nil {
+		return
+	}
+	userRepo := repository.UserRepo()
+	user, err := userRepo.FindByID(authCode.UserID)
+	if err != nil || user == nil {
+		oidcTokenError(ctx.Ctx, http.StatusBadRequest, "invalid_grant", "user not found")
+		return
+	}
+	appUserRepo := repository.AppUserRepo()
+	appUser, _ := appUserRepo.FindOneByFilter(map[string]interface{}{
+		"userID": authCode.UserID,
+		"appID":  appID,
+	})
+	if appUser == nil || appUser.Blocked || appUser.DeletedAt != nil {
+		oidcTokenError(ctx.Ctx, http.StatusBadRequest, "invalid_grant", "access to this app has been restricted")
+		return
+	}
+	payload, err := services.GenerateAuthTokens(map[string]any{}, app, authCode.UserAgent, authCode.DeviceID, authCode.UserID, services.RequestedFieldValues(app, user), authCode.Scopes, nil)
+	if err != nil {
+		oidcTokenError(ctx.Ctx, http.StatusInternalServerError, "server_error", "tokens could not be generated")
+		return
+	}
+	idToken, err := services.GenerateIDToken(app, user, authCode.Scopes, authCode.Nonce)
+	if err != nil {
+		logger.Error("an error occured while generating oidc id token", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "appID", Data: appID,
+		})
+		oidcTokenError(ctx.Ctx, http.StatusInternalServerError, "server_error", "tokens could not be generated")
+		return
+	}
+	accessTokenTTL, _ := services.AppTokenTTLs(app)
+	server_response.Responder.RawRespond(ctx.Ctx, http.StatusOK, map[string]any{
+		"access_token":  (*payload)["accessToken"],
+		"refresh_token": (*payload)["refreshToken"],
+		"id_token":      idToken,
+		"token_type":    "Bearer",
+		"expires_in":    accessTokenTTL,
+		"scope":         strings.Join(authCode.Scopes, " "),
+	})
+}
+
+// Exchanges a refresh token for a new token pair. The refresh token is rotated and presenting a rotated token again revokes the whole family.
+func refreshOIDCTokens(ctx *interfaces.ApplicationContext[dto.OIDCTokenDTO], appID string) {
+	introspection, err := application_usecase.VerifyRefreshTokenUseCase(ctx.Ctx, ctx.Body.RefreshToken, appID)
+	if err != nil {
+		oidcTokenError(ctx.Ctx, http.StatusInternalServerError, "server_error", "the refresh token could not be verified")
+		return
+	}
+	if !introspection.Active {
+		oidcTokenError(ctx.Ctx, http.StatusBadRequest, "invalid_grant", "the refresh token is invalid or has expired")
+		return
+	}
+	app := fetchOIDCTokenClient(ctx.Ctx, appID)
+	if app == nil {
+		return
+	}
+	userRepo := repository.UserRepo()
+	user, err := userRepo.FindByID(introspection.Sub)
+	if err != nil || user == nil {
+		oidcTokenError(ctx.Ctx, http.StatusBadRequest, "invalid_grant", "user not found")
+		return
+	}
+	_, refreshTokenTTL := services.AppTokenTTLs(app)
+	family, record, err := auth.RotateRefreshTokenFamily(introspection.FamilyID, introspection.Jti, time.Duration(refreshTokenTTL)*time.Second)
+	if err != nil {
+		if errors.Is(err, auth.ErrRefreshTokenReused) {
+			security_services.RecordSecurityEvent(entities.SecurityEvent{
+				Type:      entities.RefreshTokenReuseEvent,
+				ActorID:   &record.OwnerID,
+				ActorType: string(record.OwnerType),
+				AppID:     &app.AppID,
+				DeviceID:  &record.DeviceID,
+				IPAddress: utils.GetStringPointer(ctx.Keys["ip"].(string)),
+				Metadata: map[string]any{
+					"familyID": introspection.FamilyID,
+				},
+			})
+		}
+		oidcTokenError(ctx.Ctx, http.StatusBadRequest, "invalid_grant", "the refresh token is invalid or has expired")
+		return
+	}
+	scopes := services.ParseScopes(introspection.Scope)
+	accessTokenTTL, _ := services.AppTokenTTLs(app)
+	payload, err := services.GenerateAuthTokens(map[string]any{}, app, ctx.UserAgent, record.DeviceID, user.ID, services.RequestedFieldValues(app, user), scopes, family)
+	if err != nil {
+		oidcTokenError(ctx.Ctx, http.StatusInternalServerError, "server_error", "tokens could not be generated")
+		return
+	}
+	response := map[string]any{
+		"access_token":  (*payload)["accessToken"],
+		"refresh_token": (*payload)["refreshToken"],
+		"token_type":    "Bearer",
+		"expires_in":    accessTokenTTL,
+		"scope":         introspection.Scope,
+	}
+	if utils.HasItemString(&scopes, "openid") {
+		idToken, err := services.GenerateIDToken(app, user, scopes, nil)
+		if err != nil {
+			logger.Error("an error occured while generating oidc id token on refresh", logger.LoggerOptions{
+				Key: "err", Data: err,
+			}, logger.LoggerOptions{
+				Key: "appID", Data: appID,
+			})
+			oidcTokenError(ctx.Ctx, http.StatusInternalServerError, "server_error", "tokens could not be generated")
+			return
+		}
+		response["id_token"] = idToken
+	}
+	server_response.Responder.RawRespond(ctx.Ctx, http.StatusOK, response)
+}
+
+func APIOIDCUserInfo(ctx *interfaces.ApplicationContext[any]) {
+	authorization := ctx.GetHeader("Authorization")
+	if authorization == nil || !strings.HasPrefix(*authorization, "Bearer ") {
+		oidcTokenError(ctx.Ctx, http.StatusUnauthorized, "invalid_token", "provide a bearer access token")
+		return
+	}
+	introspection, err := application_usecase.VerifyAuthTokenUseCase(ctx.Ctx, strings.TrimPrefix(*authorization, "Bearer "), ctx.GetStringParameter("appID"), nil)
+	if err != nil {
+		oidcTokenError(ctx.Ctx, http.StatusInternalServerError, "server_error", "the access token could not be verified")
+		return
+	}
+	if !introspection.Active || introspection.TokenType != string(auth.AccessToken) {
+		oidcTokenError(ctx.Ctx, http.StatusUnauthorized, "invalid_token", "the access token is invalid or has expired")
+		return
+	}
+	appRepo := repository.ApplicationRepo()
+	app, _ := appRepo.FindOneByFilter(map[string]interface{}{
+		"appID": ctx.GetStringParameter("appID"),
+	})
+	userRepo := repository.UserRepo()
+	user, _ := userRepo.FindByID(introspection.Sub)
+	if app == nil || user == nil {
+		oidcTokenError(ctx.Ctx, http.StatusUnauthorized, "invalid_token", "the access token is invalid or has expired")
+		return
+	}
+	server_response.Responder.RawRespond(ctx.Ctx, http.StatusOK, services.OIDCUserClaims(app, user, services.ParseScopes(introspection.Scope)))
+}
+
+func fetchOIDCClient(ctx any, appID string, deviceID string) *entities.Application {
+	appRepo := repository.ApplicationRepo()
+	app, err := appRepo.FindOneByFilter(map[string]interface{}{
+		"appID": appID,
+	})
+	if err != nil {
+		logger.Error("an error occured while fetching oidc client", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "appID", Data: appID,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return nil
+	}
+	if app == nil || app.Disabled {
+		apperrors.NotFoundError(ctx, "app not found", &deviceID)
+		return nil
+	}
+	return app
+}
+
+// Fetches the app a token request is made for, responding with invalid_client when it does not exist or is disabled
+func fetchOIDCTokenClient(ctx any, appID string) *entities.Application {
+	appRepo := repository.ApplicationRepo()
+	app, err := appRepo.FindOneByFilter(map[string]interface{}{
+		"appID": appID,
+	})
+	if err != nil || app == nil || app.Disabled {
+		oidcTokenError(ctx, http.StatusUnauthorized, "invalid_client", "app not found")
+		return nil
+	}
+	return app
+}
+
+// sends an authorization error back to the client's redirect uri (RFC 6749 section 4.1.2.1)
+func redirectOIDCError(ctx any, redirectURI string, state *string, code string, description string) {
+	redirectURL, _ := url.Parse(redirectURI)
+	query := redirectURL.Query()
+	query.Set("error", code)
+	query.Set("error_description", description)
+	if state != nil {
+		query.Set("state", *state)
+	}
+	redirectURL.RawQuery = query.Encode()
+	server_response.Responder.Redirect(ctx, redirectURL.String())
+}
+
+// sends an error in the format described in RFC 6749 section 5.2
+func oidcTokenError(ctx any, status int, code string, description string) {
+	server_response.Responder.RawRespond(ctx, status, map[string]any{
+		"error":             code,
+		"error_description": description,
+	})
+}
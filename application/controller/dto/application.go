@@ -1,6 +1,10 @@
 package dto
 
-import "gateman.io/entities"
+import (
+	"time"
+
+	"gateman.io/entities"
+)
 
 type ApplicationDTO struct {
 	Name              string                        `json:"name" validate:"required,max=100,min=2"`
@@ -9,6 +13,7 @@ type ApplicationDTO struct {
 	LocaleRestriction *[]entities.LocaleRestriction `json:"localeRestriction" validate:"omitempty,dive"`
 	RequestedFields   *[]entities.RequestedField     `json:"requestedFields" validate:"omitempty,dive"`
 	CustomFormFields  *[]entities.CustomFormField   `json:"customFormFields" validate:"omitempty,dive"`
+	SigningAlgorithm  *string                       `json:"signingAlgorithm" validate:"omitempty,oneof=RS256 ES256 EdDSA"`
 }
 
 type UpdateApplications struct {
@@ -24,8 +29,39 @@ type UpdateApplications struct {
 }
 
 type ApplicationSignUpDTO struct {
-	AppID string  `json:"appID" validate:"required,ulid"`
-	Pin   *string `json:"pin"`
+	AppID        string  `json:"appID" validate:"required,ulid"`
+	Pin          *string `json:"pin"`
+	MFACode      *string `json:"mfaCode" validate:"omitempty,len=6,numeric"`
+	RecoveryCode *string `json:"recoveryCode" validate:"omitempty,len=11"`
+}
+
+type AuthorizeOIDCRequestDTO struct {
+	RequestID    string  `json:"requestID" validate:"required"`
+	Pin          *string `json:"pin"`
+	MFACode      *string `json:"mfaCode" validate:"omitempty,len=6,numeric"`
+	RecoveryCode *string `json:"recoveryCode" validate:"omitempty,len=11"`
+}
+
+type SetUpAppMFADTO struct {
+	AppID string `json:"appID" validate:"required,ulid"`
+}
+
+type ConfirmAppMFADTO struct {
+	AppID string `json:"appID" validate:"required,ulid"`
+	Code  string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type RegenerateRecoveryCodesDTO struct {
+	AppID   string `json:"appID" validate:"required,ulid"`
+	MFACode string `json:"mfaCode" validate:"required,len=6,numeric"`
+}
+
+type ResetAppUsersMFADTO struct {
+	IDs []string `json:"ids" validate:"required,min=1,dive,ulid"`
+}
+
+type UpdateRedirectURIsDTO struct {
+	URIs []string `json:"uris" validate:"required,max=20,dive,url"`
 }
 
 type SubmitCustomAppFormDTO struct {
@@ -60,7 +96,9 @@ type UpdateAccessRefreshTokenTTL struct {
 }
 
 type UpdateWhitelistIPDTO struct {
-	IPs []string `json:"ips" validate:"required,dive,ip"`
+	// IP addresses or CIDR ranges. An empty list allows every IP
+	IPs     []string `json:"ips" validate:"max=100,dive,ip|cidr"`
+	Sandbox bool     `json:"sandbox"`
 }
 
 type TogglePinProtectionSettingDTO struct {
@@ -71,3 +109,47 @@ type ToggleMFAProtectionSettingDTO struct {
 	Activated bool   `json:"activated"`
 	ID        string `json:"id" validate:"ulid"`
 }
+
+type CreateAPIKeyDTO struct {
+	Label     string                 `json:"label" validate:"required,max=50"`
+	Scopes    []entities.APIKeyScope `json:"scopes" validate:"required,min=1,dive,oneof=app:read tokens:introspect kyc:nin kyc:bvn kyc:voters_card kyc:drivers_license biometric:compare biometric:liveness"`
+	Sandbox   bool                   `json:"sandbox"`
+	ExpiresAt *time.Time             `json:"expiresAt"`
+}
+
+type RotateAPIKeyDTO struct {
+	// how long the old key keeps working. defaults to 24 hours
+	OverlapHours *uint16 `json:"overlapHours" validate:"omitempty,max=720"`
+}
+
+type UpdateRequestSigningDTO struct {
+	// when true requests to the public api without a valid signature are rejected
+	Required bool `json:"required"`
+}
+
+type UpdateScreeningSettingDTO struct {
+	// when true users with a sanctions, PEP or watch list hit that is not cleared cannot sign up to the app
+	RejectHits bool `json:"rejectHits"`
+}
+
+type ReviewScreeningHitDTO struct {
+	// cleared when the hit is somebody else and confirmed when it is the user
+	Status string  `json:"status" validate:"required,oneof=cleared confirmed"`
+	Note   *string `json:"note" validate:"omitempty,max=1000"`
+}
+
+type RotateSigningKeysDTO struct {
+	Sandbox bool `json:"sandbox"`
+	// how long tokens signed with the old key keep verifying. defaults to the rotation policy's overlap or 24 hours
+	OverlapHours *uint16 `json:"overlapHours" validate:"omitempty,max=720"`
+}
+
+type UpdateSigningKeyRotationDTO struct {
+	// nil turns off scheduled rotation
+	IntervalDays *uint16 `json:"intervalDays" validate:"omitempty,min=1,max=365"`
+	OverlapHours uint16  `json:"overlapHours" validate:"max=720"`
+}
+
+type UpdateKYCCallbackDTO struct {
+	URL *string `json:"url" validate:"omitempty,url,max=2048"`
+}
@@ -34,3 +34,24 @@ type VerifyDeviceDTO struct {
 	Email *string `json:"email" validate:"omitempty,email,max=100,min=6"`
 	Phone *string `json:"phone" validate:"omitempty,len=11"`
 }
+
+type RegisterPasskeyDTO struct {
+	Name              string   `json:"name" validate:"required,max=50"`
+	CredentialID      string   `json:"credentialID" validate:"required,max=1400"`
+	ClientDataJSON    string   `json:"clientDataJSON" validate:"required,max=4096"`
+	AttestationObject string   `json:"attestationObject" validate:"required,max=16384"`
+	Transports        []string `json:"transports" validate:"max=6,dive,max=20"`
+}
+
+type PasskeyLoginOptionsDTO struct {
+	Email *string `json:"email" validate:"omitempty,email,max=100,min=6"`
+	Phone *string `json:"phone" validate:"omitempty,len=10"`
+}
+
+type PasskeyLoginDTO struct {
+	CredentialID      string  `json:"credentialID" validate:"required,max=1400"`
+	ClientDataJSON    string  `json:"clientDataJSON" validate:"required,max=4096"`
+	AuthenticatorData string  `json:"authenticatorData" validate:"required,max=4096"`
+	Signature         string  `json:"signature" validate:"required,max=1024"`
+	UserHandle        *string `json:"userHandle" validate:"omitempty,max=128"`
+}
@@ -1,9 +1,20 @@
 package dto
 
+import "gateman.io/entities"
+
 type SetNINDetails struct {
 	NIN string `json:"nin" validate:"required,len=11"`
 }
 
+type BindIdentityFaceDTO struct {
+	IDType string `json:"idType" validate:"required,oneof=nin bvn"`
+	Number string `json:"number" validate:"required,len=11"`
+}
+
+type VerifyIdentityDocumentDTO struct {
+	DocumentType entities.IdentityDocumentType `json:"documentType" validate:"required,oneof=passport nin_slip"`
+}
+
 type SetBVNDetails struct {
 	BVN string `json:"bvn" validate:"required,len=11"`
 }
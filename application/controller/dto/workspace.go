@@ -1,6 +1,9 @@
 package dto
 
-import "gateman.io/entities"
+import (
+	"gateman.io/entities"
+	identity_verification_types "gateman.io/infrastructure/identity_verification/types"
+)
 
 type CreateWorkspaceDTO struct {
 	Name     string `json:"name" validate:"required,min=2,max=100"`
@@ -11,8 +14,22 @@ type CreateWorkspaceDTO struct {
 }
 
 type LoginWorkspaceMemberDTO struct {
-	Email    string `json:"email" validate:"required,email,min=6,max=100"`
-	Password string `json:"password" validate:"required,max=30"`
+	Email        string  `json:"email" validate:"required,email,min=6,max=100"`
+	Password     string  `json:"password" validate:"required,max=30"`
+	MFACode      *string `json:"mfaCode" validate:"omitempty,len=6,numeric"`
+	RecoveryCode *string `json:"recoveryCode" validate:"omitempty,len=11"`
+}
+
+type ConfirmWorkspaceMFADTO struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type RegenerateWorkspaceRecoveryCodesDTO struct {
+	MFACode string `json:"mfaCode" validate:"required,len=6,numeric"`
+}
+
+type UpdateWorkspaceMFAPolicyDTO struct {
+	RequireMemberMFA bool `json:"requireMemberMFA"`
 }
 
 type UpdateOrgDTO struct {
@@ -21,6 +38,15 @@ type UpdateOrgDTO struct {
 	Sector        *string `json:"sector" validate:"required,oneof=fintech government health education other"`
 }
 
+type BusinessDocumentUploadDTO struct {
+	Document entities.BusinessDocument `json:"document" validate:"required,oneof=certificate_of_incorporation memorandum_of_association status_report"`
+}
+
+type VerifyBusinessDTO struct {
+	RCNumber    string                                  `json:"rcNumber" validate:"required,alphanum,max=20"`
+	CompanyType identity_verification_types.CompanyType `json:"companyType" validate:"required,oneof=RC BN IT"`
+}
+
 type MemberInvite struct {
 	Email       string                       `json:"email" validate:"required,email,min=6,max=100"`
 	Permissions []entities.MemberPermissions `json:"permissions" validate:"required"`
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/controller/dto"
+	"gateman.io/application/interfaces"
+	kycjob_services "gateman.io/application/services/kycjob"
+	"gateman.io/infrastructure/logger"
+	server_response "gateman.io/infrastructure/serverResponse"
+	"gateman.io/infrastructure/validator"
+)
+
+func UpdateKYCCallbackURL(ctx *interfaces.ApplicationContext[dto.UpdateKYCCallbackDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	secret, found, err := kycjob_services.UpdateCallbackURL(ctx.GetStringParameter("id"), ctx.GetStringContextData("WorkspaceID"), ctx.Body.URL)
+	if errors.Is(err, kycjob_services.ErrInvalidCallbackURL) {
+		apperrors.ClientError(ctx.Ctx, err.Error(), nil, nil, ctx.DeviceID)
+		return
+	}
+	if err != nil {
+		logger.Error("an error occured while updating kyc callback url", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "id", Data: ctx.GetStringParameter("id"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if !found {
+		apperrors.NotFoundError(ctx.Ctx, "Invalid app id provided. App not found", &ctx.DeviceID)
+		return
+	}
+	if secret == nil {
+		server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "KYC callback url removed", nil, nil, nil, &ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "KYC callback url updated. The callback signing secret will only be displayed once", secret, nil, nil, &ctx.DeviceID)
+}
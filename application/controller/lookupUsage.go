@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/interfaces"
+	"gateman.io/application/repository"
+	metering_services "gateman.io/application/services/metering"
+	"gateman.io/infrastructure/logger"
+	server_response "gateman.io/infrastructure/serverResponse"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Returns the billable lookups the app made through the public api in a month, this month unless a period is queried
+func FetchLookupUsage(ctx *interfaces.ApplicationContext[any]) {
+	period := metering_services.CurrentPeriod()
+	if requested, ok := ctx.Query["period"].(string); ok && requested != "" {
+		if _, err := time.Parse("2006-01", requested); err != nil {
+			apperrors.ClientError(ctx.Ctx, "period must be a month written as YYYY-MM", nil, nil, ctx.DeviceID)
+			return
+		}
+		period = requested
+	}
+	appRepo := repository.ApplicationRepo()
+	app, err := appRepo.FindOneByFilter(map[string]interface{}{
+		"_id":         ctx.GetStringParameter("id"),
+		"workspaceID": ctx.GetStringContextData("WorkspaceID"),
+	}, options.FindOne().SetProjection(map[string]any{
+		"appID": 1,
+	}))
+	if err != nil {
+		logger.Error("an error occured while fetching app to fetch lookup usage", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "id", Data: ctx.GetStringParameter("id"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if app == nil {
+		apperrors.NotFoundError(ctx.Ctx, "Invalid app id provided. App not found", &ctx.DeviceID)
+		return
+	}
+	usage, err := metering_services.Usage(app.AppID, period)
+	if err != nil {
+		logger.Error("an error occured while fetching lookup usage", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "id", Data: ctx.GetStringParameter("id"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "lookup usage fetched", usage, nil, nil, &ctx.DeviceID)
+}
@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/controller/dto"
+	"gateman.io/application/interfaces"
+	"gateman.io/application/repository"
+	services "gateman.io/application/services/application"
+	mfa_services "gateman.io/application/services/mfa"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/logger"
+	server_response "gateman.io/infrastructure/serverResponse"
+	"gateman.io/infrastructure/validator"
+)
+
+func SetUpAppMFA(ctx *interfaces.ApplicationContext[dto.SetUpAppMFADTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	app, appUser := fetchMFAAppUser(ctx.Ctx, ctx.Body.AppID, ctx.GetStringContextData("UserID"), ctx.DeviceID)
+	if appUser == nil {
+		return
+	}
+	if appUser.AuthenticatorSecret != nil {
+		apperrors.ClientError(ctx.Ctx, "MFA has already been set up on this account", nil, nil, ctx.DeviceID)
+		return
+	}
+	accountName := ctx.GetStringContextData("Email")
+	if accountName == "" {
+		accountName = ctx.GetStringContextData("Phone")
+	}
+	url, secret, err := services.StartAppUserMFAEnrollment(appUser, fmt.Sprintf("%s (%s)", app.Name, accountName))
+	if err != nil {
+		logger.Error("an error occured while trying to generate TOTP secret", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "appUserID",
+			Data: appUser.ID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "mfa secret generated", map[string]any{
+		"url":    url,
+		"secret": secret,
+	}, nil, nil, &ctx.DeviceID)
+}
+
+func ConfirmAppMFA(ctx *interfaces.ApplicationContext[dto.ConfirmAppMFADTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	_, appUser := fetchMFAAppUser(ctx.Ctx, ctx.Body.AppID, ctx.GetStringContextData("UserID"), ctx.DeviceID)
+	if appUser == nil {
+		return
+	}
+	if appUser.AuthenticatorSecret != nil {
+		apperrors.ClientError(ctx.Ctx, "MFA has already been set up on this account", nil, nil, ctx.DeviceID)
+		return
+	}
+	recoveryCodes, confirmed, err := services.ConfirmAppUserMFAEnrollment(appUser, ctx.Body.Code)
+	if err != nil {
+		if errors.Is(err, mfa_services.ErrMFAEnrollmentExpired) {
+			apperrors.ClientError(ctx.Ctx, "MFA set up has expired. Start again to get a new QR code", nil, nil, ctx.DeviceID)
+			return
+		}
+		if errors.Is(err, mfa_services.ErrMFALocked) {
+			apperrors.AuthenticationError(ctx.Ctx, "Too many incorrect codes. Try again later", ctx.DeviceID)
+			return
+		}
+		logger.Error("an error occured while confirming app user mfa", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "appUserID",
+			Data: appUser.ID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if !confirmed {
+		apperrors.AuthenticationError(ctx.Ctx, "Incorrect authenticator code", ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "mfa set up. Store your recovery codes somewhere safe, they will not be shown again", map[string]any{
+		"recoveryCodes": recoveryCodes,
+	}, nil, nil, &ctx.DeviceID)
+}
+
+func RegenerateAppRecoveryCodes(ctx *interfaces.ApplicationContext[dto.RegenerateRecoveryCodesDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	app, appUser := fetchMFAAppUser(ctx.Ctx, ctx.Body.AppID, ctx.GetStringContextData("UserID"), ctx.DeviceID)
+	if appUser == nil {
+		return
+	}
+	if appUser.AuthenticatorSecret == nil {
+		apperrors.ClientError(ctx.Ctx, "MFA has not been set up on this account", nil, nil, ctx.DeviceID)
+		return
+	}
+	if !services.VerifyAppUserMFA(ctx.Ctx, app, appUser, &ctx.Body.MFACode, nil, ctx.DeviceID) {
+		return
+	}
+	recoveryCodes, err := services.RegenerateAppUserRecoveryCodes(appUser)
+	if err != nil {
+		logger.Error("an error occured while regenerating app user recovery codes", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "appUserID",
+			Data: appUser.ID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "recovery codes generated", map[string]any{
+		"recoveryCodes": recoveryCodes,
+	}, nil, nil, &ctx.DeviceID)
+}
+
+// Lets workspace admins remove the authenticator of users who have lost access to it and their recovery codes
+func ResetAppUsersMFA(ctx *interfaces.ApplicationContext[dto.ResetAppUsersMFADTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	err := services.ResetAppUserMFA(map[string]interface{}{
+		"_id": map[string]any{
+			"$in": ctx.Body.IDs,
+		},
+		"appID":       ctx.GetStringParameter("id"),
+		"workspaceID": ctx.GetStringContextData("WorkspaceID"),
+	})
+	if err != nil {
+		logger.Error("an error occured while resetting app users mfa", logger.LoggerOptions{
+			Key:  "ids",
+			Data: ctx.Body.IDs,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "users mfa reset", nil, nil, nil, &ctx.DeviceID)
+}
+
+func fetchMFAAppUser(ctx any, appID string, userID string, deviceID string) (*entities.Application, *entities.AppUser) {
+	appRepo := repository.ApplicationRepo()
+	app, err := appRepo.FindOneByFilter(map[string]interface{}{
+		"appID": appID,
+	})
+	if err != nil {
+		logger.Error("an error occured while fetching app for mfa", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "appID",
+			Data: appID,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return nil, nil
+	}
+	if app == nil {
+		apperrors.NotFoundError(ctx, "App not found", &deviceID)
+		return nil, nil
+	}
+	appUserRepo := repository.AppUserRepo()
+	appUser, err := appUserRepo.FindOneByFilter(map[string]interface{}{
+		"userID": userID,
+		"appID":  appID,
+	})
+	if err != nil {
+		logger.Error("an error occured while fetching app user for mfa", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "userID",
+			Data: userID,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return nil, nil
+	}
+	if appUser == nil {
+		apperrors.ClientError(ctx, "Sign up to the app before setting up MFA", nil, nil, deviceID)
+		return nil, nil
+	}
+	if appUser.Blocked {
+		apperrors.AuthenticationError(ctx, "Your access to this application has been restricted", deviceID)
+		return nil, nil
+	}
+	return app, appUser
+}
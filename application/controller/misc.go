@@ -10,6 +10,7 @@ import (
 	"gateman.io/application/controller/dto"
 	"gateman.io/application/interfaces"
 	"gateman.io/application/repository"
+	kyb_services "gateman.io/application/services/kyb"
 	"gateman.io/application/utils"
 	"gateman.io/entities"
 	fileupload "gateman.io/infrastructure/file_upload"
@@ -131,6 +132,15 @@ func GeneratePaymentLink(ctx *interfaces.ApplicationContext[dto.GeneratePaymentL
 		apperrors.NotFoundError(ctx.Ctx, "Application not found", &ctx.DeviceID)
 		return
 	}
+	verified, err := kyb_services.WorkspaceVerified(application.WorkspaceID)
+	if err != nil {
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if !verified {
+		apperrors.ClientError(ctx.Ctx, "Verify your business to subscribe to a paid plan", nil, nil, ctx.DeviceID)
+		return
+	}
 	subscriptionRepo := repository.SubscriptionPlanRepo()
 	newSubscription, err := subscriptionRepo.FindByID(ctx.Body.PlanID)
 	if err != nil {
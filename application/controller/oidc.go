@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/controller/dto"
+	"gateman.io/application/interfaces"
+	"gateman.io/application/repository"
+	services "gateman.io/application/services/application"
+	application_usecase "gateman.io/application/usecases/application"
+	"gateman.io/application/utils"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/cryptography"
+	"gateman.io/infrastructure/logger"
+	server_response "gateman.io/infrastructure/serverResponse"
+	"gateman.io/infrastructure/validator"
+)
+
+// Completes an OIDC authorization request for the signed in user and returns the url the client should be redirected to.
+func AuthorizeOIDCRequest(ctx *interfaces.ApplicationContext[dto.AuthorizeOIDCRequestDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	authRequest := services.FindOIDCAuthorizationRequest(ctx.Body.RequestID)
+	if authRequest == nil {
+		apperrors.ClientError(ctx.Ctx, "This authorization request has expired. Return to the app and try signing in again.", nil, nil, ctx.DeviceID)
+		return
+	}
+	app, err := application_usecase.FetchAppUseCase(ctx.Ctx, authRequest.AppID, ctx.DeviceID, ctx.Keys["ip"].(string))
+	if err != nil {
+		return
+	}
+	userID := ctx.GetStringContextData("UserID")
+	userRepo := repository.UserRepo()
+	user, err := userRepo.FindByID(userID)
+	if err != nil {
+		logger.Error("an error occured while fetching user for oidc authorization", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "userID",
+			Data: userID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if user == nil {
+		apperrors.NotFoundError(ctx.Ctx, "This user was not found", &ctx.DeviceID)
+		return
+	}
+	appUserRepo := repository.AppUserRepo()
+	appUser, err := appUserRepo.FindOneByFilter(map[string]interface{}{
+		"userID": userID,
+		"appID":  authRequest.AppID,
+	})
+	if err != nil {
+		logger.Error("an error occured while fetching app user for oidc authorization", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "userID",
+			Data: userID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	responseCode, ok := services.AuthoriseAppUserSignIn(ctx.Ctx, app, appUser, ctx.Body.Pin, ctx.Body.MFACode, ctx.Body.RecoveryCode, ctx.DeviceID)
+	if !ok {
+		return
+	}
+
+	eligible, msg, payload, _ := services.ProcessUserSignUp(app, user, ctx.Keys["ip"].(string))
+	if !eligible {
+		server_response.Responder.Respond(ctx.Ctx, http.StatusBadRequest, msg, payload, nil, nil, &ctx.DeviceID)
+		return
+	}
+	if appUser == nil {
+		var pin []byte
+		if ctx.Body.Pin != nil {
+			pin, _ = cryptography.CryptoHahser.HashString(*ctx.Body.Pin, nil)
+		}
+		appUser, err = appUserRepo.CreateOne(context.TODO(), entities.AppUser{
+			AppID:       authRequest.AppID,
+			UserID:      userID,
+			WorkspaceID: app.WorkspaceID,
+			Pin:         utils.GetStringPointer(string(pin)),
+		})
+		if err != nil {
+			logger.Error("an error occured while creating app user for oidc authorization", logger.LoggerOptions{
+				Key: "err", Data: err,
+			}, logger.LoggerOptions{
+				Key:  "userID",
+				Data: userID,
+			})
+			apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+			return
+		}
+		if !services.VerifyAppUserMFA(ctx.Ctx, app, appUser, ctx.Body.MFACode, ctx.Body.RecoveryCode, ctx.DeviceID) {
+			return
+		}
+	}
+	block, err := services.CheckMonthlyLimit(ctx.Ctx, app.ID, appUser.ID, ctx.DeviceID)
+	if err != nil || block {
+		return
+	}
+
+	code, err := services.IssueOIDCAuthorizationCode(services.OIDCAuthorizationCode{
+		OIDCAuthorizationRequest: *authRequest,
+		UserID:                   userID,
+		DeviceID:                 ctx.DeviceID,
+		UserAgent:                ctx.UserAgent,
+	})
+	if err != nil {
+		logger.Error("an error occured while issuing oidc authorization code", logger.LoggerOptions{
+			Key: "err", Data: err,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	services.DeleteOIDCAuthorizationRequest(ctx.Body.RequestID)
+
+	redirectURL, _ := url.Parse(authRequest.RedirectURI)
+	query := redirectURL.Query()
+	query.Set("code", *code)
+	if authRequest.State != nil {
+		query.Set("state", *authRequest.State)
+	}
+	redirectURL.RawQuery = query.Encode()
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, msg, map[string]any{
+		"redirectURL": redirectURL.String(),
+	}, nil, responseCode, &ctx.DeviceID)
+}
+
+func UpdateAppRedirectURIs(ctx *interfaces.ApplicationContext[dto.UpdateRedirectURIsDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	for _, uri := range ctx.Body.URIs {
+		parsedURI, err := url.Parse(uri)
+		// redirect uris are compared exactly and must not carry a fragment (RFC 6749 section 3.1.2)
+		if err != nil || parsedURI.Fragment != "" || !parsedURI.IsAbs() {
+			apperrors.ClientError(ctx.Ctx, "please enter only absolute redirect uris without a fragment", nil, nil, ctx.DeviceID)
+			return
+		}
+	}
+	appRepo := repository.ApplicationRepo()
+	updated, err := appRepo.UpdatePartialByFilter(map[string]interface{}{
+		"_id":         ctx.GetStringParameter("id"),
+		"workspaceID": ctx.GetStringContextData("WorkspaceID"),
+	}, map[string]any{
+		"redirectURIs": utils.MakeStringArrayUnique(ctx.Body.URIs),
+	})
+	if err != nil {
+		logger.Error("an error occured while updating app redirect uris", logger.LoggerOptions{
+			Key: "params", Data: ctx.Param,
+		}, logger.LoggerOptions{
+			Key: "payload", Data: ctx.Body,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if !updated {
+		apperrors.NotFoundError(ctx.Ctx, "Invalid app id provided. App not found", &ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Redirect URIs updated", nil, nil, nil, &ctx.DeviceID)
+}
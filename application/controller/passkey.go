@@ -0,0 +1,299 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/controller/dto"
+	"gateman.io/application/interfaces"
+	"gateman.io/application/repository"
+	passkey_services "gateman.io/application/services/passkey"
+	"gateman.io/application/utils"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/biometric"
+	fileupload "gateman.io/infrastructure/file_upload"
+	"gateman.io/infrastructure/file_upload/types"
+	"gateman.io/infrastructure/logger"
+	server_response "gateman.io/infrastructure/serverResponse"
+	"gateman.io/infrastructure/validator"
+	"gateman.io/infrastructure/webauthn"
+)
+
+// Starts adding a passkey. The user has to match their face on the device first so a stolen session cannot plant a passkey.
+func BeginPasskeyRegistration(ctx *interfaces.ApplicationContext[any]) {
+	user := fetchPasskeyUser(ctx.Ctx, ctx.GetStringContextData("UserID"), ctx.DeviceID)
+	if user == nil {
+		return
+	}
+	if len(user.Passkeys) >= passkey_services.MaxPasskeysPerUser {
+		apperrors.ClientError(ctx.Ctx, "You have reached the maximum number of passkeys. Remove one before adding another", nil, nil, ctx.DeviceID)
+		return
+	}
+	if !verifyFaceStepUp(ctx.Ctx, user, ctx.DeviceID) {
+		return
+	}
+	options, err := passkey_services.RegistrationOptions(user)
+	if err != nil {
+		if errors.Is(err, passkey_services.ErrPasskeyLimitReached) {
+			apperrors.ClientError(ctx.Ctx, "You have reached the maximum number of passkeys. Remove one before adding another", nil, nil, ctx.DeviceID)
+			return
+		}
+		logger.Error("an error occured while generating passkey registration options", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "userID",
+			Data: user.ID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "passkey registration options generated", options, nil, nil, &ctx.DeviceID)
+}
+
+func FinishPasskeyRegistration(ctx *interfaces.ApplicationContext[dto.RegisterPasskeyDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	clientDataJSON, err := webauthn.DecodeBase64URL(ctx.Body.ClientDataJSON)
+	if err != nil {
+		apperrors.ClientError(ctx.Ctx, "clientDataJSON must be base64url encoded", nil, nil, ctx.DeviceID)
+		return
+	}
+	attestationObject, err := webauthn.DecodeBase64URL(ctx.Body.AttestationObject)
+	if err != nil {
+		apperrors.ClientError(ctx.Ctx, "attestationObject must be base64url encoded", nil, nil, ctx.DeviceID)
+		return
+	}
+	user := fetchPasskeyUser(ctx.Ctx, ctx.GetStringContextData("UserID"), ctx.DeviceID)
+	if user == nil {
+		return
+	}
+	if len(user.Passkeys) >= passkey_services.MaxPasskeysPerUser {
+		apperrors.ClientError(ctx.Ctx, "You have reached the maximum number of passkeys. Remove one before adding another", nil, nil, ctx.DeviceID)
+		return
+	}
+	passkey, err := passkey_services.RegisterPasskey(user, ctx.Body.Name, ctx.Body.CredentialID, clientDataJSON, attestationObject, ctx.Body.Transports)
+	if err != nil {
+		if errors.Is(err, passkey_services.ErrPasskeyChallengeExpired) {
+			apperrors.ClientError(ctx.Ctx, "Passkey registration has expired. Start again", nil, nil, ctx.DeviceID)
+			return
+		}
+		if errors.Is(err, passkey_services.ErrPasskeyAlreadyRegistered) {
+			apperrors.ClientError(ctx.Ctx, "This passkey has already been registered", nil, nil, ctx.DeviceID)
+			return
+		}
+		logger.Error("an error occured while registering passkey", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "userID",
+			Data: user.ID,
+		})
+		apperrors.ClientError(ctx.Ctx, "Passkey could not be verified", nil, nil, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusCreated, "passkey registered", passkey, nil, nil, &ctx.DeviceID)
+}
+
+func FetchPasskeys(ctx *interfaces.ApplicationContext[any]) {
+	user := fetchPasskeyUser(ctx.Ctx, ctx.GetStringContextData("UserID"), ctx.DeviceID)
+	if user == nil {
+		return
+	}
+	passkeys := user.Passkeys
+	if passkeys == nil {
+		passkeys = []entities.PasskeyCredential{}
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "passkeys fetched", passkeys, nil, nil, &ctx.DeviceID)
+}
+
+func DeletePasskey(ctx *interfaces.ApplicationContext[any]) {
+	user := fetchPasskeyUser(ctx.Ctx, ctx.GetStringContextData("UserID"), ctx.DeviceID)
+	if user == nil {
+		return
+	}
+	deleted, err := passkey_services.DeletePasskey(user, ctx.GetStringParameter("id"))
+	if err != nil {
+		logger.Error("an error occured while deleting passkey", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "userID",
+			Data: user.ID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if !deleted {
+		apperrors.NotFoundError(ctx.Ctx, "passkey not found", &ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "passkey deleted", nil, nil, nil, &ctx.DeviceID)
+}
+
+// Starts a passkey login on the device. Unknown accounts get the same response as accounts without passkeys.
+func BeginPasskeyLogin(ctx *interfaces.ApplicationContext[dto.PasskeyLoginOptionsDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	var user *entities.User
+	if ctx.Body.Email != nil || ctx.Body.Phone != nil {
+		var accountSearchFilter = map[string]any{}
+		if ctx.Body.Email != nil {
+			accountSearchFilter["email"] = *ctx.Body.Email
+		} else {
+			accountSearchFilter["phone.localNumber"] = *ctx.Body.Phone
+		}
+		userRepo := repository.UserRepo()
+		account, err := userRepo.FindOneByFilter(accountSearchFilter)
+		if err != nil {
+			apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+			return
+		}
+		user = account
+	}
+	options, err := passkey_services.LoginOptions(ctx.DeviceID, user)
+	if err != nil {
+		logger.Error("an error occured while generating passkey login options", logger.LoggerOptions{
+			Key: "err", Data: err,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "passkey login options generated", options, nil, nil, &ctx.DeviceID)
+}
+
+// Signs the user in with a passkey. A successful assertion trusts the device the same way a face match does.
+func FinishPasskeyLogin(ctx *interfaces.ApplicationContext[dto.PasskeyLoginDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	clientDataJSON, err := webauthn.DecodeBase64URL(ctx.Body.ClientDataJSON)
+	if err != nil {
+		apperrors.ClientError(ctx.Ctx, "clientDataJSON must be base64url encoded", nil, nil, ctx.DeviceID)
+		return
+	}
+	authenticatorData, err := webauthn.DecodeBase64URL(ctx.Body.AuthenticatorData)
+	if err != nil {
+		apperrors.ClientError(ctx.Ctx, "authenticatorData must be base64url encoded", nil, nil, ctx.DeviceID)
+		return
+	}
+	signature, err := webauthn.DecodeBase64URL(ctx.Body.Signature)
+	if err != nil {
+		apperrors.ClientError(ctx.Ctx, "signature must be base64url encoded", nil, nil, ctx.DeviceID)
+		return
+	}
+	account, err := passkey_services.VerifyPasskeyLogin(ctx.DeviceID, ctx.Body.CredentialID, ctx.Body.UserHandle, clientDataJSON, authenticatorData, signature, ctx.GetStringContextData("ip"))
+	if err != nil {
+		if errors.Is(err, passkey_services.ErrPasskeyChallengeExpired) {
+			apperrors.ClientError(ctx.Ctx, "Passkey login has expired. Start again", nil, nil, ctx.DeviceID)
+			return
+		}
+		logger.Error("passkey login failed", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "credentialID",
+			Data: ctx.Body.CredentialID,
+		})
+		apperrors.AuthenticationError(ctx.Ctx, "Passkey could not be verified", ctx.DeviceID)
+		return
+	}
+	if !account.VerifiedAccount {
+		apperrors.AuthenticationError(ctx.Ctx, "Verify your account before attempting to login", ctx.DeviceID)
+		return
+	}
+	if account.Deactivated || account.Blocked {
+		apperrors.AuthenticationError(ctx.Ctx, "Your account has been restricted", ctx.DeviceID)
+		return
+	}
+	signInVerifiedDevice(ctx.Ctx, account, entities.Device{
+		ID:        ctx.DeviceID,
+		Name:      ctx.DeviceName,
+		UserAgent: ctx.UserAgent,
+		LastLogin: time.Now(),
+	})
+}
+
+func fetchPasskeyUser(ctx any, userID string, deviceID string) *entities.User {
+	userRepo := repository.UserRepo()
+	user, err := userRepo.FindByID(userID)
+	if err != nil {
+		logger.Error("an error occured while fetching user for passkeys", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "userID",
+			Data: userID,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return nil
+	}
+	if user == nil {
+		apperrors.NotFoundError(ctx, "account not found", &deviceID)
+		return nil
+	}
+	return user
+}
+
+// Checks the face image the user just uploaded for the device against the one on their account.
+// The upload is removed once it matches so it cannot be reused for another step up.
+func verifyFaceStepUp(ctx any, user *entities.User, deviceID string) bool {
+	imagePath := fmt.Sprintf("%s/%s", user.ID, deviceID)
+	exists, err := fileupload.FileUploader.CheckFileExists(imagePath)
+	if err != nil {
+		apperrors.ExternalDependencyError(ctx, "azure", "500", err, deviceID)
+		return false
+	}
+	if !exists {
+		apperrors.ClientError(ctx, "Image has not been uploaded. Request for a new url and upload image before attempting this request again.", nil, utils.GetUIntPointer(http.StatusBadRequest), deviceID)
+		return false
+	}
+	url, _ := fileupload.FileUploader.GeneratedSignedURL(imagePath, types.SignedURLPermission{
+		Read: true,
+	}, time.Minute*1)
+	alive, err := biometric.BiometricService.ImageLivenessCheck(url)
+	if err != nil {
+		logger.Error("something went wrong when verifying image", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return false
+	}
+	if !alive.Success {
+		apperrors.ClientError(ctx, "Please make sure to take a clear picture of your face", nil, nil, deviceID)
+		return false
+	}
+	accountImgURL, _ := fileupload.FileUploader.GeneratedSignedURL(user.Image, types.SignedURLPermission{
+		Read: true,
+	}, time.Minute*1)
+	match, err := biometric.BiometricService.CompareFaces(url, accountImgURL)
+	if err != nil {
+		logger.Error("something went wrong when match images", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return false
+	}
+	if !match.Success {
+		apperrors.ClientError(ctx, "Face mismatch", nil, nil, deviceID)
+		return false
+	}
+	err = fileupload.FileUploader.DeleteFile(imagePath)
+	if err != nil {
+		logger.Error("an error occured while trying to clear user device image", logger.LoggerOptions{
+			Key:  "filePath",
+			Data: imagePath,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"net/http"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/controller/dto"
+	"gateman.io/application/interfaces"
+	"gateman.io/application/repository"
+	services "gateman.io/application/services/application"
+	"gateman.io/infrastructure/logger"
+	server_response "gateman.io/infrastructure/serverResponse"
+	"gateman.io/infrastructure/validator"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func RefreshRequestSigningSecret(ctx *interfaces.ApplicationContext[any]) {
+	secret, found, err := services.RefreshRequestSigningSecret(ctx.GetStringParameter("id"), ctx.GetStringContextData("WorkspaceID"))
+	if err != nil {
+		logger.Error("an error occured while refreshing request signing secret", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "id", Data: ctx.GetStringParameter("id"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if !found {
+		apperrors.NotFoundError(ctx.Ctx, "Invalid app id provided. App not found", &ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Request signing secret updated. This will only be displayed once", secret, nil, nil, &ctx.DeviceID)
+}
+
+func UpdateRequestSigningSetting(ctx *interfaces.ApplicationContext[dto.UpdateRequestSigningDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	appRepo := repository.ApplicationRepo()
+	app, err := appRepo.FindOneByFilter(map[string]interface{}{
+		"_id":         ctx.GetStringParameter("id"),
+		"workspaceID": ctx.GetStringContextData("WorkspaceID"),
+	}, options.FindOne().SetProjection(map[string]any{
+		"appID":                1,
+		"requestSigningSecret": 1,
+	}))
+	if err != nil {
+		logger.Error("an error occured while fetching app to update request signing", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "id", Data: ctx.GetStringParameter("id"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if app == nil {
+		apperrors.NotFoundError(ctx.Ctx, "Invalid app id provided. App not found", &ctx.DeviceID)
+		return
+	}
+	if ctx.Body.Required && app.RequestSigningSecret == "" {
+		apperrors.ClientError(ctx.Ctx, "Generate a request signing secret before making signed requests mandatory", nil, nil, ctx.DeviceID)
+		return
+	}
+	_, err = appRepo.UpdatePartialByID(app.ID, map[string]any{
+		"requireSignedRequests": ctx.Body.Required,
+	})
+	if err != nil {
+		logger.Error("an error occured while updating request signing setting", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "id", Data: app.ID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	services.PurgeAPIKeyCache(app.AppID, services.APIKeyCachePurgeSigningUpdated)
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Request signing setting updated", nil, nil, nil, &ctx.DeviceID)
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/controller/dto"
+	"gateman.io/application/interfaces"
+	"gateman.io/application/repository"
+	screening_services "gateman.io/application/services/screening"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/logger"
+	server_response "gateman.io/infrastructure/serverResponse"
+	"gateman.io/infrastructure/validator"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func UpdateScreeningSetting(ctx *interfaces.ApplicationContext[dto.UpdateScreeningSettingDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	appRepo := repository.ApplicationRepo()
+	found, err := appRepo.UpdatePartialByFilter(map[string]interface{}{
+		"_id":         ctx.GetStringParameter("id"),
+		"workspaceID": ctx.GetStringContextData("WorkspaceID"),
+	}, map[string]any{
+		"rejectScreeningHits": ctx.Body.RejectHits,
+	})
+	if err != nil {
+		logger.Error("an error occured while updating screening setting", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "id", Data: ctx.GetStringParameter("id"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if !found {
+		apperrors.NotFoundError(ctx.Ctx, "Invalid app id provided. App not found", &ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Screening setting updated", nil, nil, nil, &ctx.DeviceID)
+}
+
+// Returns the screening hits that stopped users from signing up to the app along with the app's reviews of them
+func FetchScreeningHitReviews(ctx *interfaces.ApplicationContext[any]) {
+	app := fetchScreeningApp(ctx)
+	if app == nil {
+		return
+	}
+	var status *entities.ScreeningHitStatus
+	if requested, ok := ctx.Query["status"].(string); ok && requested != "" {
+		switch entities.ScreeningHitStatus(requested) {
+		case entities.ScreeningHitOpen, entities.ScreeningHitCleared, entities.ScreeningHitConfirmed:
+			status = (*entities.ScreeningHitStatus)(&requested)
+		default:
+			apperrors.ClientError(ctx.Ctx, "status must be one of open, cleared or confirmed", nil, nil, ctx.DeviceID)
+			return
+		}
+	}
+	var lastID *string
+	if requested, ok := ctx.Query["lastID"].(string); ok && requested != "" {
+		lastID = &requested
+	}
+	reviews, err := screening_services.AppHitReviews(app.ID, status, lastID, 20)
+	if err != nil {
+		logger.Error("an error occured while fetching screening hit reviews", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "id", Data: app.ID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "screening hit reviews fetched", reviews, nil, nil, &ctx.DeviceID)
+}
+
+// Clears or confirms a screening hit for the app
+func ReviewScreeningHit(ctx *interfaces.ApplicationContext[dto.ReviewScreeningHitDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	app := fetchScreeningApp(&interfaces.ApplicationContext[any]{
+		Ctx:      ctx.Ctx,
+		Keys:     ctx.Keys,
+		Param:    ctx.Param,
+		DeviceID: ctx.DeviceID,
+	})
+	if app == nil {
+		return
+	}
+	err := screening_services.ReviewHit(app.ID, ctx.GetStringParameter("reviewID"), ctx.GetStringContextData("UserID"), entities.ScreeningHitStatus(ctx.Body.Status), ctx.Body.Note)
+	if err != nil {
+		if errors.Is(err, screening_services.ErrReviewNotFound) {
+			apperrors.NotFoundError(ctx.Ctx, "screening hit review not found", &ctx.DeviceID)
+			return
+		}
+		logger.Error("an error occured while reviewing screening hit", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "reviewID", Data: ctx.GetStringParameter("reviewID"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "screening hit reviewed", nil, nil, nil, &ctx.DeviceID)
+}
+
+func fetchScreeningApp(ctx *interfaces.ApplicationContext[any]) *entities.Application {
+	app, err := repository.ApplicationRepo().FindOneByFilter(map[string]interface{}{
+		"_id":         ctx.GetStringParameter("id"),
+		"workspaceID": ctx.GetStringContextData("WorkspaceID"),
+	}, options.FindOne().SetProjection(map[string]any{
+		"_id": 1,
+	}))
+	if err != nil {
+		logger.Error("an error occured while fetching app for screening reviews", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "id", Data: ctx.GetStringParameter("id"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return nil
+	}
+	if app == nil {
+		apperrors.NotFoundError(ctx.Ctx, "Invalid app id provided. App not found", &ctx.DeviceID)
+		return nil
+	}
+	return app
+}
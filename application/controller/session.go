@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"net/http"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/interfaces"
+	"gateman.io/application/repository"
+	auth_usecases "gateman.io/application/usecases/auth"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/auth"
+	"gateman.io/infrastructure/logger"
+	server_response "gateman.io/infrastructure/serverResponse"
+)
+
+func FetchUserSessions(ctx *interfaces.ApplicationContext[any]) {
+	user := fetchSessionUser(ctx)
+	if user == nil {
+		return
+	}
+	sessions := auth_usecases.FetchSessionsUseCase(user.Devices, user.ID, auth.UserTokenFamily, ctx.DeviceID)
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "sessions fetched", sessions, nil, nil, &ctx.DeviceID)
+}
+
+func RevokeUserSession(ctx *interfaces.ApplicationContext[any]) {
+	user := fetchSessionUser(ctx)
+	if user == nil {
+		return
+	}
+	deviceID := ctx.GetStringParameter("deviceID")
+	if !hasDevice(user.Devices, deviceID) {
+		apperrors.NotFoundError(ctx.Ctx, "device not found", &ctx.DeviceID)
+		return
+	}
+	auth_usecases.RevokeSessionUseCase(ctx.Ctx, user.ID, auth.UserTokenFamily, deviceID, "user revoked device session")
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "device signed out", nil, nil, nil, &ctx.DeviceID)
+}
+
+func SignOutUserEverywhere(ctx *interfaces.ApplicationContext[any]) {
+	user := fetchSessionUser(ctx)
+	if user == nil {
+		return
+	}
+	auth_usecases.SignOutEverywhereUseCase(ctx.Ctx, user.ID, auth.UserTokenFamily, user.Devices, "user signed out of all devices")
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "signed out of all devices", nil, nil, nil, &ctx.DeviceID)
+}
+
+func FetchWorkspaceMemberSessions(ctx *interfaces.ApplicationContext[any]) {
+	member := fetchSessionWorkspaceMember(ctx)
+	if member == nil {
+		return
+	}
+	sessions := auth_usecases.FetchSessionsUseCase(member.Devices, member.ID, auth.WorkspaceMemberTokenFamily, ctx.DeviceID)
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "sessions fetched", sessions, nil, nil, &ctx.DeviceID)
+}
+
+func RevokeWorkspaceMemberSession(ctx *interfaces.ApplicationContext[any]) {
+	member := fetchSessionWorkspaceMember(ctx)
+	if member == nil {
+		return
+	}
+	deviceID := ctx.GetStringParameter("deviceID")
+	if !hasDevice(member.Devices, deviceID) {
+		apperrors.NotFoundError(ctx.Ctx, "device not found", &ctx.DeviceID)
+		return
+	}
+	auth_usecases.RevokeSessionUseCase(ctx.Ctx, member.ID, auth.WorkspaceMemberTokenFamily, deviceID, "workspace member revoked device session")
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "device signed out", nil, nil, nil, &ctx.DeviceID)
+}
+
+func SignOutWorkspaceMemberEverywhere(ctx *interfaces.ApplicationContext[any]) {
+	member := fetchSessionWorkspaceMember(ctx)
+	if member == nil {
+		return
+	}
+	auth_usecases.SignOutEverywhereUseCase(ctx.Ctx, member.ID, auth.WorkspaceMemberTokenFamily, member.Devices, "workspace member signed out of all devices")
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "signed out of all devices", nil, nil, nil, &ctx.DeviceID)
+}
+
+func fetchSessionUser(ctx *interfaces.ApplicationContext[any]) *entities.User {
+	userRepo := repository.UserRepo()
+	user, err := userRepo.FindByID(ctx.GetStringContextData("UserID"))
+	if err != nil {
+		logger.Error("an error occured while fetching user for session management", logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return nil
+	}
+	if user == nil {
+		apperrors.NotFoundError(ctx.Ctx, "This user was not found", &ctx.DeviceID)
+		return nil
+	}
+	return user
+}
+
+func fetchSessionWorkspaceMember(ctx *interfaces.ApplicationContext[any]) *entities.WorkspaceMember {
+	workspaceMemberRepo := repository.WorkspaceMemberRepo()
+	member, err := workspaceMemberRepo.FindByID(ctx.GetStringContextData("UserID"))
+	if err != nil {
+		logger.Error("an error occured while fetching workspace member for session management", logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return nil
+	}
+	if member == nil {
+		apperrors.NotFoundError(ctx.Ctx, "workspace member not found", &ctx.DeviceID)
+		return nil
+	}
+	return member
+}
+
+func hasDevice(devices []entities.Device, deviceID string) bool {
+	for _, device := range devices {
+		if device.ID == deviceID {
+			return true
+		}
+	}
+	return false
+}
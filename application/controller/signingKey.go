@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/controller/dto"
+	"gateman.io/application/interfaces"
+	"gateman.io/application/repository"
+	services "gateman.io/application/services/application"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/logger"
+	server_response "gateman.io/infrastructure/serverResponse"
+	"gateman.io/infrastructure/validator"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Issues a new signing key version for an environment. Tokens signed with the old version keep verifying for the overlap.
+func RotateAppSigningKeys(ctx *interfaces.ApplicationContext[dto.RotateSigningKeysDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	signingKey, appSigningKey, retiresAt := rotateAppSigningKeys(ctx.Ctx, ctx.GetStringParameter("id"), ctx.GetStringContextData("WorkspaceID"), ctx.Body.Sandbox, ctx.Body.OverlapHours, ctx.DeviceID)
+	if signingKey == nil {
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Signing keys rotated. The app signing key will only be displayed once", map[string]any{
+		"keyID":             signingKey.KeyID,
+		"version":           signingKey.Version,
+		"algorithm":         signingKey.Algorithm,
+		"publicKey":         signingKey.PublicKey,
+		"appSigningKey":     appSigningKey,
+		"appSigningKeyID":   services.AppSigningKeyID(*appSigningKey),
+		"previousRetiresAt": retiresAt,
+	}, nil, nil, &ctx.DeviceID)
+}
+
+func FetchAppSigningKeys(ctx *interfaces.ApplicationContext[any]) {
+	app := fetchSigningKeyApp(ctx.Ctx, ctx.GetStringParameter("id"), ctx.GetStringContextData("WorkspaceID"), ctx.DeviceID)
+	if app == nil {
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "signing keys fetched", map[string]any{
+		"versions":           services.AppSigningKeyHistory(app),
+		"signingKeyRotation": app.SigningKeyRotation,
+	}, nil, nil, &ctx.DeviceID)
+}
+
+// Sets how often the app's signing keys are rotated by the scheduled rotation
+func UpdateSigningKeyRotation(ctx *interfaces.ApplicationContext[dto.UpdateSigningKeyRotationDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	var policy *entities.KeyRotationPolicy
+	if ctx.Body.IntervalDays != nil {
+		policy = &entities.KeyRotationPolicy{
+			IntervalDays:   *ctx.Body.IntervalDays,
+			OverlapHours:   ctx.Body.OverlapHours,
+			NextRotationAt: time.Now().Add(time.Hour * 24 * time.Duration(*ctx.Body.IntervalDays)),
+		}
+	}
+	appRepo := repository.ApplicationRepo()
+	updated, err := appRepo.UpdatePartialByFilter(map[string]interface{}{
+		"_id":         ctx.GetStringParameter("id"),
+		"workspaceID": ctx.GetStringContextData("WorkspaceID"),
+	}, map[string]any{
+		"signingKeyRotation": policy,
+	})
+	if err != nil {
+		logger.Error("an error occured while updating signing key rotation", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "id", Data: ctx.GetStringParameter("id"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if !updated {
+		apperrors.NotFoundError(ctx.Ctx, "Invalid app id provided. App not found", &ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "signing key rotation updated", policy, nil, nil, &ctx.DeviceID)
+}
+
+// Rotates the signing keys of an environment of the app and responds with an error if it could not.
+// When overlapHours is nil the overlap of the app's rotation policy is used, or 24 hours if the app has none.
+func rotateAppSigningKeys(ctx any, id string, workspaceID string, sandbox bool, overlapHours *uint16, deviceID string) (*entities.TokenSigningKey, *string, *time.Time) {
+	app := fetchSigningKeyApp(ctx, id, workspaceID, deviceID)
+	if app == nil {
+		return nil, nil, nil
+	}
+	overlap := uint16(24)
+	if overlapHours != nil {
+		overlap = *overlapHours
+	} else if app.SigningKeyRotation != nil {
+		overlap = app.SigningKeyRotation.OverlapHours
+	}
+	hadSigningKey := app.TokenSigningKey != nil
+	if sandbox {
+		hadSigningKey = app.SandboxTokenSigningKey != nil
+	}
+	signingKey, appSigningKey, err := services.RotateAppSigningKeys(app, sandbox, time.Hour*time.Duration(overlap))
+	if err != nil {
+		if errors.Is(err, services.ErrSigningKeyRotationConflict) {
+			apperrors.ClientError(ctx, "The signing keys were rotated by another request. Fetch the keys and try again", nil, nil, deviceID)
+			return nil, nil, nil
+		}
+		logger.Error("an error occured while rotating app signing keys", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "id", Data: id,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return nil, nil, nil
+	}
+	if !hadSigningKey {
+		return signingKey, appSigningKey, nil
+	}
+	retiresAt := time.Now().Add(time.Hour * time.Duration(overlap))
+	return signingKey, appSigningKey, &retiresAt
+}
+
+func fetchSigningKeyApp(ctx any, id string, workspaceID string, deviceID string) *entities.Application {
+	appRepo := repository.ApplicationRepo()
+	app, err := appRepo.FindOneByFilter(map[string]interface{}{
+		"_id":         id,
+		"workspaceID": workspaceID,
+	}, options.FindOne().SetProjection(map[string]any{
+		"appID":                  1,
+		"appSigningKey":          1,
+		"sandBoxAppSigningKey":   1,
+		"tokenSigningKey":        1,
+		"sandboxTokenSigningKey": 1,
+		"retiredSigningKeys":     1,
+		"signingKeyRotation":     1,
+	}))
+	if err != nil {
+		logger.Error("an error occured while fetching app for signing keys", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "id", Data: id,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return nil
+	}
+	if app == nil {
+		apperrors.NotFoundError(ctx, "Invalid app id provided. App not found", &deviceID)
+		return nil
+	}
+	return app
+}
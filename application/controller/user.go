@@ -1,7 +1,7 @@
 package controller
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -12,6 +12,12 @@ import (
 	"gateman.io/application/controller/dto"
 	"gateman.io/application/interfaces"
 	"gateman.io/application/repository"
+	dataprotection_services "gateman.io/application/services/dataprotection"
+	document_services "gateman.io/application/services/document"
+	facebinding_services "gateman.io/application/services/facebinding"
+	identitymatch_services "gateman.io/application/services/identitymatch"
+	metering_services "gateman.io/application/services/metering"
+	screening_services "gateman.io/application/services/screening"
 	"gateman.io/application/utils"
 	"gateman.io/entities"
 	"gateman.io/infrastructure/auth"
@@ -80,14 +86,19 @@ func SetAccountImage(ctx *interfaces.ApplicationContext[any]) {
 		return
 	}
 	account.Devices = append(account.Devices, entities.Device{
-		ID:        savedDevice.ID,
-		Name:      savedDevice.Name,
-		LastLogin: savedDevice.LastLogin,
-		Verified:  true,
+		ID:                savedDevice.ID,
+		Name:              savedDevice.Name,
+		UserAgent:         savedDevice.UserAgent,
+		LastLogin:         savedDevice.LastLogin,
+		LastLoginLocation: savedDevice.LastLoginLocation,
+		Verified:          true,
 	})
 	_, err = userRepo.UpdatePartialByID(account.ID, map[string]any{
 		"image":   fmt.Sprintf("%s/%s", ctx.GetStringContextData("UserID"), "accountimage"),
 		"devices": account.Devices,
+		// faces matched to the previous image have to be matched again
+		"ninFaceBinding": nil,
+		"bvnFaceBinding": nil,
 	})
 
 	if err != nil {
@@ -132,15 +143,65 @@ func SetAccountImage(ctx *interfaces.ApplicationContext[any]) {
 	}
 	hashedAccessToken, _ := cryptography.CryptoHahser.HashString(*accessToken, nil)
 	hashedRefreshToken, _ := cryptography.CryptoHahser.HashString(*refreshToken, nil)
-	hashedDeviceID, _ := cryptography.CryptoHahser.HashString(ctx.DeviceID, []byte(os.Getenv("HASH_FIXED_SALT")))
-	cache.Cache.CreateEntry(fmt.Sprintf("%s-access", string(hashedDeviceID)), hashedAccessToken, time.Hour*1)        // token should last for 10 mins
-	cache.Cache.CreateEntry(fmt.Sprintf("%s-refresh", string(hashedDeviceID)), hashedRefreshToken, time.Hour*24*180) // token should last for 10 mins
+	cache.Cache.CreateEntry(auth.DeviceSessionKey(ctx.DeviceID, "access"), hashedAccessToken, time.Hour*1)        // token should last for 10 mins
+	cache.Cache.CreateEntry(auth.DeviceSessionKey(ctx.DeviceID, "refresh"), hashedRefreshToken, time.Hour*24*180) // token should last for 10 mins
 	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "image set", map[string]any{
 		"accessToken":  accessToken,
 		"refreshToken": refreshToken,
 	}, nil, nil, &ctx.DeviceID)
 }
 
+// Returns whether an identity record matched the account of userID.
+// How it was decided is saved on the account and logged when it did not match.
+func identityMatches(userID string, result identitymatch_services.MatchResult) bool {
+	if _, err := repository.UserRepo().UpdatePartialByID(userID, map[string]any{
+		fmt.Sprintf("identityMatches.%s", result.Check): result.Explanation(),
+	}); err != nil {
+		logger.Error("an error occured while saving identity match", logger.LoggerOptions{
+			Key: "userID", Data: userID,
+		}, logger.LoggerOptions{
+			Key: "err", Data: err,
+		})
+	}
+	if !result.Match {
+		logger.Info("identity record did not match account", logger.LoggerOptions{
+			Key: "userID", Data: userID,
+		}, logger.LoggerOptions{
+			Key: "match", Data: result,
+		})
+	}
+	return result.Match
+}
+
+// Screens the identity just verified for userID against the sanctions and PEP lists without holding up the response.
+// watchListed is the watch list flag of a BVN record and empty for other records.
+func screenVerifiedIdentity(userID string, trigger entities.ScreeningTrigger, identity identitymatch_services.Identity, watchListed string) {
+	go func() {
+		if err := screening_services.RecordBVNWatchList(userID, watchListed, identity); err != nil {
+			logger.Error("an error occured while recording bvn watch list hit", logger.LoggerOptions{
+				Key: "userID", Data: userID,
+			}, logger.LoggerOptions{
+				Key: "err", Data: err,
+			})
+		}
+		if _, err := screening_services.ScreenUser(userID, identity, trigger); err != nil {
+			logger.Error("an error occured while screening verified identity", logger.LoggerOptions{
+				Key: "userID", Data: userID,
+			}, logger.LoggerOptions{
+				Key: "err", Data: err,
+			})
+		}
+	}()
+}
+
+// The fields of the provider responses the verification flows read. The rest of a cached response is never decrypted.
+var (
+	ninFieldsInUse      = []string{"first_name", "middle_name", "last_name", "phone_number", "gender", "date_of_birth", "residence_address_line_1"}
+	bvnFieldsInUse      = []string{"first_name", "middle_name", "last_name", "phone_number1", "gender", "date_of_birth", "residential_address", "watch_listed"}
+	driverIDFieldsInUse = []string{"firstName", "middleName", "lastName", "gender", "birthDate", "photo"}
+	voterIDFieldsInUse  = []string{"full_name", "gender", "phone", "address"}
+)
+
 func SetNINDetails(ctx *interfaces.ApplicationContext[dto.SetNINDetails]) {
 	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
 	if valiedationErr != nil {
@@ -149,46 +210,55 @@ func SetNINDetails(ctx *interfaces.ApplicationContext[dto.SetNINDetails]) {
 	}
 	userRepo := repository.UserRepo()
 	account, _ := userRepo.FindByID(ctx.GetStringContextData("UserID"), options.FindOne().SetProjection(map[string]any{
-		"nin": 1,
+		"nin":        1,
+		"firstName":  1,
+		"middleName": 1,
+		"lastName":   1,
+		"dob":        1,
 	}))
 	if account.NIN != nil {
 		server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Seems you have verified your NIN already. You're good to go!", nil, nil, nil, &ctx.DeviceID)
 		return
 	}
-	hashedNIN, _ := cryptography.CryptoHahser.HashString(ctx.Body.NIN, []byte(os.Getenv("HASH_FIXED_SALT")))
-	ninExists, _ := userRepo.CountDocs(map[string]interface{}{
-		"nin": hashedNIN,
-	})
-	if ninExists != 0 {
+	hashedNIN, ninExists, err := dataprotection_services.IdentityNumberIndex("nin", cryptography.NINIndex, ctx.Body.NIN)
+	if err != nil {
+		logger.Error("an error occured while looking up nin index", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "userID", Data: ctx.GetStringContextData("UserID"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if ninExists {
 		server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "This NIN is already linked to another Gateman account.", nil, nil, nil, &ctx.DeviceID)
 		return
 	}
 	var nin identity_verification_types.NINData
-	cachedNIN := cache.Cache.FindOne(string(hashedNIN))
-	if cachedNIN == nil {
+	found, err := dataprotection_services.FindProtected(ctx.GetStringContextData("UserID"), hashedNIN, &nin, ninFieldsInUse...)
+	if err != nil {
+		logger.Error("failed to read cached nin data", logger.LoggerOptions{
+			Key: "userID", Data: ctx.GetStringContextData("UserID"),
+		}, logger.LoggerOptions{
+			Key: "hashedNIN", Data: hashedNIN,
+		}, logger.LoggerOptions{
+			Key: "err", Data: err,
+		})
+	}
+	if !found {
 		fetchedNIN, _ := identityverification.IdentityVerifier.FetchNINDetails(ctx.Body.NIN)
 		if fetchedNIN == nil {
 			apperrors.NotFoundError(ctx.Ctx, "Invalid NIN provided", &ctx.DeviceID)
 			return
 		}
 		nin = *fetchedNIN
-		ninByte, _ := nin.MarshalBinary()
-		cache.Cache.CreateEntry(string(hashedNIN), ninByte, time.Hour*24*365) // save fetched nin details for a year
-	} else {
-		err := json.Unmarshal([]byte(*cachedNIN), &nin)
-		if err != nil {
-			logger.Error("failed to marshal cached nin data", logger.LoggerOptions{
+		// save fetched nin details for a year, encrypted with the data key of the user
+		if err := dataprotection_services.CacheProtected(ctx.GetStringContextData("UserID"), hashedNIN, nin, time.Hour*24*365); err != nil {
+			logger.Error("failed to cache nin data", logger.LoggerOptions{
 				Key: "userID", Data: ctx.GetStringContextData("UserID"),
 			}, logger.LoggerOptions{
-				Key: "hashedNIN", Data: hashedNIN,
+				Key: "err", Data: err,
 			})
-			fetchedNIN, _ := identityverification.IdentityVerifier.FetchNINDetails(ctx.Body.NIN)
-			if fetchedNIN == nil {
-				apperrors.NotFoundError(ctx.Ctx, "Invalid NIN provided", &ctx.DeviceID)
-				return
-			}
-			ninByte, _ := nin.MarshalBinary()
-			cache.Cache.CreateEntry(string(hashedNIN), ninByte, time.Hour*24*365) // save fetched nin details for a year
 		}
 	}
 	if os.Getenv("APP_ENV") != "production" {
@@ -206,7 +276,12 @@ func SetNINDetails(ctx *interfaces.ApplicationContext[dto.SetNINDetails]) {
 		return
 	}
 	if ctx.GetStringContextData("Phone") != "" && nin.PhoneNumber != nil {
-		if *nin.PhoneNumber == ctx.GetStringContextData("Phone") || (nin.FirstName == *account.FirstName.Value && nin.LastName == *account.LastName.Value && parsedNINDOB.Equal(*account.DOB.Value)) {
+		if *nin.PhoneNumber == ctx.GetStringContextData("Phone") || identityMatches(ctx.GetStringContextData("UserID"), identitymatch_services.MatchIdentity(identity_verification_types.NINCheck, identitymatch_services.AccountIdentity(account), identitymatch_services.Identity{
+			FirstName:   nin.FirstName,
+			MiddleName:  utils.GetStringValue(nin.MiddleName),
+			LastName:    nin.LastName,
+			DateOfBirth: &parsedNINDOB,
+		})) {
 			parsedNINDOB, err := time.Parse("2006-01-02", nin.DateOfBirth)
 			if err != nil {
 				logger.Error("failed to parse NIN DOB", logger.LoggerOptions{
@@ -255,6 +330,12 @@ func SetNINDetails(ctx *interfaces.ApplicationContext[dto.SetNINDetails]) {
 
 			}
 			userRepo.UpdatePartialByID(ctx.GetStringContextData("UserID"), payload)
+			screenVerifiedIdentity(ctx.GetStringContextData("UserID"), entities.NINScreening, identitymatch_services.Identity{
+				FirstName:   nin.FirstName,
+				MiddleName:  utils.GetStringValue(nin.MiddleName),
+				LastName:    nin.LastName,
+				DateOfBirth: &parsedNINDOB,
+			}, "")
 			server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "NIN Added", nil, nil, nil, &ctx.DeviceID)
 			return
 		}
@@ -296,11 +377,15 @@ func VerifyNINDetails(ctx *interfaces.ApplicationContext[any]) {
 		apperrors.NotFoundError(ctx.Ctx, "NIN verification failed. Please restart verification process", &ctx.DeviceID)
 		return
 	}
-	cachedNIN := cache.Cache.FindOne(*cachedNINNumber)
-	if cachedNIN == nil {
+	var nin identity_verification_types.NINData
+	found, err := dataprotection_services.FindProtected(ctx.GetStringContextData("UserID"), *cachedNINNumber, &nin, ninFieldsInUse...)
+	if !found {
 		logger.Error("cached nin not found", logger.LoggerOptions{
 			Key:  "id",
 			Data: ctx.GetStringContextData("UserID"),
+		}, logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
 		})
 		apperrors.NotFoundError(ctx.Ctx, "NIN verification failed. Please restart verification process", &ctx.DeviceID)
 		return
@@ -314,17 +399,6 @@ func VerifyNINDetails(ctx *interfaces.ApplicationContext[any]) {
 		apperrors.NotFoundError(ctx.Ctx, "NIN verification failed. Please restart verification process", &ctx.DeviceID)
 		return
 	}
-	var nin identity_verification_types.NINData
-	err := json.Unmarshal([]byte(*cachedNIN), &nin)
-	if err != nil {
-		logger.Error("failed to marshal cached nin data", logger.LoggerOptions{
-			Key: "userID", Data: ctx.GetStringContextData("UserID"),
-		}, logger.LoggerOptions{
-			Key: "cachedNIN", Data: cachedNIN,
-		})
-		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
-		return
-	}
 	parsedNINDOB, err := time.Parse("2006-01-02", "1990-01-01")
 	if err != nil {
 		logger.Error("failed to parse NIN DOB", logger.LoggerOptions{
@@ -374,6 +448,16 @@ func VerifyNINDetails(ctx *interfaces.ApplicationContext[any]) {
 
 	}
 	userRepo.UpdatePartialByID(*userID, payload)
+	var ninDOB *time.Time
+	if parsed, err := time.Parse("2006-01-02", nin.DateOfBirth); err == nil {
+		ninDOB = &parsed
+	}
+	screenVerifiedIdentity(*userID, entities.NINScreening, identitymatch_services.Identity{
+		FirstName:   nin.FirstName,
+		MiddleName:  utils.GetStringValue(nin.MiddleName),
+		LastName:    nin.LastName,
+		DateOfBirth: ninDOB,
+	}, "")
 	cache.Cache.DeleteOne(fmt.Sprintf("%s-nin", ctx.GetStringContextData("UserID")))
 	cache.Cache.DeleteOne(*cachedNINNumber)
 
@@ -393,8 +477,7 @@ func VerifyNINDetails(ctx *interfaces.ApplicationContext[any]) {
 		ExpiresAt:       time.Now().Add(time.Hour * 1).Unix(), //lasts for 1 hr
 	})
 	hashedAccessToken, _ := cryptography.CryptoHahser.HashString(*accessToken, nil)
-	hashedDeviceID, _ := cryptography.CryptoHahser.HashString(ctx.DeviceID, []byte(os.Getenv("HASH_FIXED_SALT")))
-	cache.Cache.CreateEntry(fmt.Sprintf("%s-access", string(hashedDeviceID)), hashedAccessToken, time.Hour*24)
+	cache.Cache.CreateEntry(auth.DeviceSessionKey(ctx.DeviceID, "access"), hashedAccessToken, time.Hour*24)
 	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "NIN Added", map[string]any{
 		"accessToken": accessToken,
 	}, nil, nil, &ctx.DeviceID)
@@ -408,46 +491,55 @@ func SetBVNDetails(ctx *interfaces.ApplicationContext[dto.SetBVNDetails]) {
 	}
 	userRepo := repository.UserRepo()
 	account, _ := userRepo.FindByID(ctx.GetStringContextData("UserID"), options.FindOne().SetProjection(map[string]any{
-		"bvn": 1,
+		"bvn":        1,
+		"firstName":  1,
+		"middleName": 1,
+		"lastName":   1,
+		"dob":        1,
 	}))
 	if account.BVN != nil {
 		server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Seems you have verified your BVN already. You're good to go!", nil, nil, nil, &ctx.DeviceID)
 		return
 	}
-	hashedBVN, _ := cryptography.CryptoHahser.HashString(ctx.Body.BVN, []byte(os.Getenv("HASH_FIXED_SALT")))
-	bvnExists, _ := userRepo.CountDocs(map[string]interface{}{
-		"bvn": hashedBVN,
-	})
-	if bvnExists != 0 {
+	hashedBVN, bvnExists, err := dataprotection_services.IdentityNumberIndex("bvn", cryptography.BVNIndex, ctx.Body.BVN)
+	if err != nil {
+		logger.Error("an error occured while looking up bvn index", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "userID", Data: ctx.GetStringContextData("UserID"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if bvnExists {
 		server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "This BVN is already linked to another Gateman account.", nil, nil, nil, &ctx.DeviceID)
 		return
 	}
 	var bvn identity_verification_types.BVNData
-	cachedBVN := cache.Cache.FindOne(string(hashedBVN))
-	if cachedBVN == nil {
+	found, err := dataprotection_services.FindProtected(ctx.GetStringContextData("UserID"), hashedBVN, &bvn, bvnFieldsInUse...)
+	if err != nil {
+		logger.Error("failed to read cached bvn data", logger.LoggerOptions{
+			Key: "userID", Data: ctx.GetStringContextData("UserID"),
+		}, logger.LoggerOptions{
+			Key: "hashedBVN", Data: hashedBVN,
+		}, logger.LoggerOptions{
+			Key: "err", Data: err,
+		})
+	}
+	if !found {
 		fetchedBVN, _ := identityverification.IdentityVerifier.FetchBVNDetails(ctx.Body.BVN)
 		if fetchedBVN == nil {
 			apperrors.NotFoundError(ctx.Ctx, "Invalid BVN provided", &ctx.DeviceID)
 			return
 		}
 		bvn = *fetchedBVN
-		bvnByte, _ := bvn.MarshalBinary()
-		cache.Cache.CreateEntry(string(hashedBVN), bvnByte, time.Hour*24*365) // save fetched bvn details for a year
-	} else {
-		err := json.Unmarshal([]byte(*cachedBVN), &bvn)
-		if err != nil {
-			logger.Error("failed to marshal cached bvn data", logger.LoggerOptions{
+		// save fetchedBVN bvn details for a year, encrypted with the data key of the user
+		if err := dataprotection_services.CacheProtected(ctx.GetStringContextData("UserID"), hashedBVN, bvn, time.Hour*24*365); err != nil {
+			logger.Error("failed to cache bvn data", logger.LoggerOptions{
 				Key: "userID", Data: ctx.GetStringContextData("UserID"),
 			}, logger.LoggerOptions{
-				Key: "hashedBVN", Data: hashedBVN,
+				Key: "err", Data: err,
 			})
-			fetchedBVN, _ := identityverification.IdentityVerifier.FetchBVNDetails(ctx.Body.BVN)
-			if fetchedBVN == nil {
-				apperrors.NotFoundError(ctx.Ctx, "Invalid BVN provided", &ctx.DeviceID)
-				return
-			}
-			bvnByte, _ := bvn.MarshalBinary()
-			cache.Cache.CreateEntry(string(hashedBVN), bvnByte, time.Hour*24*365) // save fetched bvn details for a year
 		}
 	}
 	if os.Getenv("APP_ENV") != "production" {
@@ -460,24 +552,29 @@ func SetBVNDetails(ctx *interfaces.ApplicationContext[dto.SetBVNDetails]) {
 		if err != nil {
 			logger.Error("failed to parse BVN DOB", logger.LoggerOptions{
 				Key: "userID", Data: ctx.GetStringContextData("UserID"),
-			}, logger.LoggerOptions{Key: "hashedBVN", Data: string(hashedBVN)}, logger.LoggerOptions{
+			}, logger.LoggerOptions{Key: "hashedBVN", Data: hashedBVN}, logger.LoggerOptions{
 				Key: `err`, Data: err,
 			})
 			return
 		}
-		if bvn.PhoneNumber == ctx.GetStringContextData("Phone") || (bvn.FirstName == *account.FirstName.Value && bvn.LastName == *account.LastName.Value && parsedBVNDOB.Equal(*account.DOB.Value)) {
+		if bvn.PhoneNumber == ctx.GetStringContextData("Phone") || identityMatches(ctx.GetStringContextData("UserID"), identitymatch_services.MatchIdentity(identity_verification_types.BVNCheck, identitymatch_services.AccountIdentity(account), identitymatch_services.Identity{
+			FirstName:   bvn.FirstName,
+			MiddleName:  utils.GetStringValue(bvn.MiddleName),
+			LastName:    bvn.LastName,
+			DateOfBirth: &parsedBVNDOB,
+		})) {
 			parsedBVNDOB, err := time.Parse("2006-01-02", bvn.DateOfBirth)
 			if err != nil {
 				logger.Error("failed to parse BVN DOB", logger.LoggerOptions{
 					Key: "userID", Data: ctx.GetStringContextData("UserID"),
-				}, logger.LoggerOptions{Key: "hashedBVN", Data: string(hashedBVN)}, logger.LoggerOptions{
+				}, logger.LoggerOptions{Key: "hashedBVN", Data: hashedBVN}, logger.LoggerOptions{
 					Key: `err`, Data: err,
 				})
 				return
 			}
 
 			user, _ := userRepo.FindByID(ctx.GetStringContextData("UserID"))
-			payload := map[string]any{"bvn": string(hashedBVN)}
+			payload := map[string]any{"bvn": hashedBVN}
 			if user.Address == nil {
 				payload["address"] = entities.Address{
 					Value: &bvn.Address,
@@ -515,6 +612,12 @@ func SetBVNDetails(ctx *interfaces.ApplicationContext[dto.SetBVNDetails]) {
 
 			}
 			userRepo.UpdatePartialByID(ctx.GetStringContextData("UserID"), payload)
+			screenVerifiedIdentity(ctx.GetStringContextData("UserID"), entities.BVNScreening, identitymatch_services.Identity{
+				FirstName:   bvn.FirstName,
+				MiddleName:  utils.GetStringValue(bvn.MiddleName),
+				LastName:    bvn.LastName,
+				DateOfBirth: &parsedBVNDOB,
+			}, bvn.WatchListed)
 			server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "BVN Added", nil, nil, nil, &ctx.DeviceID)
 			return
 		}
@@ -556,11 +659,15 @@ func VerifyBVNDetails(ctx *interfaces.ApplicationContext[any]) {
 		apperrors.NotFoundError(ctx.Ctx, "BVN verification failed. Please restart verification process", &ctx.DeviceID)
 		return
 	}
-	cachedBVN := cache.Cache.FindOne(*cachedBVNNumber)
-	if cachedBVN == nil {
+	var bvn identity_verification_types.BVNData
+	found, err := dataprotection_services.FindProtected(ctx.GetStringContextData("UserID"), *cachedBVNNumber, &bvn, bvnFieldsInUse...)
+	if !found {
 		logger.Error("cached BVN not found", logger.LoggerOptions{
 			Key:  "id",
 			Data: ctx.GetStringContextData("UserID"),
+		}, logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
 		})
 		apperrors.NotFoundError(ctx.Ctx, "BVN verification failed. Please restart verification process", &ctx.DeviceID)
 		return
@@ -574,17 +681,6 @@ func VerifyBVNDetails(ctx *interfaces.ApplicationContext[any]) {
 		apperrors.NotFoundError(ctx.Ctx, "BVN verification failed. Please restart verification process", &ctx.DeviceID)
 		return
 	}
-	var bvn identity_verification_types.BVNData
-	err := json.Unmarshal([]byte(*cachedBVN), &bvn)
-	if err != nil {
-		logger.Error("failed to marshal cached bvn data", logger.LoggerOptions{
-			Key: "userID", Data: ctx.GetStringContextData("UserID"),
-		}, logger.LoggerOptions{
-			Key: "cachedBVN", Data: cachedBVN,
-		})
-		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
-		return
-	}
 	parsedBVNDOB, err := time.Parse("02-Jan-2006", bvn.DateOfBirth)
 	if err != nil {
 		logger.Error("failed to parse BVN DOB", logger.LoggerOptions{
@@ -634,6 +730,12 @@ func VerifyBVNDetails(ctx *interfaces.ApplicationContext[any]) {
 
 	}
 	userRepo.UpdatePartialByID(*userID, payload)
+	screenVerifiedIdentity(*userID, entities.BVNScreening, identitymatch_services.Identity{
+		FirstName:   bvn.FirstName,
+		MiddleName:  utils.GetStringValue(bvn.MiddleName),
+		LastName:    bvn.LastName,
+		DateOfBirth: &parsedBVNDOB,
+	}, bvn.WatchListed)
 	cache.Cache.DeleteOne(fmt.Sprintf("%s-bvn", ctx.GetStringContextData("UserID")))
 	cache.Cache.DeleteOne(*cachedBVNNumber)
 
@@ -653,8 +755,7 @@ func VerifyBVNDetails(ctx *interfaces.ApplicationContext[any]) {
 		ExpiresAt:       time.Now().Add(time.Hour * 1).Unix(), //lasts for 1 hr
 	})
 	hashedAccessToken, _ := cryptography.CryptoHahser.HashString(*accessToken, nil)
-	hashedDeviceID, _ := cryptography.CryptoHahser.HashString(ctx.DeviceID, []byte(os.Getenv("HASH_FIXED_SALT")))
-	cache.Cache.CreateEntry(fmt.Sprintf("%s-access", string(hashedDeviceID)), hashedAccessToken, time.Hour*24)
+	cache.Cache.CreateEntry(auth.DeviceSessionKey(ctx.DeviceID, "access"), hashedAccessToken, time.Hour*24)
 	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "BVN Added", map[string]any{
 		"accessToken": accessToken,
 	}, nil, nil, &ctx.DeviceID)
@@ -668,27 +769,43 @@ func SetDriversLicenseDetails(ctx *interfaces.ApplicationContext[dto.SetDriversL
 	}
 	userRepo := repository.UserRepo()
 	account, _ := userRepo.FindByID(ctx.GetStringContextData("UserID"), options.FindOne().SetProjection(map[string]any{
-		"driverID":  1,
-		"image":     1,
-		"firstName": 1,
-		"lastName":  1,
-		"dob":       1,
+		"driverID":   1,
+		"image":      1,
+		"firstName":  1,
+		"middleName": 1,
+		"lastName":   1,
+		"dob":        1,
 	}))
 	if account.DriverID != nil {
 		server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Seems you have verified your Drivers License already. You're good to go!", nil, nil, nil, &ctx.DeviceID)
 		return
 	}
-	hashedDriversID, _ := cryptography.CryptoHahser.HashString(ctx.Body.DriverID, []byte(os.Getenv("HASH_FIXED_SALT")))
-	driversIDExists, _ := userRepo.CountDocs(map[string]interface{}{
-		"driverID": hashedDriversID,
-	})
-	if driversIDExists != 0 {
+	hashedDriversID, driversIDExists, err := dataprotection_services.IdentityNumberIndex("driverID", cryptography.DriverIDIndex, ctx.Body.DriverID)
+	if err != nil {
+		logger.Error("an error occured while looking up drivers license index", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "userID", Data: ctx.GetStringContextData("UserID"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if driversIDExists {
 		server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "This Drivers License is already linked to another Gateman account.", nil, nil, nil, &ctx.DeviceID)
 		return
 	}
 	var driverID identity_verification_types.DriversID
-	cachedDriverID := cache.Cache.FindOne(string(hashedDriversID))
-	if cachedDriverID == nil {
+	found, err := dataprotection_services.FindProtected(ctx.GetStringContextData("UserID"), hashedDriversID, &driverID, driverIDFieldsInUse...)
+	if err != nil {
+		logger.Error("failed to read cached driver id data", logger.LoggerOptions{
+			Key: "userID", Data: ctx.GetStringContextData("UserID"),
+		}, logger.LoggerOptions{
+			Key: "hashedDriverID", Data: hashedDriversID,
+		}, logger.LoggerOptions{
+			Key: "err", Data: err,
+		})
+	}
+	if !found {
 		fetchedDriverID, err := identityverification.IdentityVerifier.FetchDriverIDDetails(ctx.Body.DriverID)
 		if fetchedDriverID == nil {
 			apperrors.NotFoundError(ctx.Ctx, "Invalid Driver ID provided", &ctx.DeviceID)
@@ -699,23 +816,13 @@ func SetDriversLicenseDetails(ctx *interfaces.ApplicationContext[dto.SetDriversL
 			return
 		}
 		driverID = *fetchedDriverID
-		driverIDByte, _ := driverID.MarshalBinary()
-		cache.Cache.CreateEntry(string(hashedDriversID), driverIDByte, time.Hour*24*365) // save fetched driver id details for a year
-	} else {
-		err := json.Unmarshal([]byte(*cachedDriverID), &driverID)
-		if err != nil {
-			logger.Error("failed to marshal cached Driver ID data", logger.LoggerOptions{
+		// save fetchedDriverID driver id details for a year, encrypted with the data key of the user
+		if err := dataprotection_services.CacheProtected(ctx.GetStringContextData("UserID"), hashedDriversID, driverID, time.Hour*24*365); err != nil {
+			logger.Error("failed to cache driver id data", logger.LoggerOptions{
 				Key: "userID", Data: ctx.GetStringContextData("UserID"),
 			}, logger.LoggerOptions{
-				Key: "hashedDriverID", Data: hashedDriversID,
+				Key: "err", Data: err,
 			})
-			fetchedDriverID, _ := identityverification.IdentityVerifier.FetchDriverIDDetails(ctx.Body.DriverID)
-			if fetchedDriverID == nil {
-				apperrors.NotFoundError(ctx.Ctx, "Invalid Driver ID provided", &ctx.DeviceID)
-				return
-			}
-			driverIDByte, _ := driverID.MarshalBinary()
-			cache.Cache.CreateEntry(string(hashedDriversID), driverIDByte, time.Hour*24*365) // save fetched driver id details for a year
 		}
 	}
 	accountImgURL, _ := fileupload.FileUploader.GeneratedSignedURL(account.Image, types.SignedURLPermission{
@@ -727,12 +834,17 @@ func SetDriversLicenseDetails(ctx *interfaces.ApplicationContext[dto.SetDriversL
 		if err != nil {
 			logger.Error("failed to parse Driver ID DOB", logger.LoggerOptions{
 				Key: "userID", Data: ctx.GetStringContextData("UserID"),
-			}, logger.LoggerOptions{Key: "hashedDriverID", Data: string(hashedDriversID)}, logger.LoggerOptions{
+			}, logger.LoggerOptions{Key: "hashedDriverID", Data: hashedDriversID}, logger.LoggerOptions{
 				Key: `err`, Data: err,
 			})
 			return
 		}
-		if driverID.FirstName == *account.FirstName.Value && driverID.LastName == *account.LastName.Value && parsedDriverIDDOB.Equal(*account.DOB.Value) {
+		if identityMatches(ctx.GetStringContextData("UserID"), identitymatch_services.MatchIdentity(identity_verification_types.DriversLicenseCheck, identitymatch_services.AccountIdentity(account), identitymatch_services.Identity{
+			FirstName:   driverID.FirstName,
+			MiddleName:  utils.GetStringValue(driverID.MiddleName),
+			LastName:    driverID.LastName,
+			DateOfBirth: &parsedDriverIDDOB,
+		})) {
 			success.Success = true
 		}
 	}
@@ -741,14 +853,14 @@ func SetDriversLicenseDetails(ctx *interfaces.ApplicationContext[dto.SetDriversL
 		if err != nil {
 			logger.Error("failed to parse Driver ID DOB", logger.LoggerOptions{
 				Key: "userID", Data: ctx.GetStringContextData("UserID"),
-			}, logger.LoggerOptions{Key: "hashedDriverID", Data: string(hashedDriversID)}, logger.LoggerOptions{
+			}, logger.LoggerOptions{Key: "hashedDriverID", Data: hashedDriversID}, logger.LoggerOptions{
 				Key: `err`, Data: err,
 			})
 			return
 		}
 
 		user, _ := userRepo.FindByID(ctx.GetStringContextData("UserID"))
-		payload := map[string]any{"driverID": string(hashedDriversID)}
+		payload := map[string]any{"driverID": hashedDriversID}
 		if user.FirstName == nil || !user.FirstName.Verified {
 			payload["firstName"] = entities.KYCData[string]{
 				Value: &driverID.FirstName,
@@ -780,14 +892,14 @@ func SetDriversLicenseDetails(ctx *interfaces.ApplicationContext[dto.SetDriversL
 		if err != nil {
 			logger.Error("failed to parse Driver ID DOB", logger.LoggerOptions{
 				Key: "userID", Data: ctx.GetStringContextData("UserID"),
-			}, logger.LoggerOptions{Key: "hashedDriverID", Data: string(hashedDriversID)}, logger.LoggerOptions{
+			}, logger.LoggerOptions{Key: "hashedDriverID", Data: hashedDriversID}, logger.LoggerOptions{
 				Key: `err`, Data: err,
 			})
 			return
 		}
 
 		user, _ := userRepo.FindByID(ctx.GetStringContextData("UserID"))
-		payload := map[string]any{"driverID": string(hashedDriversID)}
+		payload := map[string]any{"driverID": hashedDriversID}
 		if user.FirstName == nil || !user.FirstName.Verified {
 			payload["firstName"] = entities.KYCData[string]{
 				Value:    &driverID.FirstName,
@@ -832,46 +944,55 @@ func SetVoterIDDetails(ctx *interfaces.ApplicationContext[dto.SetVoterIDDetails]
 	}
 	userRepo := repository.UserRepo()
 	account, _ := userRepo.FindByID(ctx.GetStringContextData("UserID"), options.FindOne().SetProjection(map[string]any{
-		"voterID": 1,
+		"voterID":    1,
+		"firstName":  1,
+		"middleName": 1,
+		"lastName":   1,
+		"dob":        1,
 	}))
 	if account.VoterID != nil {
 		server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Seems you have verified your Voter ID already. You're good to go!", nil, nil, nil, &ctx.DeviceID)
 		return
 	}
-	hashedVoterID, _ := cryptography.CryptoHahser.HashString(ctx.Body.VoterID, []byte(os.Getenv("HASH_FIXED_SALT")))
-	voterIDExists, _ := userRepo.CountDocs(map[string]interface{}{
-		"voterID": hashedVoterID,
-	})
-	if voterIDExists != 0 {
+	hashedVoterID, voterIDExists, err := dataprotection_services.IdentityNumberIndex("voterID", cryptography.VoterIDIndex, ctx.Body.VoterID)
+	if err != nil {
+		logger.Error("an error occured while looking up voter id index", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "userID", Data: ctx.GetStringContextData("UserID"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if voterIDExists {
 		server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "This Voter ID is already linked to another Gateman account.", nil, nil, nil, &ctx.DeviceID)
 		return
 	}
 	var voterID identity_verification_types.VoterID
-	cachedVoterID := cache.Cache.FindOne(string(hashedVoterID))
-	if cachedVoterID == nil {
+	found, err := dataprotection_services.FindProtected(ctx.GetStringContextData("UserID"), hashedVoterID, &voterID, voterIDFieldsInUse...)
+	if err != nil {
+		logger.Error("failed to read cached voter id data", logger.LoggerOptions{
+			Key: "userID", Data: ctx.GetStringContextData("UserID"),
+		}, logger.LoggerOptions{
+			Key: "hashedVoterID", Data: hashedVoterID,
+		}, logger.LoggerOptions{
+			Key: "err", Data: err,
+		})
+	}
+	if !found {
 		fetchedVoterID, _ := identityverification.IdentityVerifier.FetchVoterIDDetails(ctx.Body.VoterID)
 		if fetchedVoterID == nil {
 			apperrors.NotFoundError(ctx.Ctx, "Invalid Voter ID provided", &ctx.DeviceID)
 			return
 		}
 		voterID = *fetchedVoterID
-		bvnByte, _ := voterID.MarshalBinary()
-		cache.Cache.CreateEntry(string(hashedVoterID), bvnByte, time.Hour*24*365) // save fetched bvn details for a year
-	} else {
-		err := json.Unmarshal([]byte(*cachedVoterID), &voterID)
-		if err != nil {
-			logger.Error("failed to marshal cached Voter ID data", logger.LoggerOptions{
+		// save fetchedVoterID voter id details for a year, encrypted with the data key of the user
+		if err := dataprotection_services.CacheProtected(ctx.GetStringContextData("UserID"), hashedVoterID, voterID, time.Hour*24*365); err != nil {
+			logger.Error("failed to cache voter id data", logger.LoggerOptions{
 				Key: "userID", Data: ctx.GetStringContextData("UserID"),
 			}, logger.LoggerOptions{
-				Key: "hashedBVN", Data: hashedVoterID,
+				Key: "err", Data: err,
 			})
-			fetchedBVN, _ := identityverification.IdentityVerifier.FetchVoterIDDetails(ctx.Body.VoterID)
-			if fetchedBVN == nil {
-				apperrors.NotFoundError(ctx.Ctx, "Invalid Voter ID provided", &ctx.DeviceID)
-				return
-			}
-			bvnByte, _ := voterID.MarshalBinary()
-			cache.Cache.CreateEntry(string(hashedVoterID), bvnByte, time.Hour*24*365) // save fetched bvn details for a year
 		}
 	}
 	if os.Getenv("APP_ENV") != "production" {
@@ -884,25 +1005,27 @@ func SetVoterIDDetails(ctx *interfaces.ApplicationContext[dto.SetVoterIDDetails]
 		// if err != nil {
 		// 	logger.Error("failed to parse Voter ID DOB", logger.LoggerOptions{
 		// 		Key: "userID", Data: ctx.GetStringContextData("UserID"),
-		// 	}, logger.LoggerOptions{Key: "hashedBVN", Data: string(hashedVoterID)}, logger.LoggerOptions{
+		// 	}, logger.LoggerOptions{Key: "hashedBVN", Data: hashedVoterID}, logger.LoggerOptions{
 		// 		Key: `err`, Data: err,
 		// 	})
 		// 	return
 		// }
 		names := strings.Split(voterID.FullName, " ")
-		if voterID.Phone == ctx.GetStringContextData("Phone") || (names[2] == *account.FirstName.Value && names[0] == *account.LastName.Value) {
+		if voterID.Phone == ctx.GetStringContextData("Phone") || identityMatches(ctx.GetStringContextData("UserID"), identitymatch_services.MatchIdentity(identity_verification_types.VotersCardCheck, identitymatch_services.AccountIdentity(account), identitymatch_services.Identity{
+			FullName: voterID.FullName,
+		})) {
 			// parsedDOB, err := time.Parse("2006-01-02", voterID.DateOfBirth)
 			// if err != nil {
 			// 	logger.Error("failed to parse Voter ID DOB", logger.LoggerOptions{
 			// 		Key: "userID", Data: ctx.GetStringContextData("UserID"),
-			// 	}, logger.LoggerOptions{Key: "hashedBVN", Data: string(hashedVoterID)}, logger.LoggerOptions{
+			// 	}, logger.LoggerOptions{Key: "hashedBVN", Data: hashedVoterID}, logger.LoggerOptions{
 			// 		Key: `err`, Data: err,
 			// 	})
 			// 	return
 			// }
 
 			user, _ := userRepo.FindByID(ctx.GetStringContextData("UserID"))
-			payload := map[string]any{"voterID": string(hashedVoterID)}
+			payload := map[string]any{"voterID": hashedVoterID}
 			if user.Address == nil {
 				payload["address"] = entities.Address{
 					Value: &voterID.Address,
@@ -981,11 +1104,15 @@ func VerifyVoterIDDetails(ctx *interfaces.ApplicationContext[any]) {
 		apperrors.NotFoundError(ctx.Ctx, "Voter ID verification failed. Please restart verification process", &ctx.DeviceID)
 		return
 	}
-	cachedVoterID := cache.Cache.FindOne(*cachedVoterIDNumber)
-	if cachedVoterID == nil {
+	var voterID identity_verification_types.VoterID
+	found, err := dataprotection_services.FindProtected(ctx.GetStringContextData("UserID"), *cachedVoterIDNumber, &voterID, voterIDFieldsInUse...)
+	if !found {
 		logger.Error("cached Voter ID not found", logger.LoggerOptions{
 			Key:  "id",
 			Data: ctx.GetStringContextData("UserID"),
+		}, logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
 		})
 		apperrors.NotFoundError(ctx.Ctx, "Voter ID verification failed. Please restart verification process", &ctx.DeviceID)
 		return
@@ -999,17 +1126,6 @@ func VerifyVoterIDDetails(ctx *interfaces.ApplicationContext[any]) {
 		apperrors.NotFoundError(ctx.Ctx, "Voter ID verification failed. Please restart verification process", &ctx.DeviceID)
 		return
 	}
-	var voterID identity_verification_types.VoterID
-	err := json.Unmarshal([]byte(*cachedVoterID), &voterID)
-	if err != nil {
-		logger.Error("failed to marshal cached Voter ID data", logger.LoggerOptions{
-			Key: "userID", Data: ctx.GetStringContextData("UserID"),
-		}, logger.LoggerOptions{
-			Key: "cachedVoterID", Data: cachedVoterID,
-		})
-		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
-		return
-	}
 	// parsedDOB, err := time.Parse("2006-01-02", voterID.DateOfBirth)
 	// if err != nil {
 	// 	logger.Error("failed to parse Voter ID DOB", logger.LoggerOptions{
@@ -1060,7 +1176,7 @@ func VerifyVoterIDDetails(ctx *interfaces.ApplicationContext[any]) {
 	// }
 	userRepo.UpdatePartialByID(*userID, payload)
 	cache.Cache.DeleteOne(fmt.Sprintf("%s-voter-id", ctx.GetStringContextData("UserID")))
-	cache.Cache.DeleteOne(*cachedVoterID)
+	cache.Cache.DeleteOne(*cachedVoterIDNumber)
 
 	var phone *string
 	if user.Phone != nil {
@@ -1078,9 +1194,277 @@ func VerifyVoterIDDetails(ctx *interfaces.ApplicationContext[any]) {
 		ExpiresAt:       time.Now().Add(time.Hour * 1).Unix(), //lasts for 1 hr
 	})
 	hashedAccessToken, _ := cryptography.CryptoHahser.HashString(*accessToken, nil)
-	hashedDeviceID, _ := cryptography.CryptoHahser.HashString(ctx.DeviceID, []byte(os.Getenv("HASH_FIXED_SALT")))
-	cache.Cache.CreateEntry(fmt.Sprintf("%s-access", string(hashedDeviceID)), hashedAccessToken, time.Hour*24)
+	cache.Cache.CreateEntry(auth.DeviceSessionKey(ctx.DeviceID, "access"), hashedAccessToken, time.Hour*24)
 	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Voter ID Added", map[string]any{
 		"accessToken": accessToken,
 	}, nil, nil, &ctx.DeviceID)
 }
+
+// Compares the account image of the user with the photo on the government record of their NIN or BVN.
+// The number is presented again since only its blind index is stored.
+func BindIdentityFace(ctx *interfaces.ApplicationContext[dto.BindIdentityFaceDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	userID := ctx.GetStringContextData("UserID")
+	domain := cryptography.NINIndex
+	if ctx.Body.IDType == "bvn" {
+		domain = cryptography.BVNIndex
+	}
+	index, _, err := dataprotection_services.IdentityNumberIndex(ctx.Body.IDType, domain, ctx.Body.Number)
+	if err != nil {
+		logger.Error("an error occured while looking up identity number index", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "userID", Data: userID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	userRepo := repository.UserRepo()
+	account, err := userRepo.FindByID(userID)
+	if err != nil {
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if account == nil {
+		apperrors.NotFoundError(ctx.Ctx, "account not found", &ctx.DeviceID)
+		return
+	}
+	linkedIndex := account.NIN
+	if ctx.Body.IDType == "bvn" {
+		linkedIndex = account.BVN
+	}
+	if linkedIndex == nil || *linkedIndex != index {
+		apperrors.ClientError(ctx.Ctx, fmt.Sprintf("Verify this %s on your account before matching your face to it", strings.ToUpper(ctx.Body.IDType)), nil, nil, ctx.DeviceID)
+		return
+	}
+
+	var governmentPhoto string
+	if ctx.Body.IDType == "bvn" {
+		var bvn identity_verification_types.BVNData
+		found, _ := dataprotection_services.FindProtected(userID, index, &bvn, "image")
+		if !found {
+			fetchedBVN, _ := identityverification.IdentityVerifier.FetchBVNDetails(ctx.Body.Number)
+			if fetchedBVN == nil {
+				apperrors.NotFoundError(ctx.Ctx, "Invalid BVN provided", &ctx.DeviceID)
+				return
+			}
+			bvn = *fetchedBVN
+			if err := dataprotection_services.CacheProtected(userID, index, bvn, time.Hour*24*365); err != nil {
+				logger.Error("failed to cache bvn data", logger.LoggerOptions{
+					Key: "userID", Data: userID,
+				}, logger.LoggerOptions{
+					Key: "err", Data: err,
+				})
+			}
+		}
+		governmentPhoto = bvn.Image
+	} else {
+		var nin identity_verification_types.NINData
+		found, _ := dataprotection_services.FindProtected(userID, index, &nin, "photo")
+		if !found {
+			fetchedNIN, _ := identityverification.IdentityVerifier.FetchNINDetails(ctx.Body.Number)
+			if fetchedNIN == nil {
+				apperrors.NotFoundError(ctx.Ctx, "Invalid NIN provided", &ctx.DeviceID)
+				return
+			}
+			nin = *fetchedNIN
+			if err := dataprotection_services.CacheProtected(userID, index, nin, time.Hour*24*365); err != nil {
+				logger.Error("failed to cache nin data", logger.LoggerOptions{
+					Key: "userID", Data: userID,
+				}, logger.LoggerOptions{
+					Key: "err", Data: err,
+				})
+			}
+		}
+		governmentPhoto = nin.Photo
+	}
+
+	binding, err := facebinding_services.BindFace(account, governmentPhoto)
+	if err != nil {
+		switch {
+		case errors.Is(err, facebinding_services.ErrNoAccountImage):
+			apperrors.ClientError(ctx.Ctx, "Set your account image before matching your face to your ID", nil, nil, ctx.DeviceID)
+		case errors.Is(err, facebinding_services.ErrNoGovernmentPhoto):
+			apperrors.ClientError(ctx.Ctx, fmt.Sprintf("There is no photo on record for this %s", strings.ToUpper(ctx.Body.IDType)), nil, nil, ctx.DeviceID)
+		default:
+			logger.Error("something went wrong when matching face to government photo", logger.LoggerOptions{
+				Key: "error", Data: err,
+			}, logger.LoggerOptions{
+				Key: "userID", Data: userID,
+			})
+			apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		}
+		return
+	}
+	if _, err := userRepo.UpdatePartialByID(userID, map[string]any{
+		fmt.Sprintf("%sFaceBinding", ctx.Body.IDType): binding,
+	}); err != nil {
+		logger.Error("something went wrong when saving face binding", logger.LoggerOptions{
+			Key: "error", Data: err,
+		}, logger.LoggerOptions{
+			Key: "userID", Data: userID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if !binding.Match {
+		server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Your face does not match the photo on your ID", binding, nil, nil, &ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Face matched", binding, nil, nil, &ctx.DeviceID)
+}
+
+func identityDocumentPath(userID string) string {
+	return fmt.Sprintf("%s/%s", userID, "identitydocument")
+}
+
+// Returns a signed url the user uploads the image of their passport data page or NIN slip to
+func RequestIdentityDocumentUploadURL(ctx *interfaces.ApplicationContext[any]) {
+	url, err := fileupload.FileUploader.GeneratedSignedURL(identityDocumentPath(ctx.GetStringContextData("UserID")), types.SignedURLPermission{
+		Write: true,
+	}, time.Minute*10)
+	if err != nil {
+		apperrors.ExternalDependencyError(ctx.Ctx, "CLOUDFLARE", "500", err, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "upload url generated", map[string]any{
+		"url": url,
+	}, nil, nil, &ctx.DeviceID)
+}
+
+// Reads the machine readable zone off the uploaded document and links the document to the user once it matches their name and date of birth
+func VerifyIdentityDocument(ctx *interfaces.ApplicationContext[dto.VerifyIdentityDocumentDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	userID := ctx.GetStringContextData("UserID")
+	documentPath := identityDocumentPath(userID)
+	exists, err := fileupload.FileUploader.CheckFileExists(documentPath)
+	if err != nil {
+		apperrors.ExternalDependencyError(ctx.Ctx, "CLOUDFLARE", "500", err, ctx.DeviceID)
+		return
+	}
+	if !exists {
+		apperrors.ClientError(ctx.Ctx, "Document has not been uploaded. Request for a new url and upload the document before attempting this request again.", nil, utils.GetUIntPointer(http.StatusBadRequest), ctx.DeviceID)
+		return
+	}
+	// the document is only kept until it has been read
+	defer fileupload.FileUploader.DeleteFile(documentPath)
+
+	url, err := fileupload.FileUploader.GeneratedSignedURL(documentPath, types.SignedURLPermission{
+		Read: true,
+	}, time.Minute*1)
+	if err != nil {
+		apperrors.ExternalDependencyError(ctx.Ctx, "CLOUDFLARE", "500", err, ctx.DeviceID)
+		return
+	}
+	read, err := biometric.DocumentReader.ReadMRZ(url)
+	if err != nil {
+		logger.Error("something went wrong when reading identity document", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key: "userID", Data: userID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if !read.Success {
+		apperrors.ClientError(ctx.Ctx, "We could not read your document. Please upload a clear picture of the whole document", nil, nil, ctx.DeviceID)
+		return
+	}
+
+	userRepo := repository.UserRepo()
+	account, err := userRepo.FindByID(userID)
+	if err != nil {
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if account == nil {
+		apperrors.NotFoundError(ctx.Ctx, "account not found", &ctx.DeviceID)
+		return
+	}
+	document, err := document_services.VerifyIdentityDocument(account, ctx.Body.DocumentType, read.Lines)
+	if err != nil {
+		var rejected *document_services.DocumentRejectedError
+		var checkDigit *document_services.MRZCheckDigitError
+		switch {
+		case errors.As(err, &rejected):
+			apperrors.ClientError(ctx.Ctx, rejected.Reason, nil, nil, ctx.DeviceID)
+		case errors.As(err, &checkDigit), errors.Is(err, document_services.ErrUnrecognisedMRZ):
+			apperrors.ClientError(ctx.Ctx, "We could not read your document. Please upload a clear picture of the whole document", nil, nil, ctx.DeviceID)
+		default:
+			logger.Error("something went wrong when verifying identity document", logger.LoggerOptions{
+				Key:  "error",
+				Data: err,
+			}, logger.LoggerOptions{
+				Key: "userID", Data: userID,
+			})
+			apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		}
+		return
+	}
+	if _, err := userRepo.UpdatePartialByID(userID, map[string]any{
+		"idDocument": document,
+	}); err != nil {
+		logger.Error("something went wrong when saving identity document", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key: "userID", Data: userID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Document verified", document, nil, nil, &ctx.DeviceID)
+}
+
+// Shreds the data key of the user. Every cached and stored copy of their identity documents becomes unreadable.
+func EraseKYCData(ctx *interfaces.ApplicationContext[any]) {
+	userID := ctx.GetStringContextData("UserID")
+	account, err := repository.UserRepo().FindByID(userID)
+	if err != nil {
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if account == nil {
+		apperrors.NotFoundError(ctx.Ctx, "account not found", &ctx.DeviceID)
+		return
+	}
+	// the lookups developers made of the user's ids are erased along with the records cached for the user
+	indexes := []string{}
+	lookups := map[entities.MeteredLookupType]*string{
+		entities.NINLookup:            account.NIN,
+		entities.BVNLookup:            account.BVN,
+		entities.VotersCardLookup:     account.VoterID,
+		entities.DriversLicenseLookup: account.DriverID,
+	}
+	for lookupType, index := range lookups {
+		if index == nil {
+			continue
+		}
+		indexes = append(indexes, *index)
+		if err = metering_services.EraseCachedLookup(lookupType, *index); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = dataprotection_services.EraseSubject(userID, indexes...)
+	}
+	if err != nil {
+		logger.Error("an error occured while shredding kyc data key", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "userID", Data: userID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "identity data erased", nil, nil, nil, &ctx.DeviceID)
+}
@@ -78,6 +78,12 @@ func ProcessPaystackWebhook(ctx *interfaces.ApplicationContext[[]byte]) {
 			server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "processed successfully", nil, nil, nil, &ctx.DeviceID)
 			return
 		}
+		// overage charges are marked billed by the task that made them and do not renew a subscription
+		if verifiedData.Metadata.LookupOverage == "true" {
+			workspace_usecases.SaveCardAndCreateTransaction(&ctx.Ctx, "Gateman lookup overage", verifiedData)
+			server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "processed successfully", nil, nil, nil, &ctx.DeviceID)
+			return
+		}
 		subscriptionRepo := repository.SubscriptionPlanRepo()
 		subscription, _ := subscriptionRepo.FindByID(verifiedData.Metadata.PlanID)
 		activeSubscriptionRepo := repository.ActiveSubscriptionRepo()
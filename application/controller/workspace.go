@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
@@ -130,6 +129,23 @@ func LoginWorkspaceMember(ctx *interfaces.ApplicationContext[dto.LoginWorkspaceM
 		return
 	}
 
+	workspaceRepo := repository.WorkspaceRepository()
+	workspace, err := workspaceRepo.FindByID(member.WorkspaceID)
+	if err != nil {
+		logger.Error("an error occured while trying to fetch workspace for member login", logger.LoggerOptions{
+			Key:  "workspaceID",
+			Data: member.WorkspaceID,
+		}, logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if !org_usecases.VerifyWorkspaceMemberMFA(ctx.Ctx, member, workspace, ctx.Body.MFACode, ctx.Body.RecoveryCode, ctx.DeviceID) {
+		return
+	}
+
 	var savedDevice *entities.Device
 	for i, device := range member.Devices {
 		if device.ID == ctx.DeviceID {
@@ -143,6 +159,7 @@ func LoginWorkspaceMember(ctx *interfaces.ApplicationContext[dto.LoginWorkspaceM
 		member.Devices = append(member.Devices, entities.Device{
 			ID:                ctx.DeviceID,
 			Name:              ctx.DeviceName,
+			UserAgent:         ctx.UserAgent,
 			LastLogin:         time.Now(),
 			LastLoginLocation: fmt.Sprintf("%s, %s - (%f, %f)", strings.ToUpper(ipLookupRes.City), strings.ToUpper(ipLookupRes.CountryCode), ipLookupRes.Longitude, ipLookupRes.Latitude),
 			Verified:          true,
@@ -152,6 +169,7 @@ func LoginWorkspaceMember(ctx *interfaces.ApplicationContext[dto.LoginWorkspaceM
 		member.Devices = append(member.Devices, entities.Device{
 			ID:                savedDevice.ID,
 			Name:              savedDevice.Name,
+			UserAgent:         ctx.UserAgent,
 			LastLogin:         time.Now(),
 			LastLoginLocation: fmt.Sprintf("%s, %s - (%f, %f)", strings.ToUpper(ipLookupRes.City), strings.ToUpper(ipLookupRes.CountryCode), ipLookupRes.Longitude, ipLookupRes.Latitude),
 			Verified:          true,
@@ -196,9 +214,8 @@ func LoginWorkspaceMember(ctx *interfaces.ApplicationContext[dto.LoginWorkspaceM
 	}
 	hashedAccessToken, _ := cryptography.CryptoHahser.HashString(*accessToken, nil)
 	hashedRefreshToken, _ := cryptography.CryptoHahser.HashString(*refreshToken, nil)
-	hashedDeviceID, _ := cryptography.CryptoHahser.HashString(ctx.DeviceID, []byte(os.Getenv("HASH_FIXED_SALT")))
-	cache.Cache.CreateEntry(fmt.Sprintf("%s-workspace-access", string(hashedDeviceID)), hashedAccessToken, time.Hour*24)       // token should last for 10 mins
-	cache.Cache.CreateEntry(fmt.Sprintf("%s-workspace-refresh", string(hashedDeviceID)), hashedRefreshToken, time.Hour*24*180) // token should last for 100 days
+	cache.Cache.CreateEntry(auth.DeviceSessionKey(ctx.DeviceID, "workspace-access"), hashedAccessToken, time.Hour*24)       // token should last for 10 mins
+	cache.Cache.CreateEntry(auth.DeviceSessionKey(ctx.DeviceID, "workspace-refresh"), hashedRefreshToken, time.Hour*24*180) // token should last for 100 days
 	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "login successful", map[string]any{
 		"workspaceAccessToken":  accessToken,
 		"workspaceRefreshToken": refreshToken,
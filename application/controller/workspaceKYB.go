@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/controller/dto"
+	"gateman.io/application/interfaces"
+	"gateman.io/application/repository"
+	kyb_services "gateman.io/application/services/kyb"
+	fileupload "gateman.io/infrastructure/file_upload"
+	"gateman.io/infrastructure/file_upload/types"
+	identity_verification_types "gateman.io/infrastructure/identity_verification/types"
+	"gateman.io/infrastructure/logger"
+	server_response "gateman.io/infrastructure/serverResponse"
+	"gateman.io/infrastructure/validator"
+)
+
+// Returns a signed url a business document of the workspace is uploaded to
+func RequestBusinessDocumentUploadURL(ctx *interfaces.ApplicationContext[dto.BusinessDocumentUploadDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	path := kyb_services.BusinessDocumentPath(ctx.GetStringContextData("WorkspaceID"), ctx.Body.Document)
+	url, err := fileupload.FileUploader.GeneratedSignedURL(path, types.SignedURLPermission{
+		Write: true,
+	}, time.Minute*10)
+	if err != nil {
+		apperrors.ExternalDependencyError(ctx.Ctx, "CLOUDFLARE", "500", err, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "upload url generated", map[string]any{
+		"url":      url,
+		"filePath": path,
+	}, nil, nil, &ctx.DeviceID)
+}
+
+// Verifies the business of the workspace against the CAC registry. Only the owner of the workspace can verify it.
+func VerifyWorkspaceBusiness(ctx *interfaces.ApplicationContext[dto.VerifyBusinessDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	workspaceID := ctx.GetStringContextData("WorkspaceID")
+	memberID := ctx.GetStringContextData("UserID")
+	workspace, err := repository.WorkspaceRepository().FindByID(workspaceID)
+	if err != nil {
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if workspace == nil {
+		apperrors.NotFoundError(ctx.Ctx, "workspace not found", &ctx.DeviceID)
+		return
+	}
+	if workspace.Verified {
+		server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Your business has been verified already", workspace.BusinessVerification, nil, nil, &ctx.DeviceID)
+		return
+	}
+	if workspace.SuperMember != memberID {
+		apperrors.ClientError(ctx.Ctx, "Only the owner of the workspace can verify its business", nil, nil, ctx.DeviceID)
+		return
+	}
+	owner, err := repository.WorkspaceMemberRepo().FindByID(memberID)
+	if err != nil {
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if owner == nil {
+		apperrors.NotFoundError(ctx.Ctx, "member not found", &ctx.DeviceID)
+		return
+	}
+	verification, err := kyb_services.VerifyBusiness(workspace, owner, ctx.Body.RCNumber, ctx.Body.CompanyType)
+	if err != nil {
+		var rejected *kyb_services.BusinessRejectedError
+		if errors.As(err, &rejected) {
+			apperrors.ClientError(ctx.Ctx, rejected.Reason, nil, nil, ctx.DeviceID)
+			return
+		}
+		logger.Error("an error occured while verifying workspace business", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "workspaceID", Data: workspaceID,
+		})
+		if errors.Is(err, identity_verification_types.ErrIdentityProviderUnavailable) {
+			apperrors.ExternalDependencyError(ctx.Ctx, "IDENTITY_VERIFICATION", "503", err, ctx.DeviceID)
+			return
+		}
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if err := kyb_services.MarkWorkspaceVerified(workspaceID, verification); err != nil {
+		var rejected *kyb_services.BusinessRejectedError
+		if errors.As(err, &rejected) {
+			apperrors.ClientError(ctx.Ctx, rejected.Reason, nil, nil, ctx.DeviceID)
+			return
+		}
+		logger.Error("an error occured while saving workspace business verification", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "workspaceID", Data: workspaceID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	logger.Info("workspace business verified", logger.LoggerOptions{
+		Key: "workspaceID", Data: workspaceID,
+	}, logger.LoggerOptions{
+		Key: "rcNumber", Data: verification.RCNumber,
+	})
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "Business verified", verification, nil, nil, &ctx.DeviceID)
+}
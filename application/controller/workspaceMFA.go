@@ -0,0 +1,266 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/controller/dto"
+	"gateman.io/application/interfaces"
+	"gateman.io/application/repository"
+	mfa_services "gateman.io/application/services/mfa"
+	auth_usecases "gateman.io/application/usecases/auth"
+	org_usecases "gateman.io/application/usecases/workspace"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/auth"
+	"gateman.io/infrastructure/database/repository/cache"
+	"gateman.io/infrastructure/logger"
+	server_response "gateman.io/infrastructure/serverResponse"
+	"gateman.io/infrastructure/validator"
+)
+
+// Starts MFA set up for a logged in member or for a member the workspace policy requires to set it up before logging in
+func SetUpWorkspaceMFA(ctx *interfaces.ApplicationContext[any]) {
+	member := fetchMFAWorkspaceMember(ctx.Ctx, ctx.GetStringContextData("UserID"), ctx.GetStringContextData("OTPEmail"), ctx.DeviceID)
+	if member == nil {
+		return
+	}
+	if member.MFAEnabled {
+		apperrors.ClientError(ctx.Ctx, "MFA has already been set up on this account", nil, nil, ctx.DeviceID)
+		return
+	}
+	url, secret, err := mfa_services.StartEnrollment(member.ID, fmt.Sprintf("%s (%s)", member.WorkspaceName, member.Email))
+	if err != nil {
+		logger.Error("an error occured while trying to generate TOTP secret for workspace member", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "memberID",
+			Data: member.ID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "mfa secret generated", map[string]any{
+		"url":    url,
+		"secret": secret,
+	}, nil, nil, &ctx.DeviceID)
+}
+
+func ConfirmWorkspaceMFA(ctx *interfaces.ApplicationContext[dto.ConfirmWorkspaceMFADTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	member := fetchMFAWorkspaceMember(ctx.Ctx, ctx.GetStringContextData("UserID"), ctx.GetStringContextData("OTPEmail"), ctx.DeviceID)
+	if member == nil {
+		return
+	}
+	if member.MFAEnabled {
+		apperrors.ClientError(ctx.Ctx, "MFA has already been set up on this account", nil, nil, ctx.DeviceID)
+		return
+	}
+	recoveryCodes, confirmed, err := org_usecases.ConfirmWorkspaceMemberMFAEnrollment(member, ctx.Body.Code)
+	if err != nil {
+		if errors.Is(err, mfa_services.ErrMFAEnrollmentExpired) {
+			apperrors.ClientError(ctx.Ctx, "MFA set up has expired. Start again to get a new QR code", nil, nil, ctx.DeviceID)
+			return
+		}
+		if errors.Is(err, mfa_services.ErrMFALocked) {
+			apperrors.AuthenticationError(ctx.Ctx, "Too many incorrect codes. Try again later", ctx.DeviceID)
+			return
+		}
+		logger.Error("an error occured while confirming workspace member mfa", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "memberID",
+			Data: member.ID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if !confirmed {
+		apperrors.AuthenticationError(ctx.Ctx, "Incorrect authenticator code", ctx.DeviceID)
+		return
+	}
+	// the set up token can not be used again once MFA is set up
+	if ctx.GetStringContextData("OTPEmail") != "" {
+		cache.Cache.DeleteOne(fmt.Sprintf("%s-otp-intent", member.Email))
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "mfa set up. Store your recovery codes somewhere safe, they will not be shown again", map[string]any{
+		"recoveryCodes": recoveryCodes,
+	}, nil, nil, &ctx.DeviceID)
+}
+
+func RegenerateWorkspaceRecoveryCodes(ctx *interfaces.ApplicationContext[dto.RegenerateWorkspaceRecoveryCodesDTO]) {
+	valiedationErr := validator.ValidatorInstance.ValidateStruct(ctx.Body)
+	if valiedationErr != nil {
+		apperrors.ValidationFailedError(ctx.Ctx, valiedationErr, ctx.DeviceID)
+		return
+	}
+	member := fetchMFAWorkspaceMember(ctx.Ctx, ctx.GetStringContextData("UserID"), "", ctx.DeviceID)
+	if member == nil {
+		return
+	}
+	if !member.MFAEnabled {
+		apperrors.ClientError(ctx.Ctx, "MFA has not been set up on this account", nil, nil, ctx.DeviceID)
+		return
+	}
+	if !org_usecases.VerifyWorkspaceMemberMFA(ctx.Ctx, member, nil, &ctx.Body.MFACode, nil, ctx.DeviceID) {
+		return
+	}
+	recoveryCodes, hashedRecoveryCodes, err := mfa_services.GenerateRecoveryCodes()
+	if err == nil {
+		workspaceMemberRepo := repository.WorkspaceMemberRepo()
+		_, err = workspaceMemberRepo.UpdatePartialByID(member.ID, map[string]any{
+			"accountRecoveryTokens": hashedRecoveryCodes,
+		})
+	}
+	if err != nil {
+		logger.Error("an error occured while regenerating workspace member recovery codes", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "memberID",
+			Data: member.ID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "recovery codes generated", map[string]any{
+		"recoveryCodes": recoveryCodes,
+	}, nil, nil, &ctx.DeviceID)
+}
+
+// Turns the workspace MFA policy on or off.
+// Turning it on signs out members holding MFA enforced permissions who have not set up MFA so they set it up on their next login.
+func UpdateWorkspaceMFAPolicy(ctx *interfaces.ApplicationContext[dto.UpdateWorkspaceMFAPolicyDTO]) {
+	workspaceID := ctx.GetStringContextData("WorkspaceID")
+	if ctx.Body.RequireMemberMFA {
+		member := fetchMFAWorkspaceMember(ctx.Ctx, ctx.GetStringContextData("UserID"), "", ctx.DeviceID)
+		if member == nil {
+			return
+		}
+		if !member.MFAEnabled {
+			apperrors.ClientError(ctx.Ctx, "Set up MFA on your account before requiring it for your workspace", nil, nil, ctx.DeviceID)
+			return
+		}
+	}
+	workspaceRepo := repository.WorkspaceRepository()
+	_, err := workspaceRepo.UpdatePartialByID(workspaceID, map[string]any{
+		"requireMemberMFA": ctx.Body.RequireMemberMFA,
+	})
+	if err != nil {
+		logger.Error("an error occured while updating workspace mfa policy", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "workspaceID",
+			Data: workspaceID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if ctx.Body.RequireMemberMFA {
+		workspaceMemberRepo := repository.WorkspaceMemberRepo()
+		members, err := workspaceMemberRepo.FindMany(map[string]interface{}{
+			"workspaceID": workspaceID,
+			// members who joined before MFA was added do not have the field
+			"mfaEnabled": map[string]any{"$ne": true},
+		})
+		if err != nil {
+			logger.Error("an error occured while fetching workspace members without mfa", logger.LoggerOptions{
+				Key: "err", Data: err,
+			}, logger.LoggerOptions{
+				Key:  "workspaceID",
+				Data: workspaceID,
+			})
+		} else {
+			for _, member := range *members {
+				if member.HoldsMFAEnforcedPermission() {
+					auth_usecases.SignOutEverywhereUseCase(ctx.Ctx, member.ID, auth.WorkspaceMemberTokenFamily, member.Devices, "workspace mfa policy enabled")
+				}
+			}
+		}
+	}
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "workspace mfa policy updated", nil, nil, nil, &ctx.DeviceID)
+}
+
+// Lets workspace admins remove the authenticator of a member who has lost access to it and their recovery codes.
+// Admins can only reset members whose permissions they also hold and the member is signed out everywhere.
+func ResetWorkspaceMemberMFA(ctx *interfaces.ApplicationContext[any]) {
+	workspaceID := ctx.GetStringContextData("WorkspaceID")
+	admin := fetchMFAWorkspaceMember(ctx.Ctx, ctx.GetStringContextData("UserID"), "", ctx.DeviceID)
+	if admin == nil {
+		return
+	}
+	workspaceMemberRepo := repository.WorkspaceMemberRepo()
+	member, err := workspaceMemberRepo.FindOneByFilter(map[string]interface{}{
+		"_id":         ctx.GetStringParameter("id"),
+		"workspaceID": workspaceID,
+	})
+	if err != nil {
+		logger.Error("an error occured while fetching workspace member for mfa reset", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "memberID",
+			Data: ctx.GetStringParameter("id"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	if member == nil || admin.WorkspaceID != workspaceID {
+		apperrors.NotFoundError(ctx.Ctx, "workspace member not found", &ctx.DeviceID)
+		return
+	}
+	if !admin.HoldsPermissionsOf(*member) {
+		apperrors.AuthenticationError(ctx.Ctx, "you can not reset mfa for a member with more access than you", ctx.DeviceID)
+		return
+	}
+	_, err = workspaceMemberRepo.UpdatePartialByID(member.ID, map[string]any{
+		"mfaEnabled":            false,
+		"authenticatorSecret":   nil,
+		"accountRecoveryTokens": nil,
+	})
+	if err != nil {
+		logger.Error("an error occured while resetting workspace member mfa", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "memberID",
+			Data: member.ID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return
+	}
+	// sessions opened with the old authenticator should not outlive it
+	auth_usecases.SignOutEverywhereUseCase(ctx.Ctx, member.ID, auth.WorkspaceMemberTokenFamily, member.Devices, "workspace member mfa reset")
+	server_response.Responder.Respond(ctx.Ctx, http.StatusOK, "member mfa reset", nil, nil, nil, &ctx.DeviceID)
+}
+
+// finds the member by id when they are logged in or by email when they are using a set up token
+func fetchMFAWorkspaceMember(ctx any, memberID string, email string, deviceID string) *entities.WorkspaceMember {
+	filter := map[string]interface{}{
+		"_id": memberID,
+	}
+	if memberID == "" {
+		filter = map[string]interface{}{
+			"email": email,
+		}
+	}
+	workspaceMemberRepo := repository.WorkspaceMemberRepo()
+	member, err := workspaceMemberRepo.FindOneByFilter(filter)
+	if err != nil {
+		logger.Error("an error occured while fetching workspace member for mfa", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "filter",
+			Data: filter,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return nil
+	}
+	if member == nil || member.Deactivated {
+		apperrors.NotFoundError(ctx, "workspace member not found", &deviceID)
+		return nil
+	}
+	return member
+}
@@ -5,10 +5,13 @@ import (
 	"context"
 	"io"
 	"net"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"gateman.io/application/repository"
+	"gateman.io/application/utils"
 	"gateman.io/entities"
 	"gateman.io/infrastructure/logger"
 	"github.com/gin-gonic/gin"
@@ -56,7 +59,7 @@ func ActivityLogMiddleware() gin.HandlerFunc {
 		duration := time.Since(startTime).Milliseconds()
 
 		// Extract IP address
-		ipAddress := getClientIP(c)
+		ipAddress := ClientIP(c)
 
 		// Extract App ID from header
 		var appID *string
@@ -129,39 +132,59 @@ func ActivityLogMiddleware() gin.HandlerFunc {
 	}
 }
 
-// getClientIP extracts the real client IP address from various headers
-func getClientIP(c *gin.Context) string {
+// ClientIP extracts the real client IP address of a request.
+// Proxy headers are only trusted when the request comes from one of the load balancers in TRUSTED_PROXIES
+// (comma separated IPs or CIDR ranges), otherwise any caller could pick the IP they are seen as.
+func ClientIP(c *gin.Context) string {
+	remoteIP, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		remoteIP = c.Request.RemoteAddr
+	}
+	trustedProxies := trustedProxyRanges()
+	if !utils.IPMatchesAllowlist(remoteIP, trustedProxies) {
+		return remoteIP
+	}
+
 	// Check X-Forwarded-For header (most common)
 	if xff := c.GetHeader("X-Forwarded-For"); xff != "" {
-		// X-Forwarded-For can contain multiple IPs, take the first one
+		// each proxy appends the address it received the request from, so the client is the
+		// right most address that is not one of our proxies. Anything left of it was sent by the client.
 		ips := strings.Split(xff, ",")
-		if len(ips) > 0 {
-			ip := strings.TrimSpace(ips[0])
-			if ip != "" && ip != "unknown" {
+		for i := len(ips) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(ips[i])
+			if net.ParseIP(ip) == nil {
+				break
+			}
+			if i == 0 || !utils.IPMatchesAllowlist(ip, trustedProxies) {
 				return ip
 			}
 		}
 	}
 
 	// Check X-Real-IP header
-	if xri := c.GetHeader("X-Real-IP"); xri != "" && xri != "unknown" {
+	if xri := c.GetHeader("X-Real-IP"); net.ParseIP(xri) != nil {
 		return xri
 	}
 
 	// Check X-Client-IP header
-	if xci := c.GetHeader("X-Client-IP"); xci != "" && xci != "unknown" {
+	if xci := c.GetHeader("X-Client-IP"); net.ParseIP(xci) != nil {
 		return xci
 	}
 
 	// Check CF-Connecting-IP header (Cloudflare)
-	if cfip := c.GetHeader("CF-Connecting-IP"); cfip != "" && cfip != "unknown" {
+	if cfip := c.GetHeader("CF-Connecting-IP"); net.ParseIP(cfip) != nil {
 		return cfip
 	}
 
-	// Fallback to RemoteAddr
-	ip, _, err := net.SplitHostPort(c.Request.RemoteAddr)
-	if err != nil {
-		return c.Request.RemoteAddr
-	}
-	return ip
+	return remoteIP
 }
+
+var trustedProxyRanges = sync.OnceValue(func() []string {
+	proxies := []string{}
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+})
@@ -3,17 +3,21 @@ package middlewares
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	apperrors "gateman.io/application/appErrors"
 	"gateman.io/application/interfaces"
-	"gateman.io/application/repository"
-	"gateman.io/infrastructure/cryptography"
+	services "gateman.io/application/services/application"
+	kyb_services "gateman.io/application/services/kyb"
+	security_services "gateman.io/application/services/security"
+	"gateman.io/entities"
 	"gateman.io/infrastructure/database/connection/cache"
 )
 
-func AppAuthenticationMiddleware(ctx *interfaces.ApplicationContext[any], ipAddress string) (*interfaces.ApplicationContext[any], bool) {
+// Authenticates requests to the public api. Scoped api keys must hold requiredScope.
+// The app's legacy api keys are not scoped and can call every route.
+// ipAddress must be resolved with ClientIP so it is checked against the app's allowlist for the key's environment.
+func AppAuthenticationMiddleware(ctx *interfaces.ApplicationContext[any], ipAddress string, requiredScope entities.APIKeyScope) (*interfaces.ApplicationContext[any], bool) {
 	apiKeyPointer := ctx.GetHeader("X-Api-Key")
 	if apiKeyPointer == nil {
 		apperrors.AuthenticationError(ctx.Ctx, "provide an api key", ctx.DeviceID)
@@ -32,48 +36,51 @@ func AppAuthenticationMiddleware(ctx *interfaces.ApplicationContext[any], ipAddr
 		apperrors.ClientError(ctx.Ctx, "rate limit exceeded, please try again later", nil, nil, ctx.DeviceID)
 		return nil, false
 	}
-	appRepo := repository.ApplicationRepo()
-	app, _ := appRepo.FindOneByFilter(map[string]interface{}{
-		"appID": appID,
-	})
-	if app == nil {
-		apperrors.NotFoundError(ctx.Ctx, "invalid credentials", ctx.GetHeader("X-Device-Id"))
+	app, err := services.AuthenticateAPIKey(appID, apiKey)
+	if err != nil {
+		apperrors.ClientError(ctx.Ctx, "invalid credentials", nil, nil, ctx.DeviceID)
+		return nil, false
+	}
+	if !app.HasScope(requiredScope) {
+		apperrors.AuthenticationError(ctx.Ctx, fmt.Sprintf("this api key does not have the %s scope", requiredScope), ctx.DeviceID)
 		return nil, false
 	}
-	var appAPIKey string
-	var sanbox = strings.Contains(apiKey, "sandbox")
-	if sanbox {
-		appAPIKey = app.SandboxAPIKey
-		apiKey = strings.Split(apiKey, "-")[1]
-	} else {
-		appAPIKey = app.APIKey
+	if requiredScope.KYC() && !app.Sandbox {
+		verified, err := kyb_services.WorkspaceVerified(app.WorkspaceID)
+		if err != nil {
+			apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+			return nil, false
+		}
+		if !verified {
+			apperrors.AuthenticationError(ctx.Ctx, "verify your business to call the kyc api with production keys", ctx.DeviceID)
+			return nil, false
+		}
 	}
-	match := cryptography.CryptoHahser.VerifyHashData(appAPIKey, apiKey)
-	if !match {
-		apperrors.ClientError(ctx.Ctx, "invalid credentials", nil, nil, ctx.DeviceID)
+	if !app.IPAllowed(ipAddress) {
+		go security_services.RecordSecurityEvent(entities.SecurityEvent{
+			Type:        entities.AppIPNotAllowed,
+			ActorID:     &app.AppID,
+			ActorType:   "app",
+			AppID:       &app.AppID,
+			WorkspaceID: &app.WorkspaceID,
+			IPAddress:   &ipAddress,
+			Metadata: map[string]any{
+				"apiKeyID": app.KeyID,
+				"sandbox":  app.Sandbox,
+			},
+		})
+		apperrors.AuthenticationError(ctx.Ctx, "requests from this ip address are not allowed for this app", ctx.DeviceID)
 		return nil, false
 	}
-	// if os.Getenv("APP_ENV") == "production" && app.WhiteListedIPs == nil {
-	// 	apperrors.ClientError(ctx.Ctx, "no ip address whitelisted", nil, nil, ctx.DeviceID)
-	// 	return nil, false
-	// }
-	// if os.Getenv("APP_ENV") == "production" {
-	// 	validIP := false
-	// 	for _, wIP := range *app.WhiteListedIPs {
-	// 		if wIP == ipAddress {
-	// 			validIP = true
-	// 			break
-	// 		}
-	// 	}
-	// 	if !validIP {
-	// 		apperrors.ClientError(ctx.Ctx, "unauthorised access", nil, nil, ctx.DeviceID)
-	// 		return nil, false
-	// 	}
-	// }
+	// only requests that are let through count as a use of the key
+	if app.KeyID != nil {
+		go services.RecordAPIKeyUse(*app.KeyID, ipAddress)
+		ctx.SetContextData("APIKeyID", *app.KeyID)
+	}
 	ctx.SetContextData("AppID", appID)
 	ctx.SetContextData("Name", app.Name)
 	ctx.SetContextData("WorkspaceID", app.WorkspaceID)
-	ctx.SetContextData("SandboxEnv", sanbox)
+	ctx.SetContextData("SandboxEnv", app.Sandbox)
 	return ctx, true
 }
 
@@ -1,41 +1,86 @@
 package middlewares
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	apperrors "gateman.io/application/appErrors"
 	"gateman.io/application/interfaces"
-	"gateman.io/application/utils"
 	"gateman.io/infrastructure/cryptography"
 	"gateman.io/infrastructure/database/repository/cache"
 	"gateman.io/infrastructure/logger"
 )
 
-func DecryptPayloadMiddleware(ctx *interfaces.ApplicationContext[string]) []byte {
-	if ctx.Body == nil || *ctx.Body == "" {
-		return nil
-	}
-	sharedKey := cache.Cache.FindOne(fmt.Sprintf("%s-key", ctx.DeviceID))
-	if sharedKey == nil {
-		apperrors.ClientError(ctx.Ctx, "expired encryption key", nil, nil, ctx.DeviceID)
-		return nil
-	}
+// how far the timestamp of an encrypted request may be from the server's clock
+const PayloadTimestampTolerance = time.Minute * 5
+
+// The body of an encrypted request and of the response to it
+type EncryptedPayload struct {
+	Payload string `json:"payload"`
+}
 
-	decryptedKey, err := cryptography.DecryptData(*sharedKey, nil)
+// The additional data a request body is sealed with: METHOD\nPATH\nTIMESTAMP\nNONCE.
+// It ties the body to the route and nonce it was sent with so it cannot be replayed elsewhere.
+func PayloadAdditionalData(method string, path string, timestamp string, nonce string) []byte {
+	return []byte(strings.Join([]string{strings.ToUpper(method), path, timestamp, nonce}, "\n"))
+}
+
+// Decrypts the body of a request from a device that has run the key exchange.
+// Encrypted requests carry X-Payload-Nonce and X-Payload-Timestamp (unix seconds) and, when they have a body, send
+// {"payload": hex(nonce || ciphertext || tag)} sealed with AES-GCM under the device's payload key.
+// A nonce is only accepted once. The decrypted body is returned and ctx.Nonce is set so the response is encrypted.
+func DecryptPayloadMiddleware(ctx *interfaces.ApplicationContext[string], method string, path string) ([]byte, bool) {
+	nonce := ctx.GetHeader("X-Payload-Nonce")
+	timestamp := ctx.GetHeader("X-Payload-Timestamp")
+	if ctx.DeviceID == "" || nonce == nil || timestamp == nil || len(*nonce) < 16 || len(*nonce) > 128 {
+		apperrors.MalformedHeader(ctx.Ctx, &ctx.DeviceID)
+		return nil, false
+	}
+	issuedAt, err := strconv.ParseInt(*timestamp, 10, 64)
 	if err != nil {
-		logger.Error("an error occured while decrypting user payload", logger.LoggerOptions{
-			Key:  "error",
-			Data: err,
-		})
-		return nil
+		apperrors.MalformedHeader(ctx.Ctx, &ctx.DeviceID)
+		return nil, false
+	}
+	drift := time.Since(time.Unix(issuedAt, 0))
+	if drift > PayloadTimestampTolerance || drift < -PayloadTimestampTolerance {
+		apperrors.AuthenticationError(ctx.Ctx, "request timestamp is outside the allowed window", ctx.DeviceID)
+		return nil, false
+	}
+	key, err := cryptography.DevicePayloadKey(ctx.DeviceID)
+	if errors.Is(err, cryptography.ErrEncryptionKeyExpired) {
+		apperrors.EncryptionKeyExpired(ctx.Ctx, ctx.DeviceID)
+		return nil, false
 	}
-	result, err := cryptography.DecryptData(*ctx.Body, utils.GetStringPointer(string(decryptedKey)))
 	if err != nil {
-		logger.Error("an error occured while decrypting user payload", logger.LoggerOptions{
+		logger.Error("an error occured while fetching device payload key", logger.LoggerOptions{
 			Key:  "error",
 			Data: err,
 		})
-		return nil
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return nil, false
+	}
+	var result []byte
+	if ctx.Body != nil && *ctx.Body != "" {
+		var body EncryptedPayload
+		if err := json.Unmarshal([]byte(*ctx.Body), &body); err != nil || body.Payload == "" {
+			apperrors.ErrorProcessingPayload(ctx.Ctx, &ctx.DeviceID)
+			return nil, false
+		}
+		result, err = cryptography.OpenPayload(key, body.Payload, PayloadAdditionalData(method, path, *timestamp, *nonce))
+		if err != nil {
+			apperrors.ClientError(ctx.Ctx, "payload could not be decrypted", nil, nil, ctx.DeviceID)
+			return nil, false
+		}
+	}
+	// the nonce is kept for both sides of the tolerance window, after which the timestamp check rejects the request anyway
+	if !cache.Cache.CreateEntryIfNotExists(fmt.Sprintf("payload-nonce:%s:%s", ctx.DeviceID, *nonce), *timestamp, PayloadTimestampTolerance*2) {
+		apperrors.AuthenticationError(ctx.Ctx, "request nonce has already been used", ctx.DeviceID)
+		return nil, false
 	}
-	return result
+	ctx.Nonce = nonce
+	return result, true
 }
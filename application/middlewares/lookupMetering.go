@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"errors"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/constants"
+	"gateman.io/application/interfaces"
+	metering_services "gateman.io/application/services/metering"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/logger"
+)
+
+// Reserves a lookup of lookupType against the quota of the app and stops apps that have used the lookups of lookupType
+// their plan includes this month
+func LookupQuotaMiddleware(ctx *interfaces.ApplicationContext[any], lookupType entities.MeteredLookupType) (*interfaces.ApplicationContext[any], bool) {
+	reservation, err := metering_services.ReserveLookups(ctx.GetStringContextData("AppID"), lookupType, 1, ctx.GetBoolContextData("SandboxEnv"))
+	if errors.Is(err, metering_services.ErrLookupQuotaExceeded) {
+		apperrors.CustomError(ctx.Ctx, err.Error(), &constants.LOOKUP_QUOTA_EXCEEDED, ctx.DeviceID)
+		return nil, false
+	}
+	if err != nil {
+		logger.Error("an error occured while checking the lookup quota of an app", logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key:  "appID",
+			Data: ctx.GetStringContextData("AppID"),
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+		return nil, false
+	}
+	ctx.SetContextData("LookupReservation", reservation)
+	return ctx, true
+}
+
+// Records a lookup of lookupType made by the app of ctx, or gives its reservation back when the route did not make it.
+// The response has been sent, so a failure is only logged.
+func RecordMeteredLookup(ctx *interfaces.ApplicationContext[any], lookupType entities.MeteredLookupType, made bool) {
+	value, _ := ctx.GetContextData("LookupReservation")
+	reservation, _ := value.(entities.LookupReservation)
+	var err error
+	if made {
+		err = metering_services.RecordLookup(entities.MeteredLookup{
+			AppID:       ctx.GetStringContextData("AppID"),
+			WorkspaceID: ctx.GetStringContextData("WorkspaceID"),
+			Type:        lookupType,
+			Source:      entities.ProviderLookup,
+			Sandbox:     ctx.GetBoolContextData("SandboxEnv"),
+			Plan:        reservation.Plan,
+		})
+	} else {
+		err = metering_services.ReleaseLookups(ctx.GetStringContextData("AppID"), reservation, reservation.Count)
+	}
+	if err != nil {
+		logger.Error("an error occured while recording a metered lookup", logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key:  "appID",
+			Data: ctx.GetStringContextData("AppID"),
+		})
+	}
+}
@@ -1,19 +1,21 @@
 package middlewares
 
 import (
-	"fmt"
+	"errors"
 	"os"
+	"time"
 
 	apperrors "gateman.io/application/appErrors"
 	"gateman.io/application/interfaces"
+	security_services "gateman.io/application/services/security"
+	"gateman.io/entities"
 	"gateman.io/infrastructure/auth"
 	"gateman.io/infrastructure/cryptography"
-	"gateman.io/infrastructure/database/repository/cache"
 	"gateman.io/infrastructure/logger"
 	"github.com/golang-jwt/jwt"
 )
 
-func RefreshTokenMiddleware(ctx *interfaces.ApplicationContext[any], workspaceToken bool, authToken string) (*interfaces.ApplicationContext[any], bool) {
+func RefreshTokenMiddleware(ctx *interfaces.ApplicationContext[any], workspaceToken bool, authToken string, ipAddress string) (*interfaces.ApplicationContext[any], bool) {
 	if authToken == "" {
 		apperrors.AuthenticationError(ctx.Ctx, "missing auth token", ctx.DeviceID)
 		return nil, false
@@ -37,20 +39,16 @@ func RefreshTokenMiddleware(ctx *interfaces.ApplicationContext[any], workspaceTo
 		return nil, false
 	}
 
-	deviceIDHash, _ := cryptography.CryptoHahser.HashString(ctx.DeviceID, []byte(os.Getenv("HASH_FIXED_SALT")))
-	var tokenKey string
-	if workspaceToken {
-		tokenKey = fmt.Sprintf("%s-workspace-refresh", string(deviceIDHash))
-	} else {
-		tokenKey = fmt.Sprintf("%s-refresh", string(deviceIDHash))
-	}
-	validToken := cache.Cache.FindOne(tokenKey)
-	if validToken == nil {
-		apperrors.AuthenticationError(ctx.Ctx, "this session has expired", ctx.DeviceID)
-		return nil, false
-	}
-	match := cryptography.CryptoHahser.VerifyHashData(*validToken, authToken)
-	if !match {
+	familyID, hasFamily := authTokenClaims["fam"].(string)
+	tokenID, _ := authTokenClaims["jti"].(string)
+	if !matchesSavedRefreshToken(ctx.DeviceID, workspaceToken, authToken) {
+		// a token that was already exchanged no longer matches the saved one, which means it is being replayed
+		if hasFamily && auth.IsRefreshTokenConsumed(familyID, tokenID) && auth.IsRefreshTokenFamilyActive(familyID) {
+			auth.RevokeRefreshTokenFamily(familyID)
+			if record := auth.FindRefreshTokenFamily(familyID); record != nil {
+				handleRefreshTokenReuse(familyID, record, workspaceToken, ipAddress)
+			}
+		}
 		apperrors.AuthenticationError(ctx.Ctx, "this session has expired", ctx.DeviceID)
 		return nil, false
 	}
@@ -82,8 +80,54 @@ func RefreshTokenMiddleware(ctx *interfaces.ApplicationContext[any], workspaceTo
 		return nil, false
 	}
 
+	if hasFamily {
+		// rotation happens after every other check so a rejected request does not consume the token
+		family, record, err := auth.RotateRefreshTokenFamily(familyID, tokenID, time.Hour*24*180)
+		if err != nil {
+			if errors.Is(err, auth.ErrRefreshTokenReused) && record != nil {
+				handleRefreshTokenReuse(familyID, record, workspaceToken, ipAddress)
+			}
+			apperrors.AuthenticationError(ctx.Ctx, "this session has expired", ctx.DeviceID)
+			return nil, false
+		}
+		ctx.SetContextData("RefreshTokenFamily", family)
+	}
+
 	ctx.SetContextData("UserID", authTokenClaims["userID"])
 	ctx.SetContextData("Email", authTokenClaims["email"])
 	ctx.SetContextData("Phone", authTokenClaims["phone"])
 	return ctx, true
 }
+
+func matchesSavedRefreshToken(deviceID string, workspaceToken bool, authToken string) bool {
+	suffix := "refresh"
+	if workspaceToken {
+		suffix = "workspace-refresh"
+	}
+	validToken := auth.FindDeviceSession(deviceID, suffix)
+	if validToken == nil {
+		return false
+	}
+	return cryptography.CryptoHahser.VerifyHashData(*validToken, authToken)
+}
+
+// signs the family's device out and records the reuse since it usually means the refresh token was stolen
+func handleRefreshTokenReuse(familyID string, record *auth.TokenFamilyRecord, workspaceToken bool, ipAddress string) {
+	if workspaceToken {
+		auth.DeleteDeviceSession(record.DeviceID, "workspace-access")
+		auth.DeleteDeviceSession(record.DeviceID, "workspace-refresh")
+	} else {
+		auth.DeleteDeviceSession(record.DeviceID, "access")
+		auth.DeleteDeviceSession(record.DeviceID, "refresh")
+	}
+	security_services.RecordSecurityEvent(entities.SecurityEvent{
+		Type:      entities.RefreshTokenReuseEvent,
+		ActorID:   &record.OwnerID,
+		ActorType: string(record.OwnerType),
+		DeviceID:  &record.DeviceID,
+		IPAddress: &ipAddress,
+		Metadata: map[string]any{
+			"familyID": familyID,
+		},
+	})
+}
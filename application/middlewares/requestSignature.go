@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"errors"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/interfaces"
+	services "gateman.io/application/services/application"
+	"gateman.io/infrastructure/logger"
+)
+
+// Verifies the HMAC signature of a public api request. Must run after AppAuthenticationMiddleware since it needs the app.
+// Signature headers are X-Signature, X-Signature-Timestamp (unix seconds) and X-Signature-Nonce.
+func AppRequestSignatureMiddleware(ctx *interfaces.ApplicationContext[any], method string, path string, rawQuery string, body []byte) (*interfaces.ApplicationContext[any], bool) {
+	appID := ctx.GetStringContextData("AppID")
+	err := services.VerifyRequestSignature(appID, services.SignedRequest{
+		Method:    method,
+		Path:      path,
+		RawQuery:  rawQuery,
+		Body:      body,
+		Timestamp: ctx.GetHeader("X-Signature-Timestamp"),
+		Nonce:     ctx.GetHeader("X-Signature-Nonce"),
+		Signature: ctx.GetHeader("X-Signature"),
+	})
+	if err == nil {
+		return ctx, true
+	}
+	switch {
+	case errors.Is(err, services.ErrRequestSignatureRequired),
+		errors.Is(err, services.ErrRequestSigningNotSetUp),
+		errors.Is(err, services.ErrRequestSignatureMalformed),
+		errors.Is(err, services.ErrRequestSignatureExpired),
+		errors.Is(err, services.ErrRequestNonceReused),
+		errors.Is(err, services.ErrInvalidRequestSignature):
+		apperrors.AuthenticationError(ctx.Ctx, err.Error(), ctx.DeviceID)
+	default:
+		logger.Error("an error occured while verifying request signature", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "appID",
+			Data: appID,
+		})
+		apperrors.UnknownError(ctx.Ctx, err, nil, ctx.DeviceID)
+	}
+	return nil, false
+}
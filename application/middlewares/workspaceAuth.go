@@ -10,7 +10,6 @@ import (
 	"gateman.io/entities"
 	"gateman.io/infrastructure/auth"
 	"gateman.io/infrastructure/cryptography"
-	"gateman.io/infrastructure/database/repository/cache"
 	"gateman.io/infrastructure/logger"
 	"github.com/golang-jwt/jwt"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -38,8 +37,7 @@ func WorkspaceAuthenticationMiddleware(ctx *interfaces.ApplicationContext[any],
 		return nil, false
 	}
 
-	deviceIDHash, _ := cryptography.CryptoHahser.HashString(ctx.DeviceID, []byte(os.Getenv("HASH_FIXED_SALT")))
-	validToken := cache.Cache.FindOne(fmt.Sprintf("%s-workspace-access", string(deviceIDHash)))
+	validToken := auth.FindDeviceSession(ctx.DeviceID, "workspace-access")
 	if validToken == nil {
 		apperrors.AuthenticationError(ctx.Ctx, "this session has expired", ctx.DeviceID)
 		return nil, false
@@ -105,19 +103,22 @@ func WorkspaceAuthenticationMiddleware(ctx *interfaces.ApplicationContext[any],
 			apperrors.AuthenticationError(ctx.Ctx, "unauthorized access", ctx.DeviceID)
 			return nil, false
 		}
-		hasAccess := false
-		for _, rPermission := range *requiredPermissions {
-			hasPermission := false
-			for _, uPermission := range workspaceMember.Permissions {
-				if uPermission == entities.SUPER_ACCESS || uPermission == rPermission {
-					hasPermission = true
+		// routes every member can use, such as managing their own sessions, do not require a permission
+		hasAccess := requiredPermissions == nil
+		if requiredPermissions != nil {
+			for _, rPermission := range *requiredPermissions {
+				hasPermission := false
+				for _, uPermission := range workspaceMember.Permissions {
+					if uPermission == entities.SUPER_ACCESS || uPermission == rPermission {
+						hasPermission = true
+						break
+					}
+				}
+				if hasPermission {
+					hasAccess = true
 					break
 				}
 			}
-			if hasPermission {
-				hasAccess = true
-				break
-			}
 		}
 		if !hasAccess {
 			apperrors.AuthenticationError(ctx.Ctx, "unauthorized access", ctx.DeviceID)
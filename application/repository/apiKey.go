@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"sync"
+
+	"gateman.io/entities"
+	"gateman.io/infrastructure/database/connection/datastore"
+	"gateman.io/infrastructure/database/repository/mongo"
+)
+
+var apiKeyOnce = sync.Once{}
+
+var apiKeyRepository mongo.MongoRepository[entities.APIKey]
+
+func APIKeyRepo() *mongo.MongoRepository[entities.APIKey] {
+	apiKeyOnce.Do(func() {
+		apiKeyRepository = mongo.MongoRepository[entities.APIKey]{Model: datastore.APIKeyModel}
+	})
+	return &apiKeyRepository
+}
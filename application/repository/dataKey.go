@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"sync"
+
+	"gateman.io/entities"
+	"gateman.io/infrastructure/database/connection/datastore"
+	"gateman.io/infrastructure/database/repository/mongo"
+)
+
+var dataKeyOnce = sync.Once{}
+
+var dataKeyRepository mongo.MongoRepository[entities.DataKey]
+
+func DataKeyRepo() *mongo.MongoRepository[entities.DataKey] {
+	dataKeyOnce.Do(func() {
+		dataKeyRepository = mongo.MongoRepository[entities.DataKey]{Model: datastore.DataKeyModel}
+	})
+	return &dataKeyRepository
+}
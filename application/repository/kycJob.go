@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"sync"
+
+	"gateman.io/entities"
+	"gateman.io/infrastructure/database/connection/datastore"
+	"gateman.io/infrastructure/database/repository/mongo"
+)
+
+var kycJobOnce = sync.Once{}
+
+var kycJobRepository mongo.MongoRepository[entities.KYCJob]
+
+func KYCJobRepo() *mongo.MongoRepository[entities.KYCJob] {
+	kycJobOnce.Do(func() {
+		kycJobRepository = mongo.MongoRepository[entities.KYCJob]{Model: datastore.KYCJobModel}
+	})
+	return &kycJobRepository
+}
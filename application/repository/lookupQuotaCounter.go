@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"sync"
+
+	"gateman.io/entities"
+	"gateman.io/infrastructure/database/connection/datastore"
+	"gateman.io/infrastructure/database/repository/mongo"
+)
+
+var lookupQuotaCounterOnce = sync.Once{}
+
+var lookupQuotaCounterRepository mongo.MongoRepository[entities.LookupQuotaCounter]
+
+func LookupQuotaCounterRepo() *mongo.MongoRepository[entities.LookupQuotaCounter] {
+	lookupQuotaCounterOnce.Do(func() {
+		lookupQuotaCounterRepository = mongo.MongoRepository[entities.LookupQuotaCounter]{Model: datastore.LookupQuotaCounterModel}
+	})
+	return &lookupQuotaCounterRepository
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"sync"
+
+	"gateman.io/entities"
+	"gateman.io/infrastructure/database/connection/datastore"
+	"gateman.io/infrastructure/database/repository/mongo"
+)
+
+var meteredLookupOnce = sync.Once{}
+
+var meteredLookupRepository mongo.MongoRepository[entities.MeteredLookup]
+
+func MeteredLookupRepo() *mongo.MongoRepository[entities.MeteredLookup] {
+	meteredLookupOnce.Do(func() {
+		meteredLookupRepository = mongo.MongoRepository[entities.MeteredLookup]{Model: datastore.MeteredLookupModel}
+	})
+	return &meteredLookupRepository
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"sync"
+
+	"gateman.io/entities"
+	"gateman.io/infrastructure/database/connection/datastore"
+	"gateman.io/infrastructure/database/repository/mongo"
+)
+
+var screeningHitOnce = sync.Once{}
+
+var screeningHitRepository mongo.MongoRepository[entities.ScreeningHit]
+
+func ScreeningHitRepo() *mongo.MongoRepository[entities.ScreeningHit] {
+	screeningHitOnce.Do(func() {
+		screeningHitRepository = mongo.MongoRepository[entities.ScreeningHit]{Model: datastore.ScreeningHitModel}
+	})
+	return &screeningHitRepository
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"sync"
+
+	"gateman.io/entities"
+	"gateman.io/infrastructure/database/connection/datastore"
+	"gateman.io/infrastructure/database/repository/mongo"
+)
+
+var screeningHitReviewOnce = sync.Once{}
+
+var screeningHitReviewRepository mongo.MongoRepository[entities.ScreeningHitReview]
+
+func ScreeningHitReviewRepo() *mongo.MongoRepository[entities.ScreeningHitReview] {
+	screeningHitReviewOnce.Do(func() {
+		screeningHitReviewRepository = mongo.MongoRepository[entities.ScreeningHitReview]{Model: datastore.ScreeningHitReviewModel}
+	})
+	return &screeningHitReviewRepository
+}
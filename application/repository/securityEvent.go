@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"sync"
+
+	"gateman.io/entities"
+	"gateman.io/infrastructure/database/connection/datastore"
+	"gateman.io/infrastructure/database/repository/mongo"
+)
+
+var securityEventOnce = sync.Once{}
+
+var securityEventRepository mongo.MongoRepository[entities.SecurityEvent]
+
+func SecurityEventRepo() *mongo.MongoRepository[entities.SecurityEvent] {
+	securityEventOnce.Do(func() {
+		securityEventRepository = mongo.MongoRepository[entities.SecurityEvent]{Model: datastore.SecurityEventModel}
+	})
+	return &securityEventRepository
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gateman.io/application/repository"
+	"gateman.io/application/utils"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/cryptography"
+	"gateman.io/infrastructure/database/repository/cache"
+	"gateman.io/infrastructure/logger"
+)
+
+// scoped api keys are sent as gmk_<keyID>_<secret> so the key can be found without scanning every hash of the app
+const scopedAPIKeyPrefix = "gmk_"
+
+const MaxActiveAPIKeys = 20
+
+var (
+	ErrInvalidAPIKey        = errors.New("invalid api key")
+	ErrAPIKeyLimitReached   = errors.New("api key limit reached")
+	ErrAPIKeyNotFound       = errors.New("api key not found")
+	ErrAPIKeyAlreadyRevoked = errors.New("api key has already been revoked")
+	ErrAPIKeyInactive       = errors.New("api key has been revoked or has expired")
+)
+
+// Returns true if key is a scoped api key rather than the app's legacy api key
+func IsScopedAPIKey(key string) bool {
+	return strings.HasPrefix(key, scopedAPIKeyPrefix)
+}
+
+func parseScopedAPIKey(key string) (keyID string, secret string, err error) {
+	parts := strings.Split(strings.TrimPrefix(key, scopedAPIKeyPrefix), "_")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", ErrInvalidAPIKey
+	}
+	return parts[0], parts[1], nil
+}
+
+// Creates an api key for app. The plain key is only ever returned here, it is stored hashed.
+func CreateAPIKey(app *entities.Application, label string, scopes []entities.APIKeyScope, sandbox bool, expiresAt *time.Time, creatorID string, rotatedFrom *string) (*entities.APIKey, *string, error) {
+	apiKeyRepo := repository.APIKeyRepo()
+	activeKeys, err := apiKeyRepo.CountDocs(activeAPIKeysFilter(app.AppID))
+	if err != nil {
+		return nil, nil, err
+	}
+	if activeKeys >= MaxActiveAPIKeys {
+		return nil, nil, ErrAPIKeyLimitReached
+	}
+	secretBytes := make([]byte, 32)
+	if _, err = rand.Read(secretBytes); err != nil {
+		return nil, nil, err
+	}
+	secret := hex.EncodeToString(secretBytes)
+	hashedSecret, err := cryptography.CryptoHahser.HashString(secret, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	apiKey := entities.APIKey{
+		ID:          utils.GenerateUULDString(),
+		AppID:       app.AppID,
+		WorkspaceID: app.WorkspaceID,
+		Label:       label,
+		HashedKey:   string(hashedSecret),
+		Scopes:      uniqueAPIKeyScopes(scopes),
+		Sandbox:     sandbox,
+		ExpiresAt:   expiresAt,
+		CreatorID:   creatorID,
+		RotatedFrom: rotatedFrom,
+	}
+	_, err = apiKeyRepo.CreateOne(context.TODO(), apiKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	plainKey := fmt.Sprintf("%s%s_%s", scopedAPIKeyPrefix, apiKey.ID, secret)
+	return &apiKey, &plainKey, nil
+}
+
+// Replaces key with a new key that has the same label, scopes and expiry. Only active keys can be rotated.
+// The old key keeps working for overlap so integrations can switch to the new key without downtime.
+func RotateAPIKey(app *entities.Application, key *entities.APIKey, overlap time.Duration, creatorID string) (*entities.APIKey, *string, error) {
+	if !key.Active() {
+		return nil, nil, ErrAPIKeyInactive
+	}
+	newKey, plainKey, err := CreateAPIKey(app, key.Label, key.Scopes, key.Sandbox, key.ExpiresAt, creatorID, &key.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	expiresAt := time.Now().Add(overlap)
+	if key.ExpiresAt != nil && key.ExpiresAt.Before(expiresAt) {
+		expiresAt = *key.ExpiresAt
+	}
+	apiKeyRepo := repository.APIKeyRepo()
+	_, err = apiKeyRepo.UpdatePartialByID(key.ID, map[string]any{
+		"expiresAt": expiresAt,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	PurgeAPIKeyCache(app.AppID, APIKeyCachePurgeKeyRotated)
+	return newKey, plainKey, nil
+}
+
+// Revokes the key immediately
+func RevokeAPIKey(key *entities.APIKey) error {
+	if key.RevokedAt != nil {
+		return ErrAPIKeyAlreadyRevoked
+	}
+	apiKeyRepo := repository.APIKeyRepo()
+	_, err := apiKeyRepo.UpdatePartialByID(key.ID, map[string]any{
+		"revokedAt": time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	PurgeAPIKeyCache(key.AppID, APIKeyCachePurgeKeyRevoked)
+	return nil
+}
+
+// Finds one of the keys of the app
+func FindAppAPIKey(appID string, workspaceID string, keyID string) (*entities.APIKey, error) {
+	apiKeyRepo := repository.APIKeyRepo()
+	key, err := apiKeyRepo.FindOneByFilter(map[string]interface{}{
+		"_id":         keyID,
+		"appID":       appID,
+		"workspaceID": workspaceID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// Returns the key if it belongs to appID, is active and its secret matches
+func VerifyAPIKey(appID string, key string) (*entities.APIKey, error) {
+	keyID, secret, err := parseScopedAPIKey(key)
+	if err != nil {
+		return nil, err
+	}
+	apiKeyRepo := repository.APIKeyRepo()
+	apiKey, err := apiKeyRepo.FindOneByFilter(map[string]interface{}{
+		"_id":   keyID,
+		"appID": appID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if apiKey == nil || !apiKey.Active() || !cryptography.CryptoHahser.VerifyHashData(apiKey.HashedKey, secret) {
+		return nil, ErrInvalidAPIKey
+	}
+	return apiKey, nil
+}
+
+// how often the last use of a key is saved, so busy keys do not write on every request
+const apiKeyUseRecordInterval = time.Minute
+
+// Saves when and where a key was last used. Failures are only logged.
+func RecordAPIKeyUse(keyID string, ipAddress string) {
+	recordedKey := fmt.Sprintf("apikey-used:%s", keyID)
+	if cache.Cache.FindOne(recordedKey) != nil {
+		return
+	}
+	cache.Cache.CreateEntry(recordedKey, ipAddress, apiKeyUseRecordInterval)
+	apiKeyRepo := repository.APIKeyRepo()
+	_, err := apiKeyRepo.UpdatePartialByID(keyID, map[string]any{
+		"lastUsedAt": time.Now(),
+		"lastUsedIP": ipAddress,
+	})
+	if err != nil {
+		logger.Error("an error occured while recording api key use", logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key:  "keyID",
+			Data: keyID,
+		})
+	}
+}
+
+func activeAPIKeysFilter(appID string) map[string]interface{} {
+	return map[string]interface{}{
+		"appID":     appID,
+		"revokedAt": nil,
+		"$or": []map[string]any{
+			{"expiresAt": nil},
+			{"expiresAt": map[string]any{"$gt": time.Now()}},
+		},
+	}
+}
+
+func uniqueAPIKeyScopes(scopes []entities.APIKeyScope) []entities.APIKeyScope {
+	unique := []entities.APIKeyScope{}
+	for _, scope := range scopes {
+		if !slices.Contains(unique, scope) {
+			unique = append(unique, scope)
+		}
+	}
+	return unique
+}
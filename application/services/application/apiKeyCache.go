@@ -0,0 +1,256 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gateman.io/application/repository"
+	"gateman.io/application/utils"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/cryptography"
+	"gateman.io/infrastructure/database/repository/cache"
+	"gateman.io/infrastructure/logger"
+	"gateman.io/infrastructure/metrics"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// how long a verified api key and an app's config are trusted before they are checked against the database again
+const apiKeyCacheTTL = time.Minute * 2
+
+// Reasons an app's cached api keys are purged. They label the purge metric.
+const (
+	APIKeyCachePurgeKeyRotated     = "key_rotated"
+	APIKeyCachePurgeKeyRevoked     = "key_revoked"
+	APIKeyCachePurgeAppUpdated     = "app_updated"
+	APIKeyCachePurgeSigningUpdated = "request_signing_updated"
+	APIKeyCachePurgeIPsUpdated     = "ip_allowlist_updated"
+	APIKeyCachePurgeAppDeleted     = "app_deleted"
+)
+
+// The app and key an api key authenticated as
+type AuthenticatedApp struct {
+	AppID       string                 `json:"appID"`
+	Name        string                 `json:"name"`
+	WorkspaceID string                 `json:"workspaceID"`
+	Sandbox     bool                   `json:"sandbox"`
+	KeyID       *string                `json:"keyID"`
+	Scopes      []entities.APIKeyScope `json:"scopes"`
+	ExpiresAt   *time.Time             `json:"expiresAt"`
+	AllowedIPs  []string               `json:"allowedIPs"` // the allowlist of the key's environment
+}
+
+// Returns true if ip may call the public api. Apps without an allowlist for the key's environment accept every IP.
+func (app *AuthenticatedApp) IPAllowed(ip string) bool {
+	if len(app.AllowedIPs) == 0 {
+		return true
+	}
+	return utils.IPMatchesAllowlist(ip, app.AllowedIPs)
+}
+
+// Legacy keys are not scoped and can call every route
+func (app *AuthenticatedApp) HasScope(scope entities.APIKeyScope) bool {
+	if app.KeyID == nil {
+		return true
+	}
+	return slices.Contains(app.Scopes, scope)
+}
+
+type appConfig struct {
+	AppID         string `json:"appID"`
+	Name          string `json:"name"`
+	WorkspaceID   string `json:"workspaceID"`
+	APIKey        string `json:"apiKey"`
+	SandboxAPIKey string `json:"sandboxAPIKey"`
+	Disabled      bool   `json:"disabled"`
+	// encrypted with ENC_KEY, the same as in the database
+	RequestSigningSecret  string   `json:"requestSigningSecret"`
+	RequireSignedRequests bool     `json:"requireSignedRequests"`
+	WhiteListedIPs        []string `json:"whiteListedIPs"`
+	SandboxWhiteListedIPs []string `json:"sandboxWhiteListedIPs"`
+}
+
+// Every cache key of an app holds the app's generation. Purging bumps the generation so
+// entries written by requests that were verifying while the purge happened are never read.
+func apiKeyCacheGenerationKey(appID string) string {
+	return fmt.Sprintf("apikey-cache-gen:%s", appID)
+}
+
+func apiKeyCacheGeneration(appID string) string {
+	generation := cache.Cache.FindOne(apiKeyCacheGenerationKey(appID))
+	if generation == nil {
+		return "0"
+	}
+	return *generation
+}
+
+// Authenticates apiKey for appID. Keys are only verified against their Argon2 hash when they are not in the cache,
+// the cache is keyed by the blind index of the presented key so plain keys are never stored.
+func AuthenticateAPIKey(appID string, apiKey string) (*AuthenticatedApp, error) {
+	start := time.Now()
+	generation := apiKeyCacheGeneration(appID)
+	cacheKey := fmt.Sprintf("apikey-verified:%s:%s:%s", appID, generation, cryptography.BlindIndex(cryptography.APIKeyIndex, apiKey))
+	if cached := cache.Cache.FindOne(cacheKey); cached != nil {
+		var app AuthenticatedApp
+		if err := json.Unmarshal([]byte(*cached), &app); err == nil && (app.ExpiresAt == nil || app.ExpiresAt.After(time.Now())) {
+			metrics.APIKeyCacheLookups.WithLabelValues("api_key", "hit").Inc()
+			metrics.APIKeyVerificationDuration.WithLabelValues("hit").Observe(time.Since(start).Seconds())
+			return &app, nil
+		}
+	}
+	metrics.APIKeyCacheLookups.WithLabelValues("api_key", "miss").Inc()
+	app, err := verifyAPIKey(appID, apiKey, generation)
+	if err != nil {
+		metrics.APIKeyVerificationDuration.WithLabelValues("rejected").Observe(time.Since(start).Seconds())
+		return nil, err
+	}
+	ttl := apiKeyCacheTTL
+	if app.ExpiresAt != nil && time.Until(*app.ExpiresAt) < ttl {
+		ttl = time.Until(*app.ExpiresAt)
+	}
+	if ttl > 0 {
+		payload, _ := json.Marshal(app)
+		cache.Cache.CreateEntry(cacheKey, string(payload), ttl)
+	}
+	metrics.APIKeyVerificationDuration.WithLabelValues("miss").Observe(time.Since(start).Seconds())
+	return app, nil
+}
+
+func verifyAPIKey(appID string, apiKey string, generation string) (*AuthenticatedApp, error) {
+	config, err := fetchAppConfig(appID, generation)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil || config.Disabled {
+		return nil, ErrInvalidAPIKey
+	}
+	app := AuthenticatedApp{
+		AppID:       config.AppID,
+		Name:        config.Name,
+		WorkspaceID: config.WorkspaceID,
+	}
+	if IsScopedAPIKey(apiKey) {
+		scopedKey, err := VerifyAPIKey(appID, apiKey)
+		if err != nil {
+			return nil, err
+		}
+		app.KeyID = &scopedKey.ID
+		app.Scopes = scopedKey.Scopes
+		app.Sandbox = scopedKey.Sandbox
+		app.ExpiresAt = scopedKey.ExpiresAt
+		app.AllowedIPs = config.allowedIPs(app.Sandbox)
+		return &app, nil
+	}
+	hashedKey := config.APIKey
+	if strings.Contains(apiKey, "sandbox") {
+		parts := strings.Split(apiKey, "-")
+		if len(parts) < 2 {
+			return nil, ErrInvalidAPIKey
+		}
+		app.Sandbox = true
+		hashedKey = config.SandboxAPIKey
+		apiKey = parts[1]
+	}
+	if !cryptography.CryptoHahser.VerifyHashData(hashedKey, apiKey) {
+		return nil, ErrInvalidAPIKey
+	}
+	app.AllowedIPs = config.allowedIPs(app.Sandbox)
+	return &app, nil
+}
+
+func (config *appConfig) allowedIPs(sandbox bool) []string {
+	if sandbox {
+		return config.SandboxWhiteListedIPs
+	}
+	return config.WhiteListedIPs
+}
+
+func fetchAppConfig(appID string, generation string) (*appConfig, error) {
+	cacheKey := fmt.Sprintf("app-config:%s:%s", appID, generation)
+	if cached := cache.Cache.FindOne(cacheKey); cached != nil {
+		var config appConfig
+		if err := json.Unmarshal([]byte(*cached), &config); err == nil {
+			metrics.APIKeyCacheLookups.WithLabelValues("app_config", "hit").Inc()
+			return &config, nil
+		}
+	}
+	metrics.APIKeyCacheLookups.WithLabelValues("app_config", "miss").Inc()
+	appRepo := repository.ApplicationRepo()
+	app, err := appRepo.FindOneByFilter(map[string]interface{}{
+		"appID": appID,
+	}, options.FindOne().SetProjection(map[string]any{
+		"appID":                 1,
+		"name":                  1,
+		"workspaceID":           1,
+		"apiKey":                1,
+		"sandBoxAPIKey":         1,
+		"disabled":              1,
+		"requestSigningSecret":  1,
+		"requireSignedRequests": 1,
+		"whiteListedIPs":        1,
+		"sandboxWhiteListedIPs": 1,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, nil
+	}
+	config := appConfig{
+		AppID:                 app.AppID,
+		Name:                  app.Name,
+		WorkspaceID:           app.WorkspaceID,
+		APIKey:                app.APIKey,
+		SandboxAPIKey:         app.SandboxAPIKey,
+		Disabled:              app.Disabled,
+		RequestSigningSecret:  app.RequestSigningSecret,
+		RequireSignedRequests: app.RequireSignedRequests,
+	}
+	if app.WhiteListedIPs != nil {
+		config.WhiteListedIPs = *app.WhiteListedIPs
+	}
+	if app.SandboxWhiteListedIPs != nil {
+		config.SandboxWhiteListedIPs = *app.SandboxWhiteListedIPs
+	}
+	payload, _ := json.Marshal(config)
+	cache.Cache.CreateEntry(cacheKey, string(payload), apiKeyCacheTTL)
+	return &config, nil
+}
+
+// Drops every cached api key and the cached config of appID
+func PurgeAPIKeyCache(appID string, reason string) {
+	if cache.Cache.IncrementField(apiKeyCacheGenerationKey(appID), 1) == 0 {
+		logger.Error("an error occured while purging api key cache", logger.LoggerOptions{
+			Key:  "appID",
+			Data: appID,
+		}, logger.LoggerOptions{
+			Key:  "reason",
+			Data: reason,
+		})
+		return
+	}
+	metrics.APIKeyCachePurges.WithLabelValues(reason).Inc()
+}
+
+// Same as PurgeAPIKeyCache for callers that only have the app's _id
+func PurgeAPIKeyCacheByID(id string, reason string) {
+	appRepo := repository.ApplicationRepo()
+	app, err := appRepo.FindOneByFilter(map[string]interface{}{
+		"_id": id,
+	}, options.FindOne().SetProjection(map[string]any{
+		"appID": 1,
+	}))
+	if err != nil || app == nil {
+		logger.Error("an error occured while fetching app to purge api key cache", logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key:  "id",
+			Data: id,
+		})
+		return
+	}
+	PurgeAPIKeyCache(app.AppID, reason)
+}
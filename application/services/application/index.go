@@ -11,10 +11,9 @@ import (
 	apperrors "gateman.io/application/appErrors"
 	"gateman.io/application/constants"
 	"gateman.io/application/repository"
-	"gateman.io/application/utils"
+	screening_services "gateman.io/application/services/screening"
 	"gateman.io/entities"
 	"gateman.io/infrastructure/auth"
-	"gateman.io/infrastructure/cryptography"
 	"gateman.io/infrastructure/database/repository/cache"
 	"gateman.io/infrastructure/ipresolver"
 	"gateman.io/infrastructure/ipresolver/types"
@@ -22,16 +21,50 @@ import (
 )
 
 func ProcessUserSignUp(app *entities.Application, user *entities.User, ip string) (bool, string, map[string]any, map[string]any) {
+	if app.RejectScreeningHits {
+		hits, err := screening_services.UnclearedHits(user.ID, app.ID)
+		if err == nil && len(hits) != 0 {
+			err = screening_services.OpenHitReviews(app, hits)
+		}
+		if err != nil {
+			logger.Error("an error occured while checking screening hits for app signup", logger.LoggerOptions{
+				Key: "err", Data: err,
+			}, logger.LoggerOptions{
+				Key: "userID", Data: user.ID,
+			})
+		}
+		// apps that opted in are not signed up to while the hits cannot be checked
+		if len(hits) != 0 || err != nil {
+			return false, "You cannot sign up to this app at the moment. Please reach out to support", map[string]any{"screening": "review_required"}, map[string]any{}
+		}
+	}
 	var eligible = true
 	outstandingIDs := []string{}
 	if app.Verifications != nil {
 		for _, id := range *app.Verifications {
-			if id.Name == "nin" {
+			switch strings.ToLower(id.Name) {
+			case "nin":
 				if user.NIN == nil && id.Required {
 					outstandingIDs = append(outstandingIDs, "nin")
 					eligible = false
 				}
-			} else {
+			case "ninface":
+				if (user.NINFaceBinding == nil || !user.NINFaceBinding.Match) && id.Required {
+					outstandingIDs = append(outstandingIDs, "nin_face")
+					eligible = false
+				}
+			case "bvnface":
+				if (user.BVNFaceBinding == nil || !user.BVNFaceBinding.Match) && id.Required {
+					outstandingIDs = append(outstandingIDs, "bvn_face")
+					eligible = false
+				}
+			case "document":
+				// a document that expired since it was verified has to be verified again
+				if (user.IDDocument == nil || !user.IDDocument.ExpiresAt.After(time.Now())) && id.Required {
+					outstandingIDs = append(outstandingIDs, "document")
+					eligible = false
+				}
+			default:
 				if user.BVN == nil && id.Required {
 					outstandingIDs = append(outstandingIDs, "bvn")
 					eligible = false
@@ -50,16 +83,12 @@ func ProcessUserSignUp(app *entities.Application, user *entities.User, ip string
 			loginLocaleRequested = true
 			continue
 		}
-		userField := userValue.FieldByName(field.Name)
-		if !userField.IsValid() {
+		userFieldData, valid := userKYCField(userValue, field.Name)
+		if !valid {
 			results = append(results, field.Name)
 			eligible = false
 			continue
 		}
-		var userFieldData entities.KYCData[any]
-		actualValue := userField.Interface()
-		jsonBytes, _ := json.Marshal(actualValue)
-		json.Unmarshal(jsonBytes, &userFieldData)
 
 		// If Verified field doesn't exist or is not true, add to results
 		if userFieldData.Value == nil || !userFieldData.Verified {
@@ -100,17 +129,42 @@ func ProcessUserSignUp(app *entities.Application, user *entities.User, ip string
 	return eligible, msg, payload, requestedFields
 }
 
-func GenerateAuthTokens(payload map[string]any, app *entities.Application, userAgent string, deviceID string, userID string, requestedFields map[string]any) (*map[string]any, error) {
-	decryptedAppSigningKey, err := cryptography.DecryptData(app.AppSigningKey, nil)
+// Returns the values of the fields an app requested from the user. LoginLocale is skipped as it is not stored on the user.
+func RequestedFieldValues(app *entities.Application, user *entities.User) map[string]any {
+	requestedFields := map[string]any{}
+	userValue := reflect.ValueOf(*user)
+	for _, field := range app.RequestedFields {
+		if field.Name == "LoginLocale" {
+			continue
+		}
+		userFieldData, valid := userKYCField(userValue, field.Name)
+		if !valid {
+			continue
+		}
+		requestedFields[field.Name] = userFieldData.Value
+	}
+	return requestedFields
+}
+
+func userKYCField(userValue reflect.Value, name string) (entities.KYCData[any], bool) {
+	var userFieldData entities.KYCData[any]
+	userField := userValue.FieldByName(name)
+	if !userField.IsValid() {
+		return userFieldData, false
+	}
+	jsonBytes, _ := json.Marshal(userField.Interface())
+	json.Unmarshal(jsonBytes, &userFieldData)
+	return userFieldData, true
+}
+
+func GenerateAuthTokens(payload map[string]any, app *entities.Application, userAgent string, deviceID string, userID string, requestedFields map[string]any, scopes []string, family *auth.RefreshTokenFamily) (*map[string]any, error) {
+	encrypted, encryptedDataKeyID, err := encryptRequestedFields(app, requestedFields)
 	if err != nil {
-		logger.Error("an error occured while generating auth token for app user sign in", logger.LoggerOptions{
+		logger.Error("an error occured while encrypting requested fields for app user sign in", logger.LoggerOptions{
 			Key: "err", Data: err,
 		}, logger.LoggerOptions{
-			Key:  "app",
-			Data: app,
-		}, logger.LoggerOptions{
-			Key:  "payload",
-			Data: payload,
+			Key:  "appID",
+			Data: app.AppID,
 		}, logger.LoggerOptions{
 			Key:  "userAgent",
 			Data: userAgent,
@@ -120,66 +174,45 @@ func GenerateAuthTokens(payload map[string]any, app *entities.Application, userA
 		})
 		return nil, err
 	}
-	requestedFieldsBytes, err := json.Marshal(requestedFields)
+	accessTokenTTL, refreshTokenTTL := AppTokenTTLs(app)
+	signingKey, err := AppTokenSigningKey(app)
 	if err != nil {
-		logger.Error("an error occured while marshaling requested fields to []byte for app user sign in", logger.LoggerOptions{
-			Key: "err", Data: err,
-		}, logger.LoggerOptions{
-			Key:  "app",
-			Data: app,
-		}, logger.LoggerOptions{
-			Key:  "payload",
-			Data: payload,
-		}, logger.LoggerOptions{
-			Key:  "userAgent",
-			Data: userAgent,
-		}, logger.LoggerOptions{
-			Key:  "deviceID",
-			Data: deviceID,
-		}, logger.LoggerOptions{
-			Key:  "requestedFields",
-			Data: requestedFields,
-		})
 		return nil, err
 	}
-	encrypted, err := cryptography.EncryptData(requestedFieldsBytes, utils.GetStringPointer(string(decryptedAppSigningKey)))
+	signer, err := AppTokenSigner(signingKey)
 	if err != nil {
-		logger.Error("an error occured while encrypting requested fields []byte for app user sign in", logger.LoggerOptions{
+		logger.Error("an error occured while decrypting app token signing key for app user sign in", logger.LoggerOptions{
 			Key: "err", Data: err,
 		}, logger.LoggerOptions{
-			Key:  "app",
-			Data: app,
-		}, logger.LoggerOptions{
-			Key:  "payload",
-			Data: payload,
-		}, logger.LoggerOptions{
-			Key:  "userAgent",
-			Data: userAgent,
-		}, logger.LoggerOptions{
-			Key:  "deviceID",
-			Data: deviceID,
-		}, logger.LoggerOptions{
-			Key:  "requestedFields",
-			Data: requestedFields,
+			Key:  "appID",
+			Data: app.AppID,
 		})
 		return nil, err
 	}
-	var accessTokenTTL uint16
-	var refreshTokenTTL uint32
-	if os.Getenv("APP_ENV") == "production" {
-		accessTokenTTL = app.AccessTokenTTL
-		refreshTokenTTL = app.RefreshTokenTTL
-	} else {
-		accessTokenTTL = app.SandboxAccessTokenTTL
-		refreshTokenTTL = app.SandboxRefreshTokenTTL
+	// a new sign in starts a new family. refreshes pass in the family rotated from the presented refresh token
+	if family == nil {
+		family, err = auth.StartRefreshTokenFamily(userID, auth.AppUserTokenFamily, deviceID, &app.AppID, time.Duration(refreshTokenTTL)*time.Second)
+		if err != nil {
+			logger.Error("an error occured while starting refresh token family for app user sign in", logger.LoggerOptions{
+				Key: "err", Data: err,
+			}, logger.LoggerOptions{
+				Key:  "appID",
+				Data: app.AppID,
+			})
+			return nil, err
+		}
 	}
+	now := time.Now()
 	accessToken, err := auth.GenerateAppUserToken(auth.ClaimsData{
-		IssuedAt:  time.Now().Unix(),
-		ExpiresAt: int64(accessTokenTTL),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Duration(accessTokenTTL) * time.Second).Unix(),
 		UserAgent: userAgent,
 		DeviceID:  deviceID,
 		UserID:    userID,
-	}, string(decryptedAppSigningKey), strings.ToLower(app.Name))
+		TokenType: auth.AccessToken,
+		Scope:     strings.Join(scopes, " "),
+		Family:    family,
+	}, *signer, strings.ToLower(app.Name))
 	if err != nil {
 		logger.Error("an error occured while generating access token for app user sign in", logger.LoggerOptions{
 			Key: "err", Data: err,
@@ -199,14 +232,17 @@ func GenerateAuthTokens(payload map[string]any, app *entities.Application, userA
 		return nil, err
 	}
 	refreshToken, err := auth.GenerateAppUserToken(auth.ClaimsData{
-		IssuedAt:  time.Now().Unix(),
-		ExpiresAt: int64(refreshTokenTTL),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Duration(refreshTokenTTL) * time.Second).Unix(),
 		UserAgent: userAgent,
 		DeviceID:  deviceID,
 		UserID:    userID,
-	}, string(decryptedAppSigningKey), strings.ToLower(app.Name))
+		TokenType: auth.RefreshToken,
+		Scope:     strings.Join(scopes, " "),
+		Family:    family,
+	}, *signer, strings.ToLower(app.Name))
 	if err != nil {
-		logger.Error("an error occured while generating access token for app user sign in", logger.LoggerOptions{
+		logger.Error("an error occured while generating refresh token for app user sign in", logger.LoggerOptions{
 			Key: "err", Data: err,
 		}, logger.LoggerOptions{
 			Key:  "app",
@@ -221,13 +257,23 @@ func GenerateAuthTokens(payload map[string]any, app *entities.Application, userA
 			Key:  "deviceID",
 			Data: deviceID,
 		})
+		return nil, err
 	}
 	payload["encryptedData"] = encrypted
+	payload["encryptedDataKeyID"] = encryptedDataKeyID
 	payload["refreshToken"] = refreshToken
 	payload["accessToken"] = accessToken
 	return &payload, nil
 }
 
+// Returns the access and refresh token lifetimes in seconds for the current environment
+func AppTokenTTLs(app *entities.Application) (accessTokenTTL uint16, refreshTokenTTL uint32) {
+	if os.Getenv("APP_ENV") == "production" {
+		return app.AccessTokenTTL, app.RefreshTokenTTL
+	}
+	return app.SandboxAccessTokenTTL, app.SandboxRefreshTokenTTL
+}
+
 func CheckMonthlyLimit(ctx any, appID string, userID string, deviceID string) (block bool, err error) {
 	activeSubRepo := repository.ActiveSubscriptionRepo()
 	appActiveSub, err := activeSubRepo.FindOneByFilter(map[string]interface{}{
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/constants"
+	"gateman.io/application/repository"
+	mfa_services "gateman.io/application/services/mfa"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/cryptography"
+	"gateman.io/infrastructure/logger"
+)
+
+// Generates a TOTP secret for the app user. The secret is only saved on the app user once a code from it is confirmed.
+func StartAppUserMFAEnrollment(appUser *entities.AppUser, accountName string) (url *string, secret *string, err error) {
+	return mfa_services.StartEnrollment(appUser.ID, accountName)
+}
+
+// Saves the pending TOTP secret if code was generated from it and returns the app user's recovery codes.
+// The recovery codes are only ever returned here, they are stored hashed.
+func ConfirmAppUserMFAEnrollment(appUser *entities.AppUser, code string) (recoveryCodes []string, confirmed bool, err error) {
+	encryptedSecret, confirmed, err := mfa_services.ConfirmEnrollment(appUser.ID, code)
+	if err != nil || !confirmed {
+		return nil, false, err
+	}
+	recoveryCodes, hashedRecoveryCodes, err := mfa_services.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, false, err
+	}
+	appUserRepo := repository.AppUserRepo()
+	_, err = appUserRepo.UpdatePartialByID(appUser.ID, map[string]any{
+		"authenticatorSecret":   *encryptedSecret,
+		"accountRecoveryTokens": hashedRecoveryCodes,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	mfa_services.CompleteEnrollment(appUser.ID, code)
+	return recoveryCodes, true, nil
+}
+
+// Replaces the app user's recovery codes with new ones.
+func RegenerateAppUserRecoveryCodes(appUser *entities.AppUser) ([]string, error) {
+	recoveryCodes, hashedRecoveryCodes, err := mfa_services.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	appUserRepo := repository.AppUserRepo()
+	_, err = appUserRepo.UpdatePartialByID(appUser.ID, map[string]any{
+		"accountRecoveryTokens": hashedRecoveryCodes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recoveryCodes, nil
+}
+
+// Checks whether the user may sign in to the app before they are signed in through the hosted flow or OIDC.
+// Existing app users must not be blocked and must pass the app's pin and their second factor, new users of
+// pin protected apps must choose a pin. New users set up MFA through VerifyAppUserMFA once they are created.
+// Returns SET_APP_PIN as the response code when an existing app user of a pin protected app has no pin yet.
+// An error response has already been sent when ok is false.
+func AuthoriseAppUserSignIn(ctx any, app *entities.Application, appUser *entities.AppUser, pin *string, mfaCode *string, recoveryCode *string, deviceID string) (responseCode *uint, ok bool) {
+	if appUser == nil {
+		if app.PinProtected && pin == nil {
+			apperrors.ClientError(ctx, "Provide your login pin", nil, nil, deviceID)
+			return nil, false
+		}
+		return nil, true
+	}
+	if appUser.Blocked {
+		apperrors.AuthenticationError(ctx, "Your access to this application has been restricted", deviceID)
+		return nil, false
+	}
+	if app.PinProtected {
+		if pin == nil {
+			apperrors.ClientError(ctx, "Provide your login pin", nil, nil, deviceID)
+			return nil, false
+		}
+		if appUser.Pin == nil {
+			responseCode = &constants.SET_APP_PIN
+		} else if !cryptography.CryptoHahser.VerifyHashData(*appUser.Pin, *pin) {
+			apperrors.AuthenticationError(ctx, "Incorrect pin", deviceID)
+			return nil, false
+		}
+	}
+	if !VerifyAppUserMFA(ctx, app, appUser, mfaCode, recoveryCode, deviceID) {
+		return nil, false
+	}
+	return responseCode, true
+}
+
+// Checks the app user's second factor before they are signed in to the app.
+// The client is sent SET_UP_APP_MFA when the app requires MFA the user has not set up and APP_MFA_REQUIRED when a code is needed.
+// Returns true if the user can be signed in. An error response has already been sent when it returns false.
+func VerifyAppUserMFA(ctx any, app *entities.Application, appUser *entities.AppUser, mfaCode *string, recoveryCode *string, deviceID string) bool {
+	if appUser.AuthenticatorSecret == nil {
+		if app.RequireAppMFA {
+			apperrors.ClientError(ctx, fmt.Sprintf("%s requires you to set up an authenticator app before signing in", app.Name), nil, &constants.SET_UP_APP_MFA, deviceID)
+			return false
+		}
+		return true
+	}
+	if mfaCode == nil && recoveryCode == nil {
+		apperrors.ClientError(ctx, "Enter the code from your authenticator app", nil, &constants.APP_MFA_REQUIRED, deviceID)
+		return false
+	}
+	if mfaCode != nil {
+		valid, err := mfa_services.VerifyCode(appUser.ID, *appUser.AuthenticatorSecret, *mfaCode)
+		if errors.Is(err, mfa_services.ErrMFALocked) {
+			apperrors.AuthenticationError(ctx, "Too many incorrect codes. Try again later", deviceID)
+			return false
+		}
+		if err != nil {
+			logger.Error("an error occured while verifying app user authenticator code", logger.LoggerOptions{
+				Key: "err", Data: err,
+			}, logger.LoggerOptions{
+				Key:  "appUserID",
+				Data: appUser.ID,
+			})
+			apperrors.UnknownError(ctx, err, nil, deviceID)
+			return false
+		}
+		if !valid {
+			apperrors.AuthenticationError(ctx, "Incorrect authenticator code", deviceID)
+			return false
+		}
+		return true
+	}
+	redeemed, err := redeemRecoveryCode(appUser, *recoveryCode)
+	if errors.Is(err, mfa_services.ErrMFALocked) {
+		apperrors.AuthenticationError(ctx, "Too many incorrect codes. Try again later", deviceID)
+		return false
+	}
+	if err != nil {
+		logger.Error("an error occured while redeeming app user recovery code", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "appUserID",
+			Data: appUser.ID,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return false
+	}
+	if !redeemed {
+		apperrors.AuthenticationError(ctx, "Incorrect recovery code", deviceID)
+		return false
+	}
+	return true
+}
+
+// Removes the app user's authenticator so they can set it up again
+func ResetAppUserMFA(filter map[string]any) error {
+	appUserRepo := repository.AppUserRepo()
+	_, err := appUserRepo.UpdatePartialByFilter(filter, map[string]any{
+		"authenticatorSecret":   nil,
+		"accountRecoveryTokens": nil,
+	})
+	return err
+}
+
+func redeemRecoveryCode(appUser *entities.AppUser, code string) (bool, error) {
+	matchedHash, remainingCodes, err := mfa_services.MatchRecoveryCode(appUser.ID, appUser.AccountRecoveryTokens, code)
+	if err != nil || matchedHash == nil {
+		return false, err
+	}
+	// the filter on the used code makes redeeming it atomic when the same code is sent concurrently
+	appUserRepo := repository.AppUserRepo()
+	updated, err := appUserRepo.UpdatePartialByFilter(map[string]any{
+		"_id":                   appUser.ID,
+		"accountRecoveryTokens": *matchedHash,
+	}, map[string]any{
+		"accountRecoveryTokens": remainingCodes,
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated, nil
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gateman.io/application/utils"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/auth"
+	"gateman.io/infrastructure/database/repository/cache"
+	"gateman.io/infrastructure/logger"
+)
+
+// A pending authorization request waiting for the user to sign in on the client
+type OIDCAuthorizationRequest struct {
+	AppID         string   `json:"appID"`
+	RedirectURI   string   `json:"redirectURI"`
+	Scopes        []string `json:"scopes"`
+	State         *string  `json:"state"`
+	Nonce         *string  `json:"nonce"`
+	CodeChallenge string   `json:"codeChallenge"`
+}
+
+// An issued authorization code waiting to be exchanged at the token endpoint
+type OIDCAuthorizationCode struct {
+	OIDCAuthorizationRequest
+	UserID    string `json:"userID"`
+	DeviceID  string `json:"deviceID"`
+	UserAgent string `json:"userAgent"`
+}
+
+// The OIDC scope and claim a requested field is released under
+type OIDCFieldClaim struct {
+	Scope string
+	Claim string
+}
+
+var OIDCFieldClaims = map[string]OIDCFieldClaim{
+	"FirstName":  {Scope: "profile", Claim: "given_name"},
+	"LastName":   {Scope: "profile", Claim: "family_name"},
+	"MiddleName": {Scope: "profile", Claim: "middle_name"},
+	"Gender":     {Scope: "profile", Claim: "gender"},
+	"DOB":        {Scope: "profile", Claim: "birthdate"},
+	"Image":      {Scope: "profile", Claim: "picture"},
+	"Email":      {Scope: "email", Claim: "email"},
+	"Phone":      {Scope: "phone", Claim: "phone_number"},
+	"NIN":        {Scope: "nin", Claim: "nin"},
+	"BVN":        {Scope: "bvn", Claim: "bvn"},
+}
+
+// The issuer of OIDC tokens for an app. Each app is its own issuer so its discovery document and JWKS are scoped to it.
+func OIDCIssuer(app *entities.Application) string {
+	return fmt.Sprintf("%s/api/public/v1/oidc/%s", os.Getenv("API_URL"), app.AppID)
+}
+
+// Returns the scopes an app can request. These are derived from the app's requested fields.
+func OIDCSupportedScopes(app *entities.Application) []string {
+	scopes := []string{"openid"}
+	for _, field := range app.RequestedFields {
+		fieldClaim, ok := OIDCFieldClaims[field.Name]
+		if !ok || utils.HasItemString(&scopes, fieldClaim.Scope) {
+			continue
+		}
+		scopes = append(scopes, fieldClaim.Scope)
+	}
+	return scopes
+}
+
+// Returns the claims supported by an app. These are derived from the app's requested fields.
+func OIDCSupportedClaims(app *entities.Application) []string {
+	claims := []string{"sub", "iss", "aud", "exp", "iat", "nonce"}
+	for _, field := range app.RequestedFields {
+		if fieldClaim, ok := OIDCFieldClaims[field.Name]; ok {
+			claims = append(claims, fieldClaim.Claim)
+		}
+	}
+	return claims
+}
+
+// Builds the claims released to an app for the granted scopes.
+func OIDCUserClaims(app *entities.Application, user *entities.User, scopes []string) map[string]any {
+	claims := map[string]any{
+		"sub": user.ID,
+	}
+	for name, value := range RequestedFieldValues(app, user) {
+		fieldClaim, ok := OIDCFieldClaims[name]
+		if !ok || !utils.HasItemString(&scopes, fieldClaim.Scope) || value == nil {
+			continue
+		}
+		claims[fieldClaim.Claim] = value
+	}
+	// these fields are not stored as KYC data on the user so they are read directly
+	for _, field := range app.RequestedFields {
+		switch field.Name {
+		case "Email":
+			if user.Email != nil && utils.HasItemString(&scopes, "email") {
+				claims["email"] = *user.Email
+			}
+		case "Phone":
+			if user.Phone != nil && utils.HasItemString(&scopes, "phone") {
+				claims["phone_number"] = user.Phone
+			}
+		case "Image":
+			if user.Image != "" && utils.HasItemString(&scopes, "profile") {
+				claims["picture"] = user.Image
+			}
+		case "DOB":
+			if user.DOB != nil && user.DOB.Value != nil && utils.HasItemString(&scopes, "profile") {
+				claims["birthdate"] = user.DOB.Value.Format("2006-01-02")
+			}
+		}
+	}
+	return claims
+}
+
+// Checks a PKCE code verifier against the S256 code challenge sent in the authorization request.
+func VerifyPKCEChallenge(codeVerifier string, codeChallenge string) bool {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:]) == codeChallenge
+}
+
+// Generates an OIDC ID token for the user signed with the app's token signing key.
+func GenerateIDToken(app *entities.Application, user *entities.User, scopes []string, nonce *string) (*string, error) {
+	signingKey, err := AppTokenSigningKey(app)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := AppTokenSigner(signingKey)
+	if err != nil {
+		return nil, err
+	}
+	accessTokenTTL := app.SandboxAccessTokenTTL
+	if os.Getenv("APP_ENV") == "production" {
+		accessTokenTTL = app.AccessTokenTTL
+	}
+	now := time.Now()
+	claims := OIDCUserClaims(app, user, scopes)
+	claims["iss"] = OIDCIssuer(app)
+	claims["aud"] = app.AppID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(time.Duration(accessTokenTTL) * time.Second).Unix()
+	if nonce != nil {
+		claims["nonce"] = *nonce
+	}
+	return auth.GenerateAppIDToken(claims, *signer)
+}
+
+// Splits a space delimited scope parameter.
+func ParseScopes(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// Saves an authorization request for 10 minutes and returns its id.
+func SaveOIDCAuthorizationRequest(request OIDCAuthorizationRequest) (*string, error) {
+	requestID, err := utils.GenerateRandomHexKey(32)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	if !cache.Cache.CreateEntry(fmt.Sprintf("oidc:request:%s", requestID), payload, 10*time.Minute) {
+		return nil, fmt.Errorf("could not save authorization request")
+	}
+	return &requestID, nil
+}
+
+func FindOIDCAuthorizationRequest(requestID string) *OIDCAuthorizationRequest {
+	data := cache.Cache.FindOne(fmt.Sprintf("oidc:request:%s", requestID))
+	if data == nil {
+		return nil
+	}
+	var request OIDCAuthorizationRequest
+	if err := json.Unmarshal([]byte(*data), &request); err != nil {
+		logger.Error("an error occured while unmarshalling oidc authorization request", logger.LoggerOptions{
+			Key: "err", Data: err,
+		})
+		return nil
+	}
+	return &request
+}
+
+func DeleteOIDCAuthorizationRequest(requestID string) {
+	cache.Cache.DeleteOne(fmt.Sprintf("oidc:request:%s", requestID))
+}
+
+// Issues an authorization code valid for 5 minutes. Only a hash of the code is stored.
+func IssueOIDCAuthorizationCode(authCode OIDCAuthorizationCode) (*string, error) {
+	code, err := utils.GenerateRandomHexKey(64)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(authCode)
+	if err != nil {
+		return nil, err
+	}
+	if !cache.Cache.CreateEntry(fmt.Sprintf("oidc:code:%s", hashOIDCCode(code)), payload, 5*time.Minute) {
+		return nil, fmt.Errorf("could not save authorization code")
+	}
+	return &code, nil
+}
+
+// Redeems an authorization code. A code can only be redeemed once.
+func RedeemOIDCAuthorizationCode(code string) *OIDCAuthorizationCode {
+	key := fmt.Sprintf("oidc:code:%s", hashOIDCCode(code))
+	data := cache.Cache.FindOne(key)
+	if data == nil {
+		return nil
+	}
+	// only the request that deletes the code gets to use it
+	if !cache.Cache.DeleteOne(key) {
+		return nil
+	}
+	var authCode OIDCAuthorizationCode
+	if err := json.Unmarshal([]byte(*data), &authCode); err != nil {
+		logger.Error("an error occured while unmarshalling oidc authorization code", logger.LoggerOptions{
+			Key: "err", Data: err,
+		})
+		return nil
+	}
+	return &authCode
+}
+
+func hashOIDCCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
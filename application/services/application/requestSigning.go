@@ -0,0 +1,138 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gateman.io/application/repository"
+	"gateman.io/infrastructure/cryptography"
+	"gateman.io/infrastructure/database/repository/cache"
+)
+
+// how far the timestamp of a signed request may be from the server's clock
+const RequestSignatureTolerance = time.Minute * 5
+
+var (
+	ErrRequestSignatureRequired  = errors.New("this app only accepts signed requests")
+	ErrRequestSigningNotSetUp    = errors.New("request signing has not been set up for this app")
+	ErrRequestSignatureMalformed = errors.New("request signature headers are malformed")
+	ErrRequestSignatureExpired   = errors.New("request timestamp is outside the allowed window")
+	ErrRequestNonceReused        = errors.New("request nonce has already been used")
+	ErrInvalidRequestSignature   = errors.New("invalid request signature")
+)
+
+// A public api request as received, with the signature headers it was sent with
+type SignedRequest struct {
+	Method    string
+	Path      string
+	RawQuery  string
+	Body      []byte
+	Timestamp *string
+	Nonce     *string
+	Signature *string
+}
+
+// Builds the string that is signed:
+// METHOD\nPATH\nQUERY\nTIMESTAMP\nNONCE\nhex(sha256(body))
+// The query is sorted by key so clients do not have to preserve parameter order.
+func CanonicalRequest(method string, path string, rawQuery string, timestamp string, nonce string, body []byte) string {
+	query, err := url.ParseQuery(rawQuery)
+	canonicalQuery := rawQuery
+	if err == nil {
+		canonicalQuery = query.Encode()
+	}
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		canonicalQuery,
+		timestamp,
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+}
+
+// Verifies the signature of a request to the public api made by appID.
+// Requests without signature headers are let through unless the app has made signing mandatory.
+// A request that carries signature headers is always verified, and its nonce can only be used once.
+func VerifyRequestSignature(appID string, request SignedRequest) error {
+	config, err := fetchAppConfig(appID, apiKeyCacheGeneration(appID))
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		return ErrInvalidAPIKey
+	}
+	if request.Signature == nil && request.Timestamp == nil && request.Nonce == nil {
+		if config.RequireSignedRequests {
+			return ErrRequestSignatureRequired
+		}
+		return nil
+	}
+	if config.RequestSigningSecret == "" {
+		return ErrRequestSigningNotSetUp
+	}
+	if request.Signature == nil || request.Timestamp == nil || request.Nonce == nil || len(*request.Nonce) < 16 || len(*request.Nonce) > 128 {
+		return ErrRequestSignatureMalformed
+	}
+	signature, err := hex.DecodeString(*request.Signature)
+	if err != nil {
+		return ErrRequestSignatureMalformed
+	}
+	timestamp, err := strconv.ParseInt(*request.Timestamp, 10, 64)
+	if err != nil {
+		return ErrRequestSignatureMalformed
+	}
+	drift := time.Since(time.Unix(timestamp, 0))
+	if drift > RequestSignatureTolerance || drift < -RequestSignatureTolerance {
+		return ErrRequestSignatureExpired
+	}
+	secret, err := cryptography.DecryptData(config.RequestSigningSecret, nil)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(CanonicalRequest(request.Method, request.Path, request.RawQuery, *request.Timestamp, *request.Nonce, request.Body)))
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return ErrInvalidRequestSignature
+	}
+	// the nonce is only saved once the signature is valid so unsigned junk cannot burn nonces.
+	// It is kept for both sides of the tolerance window, after which the timestamp check rejects the request anyway.
+	if !cache.Cache.CreateEntryIfNotExists(fmt.Sprintf("request-nonce:%s:%s", appID, *request.Nonce), *request.Timestamp, RequestSignatureTolerance*2) {
+		return ErrRequestNonceReused
+	}
+	return nil
+}
+
+// Generates a new request signing secret for the app with _id. The plain secret is only ever returned here.
+func RefreshRequestSigningSecret(id string, workspaceID string) (*string, bool, error) {
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, false, err
+	}
+	secret := hex.EncodeToString(secretBytes)
+	encryptedSecret, err := cryptography.EncryptData([]byte(secret), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	appRepo := repository.ApplicationRepo()
+	updated, err := appRepo.UpdatePartialByFilter(map[string]interface{}{
+		"_id":         id,
+		"workspaceID": workspaceID,
+	}, map[string]any{
+		"requestSigningSecret": *encryptedSecret,
+	})
+	if err != nil || !updated {
+		return nil, updated, err
+	}
+	PurgeAPIKeyCacheByID(id, APIKeyCachePurgeSigningUpdated)
+	return &secret, true, nil
+}
@@ -0,0 +1,433 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"gateman.io/application/repository"
+	"gateman.io/application/utils"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/auth"
+	"gateman.io/infrastructure/cryptography"
+	"gateman.io/infrastructure/logger"
+)
+
+// Generates a new key pair used to sign app user tokens. The private key is encrypted before it is returned.
+func GenerateTokenSigningKey(alg cryptography.SigningAlgorithm) (*entities.TokenSigningKey, error) {
+	privateKey, publicKey, err := cryptography.GenerateSigningKeyPair(alg)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := cryptography.SigningKeyThumbprint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	encryptedPrivateKey, err := cryptography.EncryptData([]byte(privateKey), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.TokenSigningKey{
+		Algorithm:  string(alg),
+		KeyID:      keyID,
+		PrivateKey: *encryptedPrivateKey,
+		PublicKey:  publicKey,
+		Version:    1,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// Generates the symmetric key apps use to decrypt the requested fields sent with their users' tokens.
+// Returns the hex key that is shown to the workspace and the same key encrypted for storage.
+func GenerateAppSigningKey() (*string, *string, error) {
+	keyBytes := make([]byte, 32)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return nil, nil, err
+	}
+	key := hex.EncodeToString(keyBytes)
+	encryptedKey, err := cryptography.EncryptData([]byte(key), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &key, encryptedKey, nil
+}
+
+// Tokens are signed and verified with the sandbox keys everywhere but production
+func sandboxEnvironment() bool {
+	return os.Getenv("APP_ENV") != "production"
+}
+
+// Returns the encrypted app signing key of the current environment, matching the environment of AppTokenSigningKey.
+//
+// The requested fields sent as encryptedData are encrypted with this key. Outside production that is the sandbox app
+// signing key. Only the workspace replaces it, the scheduled rotation leaves it alone, and encryptedDataKeyID names it.
+// Before signing keys were versioned encryptedData was encrypted with the production key everywhere, so integrations
+// running against a sandbox server decrypt with the sandbox app signing key shown on app creation or by refreshing it.
+// Apps created before sandbox keys existed have none and keep using their production key until one is generated.
+func AppSigningDataKey(app *entities.Application) string {
+	if sandboxEnvironment() && app.SandboxAppSigningKey != "" {
+		return app.SandboxAppSigningKey
+	}
+	return app.AppSigningKey
+}
+
+// Returns the id of a plain app signing key, the first 16 hex characters of its SHA-256 hash.
+// Workspaces compute the same id from the key they hold to pick the key an encryptedData was encrypted with.
+func AppSigningKeyID(appSigningKey string) string {
+	hash := sha256.Sum256([]byte(appSigningKey))
+	return hex.EncodeToString(hash[:])[:16]
+}
+
+// Encrypts the requested fields sent as encryptedData with the app signing key of the current environment.
+// Returns the encrypted fields and the id of the key.
+func encryptRequestedFields(app *entities.Application, requestedFields map[string]any) (*string, string, error) {
+	appSigningKey, err := cryptography.DecryptData(AppSigningDataKey(app), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	requestedFieldsBytes, err := json.Marshal(requestedFields)
+	if err != nil {
+		return nil, "", err
+	}
+	encrypted, err := cryptography.EncryptData(requestedFieldsBytes, utils.GetStringPointer(string(appSigningKey)))
+	if err != nil {
+		return nil, "", err
+	}
+	return encrypted, AppSigningKeyID(string(appSigningKey)), nil
+}
+
+// Returns the key used to sign tokens for the app in the current environment.
+// Apps created before asymmetric signing was introduced get a key pair generated on first use.
+func AppTokenSigningKey(app *entities.Application) (*entities.TokenSigningKey, error) {
+	sandbox := sandboxEnvironment()
+	key := app.TokenSigningKey
+	field := "tokenSigningKey"
+	if sandbox {
+		key = app.SandboxTokenSigningKey
+		field = "sandboxTokenSigningKey"
+	}
+	if key != nil {
+		return key, nil
+	}
+	key, err := GenerateTokenSigningKey(cryptography.DefaultSigningAlgorithm)
+	if err != nil {
+		logger.Error("an error occured while generating token signing key for app", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "appID", Data: app.AppID,
+		})
+		return nil, err
+	}
+	appRepo := repository.ApplicationRepo()
+	saved, err := appRepo.UpdatePartialByFilter(map[string]interface{}{
+		"_id": app.ID,
+		field: nil,
+	}, map[string]any{
+		field: key,
+	})
+	if err != nil {
+		logger.Error("an error occured while saving token signing key for app", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "appID", Data: app.AppID,
+		})
+		return nil, err
+	}
+	if !saved {
+		// another request generated the key first so use that one instead
+		updatedApp, err := appRepo.FindByID(app.ID)
+		if err != nil || updatedApp == nil {
+			return nil, errors.New("could not fetch app token signing key")
+		}
+		if sandbox {
+			key = updatedApp.SandboxTokenSigningKey
+		} else {
+			key = updatedApp.TokenSigningKey
+		}
+		if key == nil {
+			return nil, errors.New("could not fetch app token signing key")
+		}
+	}
+	if sandbox {
+		app.SandboxTokenSigningKey = key
+	} else {
+		app.TokenSigningKey = key
+	}
+	return key, nil
+}
+
+// Converts an app token signing key into the signer used by the auth package.
+func AppTokenSigner(key *entities.TokenSigningKey) (*auth.AppTokenSigner, error) {
+	privateKey, err := cryptography.DecryptData(key.PrivateKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.AppTokenSigner{
+		Algorithm:  key.Algorithm,
+		KeyID:      key.KeyID,
+		PrivateKey: string(privateKey),
+	}, nil
+}
+
+// Returns the keys that verify the app's tokens in the current environment: its current key
+// and its retired keys that are still inside their overlap window. Keys of the other environment never verify.
+func AppVerificationKeys(app *entities.Application) []*entities.TokenSigningKey {
+	sandbox := sandboxEnvironment()
+	keys := []*entities.TokenSigningKey{app.TokenSigningKey}
+	if sandbox {
+		keys = []*entities.TokenSigningKey{app.SandboxTokenSigningKey}
+	}
+	now := time.Now()
+	for i := range app.RetiredSigningKeys {
+		if app.RetiredSigningKeys[i].Sandbox == sandbox && app.RetiredSigningKeys[i].RetiresAt.After(now) {
+			keys = append(keys, &app.RetiredSigningKeys[i].TokenSigningKey)
+		}
+	}
+	return keys
+}
+
+// Builds the JSON Web Key Set containing the public keys that verify the app's tokens.
+func AppJWKS(app *entities.Application) (map[string]any, error) {
+	keys := []map[string]any{}
+	for _, key := range AppVerificationKeys(app) {
+		if key == nil {
+			continue
+		}
+		jwk, err := cryptography.PublicKeyToJWK(key.PublicKey, cryptography.SigningAlgorithm(key.Algorithm), key.KeyID)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, jwk)
+	}
+	return map[string]any{"keys": keys}, nil
+}
+
+// the longest a retired key keeps verifying tokens
+const MaxSigningKeyOverlap = time.Hour * 24 * 30
+
+// how many retired versions are kept in an app's key history
+const signingKeyHistoryLimit = 20
+
+var ErrSigningKeyRotationConflict = errors.New("signing keys were rotated by another request")
+
+// A new version of an environment's signing keys and the update that saves it
+type signingKeyRotation struct {
+	sandbox         bool
+	tokenSigningKey *entities.TokenSigningKey
+	// nil for the first key of an environment
+	retired *entities.RetiredSigningKey
+	// nil when only the token signing key pair is rotated
+	appSigningKey          *string
+	encryptedAppSigningKey *string
+	filter                 map[string]interface{}
+	update                 map[string]interface{}
+}
+
+// Generates the next version of the app's signing keys for an environment. The app signing key is only replaced
+// when rotateAppSigningKey is set since the workspace has to be given the new key to keep decrypting encryptedData.
+func newSigningKeyRotation(app *entities.Application, sandbox bool, overlap time.Duration, rotateAppSigningKey bool) (*signingKeyRotation, error) {
+	if overlap > MaxSigningKeyOverlap {
+		overlap = MaxSigningKeyOverlap
+	}
+	current, currentAppSigningKey := app.TokenSigningKey, app.AppSigningKey
+	tokenField, appKeyField := "tokenSigningKey", "appSigningKey"
+	if sandbox {
+		current, currentAppSigningKey = app.SandboxTokenSigningKey, app.SandboxAppSigningKey
+		tokenField, appKeyField = "sandboxTokenSigningKey", "sandBoxAppSigningKey"
+	}
+	algorithm := cryptography.DefaultSigningAlgorithm
+	if current != nil {
+		algorithm = cryptography.SigningAlgorithm(current.Algorithm)
+	}
+	newKey, err := GenerateTokenSigningKey(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	rotation := &signingKeyRotation{
+		sandbox:         sandbox,
+		tokenSigningKey: newKey,
+		// the filter on the current key id stops two rotations from both retiring the same version
+		filter: map[string]interface{}{
+			"_id":      app.ID,
+			tokenField: nil,
+		},
+		update: map[string]interface{}{},
+	}
+	set := map[string]any{
+		tokenField:  newKey,
+		"updatedAt": time.Now(),
+	}
+	if rotateAppSigningKey {
+		rotation.appSigningKey, rotation.encryptedAppSigningKey, err = GenerateAppSigningKey()
+		if err != nil {
+			return nil, err
+		}
+		set[appKeyField] = *rotation.encryptedAppSigningKey
+	}
+	rotation.update["$set"] = set
+	if current != nil {
+		newKey.Version = current.Version + 1
+		delete(rotation.filter, tokenField)
+		rotation.filter[tokenField+".keyID"] = current.KeyID
+		now := time.Now()
+		rotation.retired = &entities.RetiredSigningKey{
+			Sandbox:         sandbox,
+			TokenSigningKey: *current,
+			AppSigningKey:   currentAppSigningKey,
+			RetiredAt:       now,
+			RetiresAt:       now.Add(overlap),
+		}
+		rotation.update["$push"] = map[string]any{
+			"retiredSigningKeys": map[string]any{
+				"$each":  []entities.RetiredSigningKey{*rotation.retired},
+				"$slice": -signingKeyHistoryLimit,
+			},
+		}
+	}
+	return rotation, nil
+}
+
+func saveSigningKeyRotation(rotation *signingKeyRotation) error {
+	updated, err := repository.ApplicationRepo().UpdateManyWithOperator(rotation.filter, rotation.update)
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrSigningKeyRotationConflict
+	}
+	return nil
+}
+
+// Replaces the app's token signing key pair and app signing key for an environment with a new version.
+// The replaced token signing key is retired but keeps verifying tokens for overlap. Returns the new token signing key
+// and the new app signing key for the workspace, the app only stores it encrypted.
+func RotateAppSigningKeys(app *entities.Application, sandbox bool, overlap time.Duration) (*entities.TokenSigningKey, *string, error) {
+	rotation, err := newSigningKeyRotation(app, sandbox, overlap, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = saveSigningKeyRotation(rotation); err != nil {
+		return nil, nil, err
+	}
+	return rotation.tokenSigningKey, rotation.appSigningKey, nil
+}
+
+// Replaces only the app's token signing key pair for an environment, as the scheduled rotation does.
+// The app signing key is left as it is so the workspace keeps decrypting encryptedData with the key it holds.
+func RotateTokenSigningKey(app *entities.Application, sandbox bool, overlap time.Duration) (*entities.TokenSigningKey, error) {
+	rotation, err := newSigningKeyRotation(app, sandbox, overlap, false)
+	if err != nil {
+		return nil, err
+	}
+	if err = saveSigningKeyRotation(rotation); err != nil {
+		return nil, err
+	}
+	return rotation.tokenSigningKey, nil
+}
+
+// A version in an app's signing key history as shown in the dashboard
+type SigningKeyVersion struct {
+	KeyID     string     `json:"keyID"`
+	Version   uint32     `json:"version"`
+	Algorithm string     `json:"algorithm"`
+	PublicKey string     `json:"publicKey"`
+	Sandbox   bool       `json:"sandbox"`
+	Status    string     `json:"status"` // active, retiring or retired
+	CreatedAt time.Time  `json:"createdAt"`
+	RetiredAt *time.Time `json:"retiredAt"`
+	RetiresAt *time.Time `json:"retiresAt"`
+}
+
+// Lists the current and retired signing key versions of the app, newest first
+func AppSigningKeyHistory(app *entities.Application) []SigningKeyVersion {
+	versions := []SigningKeyVersion{}
+	for _, current := range []struct {
+		key     *entities.TokenSigningKey
+		sandbox bool
+	}{{app.TokenSigningKey, false}, {app.SandboxTokenSigningKey, true}} {
+		if current.key == nil {
+			continue
+		}
+		versions = append(versions, SigningKeyVersion{
+			KeyID:     current.key.KeyID,
+			Version:   current.key.Version,
+			Algorithm: current.key.Algorithm,
+			PublicKey: current.key.PublicKey,
+			Sandbox:   current.sandbox,
+			Status:    "active",
+			CreatedAt: current.key.CreatedAt,
+		})
+	}
+	now := time.Now()
+	for i := len(app.RetiredSigningKeys) - 1; i >= 0; i-- {
+		retired := app.RetiredSigningKeys[i]
+		status := "retired"
+		if retired.RetiresAt.After(now) {
+			status = "retiring"
+		}
+		versions = append(versions, SigningKeyVersion{
+			KeyID:     retired.TokenSigningKey.KeyID,
+			Version:   retired.TokenSigningKey.Version,
+			Algorithm: retired.TokenSigningKey.Algorithm,
+			PublicKey: retired.TokenSigningKey.PublicKey,
+			Sandbox:   retired.Sandbox,
+			Status:    status,
+			CreatedAt: retired.TokenSigningKey.CreatedAt,
+			RetiredAt: &retired.RetiredAt,
+			RetiresAt: &retired.RetiresAt,
+		})
+	}
+	return versions
+}
+
+// Rotates the token signing keys of both environments of apps whose rotation policy is due.
+// App signing keys are only replaced by the workspace. Each app is claimed by moving its next rotation forward first so concurrent sweeps do not rotate it twice.
+func RotateDueSigningKeys() error {
+	appRepo := repository.ApplicationRepo()
+	apps, err := appRepo.FindMany(map[string]interface{}{
+		"disabled":                          false,
+		"signingKeyRotation.nextRotationAt": map[string]any{"$lte": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	for _, app := range *apps {
+		policy := *app.SigningKeyRotation
+		claimed, err := appRepo.UpdatePartialByFilter(map[string]interface{}{
+			"_id":                               app.ID,
+			"signingKeyRotation.nextRotationAt": policy.NextRotationAt,
+		}, map[string]any{
+			"signingKeyRotation.nextRotationAt": time.Now().Add(time.Hour * 24 * time.Duration(policy.IntervalDays)),
+		})
+		if err != nil || !claimed {
+			continue
+		}
+		for _, sandbox := range []bool{false, true} {
+			// each rotation filters on the key it replaces, so the app is read again to pick up the other environment's current keys
+			current, err := appRepo.FindByID(app.ID)
+			if err != nil || current == nil {
+				logger.Error("an error occured while fetching app for scheduled signing key rotation", logger.LoggerOptions{
+					Key: "err", Data: err,
+				}, logger.LoggerOptions{
+					Key: "appID", Data: app.AppID,
+				})
+				break
+			}
+			_, err = RotateTokenSigningKey(current, sandbox, time.Hour*time.Duration(policy.OverlapHours))
+			if err != nil {
+				logger.Error("an error occured during scheduled signing key rotation", logger.LoggerOptions{
+					Key: "err", Data: err,
+				}, logger.LoggerOptions{
+					Key: "appID", Data: app.AppID,
+				}, logger.LoggerOptions{
+					Key: "sandbox", Data: sandbox,
+				})
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"gateman.io/entities"
+	"gateman.io/infrastructure/cryptography"
+)
+
+// applies a rotation to app the way saveSigningKeyRotation applies it to the stored app
+func applySigningKeyRotation(app *entities.Application, rotation *signingKeyRotation) {
+	if rotation.sandbox {
+		app.SandboxTokenSigningKey = rotation.tokenSigningKey
+		if rotation.encryptedAppSigningKey != nil {
+			app.SandboxAppSigningKey = *rotation.encryptedAppSigningKey
+		}
+	} else {
+		app.TokenSigningKey = rotation.tokenSigningKey
+		if rotation.encryptedAppSigningKey != nil {
+			app.AppSigningKey = *rotation.encryptedAppSigningKey
+		}
+	}
+	if rotation.retired != nil {
+		app.RetiredSigningKeys = append(app.RetiredSigningKeys, *rotation.retired)
+	}
+}
+
+// issues encryptedData for app and decrypts it with workspaceKey
+func decryptIssuedFields(t *testing.T, app *entities.Application, workspaceKey string) {
+	t.Helper()
+	encrypted, keyID, err := encryptRequestedFields(app, map[string]any{"email": "ada@gateman.io"})
+	if err != nil {
+		t.Fatalf("encryptRequestedFields failed: %v", err)
+	}
+	if keyID != AppSigningKeyID(workspaceKey) {
+		t.Errorf("encryptedDataKeyID = %s, want the id of the workspace's key %s", keyID, AppSigningKeyID(workspaceKey))
+	}
+	decrypted, err := cryptography.DecryptData(*encrypted, &workspaceKey)
+	if err != nil {
+		t.Fatalf("could not decrypt encryptedData with the workspace's key: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(decrypted, &fields); err != nil || fields["email"] != "ada@gateman.io" {
+		t.Errorf("decrypted encryptedData = %q, err = %v", decrypted, err)
+	}
+}
+
+func TestScheduledRotationKeepsAppSigningKey(t *testing.T) {
+	t.Setenv("ENC_KEY", "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	for _, environment := range []string{"production", "staging"} {
+		t.Setenv("APP_ENV", environment)
+		sandbox := sandboxEnvironment()
+
+		// the keys the workspace is shown when the app is created
+		workspaceKey, encryptedKey, err := GenerateAppSigningKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokenSigningKey, err := GenerateTokenSigningKey(cryptography.DefaultSigningAlgorithm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		app := &entities.Application{ID: "app", AppSigningKey: *encryptedKey, TokenSigningKey: tokenSigningKey}
+		if sandbox {
+			app = &entities.Application{ID: "app", SandboxAppSigningKey: *encryptedKey, SandboxTokenSigningKey: tokenSigningKey}
+		}
+
+		rotation, err := newSigningKeyRotation(app, sandbox, time.Hour, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rotation.appSigningKey != nil || rotation.encryptedAppSigningKey != nil {
+			t.Errorf("%s: the scheduled rotation generated an app signing key the workspace never receives", environment)
+		}
+		if rotation.tokenSigningKey.KeyID == tokenSigningKey.KeyID || rotation.tokenSigningKey.Version != 2 {
+			t.Errorf("%s: token signing key was not rotated", environment)
+		}
+		applySigningKeyRotation(app, rotation)
+		decryptIssuedFields(t, app, *workspaceKey)
+
+		// the workspace rotating the keys itself is given the new key
+		rotation, err = newSigningKeyRotation(app, sandbox, time.Hour, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rotation.appSigningKey == nil {
+			t.Fatalf("%s: rotating the app signing key returned no key", environment)
+		}
+		applySigningKeyRotation(app, rotation)
+		decryptIssuedFields(t, app, *rotation.appSigningKey)
+	}
+}
@@ -0,0 +1,27 @@
+package dataprotection_services
+
+import (
+	"fmt"
+
+	"gateman.io/application/repository"
+	"gateman.io/infrastructure/cryptography"
+)
+
+// Returns the blind index an identity number is stored under in field of users and its provider response is cached under,
+// and whether another user is already linked to the number.
+// Numbers users verified before blind indexes are moved to their blind index by security_services.MigrateBlindIndexes.
+func IdentityNumberIndex(field string, domain cryptography.BlindIndexDomain, number string) (index string, linked bool, err error) {
+	index = cryptography.BlindIndex(domain, number)
+	count, err := repository.UserRepo().CountDocs(map[string]interface{}{
+		field: index,
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return index, count != 0, nil
+}
+
+// The name the blind index migration of field is recorded under
+func IdentityIndexMigration(field string) string {
+	return fmt.Sprintf("users.%s", field)
+}
@@ -0,0 +1,192 @@
+package dataprotection_services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gateman.io/application/repository"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/cryptography"
+	"gateman.io/infrastructure/database/repository/cache"
+	"gateman.io/infrastructure/keystore"
+)
+
+var ErrDataKeyShredded = errors.New("the data key of this subject no longer exists")
+
+// Returns the data key of subjectID unwrapped. A key is created for subjects without one when create is true.
+func subjectDataKey(subjectID string, create bool) ([]byte, error) {
+	dataKeyRepo := repository.DataKeyRepo()
+	record, err := dataKeyRepo.FindOneByFilter(map[string]interface{}{
+		"subjectID": subjectID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if record != nil {
+		return keystore.KeyStore.UnwrapKey(record.WrappedKey, record.MasterKeyID)
+	}
+	if !create {
+		return nil, ErrDataKeyShredded
+	}
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	wrappedKey, masterKeyID, err := keystore.KeyStore.WrapKey(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	_, err = dataKeyRepo.CreateOne(context.TODO(), entities.DataKey{
+		SubjectID:   subjectID,
+		WrappedKey:  wrappedKey,
+		MasterKeyID: masterKeyID,
+	})
+	if err != nil {
+		// subjectID is unique so a concurrent request may have created the key first
+		if existingKey, findErr := subjectDataKey(subjectID, false); findErr == nil {
+			return existingKey, nil
+		}
+		return nil, err
+	}
+	return dataKey, nil
+}
+
+// every field is sealed with its subject and name as additional data so ciphertexts cannot be swapped between fields or records
+func fieldAdditionalData(subjectID string, field string) []byte {
+	return []byte(subjectID + "\n" + field)
+}
+
+// Encrypts every json field of record with the data key of subjectID
+func Protect(subjectID string, record any) (*entities.ProtectedRecord, error) {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+	dataKey, err := subjectDataKey(subjectID, true)
+	if err != nil {
+		return nil, err
+	}
+	protected := entities.ProtectedRecord{
+		SubjectID: subjectID,
+		Fields:    map[string]string{},
+	}
+	for field, value := range fields {
+		protected.Fields[field], err = cryptography.SealPayload(dataKey, value, fieldAdditionalData(subjectID, field))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &protected, nil
+}
+
+// Decrypts only the named fields of record into out, which is the type record was protected from.
+// Fields that are not named are left at their zero value.
+func Reveal(record *entities.ProtectedRecord, out any, fields ...string) error {
+	dataKey, err := subjectDataKey(record.SubjectID, false)
+	if err != nil {
+		return err
+	}
+	revealed := map[string]json.RawMessage{}
+	for _, field := range fields {
+		sealed, ok := record.Fields[field]
+		if !ok {
+			continue
+		}
+		value, err := cryptography.OpenPayload(dataKey, sealed, fieldAdditionalData(record.SubjectID, field))
+		if err != nil {
+			return err
+		}
+		revealed[field] = value
+	}
+	payload, err := json.Marshal(revealed)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(payload, out)
+}
+
+// records are cached per subject so one subject can never read a record cached for another
+func protectedCacheKey(subjectID string, index string) string {
+	return fmt.Sprintf("protected:%s:%s", subjectID, index)
+}
+
+// Protects record with the data key of subjectID and caches it under index, usually the blind index of the id it was looked up with
+func CacheProtected(subjectID string, index string, record any, ttl time.Duration) error {
+	protected, err := Protect(subjectID, record)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(protected)
+	if err != nil {
+		return err
+	}
+	if !cache.Cache.CreateEntry(protectedCacheKey(subjectID, index), payload, ttl) {
+		return errors.New("could not cache protected record")
+	}
+	return nil
+}
+
+// Reveals the named fields of the record cached for subjectID under index into out.
+// found is false when nothing usable is cached, which includes records whose subject's key has been shredded.
+func FindProtected(subjectID string, index string, out any, fields ...string) (found bool, err error) {
+	return findProtected(protectedCacheKey(subjectID, index), out, false, fields)
+}
+
+// Reveals every field of the record cached for subjectID under index into out. found is false when nothing usable is cached.
+func FindAllProtected(subjectID string, index string, out any) (found bool, err error) {
+	return findProtected(protectedCacheKey(subjectID, index), out, true, nil)
+}
+
+func findProtected(key string, out any, allFields bool, fields []string) (bool, error) {
+	cached := cache.Cache.FindOne(key)
+	if cached == nil {
+		return false, nil
+	}
+	var protected entities.ProtectedRecord
+	if err := json.Unmarshal([]byte(*cached), &protected); err != nil || protected.SubjectID == "" {
+		// entries cached before records were protected hold the plain provider response
+		cache.Cache.DeleteOne(key)
+		return false, err
+	}
+	if allFields {
+		for field := range protected.Fields {
+			fields = append(fields, field)
+		}
+	}
+	err := Reveal(&protected, out, fields...)
+	if errors.Is(err, ErrDataKeyShredded) {
+		cache.Cache.DeleteOne(key)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Deletes the data key of subjectID. Everything protected with it, cached or stored, can no longer be decrypted.
+func ShredSubjectKey(subjectID string) error {
+	_, err := repository.DataKeyRepo().RemoveFromDatabase(context.TODO(), map[string]interface{}{
+		"subjectID": subjectID,
+	})
+	return err
+}
+
+// Shreds the data key of subjectID and deletes the records cached for it under indexes
+func EraseSubject(subjectID string, indexes ...string) error {
+	if err := ShredSubjectKey(subjectID); err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		cache.Cache.DeleteOne(protectedCacheKey(subjectID, index))
+	}
+	return nil
+}
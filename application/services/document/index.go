@@ -0,0 +1,115 @@
+package document_services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gateman.io/application/repository"
+	identitymatch_services "gateman.io/application/services/identitymatch"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/cryptography"
+)
+
+// A document that was read but cannot be accepted for the user. Reason is safe to show to the user.
+type DocumentRejectedError struct {
+	Reason string
+}
+
+func (err *DocumentRejectedError) Error() string {
+	return err.Reason
+}
+
+// The machine readable zone format every accepted document type is printed with
+var documentFormats = map[entities.IdentityDocumentType]string{
+	entities.PassportDocument: TD3,
+	entities.NINSlipDocument:  TD1,
+}
+
+// Returns the blind index a document number is stored under
+func DocumentNumberIndex(issuingState string, documentNumber string) string {
+	return cryptography.BlindIndex(cryptography.DocumentNumberIndex, fmt.Sprintf("%s:%s", issuingState, documentNumber))
+}
+
+// Parses the machine readable zone read off a document of documentType and checks that it is unexpired,
+// belongs to user and is not linked to another account.
+// A *DocumentRejectedError or *MRZCheckDigitError is returned for documents that cannot be accepted.
+func VerifyIdentityDocument(user *entities.User, documentType entities.IdentityDocumentType, lines []string) (*entities.IdentityDocument, error) {
+	mrz, err := ParseMRZ(lines)
+	if err != nil {
+		return nil, err
+	}
+	if documentFormats[documentType] != mrz.Format {
+		return nil, &DocumentRejectedError{Reason: fmt.Sprintf("The document uploaded is not a %s", strings.ReplaceAll(string(documentType), "_", " "))}
+	}
+	now := time.Now()
+	if !mrz.ExpiresAt.After(now) {
+		return nil, &DocumentRejectedError{Reason: "The document uploaded has expired"}
+	}
+	if user.FirstName == nil || user.FirstName.Value == nil || user.LastName == nil || user.LastName.Value == nil || user.DOB == nil || user.DOB.Value == nil {
+		return nil, &DocumentRejectedError{Reason: "Set your name and date of birth before verifying a document"}
+	}
+	if !namesMatch(mrz, *user.FirstName.Value, *user.LastName.Value) {
+		return nil, &DocumentRejectedError{Reason: "The name on the document does not match the name on your account"}
+	}
+	if user.DOB.Value.UTC().Format("2006-01-02") != mrz.DateOfBirth.Format("2006-01-02") {
+		return nil, &DocumentRejectedError{Reason: "The date of birth on the document does not match the date of birth on your account"}
+	}
+
+	documentNumber := DocumentNumberIndex(mrz.IssuingState, mrz.DocumentNumber)
+	linked, err := repository.UserRepo().CountDocs(map[string]any{
+		"idDocument.documentNumber": documentNumber,
+		"_id":                       map[string]any{"$ne": user.ID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if linked != 0 {
+		return nil, &DocumentRejectedError{Reason: "This document is already linked to another Gateman account"}
+	}
+	return &entities.IdentityDocument{
+		Type:           documentType,
+		IssuingState:   mrz.IssuingState,
+		Nationality:    mrz.Nationality,
+		DocumentNumber: documentNumber,
+		ExpiresAt:      mrz.ExpiresAt,
+		VerifiedAt:     now,
+	}, nil
+}
+
+// The surname must match the surnames on the document and the first names of the user must open its given names.
+// A truncated name only has to match as far as it was printed.
+func namesMatch(mrz *MRZ, firstName string, lastName string) bool {
+	surnames := nameTokens(lastName)
+	givenNames := nameTokens(firstName)
+	if len(surnames) == 0 || len(givenNames) == 0 {
+		return false
+	}
+	if strings.Join(surnames, " ") != strings.Join(mrz.Surnames, " ") {
+		return false
+	}
+	if len(givenNames) > len(mrz.GivenNames) {
+		return false
+	}
+	for i, name := range givenNames {
+		printed := mrz.GivenNames[i]
+		if name == printed {
+			continue
+		}
+		lastPrinted := i == len(mrz.GivenNames)-1
+		if !(mrz.NameTruncated && lastPrinted && strings.HasPrefix(name, printed)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Splits name into the upper case A-Z tokens it would be printed with in a machine readable zone.
+// Names are normalised the same way they are for identity matching, so accented names match their transliteration.
+func nameTokens(name string) []string {
+	tokens := identitymatch_services.NameTokens(name)
+	for i, token := range tokens {
+		tokens[i] = strings.ToUpper(token)
+	}
+	return tokens
+}
@@ -0,0 +1,215 @@
+package document_services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Machine readable zone formats of ICAO 9303. TD1 is the three line format of id cards, TD3 the two line format of passports.
+const (
+	TD1 = "TD1"
+	TD3 = "TD3"
+)
+
+var ErrUnrecognisedMRZ = errors.New("the machine readable zone could not be read")
+
+// The fields of a machine readable zone. Names are upper case A-Z with every filler removed.
+type MRZ struct {
+	Format         string
+	DocumentCode   string
+	IssuingState   string
+	DocumentNumber string
+	Nationality    string
+	Surnames       []string
+	GivenNames     []string
+	// true when the name filled the name field and may have been truncated
+	NameTruncated bool
+	DateOfBirth   time.Time
+	Sex           string
+	ExpiresAt     time.Time
+}
+
+// A field whose check digit does not match its value
+type MRZCheckDigitError struct {
+	Field string
+}
+
+func (err *MRZCheckDigitError) Error() string {
+	return fmt.Sprintf("the check digit of the %s in the machine readable zone is invalid", err.Field)
+}
+
+// Parses the lines of a TD1 or TD3 machine readable zone and validates every check digit
+func ParseMRZ(lines []string) (*MRZ, error) {
+	cleaned := []string{}
+	for _, line := range lines {
+		line = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(line), " ", ""))
+		if line != "" {
+			cleaned = append(cleaned, line)
+		}
+	}
+	for _, line := range cleaned {
+		for _, char := range line {
+			if !(char >= 'A' && char <= 'Z') && !(char >= '0' && char <= '9') && char != '<' {
+				return nil, ErrUnrecognisedMRZ
+			}
+		}
+	}
+	switch {
+	case len(cleaned) == 2 && len(cleaned[0]) == 44 && len(cleaned[1]) == 44:
+		return parseTD3(cleaned[0], cleaned[1])
+	case len(cleaned) == 3 && len(cleaned[0]) == 30 && len(cleaned[1]) == 30 && len(cleaned[2]) == 30:
+		return parseTD1(cleaned[0], cleaned[1], cleaned[2])
+	}
+	return nil, ErrUnrecognisedMRZ
+}
+
+func parseTD3(line1 string, line2 string) (*MRZ, error) {
+	if line1[0] != 'P' {
+		return nil, ErrUnrecognisedMRZ
+	}
+	mrz := MRZ{
+		Format:         TD3,
+		DocumentCode:   trimFiller(line1[0:2]),
+		IssuingState:   trimFiller(line1[2:5]),
+		DocumentNumber: trimFiller(line2[0:9]),
+		Nationality:    trimFiller(line2[10:13]),
+		Sex:            trimFiller(line2[20:21]),
+	}
+	mrz.Surnames, mrz.GivenNames, mrz.NameTruncated = parseMRZName(line1[5:44])
+	checks := []struct {
+		field string
+		value string
+		digit byte
+	}{
+		{"document number", line2[0:9], line2[9]},
+		{"date of birth", line2[13:19], line2[19]},
+		{"expiry date", line2[21:27], line2[27]},
+		{"personal number", line2[28:42], line2[42]},
+		{"composite", line2[0:10] + line2[13:20] + line2[21:43], line2[43]},
+	}
+	for _, check := range checks {
+		if !validCheckDigit(check.value, check.digit) {
+			return nil, &MRZCheckDigitError{Field: check.field}
+		}
+	}
+	return mrz.withDates(line2[13:19], line2[21:27], time.Now().UTC())
+}
+
+func parseTD1(line1 string, line2 string, line3 string) (*MRZ, error) {
+	if !strings.ContainsRune("IAC", rune(line1[0])) {
+		return nil, ErrUnrecognisedMRZ
+	}
+	mrz := MRZ{
+		Format:       TD1,
+		DocumentCode: trimFiller(line1[0:2]),
+		IssuingState: trimFiller(line1[2:5]),
+		Nationality:  trimFiller(line2[15:18]),
+		Sex:          trimFiller(line2[7:8]),
+	}
+	mrz.Surnames, mrz.GivenNames, mrz.NameTruncated = parseMRZName(line3)
+
+	documentNumber, documentNumberDigit := line1[5:14], line1[14]
+	// document numbers longer than 9 characters continue in the optional data, which then ends with their check digit
+	if documentNumberDigit == '<' {
+		overflow := line1[15:30]
+		end := strings.IndexByte(overflow, '<')
+		if end < 1 {
+			return nil, ErrUnrecognisedMRZ
+		}
+		documentNumber += overflow[:end-1]
+		documentNumberDigit = overflow[end-1]
+	}
+	mrz.DocumentNumber = trimFiller(documentNumber)
+	checks := []struct {
+		field string
+		value string
+		digit byte
+	}{
+		{"document number", documentNumber, documentNumberDigit},
+		{"date of birth", line2[0:6], line2[6]},
+		{"expiry date", line2[8:14], line2[14]},
+		{"composite", line1[5:30] + line2[0:7] + line2[8:15] + line2[18:29], line2[29]},
+	}
+	for _, check := range checks {
+		if !validCheckDigit(check.value, check.digit) {
+			return nil, &MRZCheckDigitError{Field: check.field}
+		}
+	}
+	return mrz.withDates(line2[0:6], line2[8:14], time.Now().UTC())
+}
+
+// Resolves the two digit years of the dates against now. A date of birth is the latest date that is not in the future,
+// an expiry date the one closest to now since documents are issued for at most a few decades.
+func (mrz MRZ) withDates(dateOfBirth string, expiry string, now time.Time) (*MRZ, error) {
+	birth, err := resolveMRZDate(dateOfBirth, now)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, err := resolveMRZDate(expiry, now.AddDate(50, 0, 0))
+	if err != nil {
+		return nil, err
+	}
+	mrz.DateOfBirth = birth
+	mrz.ExpiresAt = expiresAt
+	return &mrz, nil
+}
+
+// Returns the latest date in YYMMDD that is not after latest
+func resolveMRZDate(date string, latest time.Time) (time.Time, error) {
+	parsed, err := time.Parse("060102", date)
+	if err != nil {
+		return time.Time{}, ErrUnrecognisedMRZ
+	}
+	year := latest.Year() - latest.Year()%100 + parsed.Year()%100
+	resolved := time.Date(year, parsed.Month(), parsed.Day(), 0, 0, 0, 0, time.UTC)
+	if resolved.After(latest) {
+		resolved = resolved.AddDate(-100, 0, 0)
+	}
+	return resolved, nil
+}
+
+// The primary and secondary identifiers are split by "<<" and the names within each by "<"
+func parseMRZName(field string) (surnames []string, givenNames []string, truncated bool) {
+	truncated = !strings.HasSuffix(field, "<")
+	primary, secondary, _ := strings.Cut(strings.TrimRight(field, "<"), "<<")
+	return splitMRZNames(primary), splitMRZNames(secondary), truncated
+}
+
+func splitMRZNames(names string) []string {
+	split := []string{}
+	for _, name := range strings.Split(names, "<") {
+		if name != "" {
+			split = append(split, name)
+		}
+	}
+	return split
+}
+
+func trimFiller(value string) string {
+	return strings.TrimRight(value, "<")
+}
+
+// Check digits are the sum of the values of the characters weighted 7, 3, 1 repeating, modulo 10.
+// Digits are worth themselves, A-Z are worth 10-35 and the filler is worth 0.
+func validCheckDigit(value string, digit byte) bool {
+	if digit < '0' || digit > '9' {
+		// a filler stands in for 0 in fields that are empty
+		return digit == '<' && trimFiller(value) == ""
+	}
+	weights := []int{7, 3, 1}
+	sum := 0
+	for i := 0; i < len(value); i++ {
+		char := value[i]
+		var worth int
+		switch {
+		case char >= '0' && char <= '9':
+			worth = int(char - '0')
+		case char >= 'A' && char <= 'Z':
+			worth = int(char-'A') + 10
+		}
+		sum += worth * weights[i%3]
+	}
+	return sum%10 == int(digit-'0')
+}
@@ -0,0 +1,142 @@
+package document_services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// the specimens of ICAO 9303
+var (
+	specimenTD3 = []string{
+		"P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<",
+		"L898902C36UTO7408122F1204159ZE184226B<<<<<10",
+	}
+	specimenTD1 = []string{
+		"I<UTOD231458907<<<<<<<<<<<<<<<",
+		"7408122F1204159UTO<<<<<<<<<<<6",
+		"ERIKSSON<<ANNA<MARIA<<<<<<<<<<",
+	}
+)
+
+func TestValidCheckDigit(t *testing.T) {
+	cases := []struct {
+		value string
+		digit byte
+		valid bool
+	}{
+		{"L898902C3", '6', true},
+		{"740812", '2', true},
+		{"120415", '9', true},
+		{"ZE184226B<<<<<", '1', true},
+		{"D23145890", '7', true},
+		{"740812", '3', false},
+		{"L898902C3", '<', false},
+		{"<<<<<<<<<<<<<<", '<', true},
+		{"<<<<<<<<<<<<<<", '0', true},
+	}
+	for _, c := range cases {
+		if got := validCheckDigit(c.value, c.digit); got != c.valid {
+			t.Errorf("validCheckDigit(%q, %q) = %t, want %t", c.value, c.digit, got, c.valid)
+		}
+	}
+}
+
+func TestParseMRZ(t *testing.T) {
+	for _, lines := range [][]string{specimenTD3, specimenTD1} {
+		mrz, err := ParseMRZ(lines)
+		if err != nil {
+			t.Fatalf("ParseMRZ(%q) failed: %v", lines, err)
+		}
+		if mrz.IssuingState != "UTO" || mrz.Nationality != "UTO" {
+			t.Errorf("%s: issuing state %q and nationality %q, want UTO", mrz.Format, mrz.IssuingState, mrz.Nationality)
+		}
+		if !slices.Equal(mrz.Surnames, []string{"ERIKSSON"}) || !slices.Equal(mrz.GivenNames, []string{"ANNA", "MARIA"}) {
+			t.Errorf("%s: names %v %v", mrz.Format, mrz.Surnames, mrz.GivenNames)
+		}
+		if got := mrz.DateOfBirth.Format("2006-01-02"); got != "1974-08-12" {
+			t.Errorf("%s: date of birth %s, want 1974-08-12", mrz.Format, got)
+		}
+		if got := mrz.ExpiresAt.Format("2006-01-02"); got != "2012-04-15" {
+			t.Errorf("%s: expiry %s, want 2012-04-15", mrz.Format, got)
+		}
+	}
+}
+
+func TestParseMRZInvalidCheckDigit(t *testing.T) {
+	cases := []struct {
+		lines []string
+		field string
+	}{
+		{[]string{specimenTD3[0], "L898902C37UTO7408122F1204159ZE184226B<<<<<10"}, "document number"},
+		{[]string{specimenTD3[0], "L898902C36UTO7408132F1204159ZE184226B<<<<<10"}, "date of birth"},
+		{[]string{specimenTD3[0], "L898902C36UTO7408122F1204158ZE184226B<<<<<10"}, "expiry date"},
+		{[]string{specimenTD3[0], "L898902C36UTO7408122F1204159ZE184226B<<<<<11"}, "composite"},
+		{[]string{specimenTD1[0], "7408122F1204159UTO<<<<<<<<<<<7", specimenTD1[2]}, "composite"},
+	}
+	for _, c := range cases {
+		_, err := ParseMRZ(c.lines)
+		var checkDigitErr *MRZCheckDigitError
+		if !errors.As(err, &checkDigitErr) || checkDigitErr.Field != c.field {
+			t.Errorf("ParseMRZ(%q) = %v, want a check digit error on the %s", c.lines, err, c.field)
+		}
+	}
+}
+
+func TestResolveMRZDate(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		date   string
+		latest time.Time
+		want   string
+	}{
+		{"birth this century", "050301", now, "2005-03-01"},
+		{"birth last century", "740812", now, "1974-08-12"},
+		{"birth later this year is last century", "261231", now, "1926-12-31"},
+		{"birth today", "261016", now, "2026-10-16"},
+		{"birth early last century", "120101", time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), "1912-01-01"},
+		{"expiry in the future", "360101", now.AddDate(50, 0, 0), "2036-01-01"},
+		{"expiry in the past", "120415", now.AddDate(50, 0, 0), "2012-04-15"},
+		{"expiry in the future across a century", "050101", time.Date(2098, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(50, 0, 0), "2105-01-01"},
+		{"expired last century", "990101", now.AddDate(50, 0, 0), "1999-01-01"},
+	}
+	for _, c := range cases {
+		got, err := resolveMRZDate(c.date, c.latest)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if got.Format("2006-01-02") != c.want {
+			t.Errorf("%s: resolveMRZDate(%q) = %s, want %s", c.name, c.date, got.Format("2006-01-02"), c.want)
+		}
+	}
+	if _, err := resolveMRZDate("740230", now); !errors.Is(err, ErrUnrecognisedMRZ) {
+		t.Errorf("invalid date: err = %v, want %v", err, ErrUnrecognisedMRZ)
+	}
+}
+
+func TestNamesMatch(t *testing.T) {
+	mrz := &MRZ{Surnames: []string{"ADEBAYO"}, GivenNames: []string{"OLUWASEUN", "OLUFEMI"}}
+	cases := []struct {
+		firstName string
+		lastName  string
+		match     bool
+	}{
+		{"Oluwaseun", "Adébáyọ̀", true},
+		{"Oluwaseun Olufemi", "Adebayo", true},
+		{"Olufemi", "Adebayo", false},
+		{"Oluwaseun", "Adeyemi", false},
+	}
+	for _, c := range cases {
+		if got := namesMatch(mrz, c.firstName, c.lastName); got != c.match {
+			t.Errorf("namesMatch(%q, %q) = %t, want %t", c.firstName, c.lastName, got, c.match)
+		}
+	}
+
+	truncated := &MRZ{Surnames: []string{"OKONKWO"}, GivenNames: []string{"CHUKWUEMEKA", "OBINNA"}, NameTruncated: true}
+	if !namesMatch(truncated, "Chukwuemeka Obinnaya", "Okonkwo") {
+		t.Error("a truncated given name should match the start of the account name")
+	}
+}
@@ -0,0 +1,54 @@
+package facebinding_services
+
+import (
+	"errors"
+	"time"
+
+	"gateman.io/entities"
+	"gateman.io/infrastructure/biometric"
+	fileupload "gateman.io/infrastructure/file_upload"
+	"gateman.io/infrastructure/file_upload/types"
+	"gateman.io/infrastructure/logger"
+)
+
+var (
+	ErrNoAccountImage       = errors.New("the user has not set an account image")
+	ErrNoGovernmentPhoto    = errors.New("the identity record has no photo")
+	ErrFaceComparisonFailed = errors.New("the faces could not be compared")
+)
+
+// Compares the account image of user with governmentPhoto, the base64 photo returned with an identity record.
+// The returned binding records the decision of the biometric service whether or not the faces match.
+func BindFace(user *entities.User, governmentPhoto string) (*entities.FaceBinding, error) {
+	if user.Image == "" {
+		return nil, ErrNoAccountImage
+	}
+	if governmentPhoto == "" {
+		return nil, ErrNoGovernmentPhoto
+	}
+	url, err := fileupload.FileUploader.GeneratedSignedURL(user.Image, types.SignedURLPermission{
+		Read: true,
+	}, time.Minute*1)
+	if err != nil {
+		return nil, err
+	}
+	result, err := biometric.BiometricService.CompareFaces(url, &governmentPhoto)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		logger.Error("face comparison with government photo was unsuccessful", logger.LoggerOptions{
+			Key:  "userID",
+			Data: user.ID,
+		}, logger.LoggerOptions{
+			Key:  "error",
+			Data: result.Error,
+		})
+		return nil, ErrFaceComparisonFailed
+	}
+	return &entities.FaceBinding{
+		Confidence: result.Confidence,
+		Match:      result.Match,
+		ComparedAt: time.Now(),
+	}, nil
+}
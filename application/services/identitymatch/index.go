@@ -0,0 +1,281 @@
+package identitymatch_services
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gateman.io/entities"
+	identity_verification_types "gateman.io/infrastructure/identity_verification/types"
+)
+
+// The names and date of birth of a person, either as claimed on their account or as held on an identity record.
+// Records that only hold a full name in no particular order set FullName instead of the name parts.
+type Identity struct {
+	FirstName   string
+	MiddleName  string
+	LastName    string
+	FullName    string
+	DateOfBirth *time.Time
+}
+
+// How closely a record has to match an account for a check
+type Thresholds struct {
+	// the least name score that matches
+	Name float64
+	// false for records that hold no date of birth
+	RequireDOB bool
+}
+
+// Screening of a verified identity against sanctions and PEP lists. It is not a provider check,
+// lists often hold no date of birth or only the year so the screening service compares dates of birth itself.
+const ScreeningCheck identity_verification_types.IdentityCheck = "screening"
+
+// Thresholds of the checks, overridden per check by IDENTITY_MATCH_THRESHOLDS, e.g. "nin=0.95;voters_card=0.85"
+var defaultThresholds = map[identity_verification_types.IdentityCheck]Thresholds{
+	identity_verification_types.NINCheck:            {Name: 0.92, RequireDOB: true},
+	identity_verification_types.BVNCheck:            {Name: 0.92, RequireDOB: true},
+	identity_verification_types.DriversLicenseCheck: {Name: 0.9, RequireDOB: true},
+	identity_verification_types.VotersCardCheck:     {Name: 0.92, RequireDOB: false},
+	// directors are matched to the verified identity of the workspace owner, a director listed without a date of birth does not match
+	identity_verification_types.CompanyCheck: {Name: 0.92, RequireDOB: true},
+	// hits are reviewed, so screening errs on the side of raising one
+	ScreeningCheck: {Name: 0.88, RequireDOB: false},
+}
+
+var (
+	thresholds     map[identity_verification_types.IdentityCheck]Thresholds
+	thresholdsOnce sync.Once
+)
+
+func checkThresholds(check identity_verification_types.IdentityCheck) Thresholds {
+	thresholdsOnce.Do(func() {
+		thresholds = map[identity_verification_types.IdentityCheck]Thresholds{}
+		for check, threshold := range defaultThresholds {
+			thresholds[check] = threshold
+		}
+		for _, override := range strings.Split(os.Getenv("IDENTITY_MATCH_THRESHOLDS"), ";") {
+			name, value, found := strings.Cut(strings.TrimSpace(override), "=")
+			if !found {
+				continue
+			}
+			score, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || score <= 0 || score > 1 {
+				continue
+			}
+			check := identity_verification_types.IdentityCheck(strings.TrimSpace(name))
+			threshold, ok := thresholds[check]
+			if !ok {
+				threshold = Thresholds{RequireDOB: true}
+			}
+			threshold.Name = score
+			thresholds[check] = threshold
+		}
+	})
+	if threshold, ok := thresholds[check]; ok {
+		return threshold
+	}
+	return Thresholds{Name: 0.92, RequireDOB: true}
+}
+
+// Scores of the ways a name token can match a record token.
+// An initial scores above every default threshold so a first or middle name written as an initial matches.
+const (
+	exactScore   = 1.0
+	initialScore = 0.95
+)
+
+// how many of a person's names can be matched by an initial, the last name never is
+const maxInitialsPerIdentity = 1
+
+// How one name of the account was matched on the record
+type NameComparison struct {
+	Part    string  `json:"part"`
+	Claimed string  `json:"claimed"`
+	Record  string  `json:"record"`
+	Rule    string  `json:"rule"` // exact, initial, similar or missing
+	Score   float64 `json:"score"`
+}
+
+// The decision of a match and how it was reached
+type MatchResult struct {
+	Check         identity_verification_types.IdentityCheck `json:"check"`
+	Match         bool                                      `json:"match"`
+	NameScore     float64                                   `json:"nameScore"`
+	NameThreshold float64                                   `json:"nameThreshold"`
+	Names         []NameComparison                          `json:"names"`
+	DOBChecked    bool                                      `json:"dobChecked"`
+	DOBMatch      bool                                      `json:"dobMatch"`
+	Reasons       []string                                  `json:"reasons"`
+}
+
+// Returns the result as it is stored on the account, without the names on the record
+func (result MatchResult) Explanation() entities.IdentityMatch {
+	names := []entities.IdentityNameMatch{}
+	for _, name := range result.Names {
+		names = append(names, entities.IdentityNameMatch{
+			Part:  name.Part,
+			Rule:  name.Rule,
+			Score: name.Score,
+		})
+	}
+	return entities.IdentityMatch{
+		Match:         result.Match,
+		NameScore:     result.NameScore,
+		NameThreshold: result.NameThreshold,
+		Names:         names,
+		DOBChecked:    result.DOBChecked,
+		DOBMatch:      result.DOBMatch,
+		Reasons:       result.Reasons,
+		MatchedAt:     time.Now(),
+	}
+}
+
+// Returns the identity the user claimed on their account
+func AccountIdentity(user *entities.User) Identity {
+	identity := Identity{}
+	if user.FirstName != nil && user.FirstName.Value != nil {
+		identity.FirstName = *user.FirstName.Value
+	}
+	if user.MiddleName != nil && user.MiddleName.Value != nil {
+		identity.MiddleName = *user.MiddleName.Value
+	}
+	if user.LastName != nil && user.LastName.Value != nil {
+		identity.LastName = *user.LastName.Value
+	}
+	if user.DOB != nil {
+		identity.DateOfBirth = user.DOB.Value
+	}
+	return identity
+}
+
+// Reconciles the identity claimed on an account with an identity record returned for check.
+//
+// Names are compared token by token after normalisation in any order, so reordered names and names split differently still match.
+// The first and last names of the account each have to be found on the record and the weakest of them is the name score,
+// a middle name is compared and explained but a record without it is not penalised.
+// A token matches a record token exactly, as an initial of it or by its Jaro-Winkler similarity.
+// Only one first or middle name can be matched as an initial so a record of initials does not match any account.
+func MatchIdentity(check identity_verification_types.IdentityCheck, account Identity, record Identity) MatchResult {
+	threshold := checkThresholds(check)
+	result := MatchResult{
+		Check:         check,
+		NameThreshold: threshold.Name,
+		Names:         []NameComparison{},
+		Reasons:       []string{},
+	}
+
+	recordTokens := NameTokens(strings.Join([]string{record.FirstName, record.MiddleName, record.LastName, record.FullName}, " "))
+	used := make([]bool, len(recordTokens))
+	parts := []struct {
+		name     string
+		value    string
+		required bool
+	}{
+		// the rarer names are matched first so common first names cannot take their record tokens
+		{"lastName", account.LastName, true},
+		{"firstName", account.FirstName, true},
+		{"middleName", account.MiddleName, false},
+	}
+	result.NameScore = 1
+	initials := 0
+	for _, part := range parts {
+		tokens := NameTokens(part.value)
+		if len(tokens) == 0 {
+			if part.required {
+				result.Reasons = append(result.Reasons, fmt.Sprintf("the account has no %s", part.name))
+				result.NameScore = 0
+			}
+			continue
+		}
+		for _, token := range tokens {
+			comparison := bestComparison(token, recordTokens, used, part.name != "lastName" && initials < maxInitialsPerIdentity)
+			comparison.Part = part.name
+			if comparison.Rule == "initial" {
+				initials++
+			}
+			result.Names = append(result.Names, comparison)
+			if part.required {
+				result.NameScore = min(result.NameScore, comparison.Score)
+			}
+			if comparison.Rule == "missing" {
+				if part.required {
+					result.Reasons = append(result.Reasons, fmt.Sprintf("%s %q is not on the record", part.name, token))
+				} else {
+					result.Reasons = append(result.Reasons, fmt.Sprintf("%s %q is not on the record and was ignored", part.name, token))
+				}
+			}
+		}
+	}
+	nameMatch := result.NameScore >= threshold.Name
+	if !nameMatch {
+		result.Reasons = append(result.Reasons, fmt.Sprintf("name score %.2f is below %.2f", result.NameScore, threshold.Name))
+	}
+
+	dobMatch := true
+	if threshold.RequireDOB {
+		result.DOBChecked = true
+		switch {
+		case account.DateOfBirth == nil:
+			dobMatch = false
+			result.Reasons = append(result.Reasons, "the account has no date of birth")
+		case record.DateOfBirth == nil:
+			dobMatch = false
+			result.Reasons = append(result.Reasons, "the record has no date of birth")
+		default:
+			// dates of birth are calendar dates, the time and zone they were parsed with are ignored
+			dobMatch = account.DateOfBirth.UTC().Format("2006-01-02") == record.DateOfBirth.UTC().Format("2006-01-02")
+			if !dobMatch {
+				result.Reasons = append(result.Reasons, "the date of birth does not match")
+			}
+		}
+		result.DOBMatch = dobMatch
+	}
+	result.Match = nameMatch && dobMatch
+	return result
+}
+
+// Matches token to the best unused record token and marks it used. Tokens are only matched as initials when allowInitial is set.
+func bestComparison(token string, recordTokens []string, used []bool, allowInitial bool) NameComparison {
+	type candidate struct {
+		index int
+		rule  string
+		score float64
+	}
+	candidates := []candidate{}
+	for i, recordToken := range recordTokens {
+		if used[i] {
+			continue
+		}
+		switch {
+		case token == recordToken:
+			candidates = append(candidates, candidate{i, "exact", exactScore})
+		case allowInitial && (len(token) == 1 || len(recordToken) == 1) && token[0] == recordToken[0]:
+			candidates = append(candidates, candidate{i, "initial", initialScore})
+		default:
+			candidates = append(candidates, candidate{i, "similar", jaroWinkler(token, recordToken)})
+		}
+	}
+	if len(candidates) == 0 {
+		return NameComparison{Claimed: token, Rule: "missing"}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	best := candidates[0]
+	// tokens that are barely alike are not the same name
+	if best.score < 0.75 {
+		return NameComparison{Claimed: token, Rule: "missing"}
+	}
+	used[best.index] = true
+	return NameComparison{
+		Claimed: token,
+		Record:  recordTokens[best.index],
+		Rule:    best.rule,
+		Score:   best.score,
+	}
+}
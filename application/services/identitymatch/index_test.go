@@ -0,0 +1,129 @@
+package identitymatch_services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	identity_verification_types "gateman.io/infrastructure/identity_verification/types"
+)
+
+func date(value string) *time.Time {
+	parsed, _ := time.Parse("2006-01-02", value)
+	return &parsed
+}
+
+func TestMatchIdentityNames(t *testing.T) {
+	account := Identity{FirstName: "Oluwaseun", MiddleName: "Olufemi", LastName: "Adebayo", DateOfBirth: date("1974-08-12")}
+	cases := []struct {
+		name   string
+		record Identity
+		match  bool
+	}{
+		{"same names", Identity{FirstName: "OLUWASEUN", MiddleName: "OLUFEMI", LastName: "ADEBAYO"}, true},
+		{"diacritics", Identity{FirstName: "Olúwašeun", MiddleName: "Olúfẹ́mi", LastName: "Adébáyọ̀"}, true},
+		{"names in another order", Identity{FirstName: "Adebayo", MiddleName: "Oluwaseun", LastName: "Olufemi"}, true},
+		{"full name only", Identity{FullName: "ADEBAYO OLUWASEUN OLUFEMI"}, true},
+		{"no middle name on the record", Identity{FirstName: "Oluwaseun", LastName: "Adebayo"}, true},
+		{"misspelt first name", Identity{FirstName: "Oluwasegun", LastName: "Adebayo"}, true},
+		{"initial of the first name", Identity{FirstName: "O", LastName: "Adebayo"}, true},
+		{"initial of the last name", Identity{FirstName: "Oluwaseun", LastName: "A"}, false},
+		{"another last name", Identity{FirstName: "Oluwaseun", LastName: "Adeyemi"}, false},
+		{"another person", Identity{FirstName: "Chukwuemeka", LastName: "Okonkwo"}, false},
+	}
+	for _, c := range cases {
+		c.record.DateOfBirth = date("1974-08-12")
+		if result := MatchIdentity(identity_verification_types.NINCheck, account, c.record); result.Match != c.match {
+			t.Errorf("%s: match = %t, want %t, reasons %q", c.name, result.Match, c.match, result.Reasons)
+		}
+	}
+}
+
+func TestMatchIdentityInitials(t *testing.T) {
+	dob := date("1974-08-12")
+	record := Identity{FirstName: "Oluwaseun", MiddleName: "Olufemi", LastName: "Adebayo", DateOfBirth: dob}
+	checks := []identity_verification_types.IdentityCheck{
+		identity_verification_types.NINCheck,
+		identity_verification_types.BVNCheck,
+		identity_verification_types.DriversLicenseCheck,
+		identity_verification_types.VotersCardCheck,
+	}
+	cases := []struct {
+		name    string
+		account Identity
+		part    int
+		match   bool
+	}{
+		{"initialled first name", Identity{FirstName: "O.", LastName: "Adebayo", DateOfBirth: dob}, 1, true},
+		{"initialled middle name", Identity{FirstName: "Oluwaseun", MiddleName: "O", LastName: "Adebayo", DateOfBirth: dob}, 2, true},
+		// only one name can be an initial
+		{"initialled first names", Identity{FirstName: "O. O.", LastName: "Adebayo", DateOfBirth: dob}, 1, false},
+	}
+	for _, check := range checks {
+		for _, c := range cases {
+			result := MatchIdentity(check, c.account, record)
+			if result.Match != c.match {
+				t.Errorf("%s %s: match = %t, want %t, reasons %q", check, c.name, result.Match, c.match, result.Reasons)
+			}
+			if result.Names[c.part].Rule != "initial" || result.Names[c.part].Score != initialScore {
+				t.Errorf("%s %s: %s matched as %s scoring %.2f, want an initial", check, c.name, result.Names[c.part].Part, result.Names[c.part].Rule, result.Names[c.part].Score)
+			}
+		}
+	}
+
+	// a record holding initials matches the full names of the account
+	account := Identity{FirstName: "Oluwaseun", LastName: "Adebayo", DateOfBirth: dob}
+	if result := MatchIdentity(identity_verification_types.NINCheck, account, Identity{FullName: "ADEBAYO O", DateOfBirth: dob}); !result.Match {
+		t.Errorf("an initialled record did not match, reasons %q", result.Reasons)
+	}
+}
+
+func TestMatchIdentityDateOfBirth(t *testing.T) {
+	account := Identity{FirstName: "Oluwaseun", LastName: "Adebayo", DateOfBirth: date("1974-08-12")}
+	cases := []struct {
+		name  string
+		check identity_verification_types.IdentityCheck
+		dob   *time.Time
+		match bool
+	}{
+		{"same date", identity_verification_types.NINCheck, date("1974-08-12"), true},
+		{"same date at another time", identity_verification_types.BVNCheck, func() *time.Time { d := date("1974-08-12").Add(time.Hour * 10); return &d }(), true},
+		{"another date", identity_verification_types.NINCheck, date("1974-12-08"), false},
+		{"no date on the record", identity_verification_types.NINCheck, nil, false},
+		{"no date on a voter's card", identity_verification_types.VotersCardCheck, nil, true},
+	}
+	for _, c := range cases {
+		record := Identity{FirstName: "Oluwaseun", LastName: "Adebayo", DateOfBirth: c.dob}
+		if result := MatchIdentity(c.check, account, record); result.Match != c.match {
+			t.Errorf("%s: match = %t, want %t, reasons %q", c.name, result.Match, c.match, result.Reasons)
+		}
+	}
+
+	withoutDOB := Identity{FirstName: "Oluwaseun", LastName: "Adebayo"}
+	result := MatchIdentity(identity_verification_types.NINCheck, withoutDOB, Identity{FirstName: "Oluwaseun", LastName: "Adebayo", DateOfBirth: date("1974-08-12")})
+	if result.Match || !result.DOBChecked || result.DOBMatch {
+		t.Errorf("an account without a date of birth matched: %+v", result)
+	}
+}
+
+func TestMatchResultExplanation(t *testing.T) {
+	account := Identity{FirstName: "Oluwaseun", LastName: "Adebayo", DateOfBirth: date("1974-08-12")}
+	record := Identity{FirstName: "Chukwuemeka", LastName: "Adebayo", DateOfBirth: date("1974-08-12")}
+	result := MatchIdentity(identity_verification_types.NINCheck, account, record)
+	if result.Match {
+		t.Fatal("expected the first name to not match")
+	}
+	if len(result.Reasons) == 0 || !strings.Contains(strings.Join(result.Reasons, "; "), `firstName "oluwaseun" is not on the record`) {
+		t.Errorf("reasons = %q", result.Reasons)
+	}
+
+	explanation := result.Explanation()
+	if explanation.Match || explanation.NameScore != result.NameScore || len(explanation.Names) != len(result.Names) {
+		t.Errorf("explanation %+v does not reflect %+v", explanation, result)
+	}
+	for _, name := range explanation.Names {
+		if name.Part == "" || name.Rule == "" {
+			t.Errorf("explanation name %+v is incomplete", name)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package identitymatch_services
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Letters that do not decompose into a base letter and a combining mark
+var transliterations = strings.NewReplacer(
+	"ß", "ss",
+	"æ", "ae",
+	"ø", "o",
+	"đ", "d",
+	"ł", "l",
+	"ı", "i",
+)
+
+// Splits name into lower case ASCII tokens. Tone marks and dots under letters, as in Yoruba and Igbo names, are removed,
+// apostrophes are dropped and every other punctuation separates tokens.
+func NameTokens(name string) []string {
+	decomposed := norm.NFD.String(strings.ToLower(name))
+	var folded strings.Builder
+	for _, char := range decomposed {
+		switch {
+		case unicode.Is(unicode.Mn, char), char == '\'', char == '’', char == '`':
+			continue
+		}
+		folded.WriteRune(char)
+	}
+	ascii := transliterations.Replace(folded.String())
+	return strings.FieldsFunc(ascii, func(char rune) bool {
+		return char < 'a' || char > 'z'
+	})
+}
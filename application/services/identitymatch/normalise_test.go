@@ -0,0 +1,28 @@
+package identitymatch_services
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNameTokens(t *testing.T) {
+	cases := []struct {
+		name string
+		want []string
+	}{
+		{"Adébáyọ̀", []string{"adebayo"}},
+		{"  CHUKWUEMEKA   Obinna ", []string{"chukwuemeka", "obinna"}},
+		{"Ọlárẹ̀wájú-Ògúnlẹ́yẹ", []string{"olarewaju", "ogunleye"}},
+		{"O'Neil", []string{"oneil"}},
+		{"Ngozi’s", []string{"ngozis"}},
+		{"Müller Strauß", []string{"muller", "strauss"}},
+		{"Søren Łukasz", []string{"soren", "lukasz"}},
+		{"A. B.", []string{"a", "b"}},
+		{"", []string{}},
+	}
+	for _, c := range cases {
+		if got := NameTokens(c.name); !slices.Equal(got, c.want) {
+			t.Errorf("NameTokens(%q) = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package identitymatch_services
+
+import (
+	"math"
+	"testing"
+)
+
+func TestJaroWinkler(t *testing.T) {
+	cases := []struct {
+		a    string
+		b    string
+		want float64
+	}{
+		{"martha", "marhta", 0.9611},
+		{"dwayne", "duane", 0.84},
+		{"dixon", "dicksonx", 0.8133},
+		{"oluwaseun", "oluwaseun", 1},
+		{"abc", "xyz", 0},
+		{"", "adebayo", 0},
+	}
+	for _, c := range cases {
+		got := jaroWinkler(c.a, c.b)
+		if math.Abs(got-c.want) > 0.0001 {
+			t.Errorf("jaroWinkler(%q, %q) = %.4f, want %.4f", c.a, c.b, got, c.want)
+		}
+		if reversed := jaroWinkler(c.b, c.a); math.Abs(got-reversed) > 0.0001 {
+			t.Errorf("jaroWinkler(%q, %q) = %.4f is not symmetric, got %.4f reversed", c.a, c.b, got, reversed)
+		}
+	}
+}
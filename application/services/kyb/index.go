@@ -0,0 +1,193 @@
+package kyb_services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gateman.io/application/repository"
+	identitymatch_services "gateman.io/application/services/identitymatch"
+	"gateman.io/application/utils"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/database/repository/cache"
+	fileupload "gateman.io/infrastructure/file_upload"
+	identityverification "gateman.io/infrastructure/identity_verification"
+	identity_verification_types "gateman.io/infrastructure/identity_verification/types"
+	"gateman.io/infrastructure/logger"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// A business that cannot be verified for the workspace. Reason is safe to show to the member.
+type BusinessRejectedError struct {
+	Reason string
+}
+
+func (err *BusinessRejectedError) Error() string {
+	return err.Reason
+}
+
+// Every document a workspace can submit, the certificate of incorporation is the only one required
+var BusinessDocuments = map[entities.BusinessDocument]bool{
+	entities.CertificateOfIncorporation: true,
+	entities.MemorandumOfAssociation:    false,
+	entities.StatusReport:               false,
+}
+
+// Returns the file path document of workspaceID is uploaded to
+func BusinessDocumentPath(workspaceID string, document entities.BusinessDocument) string {
+	return fmt.Sprintf("%s/kyb/%s", workspaceID, document)
+}
+
+// Looks up rcNumber on the CAC registry and checks that owner is one of its directors.
+// Owners are identified by the NIN verified identity of the Gateman account registered with their verified email,
+// the director they are matched to has to carry the same names and date of birth.
+// A *BusinessRejectedError is returned for businesses that cannot be verified.
+func VerifyBusiness(workspace *entities.Workspace, owner *entities.WorkspaceMember, rcNumber string, companyType identity_verification_types.CompanyType) (*entities.BusinessVerification, error) {
+	account, err := verifiedOwnerAccount(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := map[entities.BusinessDocument]string{}
+	for document, required := range BusinessDocuments {
+		path := BusinessDocumentPath(workspace.ID, document)
+		exists, err := fileupload.FileUploader.CheckFileExists(path)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			documents[document] = path
+		} else if required {
+			return nil, &BusinessRejectedError{Reason: fmt.Sprintf("Upload your %s before verifying your business", strings.ReplaceAll(string(document), "_", " "))}
+		}
+	}
+
+	rcNumber = strings.ToUpper(strings.TrimSpace(rcNumber))
+	linked, err := repository.WorkspaceRepository().CountDocs(map[string]any{
+		"businessVerification.rcNumber":    rcNumber,
+		"businessVerification.companyType": companyType,
+		"_id":                              map[string]any{"$ne": workspace.ID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if linked != 0 {
+		return nil, &BusinessRejectedError{Reason: "This business is already verified on another workspace"}
+	}
+
+	company, err := identityverification.IdentityVerifier.FetchCompanyDetails(rcNumber, companyType)
+	if err != nil {
+		if errors.Is(err, identity_verification_types.ErrIdentityProviderUnavailable) || errors.Is(err, identity_verification_types.ErrUnsupportedIdentityCheck) {
+			return nil, err
+		}
+		return nil, &BusinessRejectedError{Reason: "No business is registered with this number"}
+	}
+	if company.Status != "" && !strings.EqualFold(company.Status, "active") {
+		return nil, &BusinessRejectedError{Reason: fmt.Sprintf("This business is %s on the CAC registry", strings.ToLower(company.Status))}
+	}
+
+	ownerIdentity := identitymatch_services.AccountIdentity(account)
+	var matchedDirector *identity_verification_types.CompanyAffiliate
+	directorMatches := []identitymatch_services.MatchResult{}
+	for i, director := range company.Directors {
+		directorIdentity := identitymatch_services.Identity{
+			FirstName:  director.FirstName,
+			MiddleName: utils.GetStringValue(director.MiddleName),
+			LastName:   director.LastName,
+		}
+		if dob, err := time.Parse("2006-01-02", director.DateOfBirth); err == nil {
+			directorIdentity.DateOfBirth = &dob
+		}
+		directorMatch := identitymatch_services.MatchIdentity(identity_verification_types.CompanyCheck, ownerIdentity, directorIdentity)
+		if directorMatch.Match {
+			matchedDirector = &company.Directors[i]
+			break
+		}
+		directorMatches = append(directorMatches, directorMatch)
+	}
+	if matchedDirector == nil {
+		logger.Info("workspace owner did not match a director", logger.LoggerOptions{
+			Key: "workspaceID", Data: workspace.ID,
+		}, logger.LoggerOptions{
+			Key: "matches", Data: directorMatches,
+		})
+		return nil, &BusinessRejectedError{Reason: "You are not listed as a director of this business"}
+	}
+
+	return &entities.BusinessVerification{
+		RCNumber:         rcNumber,
+		CompanyType:      string(companyType),
+		CompanyName:      company.CompanyName,
+		RegistrationDate: company.RegistrationDate,
+		Address:          company.Address,
+		MatchedDirector:  strings.TrimSpace(fmt.Sprintf("%s %s", matchedDirector.FirstName, matchedDirector.LastName)),
+		OwnerID:          owner.ID,
+		OwnerUserID:      account.ID,
+		OwnerNIN:         *account.NIN,
+		Documents:        documents,
+		VerifiedAt:       time.Now(),
+	}, nil
+}
+
+// Returns the Gateman account of owner once its names and date of birth have been verified against their NIN
+func verifiedOwnerAccount(owner *entities.WorkspaceMember) (*entities.User, error) {
+	rejected := &BusinessRejectedError{Reason: fmt.Sprintf("Verify your NIN on the Gateman account registered with %s before verifying your business", owner.Email)}
+	if !owner.VerifiedEmail {
+		return nil, &BusinessRejectedError{Reason: "Verify your email before verifying your business"}
+	}
+	account, err := repository.UserRepo().FindOneByFilter(map[string]any{
+		"email": owner.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if account == nil || !account.VerifiedAccount || account.NIN == nil {
+		return nil, rejected
+	}
+	for _, field := range []bool{
+		account.FirstName != nil && account.FirstName.Verified,
+		account.LastName != nil && account.LastName.Verified,
+		account.DOB != nil && account.DOB.Verified,
+	} {
+		if !field {
+			return nil, rejected
+		}
+	}
+	return account, nil
+}
+
+func workspaceVerifiedKey(workspaceID string) string {
+	return fmt.Sprintf("workspace-verified:%s", workspaceID)
+}
+
+// Returns true if the business of workspaceID has been verified. Results are cached for 10 minutes.
+func WorkspaceVerified(workspaceID string) (bool, error) {
+	if cached := cache.Cache.FindOne(workspaceVerifiedKey(workspaceID)); cached != nil {
+		return *cached == "true", nil
+	}
+	workspace, err := repository.WorkspaceRepository().FindByID(workspaceID)
+	if err != nil {
+		return false, err
+	}
+	verified := workspace != nil && workspace.Verified
+	cache.Cache.CreateEntry(workspaceVerifiedKey(workspaceID), fmt.Sprintf("%t", verified), time.Minute*10)
+	return verified, nil
+}
+
+// Records the verification on workspaceID and takes effect on the gates right away.
+// A business can only be verified on one workspace, the unique index on it settles concurrent verifications.
+func MarkWorkspaceVerified(workspaceID string, verification *entities.BusinessVerification) error {
+	_, err := repository.WorkspaceRepository().UpdatePartialByID(workspaceID, map[string]any{
+		"verified":             true,
+		"businessVerification": verification,
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return &BusinessRejectedError{Reason: "This business is already verified on another workspace"}
+		}
+		return err
+	}
+	cache.Cache.DeleteOne(workspaceVerifiedKey(workspaceID))
+	return nil
+}
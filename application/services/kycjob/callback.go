@@ -0,0 +1,215 @@
+package kycjob_services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gateman.io/application/repository"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/cryptography"
+	"gateman.io/infrastructure/logger"
+	"gateman.io/infrastructure/network"
+)
+
+const (
+	// deliveries after the first wait 30s, 1m, 2m and so on before they are retried
+	callbackMaxAttempts  = 8
+	callbackRetryBackoff = time.Second * 30
+	callbackTimeout      = time.Second * 10
+	callbackBatchSize    = 100
+)
+
+var ErrInvalidCallbackURL = errors.New("the callback url must be an https url on a public host")
+
+// Callbacks are posted as json with the headers
+//
+//	X-Gateman-Event      kyc.job.completed
+//	X-Gateman-Timestamp  unix seconds the callback was signed at
+//	X-Gateman-Signature  hex(HMAC-SHA256(callback secret, timestamp + "." + body))
+//
+// Apps should check the signature and reject timestamps far from their clock. A job is delivered at least once,
+// so its id should be used to ignore repeated deliveries.
+const callbackEvent = "kyc.job.completed"
+
+// Returns the signature of a callback body sent at timestamp
+func CallbackSignature(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Claims the next delivery attempt of job and posts its results to the callback url of app.
+// The attempt is claimed on the job first so the retry sweep and the delivery made on completion never post twice at once.
+func deliverCallback(job *entities.KYCJob, app *entities.Application) error {
+	jobRepo := repository.KYCJobRepo()
+	attempts := job.CallbackAttempts + 1
+	claimed, err := jobRepo.UpdateManyWithOperator(map[string]interface{}{
+		"_id":              job.ID,
+		"callbackStatus":   entities.KYCCallbackPending,
+		"callbackAttempts": job.CallbackAttempts,
+	}, map[string]interface{}{
+		"$set": map[string]any{
+			"callbackAttempts": attempts,
+			// the attempt is retried after its backoff unless it is recorded as delivered
+			"nextCallbackAt": time.Now().Add(callbackRetryBackoff * time.Duration(1<<(attempts-1))),
+		},
+	})
+	if err != nil || claimed == 0 {
+		return err
+	}
+
+	err = postCallback(job, app)
+	if err == nil {
+		now := time.Now()
+		jobRepo.UpdatePartialByID(job.ID, map[string]any{
+			"callbackStatus":      entities.KYCCallbackDelivered,
+			"callbackDeliveredAt": now,
+			"nextCallbackAt":      nil,
+		})
+		return nil
+	}
+	if attempts >= callbackMaxAttempts {
+		jobRepo.UpdatePartialByID(job.ID, map[string]any{
+			"callbackStatus": entities.KYCCallbackFailed,
+			"nextCallbackAt": nil,
+		})
+	}
+	return err
+}
+
+func postCallback(job *entities.KYCJob, app *entities.Application) error {
+	if app.KYCCallbackSecret == "" {
+		return errors.New("the app has no callback secret")
+	}
+	result, err := jobResult(job)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]any{
+		"event": callbackEvent,
+		"job":   result,
+	})
+	if err != nil {
+		return err
+	}
+	secret, err := cryptography.DecryptData(app.KYCCallbackSecret, nil)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	client := network.NetworkController{HttpClient: network.PublicHTTPClient(callbackTimeout)}
+	_, statusCode, err := client.Post(*app.KYCCallbackURL, &map[string]string{
+		"X-Gateman-Event":     callbackEvent,
+		"X-Gateman-Timestamp": timestamp,
+		"X-Gateman-Signature": CallbackSignature(secret, timestamp, body),
+	}, json.RawMessage(body), nil, false, nil)
+	if err != nil {
+		return err
+	}
+	if *statusCode < 200 || *statusCode > 299 {
+		return fmt.Errorf("the callback url responded with %d", *statusCode)
+	}
+	return nil
+}
+
+// Retries the callbacks of completed jobs that are due. Jobs whose app no longer has a callback url are failed.
+func RetryCallbacks() error {
+	jobRepo := repository.KYCJobRepo()
+	var lastID *string
+	for {
+		jobs, err := jobRepo.FindManyPaginated(map[string]interface{}{
+			"callbackStatus": entities.KYCCallbackPending,
+			"nextCallbackAt": map[string]any{"$lte": time.Now()},
+		}, callbackBatchSize, lastID, 1)
+		if err != nil {
+			return err
+		}
+		for _, job := range *jobs {
+			lastID = &job.ID
+			app, err := callbackApp(job.AppID)
+			if err != nil {
+				return err
+			}
+			if app == nil || app.KYCCallbackURL == nil {
+				jobRepo.UpdatePartialByID(job.ID, map[string]any{
+					"callbackStatus": entities.KYCCallbackFailed,
+					"nextCallbackAt": nil,
+				})
+				continue
+			}
+			if err := deliverCallback(&job, app); err != nil {
+				logger.Warning("kyc job callback delivery failed", logger.LoggerOptions{
+					Key: "jobID", Data: job.ID,
+				}, logger.LoggerOptions{
+					Key: "attempt", Data: job.CallbackAttempts + 1,
+				}, logger.LoggerOptions{
+					Key: "err", Data: err.Error(),
+				})
+			}
+		}
+		if len(*jobs) < callbackBatchSize {
+			return nil
+		}
+	}
+}
+
+// Checks that callbackURL is an https url. Hosts given as an address must be public,
+// hosts given by name are checked when they are resolved for each delivery.
+func validCallbackURL(callbackURL string) bool {
+	parsed, err := url.Parse(callbackURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Hostname() == "" || parsed.User != nil {
+		return false
+	}
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil {
+		return network.PublicAddress(ip)
+	}
+	return !strings.EqualFold(parsed.Hostname(), "localhost")
+}
+
+// Sets the url the results of KYC jobs of the app with _id are posted to and generates a new secret to sign them with.
+// Returns the plain secret for the workspace to verify callbacks with, only its encrypted form is saved. A nil url removes the callback.
+func UpdateCallbackURL(id string, workspaceID string, callbackURL *string) (*string, bool, error) {
+	appRepo := repository.ApplicationRepo()
+	filter := map[string]interface{}{
+		"_id":         id,
+		"workspaceID": workspaceID,
+	}
+	if callbackURL == nil {
+		updated, err := appRepo.UpdatePartialByFilter(filter, map[string]any{
+			"kycCallbackURL":    nil,
+			"kycCallbackSecret": "",
+		})
+		return nil, updated, err
+	}
+	if !validCallbackURL(*callbackURL) {
+		return nil, false, ErrInvalidCallbackURL
+	}
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, false, err
+	}
+	secret := hex.EncodeToString(secretBytes)
+	encryptedSecret, err := cryptography.EncryptData([]byte(secret), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	updated, err := appRepo.UpdatePartialByFilter(filter, map[string]any{
+		"kycCallbackURL":    *callbackURL,
+		"kycCallbackSecret": *encryptedSecret,
+	})
+	if err != nil || !updated {
+		return nil, updated, err
+	}
+	return &secret, true, nil
+}
@@ -0,0 +1,303 @@
+package kycjob_services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gateman.io/application/repository"
+	metering_services "gateman.io/application/services/metering"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/cryptography"
+	identity_verification_types "gateman.io/infrastructure/identity_verification/types"
+	"gateman.io/infrastructure/logger"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// the most ids a job can be submitted with
+	MaxJobIDs = 100
+	// how long jobs and their results are kept after they are submitted
+	jobRetention = time.Hour * 24 * 7
+	// how many times a lookup is retried while the providers are unavailable
+	LookupMaxRetry = 5
+	// how long a lookup waits before it is retried
+	LookupRetryInterval = time.Second * 30
+)
+
+// A KYC job as returned to the app that submitted it, with the results decrypted
+type JobResult struct {
+	ID          string                      `json:"id"`
+	Check       entities.KYCJobCheck        `json:"check"`
+	Reference   *string                     `json:"reference"`
+	Status      entities.KYCJobStatus       `json:"status"`
+	Sandbox     bool                        `json:"sandbox"`
+	Items       []JobItemResult             `json:"items"`
+	CreatedAt   time.Time                   `json:"createdAt"`
+	CompletedAt *time.Time                  `json:"completedAt"`
+	ExpiresAt   time.Time                   `json:"expiresAt"`
+	Callback    *entities.KYCCallbackStatus `json:"callbackStatus"`
+}
+
+type JobItemResult struct {
+	Index    int                       `json:"index"`
+	MaskedID string                    `json:"maskedID"`
+	Status   entities.KYCJobItemStatus `json:"status"`
+	Data     json.RawMessage           `json:"data"`
+	Error    *string                   `json:"error"`
+}
+
+// Returns id with every character but the last 4 masked
+func maskID(id string) string {
+	if len(id) <= 4 {
+		return strings.Repeat("*", len(id))
+	}
+	return strings.Repeat("*", len(id)-4) + id[len(id)-4:]
+}
+
+// Saves a job that looks up every id with check for appID. The lookups are queued by the caller, one per item.
+func SubmitJob(appID string, workspaceID string, sandbox bool, check entities.KYCJobCheck, ids []string, reference *string, reservation entities.LookupReservation) (*entities.KYCJob, error) {
+	items := []entities.KYCJobItem{}
+	for i, id := range ids {
+		encryptedID, err := cryptography.EncryptData([]byte(id), nil)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, entities.KYCJobItem{
+			Index:       i,
+			MaskedID:    maskID(id),
+			EncryptedID: *encryptedID,
+			Status:      entities.KYCJobItemPending,
+		})
+	}
+	return repository.KYCJobRepo().CreateOne(context.TODO(), entities.KYCJob{
+		AppID:       appID,
+		WorkspaceID: workspaceID,
+		Sandbox:     sandbox,
+		Check:       check,
+		Reference:   reference,
+		Status:      entities.KYCJobProcessing,
+		Items:       items,
+		Remaining:   len(items),
+		ExpiresAt:   time.Now().Add(jobRetention),
+
+		QuotaReservation: reservation,
+	})
+}
+
+// Looks up the item at index of jobID and saves its result.
+// An error is returned while the providers are unavailable so the lookup is retried, on finalAttempt the item is failed instead.
+// A lookup retried after it saved its result only completes the job.
+func ProcessJobItem(jobID string, index int, finalAttempt bool) error {
+	jobRepo := repository.KYCJobRepo()
+	job, err := jobRepo.FindByID(jobID)
+	if err != nil {
+		return err
+	}
+	if job == nil || index < 0 || index >= len(job.Items) {
+		return nil
+	}
+	if job.Items[index].Status != entities.KYCJobItemPending {
+		return completeJob(job)
+	}
+	id, err := cryptography.DecryptData(job.Items[index].EncryptedID, nil)
+	if err != nil {
+		return err
+	}
+
+	update := map[string]any{}
+	record, source, err := metering_services.LookupIdentity(entities.MeteredLookupType(job.Check), string(id))
+	billable := metering_services.Billable(err)
+	switch {
+	case errors.Is(err, identity_verification_types.ErrIdentityProviderUnavailable) && !finalAttempt:
+		return err
+	case errors.Is(err, identity_verification_types.ErrIdentityProviderUnavailable), errors.Is(err, identity_verification_types.ErrUnsupportedIdentityCheck):
+		update["items.$.status"] = entities.KYCJobItemFailed
+		update["items.$.error"] = "the identity provider is unavailable, submit the id again later"
+	case record == nil:
+		update["items.$.status"] = entities.KYCJobItemNotFound
+	default:
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		encryptedData, err := cryptography.EncryptData(data, nil)
+		if err != nil {
+			return err
+		}
+		update["items.$.status"] = entities.KYCJobItemFound
+		update["items.$.data"] = *encryptedData
+	}
+	update["items.$.completedAt"] = time.Now()
+	// the item is only counted off once even when two lookups of it race
+	saved, err := jobRepo.UpdateManyWithOperator(map[string]interface{}{
+		"_id":   jobID,
+		"items": map[string]any{"$elemMatch": map[string]any{"index": index, "status": entities.KYCJobItemPending}},
+	}, map[string]interface{}{
+		"$set": update,
+		"$inc": map[string]any{"remaining": -1},
+	})
+	if err != nil || saved == 0 {
+		return err
+	}
+	recordLookup(job, source, billable)
+	job, err = jobRepo.FindByID(jobID)
+	if err != nil || job == nil {
+		return err
+	}
+	return completeJob(job)
+}
+
+// Records the lookup of an item of job in the metering ledger, or gives the quota reserved for it back when the lookup
+// was not billable. The item is saved already, so a failure is only logged.
+func recordLookup(job *entities.KYCJob, source entities.LookupSource, billable bool) {
+	if !billable {
+		if err := metering_services.ReleaseLookups(job.AppID, job.QuotaReservation, 1); err != nil {
+			logger.Error("an error occured while releasing the quota of a kyc job lookup", logger.LoggerOptions{
+				Key: "jobID", Data: job.ID,
+			}, logger.LoggerOptions{
+				Key: "err", Data: err,
+			})
+		}
+		return
+	}
+	err := metering_services.RecordLookup(entities.MeteredLookup{
+		AppID:       job.AppID,
+		WorkspaceID: job.WorkspaceID,
+		Type:        entities.MeteredLookupType(job.Check),
+		Source:      source,
+		Sandbox:     job.Sandbox,
+		JobID:       &job.ID,
+	})
+	if err != nil {
+		logger.Error("an error occured while recording a metered kyc job lookup", logger.LoggerOptions{
+			Key: "jobID", Data: job.ID,
+		}, logger.LoggerOptions{
+			Key: "err", Data: err,
+		})
+	}
+}
+
+// Marks job completed once none of its items are pending and delivers its results to the callback url of its app.
+// Only one of the lookups that see the last item done completes it.
+func completeJob(job *entities.KYCJob) error {
+	if job.Status != entities.KYCJobProcessing || job.Remaining > 0 {
+		return nil
+	}
+	app, err := callbackApp(job.AppID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	update := map[string]any{
+		"status":      entities.KYCJobCompleted,
+		"completedAt": now,
+	}
+	callback := app != nil && app.KYCCallbackURL != nil
+	if callback {
+		update["callbackStatus"] = entities.KYCCallbackPending
+		update["nextCallbackAt"] = now
+	}
+	completed, err := repository.KYCJobRepo().UpdateManyWithOperator(map[string]interface{}{
+		"_id":    job.ID,
+		"status": entities.KYCJobProcessing,
+	}, map[string]interface{}{
+		"$set": update,
+	})
+	if err != nil || completed == 0 || !callback {
+		return err
+	}
+	pending := entities.KYCCallbackPending
+	job.Status = entities.KYCJobCompleted
+	job.CompletedAt = &now
+	job.CallbackStatus = &pending
+	if err := deliverCallback(job, app); err != nil {
+		logger.Warning("kyc job callback delivery failed, it will be retried", logger.LoggerOptions{
+			Key: "jobID", Data: job.ID,
+		}, logger.LoggerOptions{
+			Key: "err", Data: err.Error(),
+		})
+	}
+	return nil
+}
+
+// Returns jobID with its results if it was submitted by appID for check
+func FetchJob(appID string, check entities.KYCJobCheck, jobID string) (*JobResult, error) {
+	job, err := repository.KYCJobRepo().FindOneByFilter(map[string]interface{}{
+		"_id":   jobID,
+		"appID": appID,
+		"check": check,
+	})
+	if err != nil || job == nil {
+		return nil, err
+	}
+	return jobResult(job)
+}
+
+func jobResult(job *entities.KYCJob) (*JobResult, error) {
+	result := JobResult{
+		ID:          job.ID,
+		Check:       job.Check,
+		Reference:   job.Reference,
+		Status:      job.Status,
+		Sandbox:     job.Sandbox,
+		Items:       []JobItemResult{},
+		CreatedAt:   job.CreatedAt,
+		CompletedAt: job.CompletedAt,
+		ExpiresAt:   job.ExpiresAt,
+		Callback:    job.CallbackStatus,
+	}
+	for _, item := range job.Items {
+		itemResult := JobItemResult{
+			Index:    item.Index,
+			MaskedID: item.MaskedID,
+			Status:   item.Status,
+			Error:    item.Error,
+		}
+		if item.Data != nil {
+			data, err := cryptography.DecryptData(*item.Data, nil)
+			if err != nil {
+				return nil, fmt.Errorf("decrypting result %d of kyc job %s: %w", item.Index, job.ID, err)
+			}
+			itemResult.Data = data
+		}
+		result.Items = append(result.Items, itemResult)
+	}
+	return &result, nil
+}
+
+// Returns a copy of ids without surrounding spaces, or an error naming the first id that is not valid for check
+func NormaliseIDs(check entities.KYCJobCheck, ids []string) ([]string, error) {
+	normalised := []string{}
+	for i, id := range ids {
+		id = strings.TrimSpace(id)
+		valid := id != "" && len(id) <= 32
+		for _, char := range id {
+			if !(char >= '0' && char <= '9') && !(char >= 'A' && char <= 'Z') && !(char >= 'a' && char <= 'z') && char != '-' && char != '/' {
+				valid = false
+			}
+		}
+		// NINs and BVNs are 11 digits
+		if (check == entities.KYCJobNIN || check == entities.KYCJobBVN) && (len(id) != 11 || strings.Trim(id, "0123456789") != "") {
+			valid = false
+		}
+		if !valid {
+			return nil, fmt.Errorf("id %d is not a valid %s", i, strings.ReplaceAll(string(check), "_", " "))
+		}
+		normalised = append(normalised, id)
+	}
+	return normalised, nil
+}
+
+func callbackApp(appID string) (*entities.Application, error) {
+	return repository.ApplicationRepo().FindOneByFilter(map[string]interface{}{
+		"appID": appID,
+	}, options.FindOne().SetProjection(map[string]any{
+		"appID":             1,
+		"kycCallbackURL":    1,
+		"kycCallbackSecret": 1,
+	}))
+}
@@ -0,0 +1,237 @@
+package metering_services
+
+import (
+	"time"
+
+	"gateman.io/application/repository"
+	"gateman.io/application/utils"
+	"gateman.io/entities"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Overage of one plan and category of lookups, added as a line to a charge
+type OverageLine struct {
+	Plan      entities.SubscriptionPlanName  `json:"plan"`
+	Category  entities.MeteredLookupCategory `json:"category"`
+	Lookups   int64                          `json:"lookups"`
+	UnitPrice int64                          `json:"unitPrice"`
+	Amount    int64                          `json:"amount"`
+}
+
+type OverageCharge struct {
+	Lines []OverageLine `json:"lines"`
+	Total int64         `json:"total"`
+
+	appID  string
+	before time.Time
+}
+
+func startOfPeriod(now time.Time) time.Time {
+	year, month, _ := now.Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+}
+
+// Prices the overage lookups matching filter at the overage price of the plan the app was on when each was made
+func overageLines(filter map[string]interface{}) ([]OverageLine, int64, error) {
+	lines := []OverageLine{}
+	var total int64
+	for _, plan := range []entities.SubscriptionPlanName{entities.Essential, entities.Premium} {
+		for _, category := range []entities.MeteredLookupCategory{entities.GovernmentIDLookups, entities.BiometricLookups} {
+			quota := PlanQuota(plan, category)
+			if quota.OveragePrice == 0 {
+				continue
+			}
+			lineFilter := map[string]interface{}{
+				"overage":  true,
+				"plan":     plan,
+				"category": category,
+			}
+			for key, value := range filter {
+				lineFilter[key] = value
+			}
+			lookups, err := repository.MeteredLookupRepo().CountDocs(lineFilter)
+			if err != nil {
+				return nil, 0, err
+			}
+			if lookups == 0 {
+				continue
+			}
+			line := OverageLine{
+				Plan:      plan,
+				Category:  category,
+				Lookups:   lookups,
+				UnitPrice: quota.OveragePrice,
+				Amount:    lookups * quota.OveragePrice,
+			}
+			lines = append(lines, line)
+			total += line.Amount
+		}
+	}
+	return lines, total, nil
+}
+
+func unbilledFilter(appID string, before time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"appID":     appID,
+		"overage":   true,
+		"sandbox":   false,
+		"billedAt":  nil,
+		"createdAt": map[string]any{"$lt": before},
+	}
+}
+
+// Returns the overage of the app with the public appID that was not billed yet. Only months that have ended are billed,
+// so a charge never holds part of the overage of a month.
+func UnbilledOverage(appID string) (*OverageCharge, error) {
+	charge := OverageCharge{
+		appID:  appID,
+		before: startOfPeriod(time.Now()),
+	}
+	lines, total, err := overageLines(unbilledFilter(charge.appID, charge.before))
+	if err != nil {
+		return nil, err
+	}
+	charge.Lines = lines
+	charge.Total = total
+	return &charge, nil
+}
+
+// Marks the lookups of charge as billed once it has been charged
+func MarkOverageBilled(charge *OverageCharge) error {
+	if charge.Total == 0 {
+		return nil
+	}
+	_, err := repository.MeteredLookupRepo().UpdateManyWithOperator(unbilledFilter(charge.appID, charge.before), map[string]interface{}{
+		"$set": map[string]any{"billedAt": time.Now()},
+	})
+	return err
+}
+
+// Returns the public appIDs of apps with lookups in months that have ended whose overage has not been settled yet
+func AppsWithUnsettledOverage() ([]string, error) {
+	counterRepo := repository.LookupQuotaCounterRepo()
+	seen := map[string]bool{}
+	appIDs := []string{}
+	var lastID *string
+	for {
+		counters, err := counterRepo.FindManyPaginated(map[string]interface{}{
+			"period":           map[string]any{"$lt": CurrentPeriod()},
+			"overageSettledAt": nil,
+		}, 500, lastID, 1, options.Find().SetProjection(map[string]any{"appID": 1}))
+		if err != nil {
+			return nil, err
+		}
+		for _, counter := range *counters {
+			lastID = utils.GetStringPointer(counter.ID)
+			if !seen[counter.AppID] {
+				seen[counter.AppID] = true
+				appIDs = append(appIDs, counter.AppID)
+			}
+		}
+		if len(*counters) < 500 {
+			return appIDs, nil
+		}
+	}
+}
+
+// Marks the overage of the months of the app that ended before charge was priced as settled, once it has been charged
+// or found to be nothing
+func SettleOverage(charge *OverageCharge) error {
+	_, err := repository.LookupQuotaCounterRepo().UpdateManyWithOperator(map[string]interface{}{
+		"appID":            charge.appID,
+		"period":           map[string]any{"$lt": charge.before.Format("2006-01")},
+		"overageSettledAt": nil,
+	}, map[string]interface{}{
+		"$set": map[string]any{"overageSettledAt": time.Now()},
+	})
+	return err
+}
+
+type LookupUsage struct {
+	Type     entities.MeteredLookupType `json:"type"`
+	Cache    int64                      `json:"cache"`
+	Provider int64                      `json:"provider"`
+}
+
+type CategoryUsage struct {
+	Category entities.MeteredLookupCategory `json:"category"`
+	Quota    Quota                          `json:"quota"`
+	Used     int64                          `json:"used"`
+	Overage  int64                          `json:"overage"`
+}
+
+type UsageReport struct {
+	Period     string                        `json:"period"`
+	Plan       entities.SubscriptionPlanName `json:"plan"`
+	Lookups    []LookupUsage                 `json:"lookups"`
+	Categories []CategoryUsage               `json:"categories"`
+	// the overage of the month priced for its next charge, it is only charged once the month has ended
+	OverageLines  []OverageLine `json:"overageLines"`
+	OverageAmount int64         `json:"overageAmount"`
+}
+
+// Returns the lookups the app with the public appID made in period by type and source,
+// and how they count against the quotas of the plan it is on now. Sandbox lookups are left out.
+func Usage(appID string, period string) (*UsageReport, error) {
+	plan, err := AppPlan(appID)
+	if err != nil {
+		return nil, err
+	}
+	report := UsageReport{
+		Period:     period,
+		Plan:       plan,
+		Lookups:    []LookupUsage{},
+		Categories: []CategoryUsage{},
+	}
+	lookupRepo := repository.MeteredLookupRepo()
+	used := map[entities.MeteredLookupCategory]int64{}
+	for _, lookupType := range entities.MeteredLookupTypes {
+		usage := LookupUsage{Type: lookupType}
+		for _, source := range []entities.LookupSource{entities.CacheLookup, entities.ProviderLookup} {
+			lookups, err := lookupRepo.CountDocs(map[string]interface{}{
+				"appID":   appID,
+				"period":  period,
+				"type":    lookupType,
+				"source":  source,
+				"sandbox": false,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if source == entities.CacheLookup {
+				usage.Cache = lookups
+			} else {
+				usage.Provider = lookups
+			}
+		}
+		used[lookupType.Category()] += usage.Cache + usage.Provider
+		report.Lookups = append(report.Lookups, usage)
+	}
+	for _, category := range []entities.MeteredLookupCategory{entities.GovernmentIDLookups, entities.BiometricLookups} {
+		overage, err := lookupRepo.CountDocs(map[string]interface{}{
+			"appID":    appID,
+			"period":   period,
+			"category": category,
+			"overage":  true,
+			"sandbox":  false,
+		})
+		if err != nil {
+			return nil, err
+		}
+		report.Categories = append(report.Categories, CategoryUsage{
+			Category: category,
+			Quota:    PlanQuota(plan, category),
+			Used:     used[category],
+			Overage:  overage,
+		})
+	}
+	report.OverageLines, report.OverageAmount, err = overageLines(map[string]interface{}{
+		"appID":   appID,
+		"period":  period,
+		"sandbox": false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
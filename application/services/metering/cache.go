@@ -0,0 +1,90 @@
+package metering_services
+
+import (
+	"fmt"
+	"time"
+
+	dataprotection_services "gateman.io/application/services/dataprotection"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/cryptography"
+	identityverification "gateman.io/infrastructure/identity_verification"
+	identity_verification_types "gateman.io/infrastructure/identity_verification/types"
+	"gateman.io/infrastructure/logger"
+)
+
+// how long a record found by a provider answers lookups of its id
+const lookupCacheTTL = time.Hour * 24
+
+var lookupIndexDomains = map[entities.MeteredLookupType]cryptography.BlindIndexDomain{
+	entities.NINLookup:            cryptography.NINIndex,
+	entities.BVNLookup:            cryptography.BVNIndex,
+	entities.VotersCardLookup:     cryptography.VoterIDIndex,
+	entities.DriversLicenseLookup: cryptography.DriverIDIndex,
+}
+
+// Looks up an id with the identity verification providers, or the lookup cache when the id was found in the last day.
+// Records are cached under the blind index of the id and protected with a data key of their own, so the record of an
+// id can be erased by shredding its key with LookupSubject. Ids that were not found are not cached.
+// The returned error wraps ErrIdentityProviderUnavailable when the lookup should be retried,
+// a nil record without an error means the id was not found.
+func LookupIdentity(lookupType entities.MeteredLookupType, id string) (any, entities.LookupSource, error) {
+	switch lookupType {
+	case entities.NINLookup:
+		record, source, err := cachedLookup(lookupType, id, identityverification.IdentityVerifier.FetchNINDetails)
+		if record == nil {
+			return nil, source, err
+		}
+		return record, source, nil
+	case entities.BVNLookup:
+		record, source, err := cachedLookup(lookupType, id, identityverification.IdentityVerifier.FetchBVNDetails)
+		if record == nil {
+			return nil, source, err
+		}
+		return record, source, nil
+	case entities.VotersCardLookup:
+		record, source, err := cachedLookup(lookupType, id, identityverification.IdentityVerifier.FetchVoterIDDetails)
+		if record == nil {
+			return nil, source, err
+		}
+		return record, source, nil
+	case entities.DriversLicenseLookup:
+		record, source, err := cachedLookup(lookupType, id, identityverification.IdentityVerifier.FetchDriverIDDetails)
+		if record == nil {
+			return nil, source, err
+		}
+		return record, source, nil
+	}
+	return nil, entities.ProviderLookup, identity_verification_types.ErrUnsupportedIdentityCheck
+}
+
+// Returns the data protection subject the cached lookup of an id is protected as
+func LookupSubject(lookupType entities.MeteredLookupType, index string) string {
+	return fmt.Sprintf("kyc-lookup:%s:%s", lookupType, index)
+}
+
+func cachedLookup[T any](lookupType entities.MeteredLookupType, id string, fetch func(string) (*T, error)) (*T, entities.LookupSource, error) {
+	index := cryptography.BlindIndex(lookupIndexDomains[lookupType], id)
+	subject := LookupSubject(lookupType, index)
+	var cached T
+	// an entry that cannot be read, like one whose key was shredded, is looked up again
+	if found, err := dataprotection_services.FindAllProtected(subject, index, &cached); err == nil && found {
+		return &cached, entities.CacheLookup, nil
+	}
+	record, err := fetch(id)
+	if record == nil {
+		return nil, entities.ProviderLookup, err
+	}
+	if err := dataprotection_services.CacheProtected(subject, index, record, lookupCacheTTL); err != nil {
+		logger.Error("an error occured while caching a kyc lookup", logger.LoggerOptions{
+			Key: "lookupType", Data: lookupType,
+		}, logger.LoggerOptions{
+			Key: "err", Data: err,
+		})
+	}
+	return record, entities.ProviderLookup, nil
+}
+
+// Erases the cached lookup of the id stored under the blind index, such as the identity numbers of a user erasing their data
+func EraseCachedLookup(lookupType entities.MeteredLookupType, index string) error {
+	return dataprotection_services.EraseSubject(LookupSubject(lookupType, index), index)
+}
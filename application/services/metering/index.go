@@ -0,0 +1,186 @@
+package metering_services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gateman.io/application/constants"
+	"gateman.io/application/repository"
+	"gateman.io/application/utils"
+	"gateman.io/entities"
+	identity_verification_types "gateman.io/infrastructure/identity_verification/types"
+	"gateman.io/infrastructure/metrics"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrLookupQuotaExceeded = errors.New("this app has used the lookups its plan includes this month, upgrade the plan to make more")
+
+// The monthly quota of a plan for a category of lookups
+type Quota struct {
+	Included  int64 `json:"included"`
+	Unlimited bool  `json:"unlimited"`
+	// the price in kobo of every lookup past Included. Apps on plans without one are stopped at Included.
+	OveragePrice int64 `json:"overagePrice"`
+}
+
+func PlanQuota(plan entities.SubscriptionPlanName, category entities.MeteredLookupCategory) Quota {
+	switch plan {
+	case entities.Premium:
+		if category == entities.GovernmentIDLookups {
+			return Quota{Unlimited: true}
+		}
+		return Quota{Included: constants.PREMIUM_TIER_BIOMETRIC_LOOKUP_LIMIT, OveragePrice: constants.PREMIUM_TIER_BIOMETRIC_LOOKUP_PRICE}
+	case entities.Essential:
+		if category == entities.GovernmentIDLookups {
+			return Quota{Included: constants.ESSENTIAL_TIER_ID_LOOKUP_LIMIT, OveragePrice: constants.ESSENTIAL_TIER_ID_LOOKUP_PRICE}
+		}
+		return Quota{Included: constants.ESSENTIAL_TIER_BIOMETRIC_LOOKUP_LIMIT, OveragePrice: constants.ESSENTIAL_TIER_BIOMETRIC_LOOKUP_PRICE}
+	}
+	if category == entities.GovernmentIDLookups {
+		return Quota{Included: constants.FREE_TIER_ID_LOOKUP_LIMIT}
+	}
+	return Quota{Included: constants.FREE_TIER_BIOMETRIC_LOOKUP_LIMIT}
+}
+
+// Quotas reset at the start of every month
+func CurrentPeriod() string {
+	return time.Now().Format("2006-01")
+}
+
+// Returns the plan of the app with the public appID. Apps without a subscription are on the Free plan.
+func AppPlan(appID string) (entities.SubscriptionPlanName, error) {
+	app, err := repository.ApplicationRepo().FindOneByFilter(map[string]interface{}{
+		"appID": appID,
+	}, options.FindOne().SetProjection(map[string]any{
+		"_id": 1,
+	}))
+	if err != nil {
+		return "", err
+	}
+	if app == nil {
+		return entities.Free, nil
+	}
+	activeSub, err := repository.ActiveSubscriptionRepo().FindOneByFilter(map[string]interface{}{
+		"appID": app.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+	if activeSub == nil || activeSub.ActiveSubName == "" {
+		return entities.Free, nil
+	}
+	return activeSub.ActiveSubName, nil
+}
+
+func counterFilter(appID string, period string, category entities.MeteredLookupCategory) map[string]interface{} {
+	return map[string]interface{}{
+		"appID":    appID,
+		"period":   period,
+		"category": category,
+	}
+}
+
+// Applies update to the counter matching filter, creating the counter when upsert is set, and returns it after the update.
+// Returns nil when no counter matched and none was created.
+func updateCounter(filter map[string]interface{}, update map[string]interface{}, upsert bool) (*entities.LookupQuotaCounter, error) {
+	now := time.Now()
+	update["$set"] = map[string]any{"updatedAt": now}
+	update["$setOnInsert"] = map[string]any{
+		"_id":              utils.GenerateUULDString(),
+		"overageSettledAt": nil,
+		"createdAt":        now,
+	}
+	counterRepo := repository.LookupQuotaCounterRepo()
+	counter, err := counterRepo.FindOneAndUpdateWithOperator(filter, update, options.FindOneAndUpdate().SetUpsert(upsert).SetReturnDocument(options.After))
+	// the counter was created by a concurrent update or it exists and did not match filter, so it is not created again
+	if upsert && mongo.IsDuplicateKeyError(err) {
+		return counterRepo.FindOneAndUpdateWithOperator(filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	}
+	return counter, err
+}
+
+// Reserves count lookups of lookupType against the quota of the plan of appID for this month before they are made.
+// Returns ErrLookupQuotaExceeded when the plan stops the app from making them. Sandbox lookups, and lookups on plans
+// that are unlimited or bill overage, reserve nothing.
+// The reservation holds the plan so the lookups can be recorded without fetching it again.
+func ReserveLookups(appID string, lookupType entities.MeteredLookupType, count int, sandbox bool) (entities.LookupReservation, error) {
+	reservation := entities.LookupReservation{
+		Category: lookupType.Category(),
+		Period:   CurrentPeriod(),
+	}
+	plan, err := AppPlan(appID)
+	if err != nil {
+		return reservation, err
+	}
+	reservation.Plan = plan
+	quota := PlanQuota(plan, reservation.Category)
+	if sandbox || quota.Unlimited || quota.OveragePrice != 0 {
+		return reservation, nil
+	}
+	if int64(count) > quota.Included {
+		return reservation, ErrLookupQuotaExceeded
+	}
+	// the counter only matches while the lookups fit, so concurrent reservations can not go past the quota together
+	filter := counterFilter(appID, reservation.Period, reservation.Category)
+	filter["reserved"] = map[string]any{"$lte": quota.Included - int64(count)}
+	counter, err := updateCounter(filter, map[string]interface{}{
+		"$inc": map[string]any{"reserved": count},
+	}, true)
+	if err != nil {
+		return reservation, err
+	}
+	if counter == nil {
+		return reservation, ErrLookupQuotaExceeded
+	}
+	reservation.Count = int64(count)
+	return reservation, nil
+}
+
+// Gives count lookups of reservation back to the quota of appID, for lookups that were reserved but not made
+func ReleaseLookups(appID string, reservation entities.LookupReservation, count int64) error {
+	if reservation.Count == 0 || count == 0 {
+		return nil
+	}
+	_, err := updateCounter(counterFilter(appID, reservation.Period, reservation.Category), map[string]interface{}{
+		"$inc": map[string]any{"reserved": -count},
+	}, false)
+	return err
+}
+
+// Records a billable lookup in the ledger. Its period and whether it is past the quota of the plan are set here, along with
+// the plan when it is not set already. Sandbox lookups are recorded for the usage report but never count against a quota.
+func RecordLookup(lookup entities.MeteredLookup) error {
+	if lookup.Plan == "" {
+		plan, err := AppPlan(lookup.AppID)
+		if err != nil {
+			return err
+		}
+		lookup.Plan = plan
+	}
+	lookup.Category = lookup.Type.Category()
+	lookup.Period = CurrentPeriod()
+	lookup.Overage = false
+	quota := PlanQuota(lookup.Plan, lookup.Category)
+	if !quota.Unlimited && !lookup.Sandbox {
+		counter, err := updateCounter(counterFilter(lookup.AppID, lookup.Period, lookup.Category), map[string]interface{}{
+			"$inc": map[string]any{"recorded": 1},
+		}, true)
+		if err != nil {
+			return err
+		}
+		lookup.Overage = counter != nil && counter.Recorded > quota.Included
+	}
+	if _, err := repository.MeteredLookupRepo().CreateOne(context.TODO(), lookup); err != nil {
+		return err
+	}
+	metrics.MeteredLookups.WithLabelValues(string(lookup.Type), string(lookup.Source)).Inc()
+	return nil
+}
+
+// Returns whether a lookup that failed with err was still answered, and billed, by a provider.
+// Providers bill ids they could not find, they do not bill requests they could not serve.
+func Billable(err error) bool {
+	return !errors.Is(err, identity_verification_types.ErrIdentityProviderUnavailable) && !errors.Is(err, identity_verification_types.ErrUnsupportedIdentityCheck)
+}
@@ -0,0 +1,169 @@
+package mfa_services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gateman.io/application/utils"
+	"gateman.io/infrastructure/cryptography"
+	"gateman.io/infrastructure/database/repository/cache"
+	"gateman.io/infrastructure/totp"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrMFAEnrollmentExpired = errors.New("mfa enrollment has expired")
+var ErrMFALocked = errors.New("too many incorrect mfa codes")
+
+const RecoveryCodeCount = 10
+
+// an owner can get MaxFailedCodeAttempts authenticator, recovery or enrollment codes wrong before codes are refused for MFALockoutPeriod
+const (
+	MaxFailedCodeAttempts = 5
+	MFALockoutPeriod      = time.Minute * 15
+)
+
+// KEYS: failed attempts. ARGV: lockout period in seconds.
+// The window starts at the first failure so later failures do not keep extending it.
+var recordFailedCodeScript = redis.NewScript(`
+local attempts = redis.call('INCR', KEYS[1])
+if attempts == 1 then
+	redis.call('EXPIRE', KEYS[1], ARGV[1])
+end
+return attempts
+`)
+
+func enrollmentKey(ownerID string) string {
+	return fmt.Sprintf("mfa-enrollment:%s", ownerID)
+}
+
+func usedCodeKey(ownerID string, code string) string {
+	return fmt.Sprintf("mfa-used:%s:%s", ownerID, code)
+}
+
+func failedCodesKey(ownerID string) string {
+	return fmt.Sprintf("mfa-failed:%s", ownerID)
+}
+
+func isLockedOut(ownerID string) bool {
+	attempts := cache.Cache.FindOne(failedCodesKey(ownerID))
+	if attempts == nil {
+		return false
+	}
+	count, err := strconv.Atoi(*attempts)
+	return err != nil || count >= MaxFailedCodeAttempts
+}
+
+func recordFailedCode(ownerID string) error {
+	_, err := cache.Cache.RunScript(recordFailedCodeScript, []string{failedCodesKey(ownerID)}, int64(MFALockoutPeriod.Seconds()))
+	return err
+}
+
+// Generates a TOTP secret for ownerID. The secret is held for 10 minutes until a code from it is confirmed.
+func StartEnrollment(ownerID string, accountName string) (url *string, secret *string, err error) {
+	secret, url, err = totp.TOTPService.GenerateSecret(accountName)
+	if err != nil {
+		return nil, nil, err
+	}
+	encryptedSecret, err := cryptography.EncryptData([]byte(*secret), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !cache.Cache.CreateEntry(enrollmentKey(ownerID), *encryptedSecret, time.Minute*10) {
+		return nil, nil, errors.New("could not save mfa enrollment")
+	}
+	return url, secret, nil
+}
+
+// Returns the encrypted pending secret of ownerID if code was generated from it.
+// The caller saves the secret and then calls CompleteEnrollment. Wrong codes count towards the lockout of VerifyCode.
+func ConfirmEnrollment(ownerID string, code string) (encryptedSecret *string, confirmed bool, err error) {
+	if isLockedOut(ownerID) {
+		return nil, false, ErrMFALocked
+	}
+	encryptedSecret = cache.Cache.FindOne(enrollmentKey(ownerID))
+	if encryptedSecret == nil {
+		return nil, false, ErrMFAEnrollmentExpired
+	}
+	secret, err := cryptography.DecryptData(*encryptedSecret, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if !totp.TOTPService.ValidateTOTP(code, string(secret)) {
+		if err := recordFailedCode(ownerID); err != nil {
+			return nil, false, err
+		}
+		return nil, false, nil
+	}
+	return encryptedSecret, true, nil
+}
+
+// Clears the pending secret and burns the code used to confirm it so it cannot also be used to sign in
+func CompleteEnrollment(ownerID string, code string) {
+	cache.Cache.DeleteOne(enrollmentKey(ownerID))
+	cache.Cache.CreateEntry(usedCodeKey(ownerID, code), true, time.Second*90)
+}
+
+// Validates a code against the encrypted secret of ownerID. A code can only be used once within its validity window.
+// ErrMFALocked is returned once the owner has sent MaxFailedCodeAttempts wrong codes within MFALockoutPeriod.
+func VerifyCode(ownerID string, encryptedSecret string, code string) (bool, error) {
+	if isLockedOut(ownerID) {
+		return false, ErrMFALocked
+	}
+	secret, err := cryptography.DecryptData(encryptedSecret, nil)
+	if err != nil {
+		return false, err
+	}
+	if !totp.TOTPService.ValidateTOTP(code, string(secret)) {
+		if err := recordFailedCode(ownerID); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+	// claiming the code is what accepts it so two requests racing with the same code can not both pass
+	if !cache.Cache.CreateEntryIfNotExists(usedCodeKey(ownerID, code), true, time.Second*90) {
+		return false, nil
+	}
+	cache.Cache.DeleteOne(failedCodesKey(ownerID))
+	return true, nil
+}
+
+// Generates recovery codes along with the hashes that should be stored
+func GenerateRecoveryCodes() (recoveryCodes []string, hashedRecoveryCodes []string, err error) {
+	recoveryCodes, err = utils.GenerateAccountRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, code := range recoveryCodes {
+		hashedCode, err := cryptography.CryptoHahser.HashString(code, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		hashedRecoveryCodes = append(hashedRecoveryCodes, string(hashedCode))
+	}
+	return recoveryCodes, hashedRecoveryCodes, nil
+}
+
+// Returns the stored hash of ownerID that matches code and the hashes left once it is used.
+// Wrong codes count towards the lockout of VerifyCode and ErrMFALocked is returned while ownerID is locked out.
+func MatchRecoveryCode(ownerID string, hashedRecoveryCodes *[]string, code string) (matchedHash *string, remaining []string, err error) {
+	if isLockedOut(ownerID) {
+		return nil, nil, ErrMFALocked
+	}
+	if hashedRecoveryCodes != nil {
+		for i, hashedCode := range *hashedRecoveryCodes {
+			if !cryptography.CryptoHahser.VerifyHashData(hashedCode, code) {
+				continue
+			}
+			remaining = append([]string{}, (*hashedRecoveryCodes)[:i]...)
+			remaining = append(remaining, (*hashedRecoveryCodes)[i+1:]...)
+			cache.Cache.DeleteOne(failedCodesKey(ownerID))
+			return &(*hashedRecoveryCodes)[i], remaining, nil
+		}
+	}
+	if err := recordFailedCode(ownerID); err != nil {
+		return nil, nil, err
+	}
+	return nil, nil, nil
+}
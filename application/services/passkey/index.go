@@ -0,0 +1,278 @@
+package passkey_services
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"gateman.io/application/repository"
+	security_services "gateman.io/application/services/security"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/auth"
+	"gateman.io/infrastructure/database/repository/cache"
+	"gateman.io/infrastructure/webauthn"
+)
+
+var (
+	ErrPasskeyChallengeExpired  = errors.New("passkey challenge has expired")
+	ErrPasskeyNotFound          = errors.New("passkey not found")
+	ErrPasskeyAlreadyRegistered = errors.New("passkey has already been registered")
+	ErrPasskeyLimitReached      = errors.New("passkey limit reached")
+)
+
+const MaxPasskeysPerUser = 10
+
+// how long the user has to complete a ceremony with their authenticator
+const ceremonyTimeout = time.Minute * 5
+
+func registrationChallengeKey(userID string) string {
+	return fmt.Sprintf("passkey-registration:%s", userID)
+}
+
+func loginChallengeKey(deviceID string) string {
+	return fmt.Sprintf("passkey-login:%s", deviceID)
+}
+
+// takes the challenge of a ceremony so it can only be answered once
+func consumeChallenge(key string) (*string, error) {
+	challenge := cache.Cache.FindAndDeleteOne(key)
+	if challenge == nil {
+		return nil, ErrPasskeyChallengeExpired
+	}
+	return challenge, nil
+}
+
+func credentialDescriptors(passkeys []entities.PasskeyCredential) []map[string]any {
+	descriptors := []map[string]any{}
+	for _, passkey := range passkeys {
+		descriptors = append(descriptors, map[string]any{
+			"type":       "public-key",
+			"id":         passkey.ID,
+			"transports": passkey.Transports,
+		})
+	}
+	return descriptors
+}
+
+// Generates the options passed to navigator.credentials.create() when the user adds a passkey
+func RegistrationOptions(user *entities.User) (map[string]any, error) {
+	if len(user.Passkeys) >= MaxPasskeysPerUser {
+		return nil, ErrPasskeyLimitReached
+	}
+	challenge, err := webauthn.GenerateChallenge()
+	if err != nil {
+		return nil, err
+	}
+	if !cache.Cache.CreateEntry(registrationChallengeKey(user.ID), challenge, ceremonyTimeout) {
+		return nil, errors.New("could not save passkey registration challenge")
+	}
+	accountName := ""
+	if user.Email != nil {
+		accountName = *user.Email
+	} else if user.Phone != nil {
+		accountName = user.Phone.ParsePhoneNumber()
+	}
+	algorithms := []map[string]any{}
+	for _, algorithm := range webauthn.SupportedAlgorithms {
+		algorithms = append(algorithms, map[string]any{
+			"type": "public-key",
+			"alg":  algorithm,
+		})
+	}
+	rp := webauthn.DefaultRelyingParty()
+	return map[string]any{
+		"challenge": challenge,
+		"rp": map[string]any{
+			"id":   rp.ID,
+			"name": rp.Name,
+		},
+		"user": map[string]any{
+			"id":          base64.RawURLEncoding.EncodeToString([]byte(user.ID)),
+			"name":        accountName,
+			"displayName": accountName,
+		},
+		"pubKeyCredParams": algorithms,
+		"timeout":          ceremonyTimeout.Milliseconds(),
+		"attestation":      "none",
+		"authenticatorSelection": map[string]any{
+			"residentKey":      "required",
+			"userVerification": "required",
+		},
+		"excludeCredentials": credentialDescriptors(user.Passkeys),
+	}, nil
+}
+
+// Verifies the registration response against the user's pending challenge and saves the new passkey
+func RegisterPasskey(user *entities.User, name string, credentialID string, clientDataJSON []byte, attestationObject []byte, transports []string) (*entities.PasskeyCredential, error) {
+	challenge, err := consumeChallenge(registrationChallengeKey(user.ID))
+	if err != nil {
+		return nil, err
+	}
+	credential, err := webauthn.DefaultRelyingParty().VerifyRegistration(*challenge, clientDataJSON, attestationObject)
+	if err != nil {
+		return nil, err
+	}
+	encodedID := base64.RawURLEncoding.EncodeToString(credential.ID)
+	if encodedID != credentialID {
+		return nil, webauthn.ErrMalformedResponse
+	}
+	userRepo := repository.UserRepo()
+	existing, err := userRepo.CountDocs(map[string]interface{}{
+		"passkeys.id": encodedID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if existing != 0 {
+		return nil, ErrPasskeyAlreadyRegistered
+	}
+	passkey := entities.PasskeyCredential{
+		ID:             encodedID,
+		PublicKey:      credential.PublicKey,
+		Algorithm:      credential.Algorithm,
+		SignCount:      credential.SignCount,
+		AAGUID:         hex.EncodeToString(credential.AAGUID),
+		Name:           name,
+		Transports:     transports,
+		BackupEligible: credential.BackupEligible,
+		CreatedAt:      time.Now(),
+	}
+	_, err = userRepo.UpdateWithOperator(map[string]interface{}{
+		"_id": user.ID,
+	}, map[string]interface{}{
+		"$push": map[string]any{
+			"passkeys": passkey,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &passkey, nil
+}
+
+// Generates the options passed to navigator.credentials.get() on deviceID.
+// allowCredentials is only filled when the user is known, otherwise the authenticator offers its discoverable passkeys.
+func LoginOptions(deviceID string, user *entities.User) (map[string]any, error) {
+	challenge, err := webauthn.GenerateChallenge()
+	if err != nil {
+		return nil, err
+	}
+	if !cache.Cache.CreateEntry(loginChallengeKey(deviceID), challenge, ceremonyTimeout) {
+		return nil, errors.New("could not save passkey login challenge")
+	}
+	allowedCredentials := []map[string]any{}
+	if user != nil {
+		allowedCredentials = credentialDescriptors(user.Passkeys)
+	}
+	return map[string]any{
+		"challenge":        challenge,
+		"rpId":             webauthn.DefaultRelyingParty().ID,
+		"timeout":          ceremonyTimeout.Milliseconds(),
+		"userVerification": "required",
+		"allowCredentials": allowedCredentials,
+	}, nil
+}
+
+// Verifies an assertion made on deviceID and returns the user who owns the passkey.
+// A signature counter that goes backwards is recorded as a security event since it points to a cloned authenticator.
+func VerifyPasskeyLogin(deviceID string, credentialID string, userHandle *string, clientDataJSON []byte, authenticatorData []byte, signature []byte, ipAddress string) (*entities.User, error) {
+	challenge, err := consumeChallenge(loginChallengeKey(deviceID))
+	if err != nil {
+		return nil, err
+	}
+	userRepo := repository.UserRepo()
+	user, err := userRepo.FindOneByFilter(map[string]interface{}{
+		"passkeys.id": credentialID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrPasskeyNotFound
+	}
+	if userHandle != nil {
+		handle, err := webauthn.DecodeBase64URL(*userHandle)
+		if err != nil || subtle.ConstantTimeCompare(handle, []byte(user.ID)) != 1 {
+			return nil, ErrPasskeyNotFound
+		}
+	}
+	var passkey *entities.PasskeyCredential
+	for i := range user.Passkeys {
+		if user.Passkeys[i].ID == credentialID {
+			passkey = &user.Passkeys[i]
+			break
+		}
+	}
+	if passkey == nil {
+		return nil, ErrPasskeyNotFound
+	}
+	signCount, err := webauthn.DefaultRelyingParty().VerifyAssertion(*challenge, passkey.PublicKey, passkey.SignCount, clientDataJSON, authenticatorData, signature)
+	if errors.Is(err, webauthn.ErrSignCountRegressed) {
+		security_services.RecordSecurityEvent(entities.SecurityEvent{
+			Type:      entities.PasskeyCloneSuspected,
+			ActorID:   &user.ID,
+			ActorType: string(auth.UserTokenFamily),
+			DeviceID:  &deviceID,
+			IPAddress: &ipAddress,
+			Metadata: map[string]any{
+				"credentialID":      credentialID,
+				"storedSignCount":   passkey.SignCount,
+				"receivedSignCount": signCount,
+			},
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the filter on the stored counter stops a concurrent login that saw a newer counter from being overwritten
+	updated, err := userRepo.UpdatePartialByFilter(map[string]interface{}{
+		"_id": user.ID,
+		"passkeys": map[string]any{
+			"$elemMatch": map[string]any{
+				"id":        credentialID,
+				"signCount": passkey.SignCount,
+			},
+		},
+	}, map[string]any{
+		"passkeys.$.signCount":  signCount,
+		"passkeys.$.lastUsedAt": time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, webauthn.ErrSignCountRegressed
+	}
+	return user, nil
+}
+
+// Removes one of the user's passkeys. Returns false if the user has no passkey with credentialID.
+func DeletePasskey(user *entities.User, credentialID string) (bool, error) {
+	found := false
+	for _, passkey := range user.Passkeys {
+		if passkey.ID == credentialID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false, nil
+	}
+	userRepo := repository.UserRepo()
+	_, err := userRepo.UpdateWithOperator(map[string]interface{}{
+		"_id": user.ID,
+	}, map[string]interface{}{
+		"$pull": map[string]any{
+			"passkeys": map[string]any{
+				"id": credentialID,
+			},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,209 @@
+package screening_services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gateman.io/application/repository"
+	identitymatch_services "gateman.io/application/services/identitymatch"
+	"gateman.io/application/utils"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/logger"
+	"gateman.io/infrastructure/metrics"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const rescreeningBatchSize = 200
+
+// Screens the identity verified for userID against every loaded list and records a hit for each entry it matched.
+// An entry matches when one of its names matches the verified name and, when both have one, a date of birth the entry
+// was published with agrees with the verified date of birth as far as it was published. Returns the hits that are new.
+func ScreenUser(userID string, identity identitymatch_services.Identity, trigger entities.ScreeningTrigger) ([]entities.ScreeningHit, error) {
+	loaded, err := currentLists()
+	if err != nil {
+		return nil, err
+	}
+	lastNames := identitymatch_services.NameTokens(identity.LastName)
+	firstNames := identitymatch_services.NameTokens(identity.FirstName)
+	hits := []entities.ScreeningHit{}
+	for _, entry := range loaded.entries {
+		// names on lists are compared in any order, so the entry only needs tokens opening like both names
+		if len(lastNames) == 0 || len(firstNames) == 0 || !entry.initials[lastNames[0][0]] || !entry.initials[firstNames[0][0]] {
+			continue
+		}
+		hit := matchEntry(entry, identity)
+		if hit == nil {
+			continue
+		}
+		hit.UserID = userID
+		hit.Trigger = trigger
+		recorded, err := recordHit(*hit)
+		if err != nil {
+			return hits, err
+		}
+		if recorded {
+			hits = append(hits, *hit)
+		}
+	}
+	now := time.Now()
+	if _, err := repository.UserRepo().UpdatePartialByID(userID, map[string]any{"screenedAt": now}); err != nil {
+		return hits, err
+	}
+	return hits, nil
+}
+
+func matchEntry(entry listEntry, identity identitymatch_services.Identity) *entities.ScreeningHit {
+	var best *identitymatch_services.MatchResult
+	var bestName string
+	for _, name := range entry.Names {
+		result := identitymatch_services.MatchIdentity(identitymatch_services.ScreeningCheck, identity, identitymatch_services.Identity{FullName: name})
+		if result.Match && (best == nil || result.NameScore > best.NameScore) {
+			best = &result
+			bestName = name
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	var dobMatch *bool
+	if identity.DateOfBirth != nil && len(entry.DatesOfBirth) != 0 {
+		matched := false
+		for _, date := range entry.DatesOfBirth {
+			if date.matches(*identity.DateOfBirth) {
+				matched = true
+				break
+			}
+		}
+		// a namesake born on another date is somebody else
+		if !matched {
+			return nil
+		}
+		dobMatch = &matched
+	}
+	return &entities.ScreeningHit{
+		ListType:    entry.ListType,
+		Source:      entry.Source,
+		Reference:   entry.Reference,
+		EntryName:   bestName,
+		MatchedName: fullName(identity),
+		NameScore:   best.NameScore,
+		DOBMatch:    dobMatch,
+		Status:      entities.ScreeningHitOpen,
+	}
+}
+
+func fullName(identity identitymatch_services.Identity) string {
+	return strings.Join(strings.Fields(strings.Join([]string{identity.FirstName, identity.MiddleName, identity.LastName}, " ")), " ")
+}
+
+// Returns whether date agrees with the parts of the date of birth the list published
+func (listed listDate) matches(date time.Time) bool {
+	date = date.UTC()
+	if listed.year != date.Year() {
+		return false
+	}
+	if listed.month != 0 && listed.month != int(date.Month()) {
+		return false
+	}
+	return listed.day == 0 || listed.day == date.Day()
+}
+
+// Records a hit for review. Returns false when the entry was already recorded against the user, whatever its review.
+func recordHit(hit entities.ScreeningHit) (bool, error) {
+	hitRepo := repository.ScreeningHitRepo()
+	existing, err := hitRepo.CountDocs(map[string]any{
+		"userID":    hit.UserID,
+		"source":    hit.Source,
+		"reference": hit.Reference,
+	})
+	if err != nil {
+		return false, err
+	}
+	if existing != 0 {
+		return false, nil
+	}
+	if _, err := hitRepo.CreateOne(context.TODO(), hit); err != nil {
+		// screened twice at once, the other screening recorded it
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	metrics.ScreeningHits.WithLabelValues(string(hit.ListType), string(hit.Trigger)).Inc()
+	logger.Warning("screening hit recorded for review", logger.LoggerOptions{
+		Key: "userID", Data: hit.UserID,
+	}, logger.LoggerOptions{
+		Key: "source", Data: hit.Source,
+	}, logger.LoggerOptions{
+		Key: "reference", Data: hit.Reference,
+	})
+	return true, nil
+}
+
+// Records a watch list hit for userID when the BVN record of identity is flagged as watch listed by the provider
+func RecordBVNWatchList(userID string, watchListed string, identity identitymatch_services.Identity) error {
+	switch strings.ToLower(strings.TrimSpace(watchListed)) {
+	case "yes", "y", "true", "1":
+	default:
+		return nil
+	}
+	_, err := recordHit(entities.ScreeningHit{
+		UserID:      userID,
+		ListType:    entities.WatchList,
+		Source:      "bvn",
+		Reference:   "bvn_watchlist",
+		EntryName:   fullName(identity),
+		MatchedName: fullName(identity),
+		NameScore:   1,
+		Trigger:     entities.BVNScreening,
+		Status:      entities.ScreeningHitOpen,
+	})
+	return err
+}
+
+// Reloads the lists and screens every user with a verified NIN or BVN again, so entries added since a user was
+// verified are found. A user that cannot be screened is logged and skipped.
+func RescreenUsers() error {
+	loaded, err := reloadLists()
+	if err != nil {
+		return err
+	}
+	if len(loaded.entries) == 0 {
+		return nil
+	}
+	userRepo := repository.UserRepo()
+	var lastID *string
+	for {
+		users, err := userRepo.FindManyPaginated(map[string]interface{}{
+			"firstName.verified": true,
+			"lastName.verified":  true,
+			"$or": []map[string]any{
+				{"nin": map[string]any{"$ne": nil}},
+				{"bvn": map[string]any{"$ne": nil}},
+			},
+		}, rescreeningBatchSize, lastID, 1, options.Find().SetProjection(map[string]any{
+			"firstName":  1,
+			"middleName": 1,
+			"lastName":   1,
+			"dob":        1,
+		}))
+		if err != nil {
+			return err
+		}
+		for _, user := range *users {
+			lastID = utils.GetStringPointer(user.ID)
+			if _, err := ScreenUser(user.ID, identitymatch_services.AccountIdentity(&user), entities.Rescreening); err != nil {
+				logger.Error("an error occured while re-screening user", logger.LoggerOptions{
+					Key: "userID", Data: user.ID,
+				}, logger.LoggerOptions{
+					Key: "err", Data: err,
+				})
+			}
+		}
+		if len(*users) < rescreeningBatchSize {
+			return nil
+		}
+	}
+}
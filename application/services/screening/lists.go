@@ -0,0 +1,230 @@
+package screening_services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	identitymatch_services "gateman.io/application/services/identitymatch"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/logger"
+)
+
+// Sanctions, PEP and watch lists are read from every .csv file in SCREENING_LISTS_DIR.
+// Lists are exported into the format below from their publishers (UN consolidated list, OFAC SDN, national PEP lists)
+// and dropped into the directory, re-screening reloads them.
+//
+// A file starts with a header naming its columns, in any order:
+//
+//	list_type      sanctions, pep or watchlist
+//	source         the publisher of the list, e.g. un_consolidated or ofac_sdn
+//	reference      the id of the entry on its list, unique within source
+//	name           the full name of the person with the names in any order
+//	aliases        other names of the person separated by "|", may be empty
+//	date_of_birth  YYYY-MM-DD, YYYY-MM or YYYY, several dates separated by "|", empty when not known
+//
+// For example:
+//
+//	list_type,source,reference,name,aliases,date_of_birth
+//	sanctions,un_consolidated,QDi.001,John Doe Example,Johnny Example|J. D. Example,1960|1961-02-03
+//
+// Rows that cannot be read are skipped and logged.
+var listColumns = []string{"list_type", "source", "reference", "name", "aliases", "date_of_birth"}
+
+var listTypes = map[string]entities.ScreeningListType{
+	string(entities.SanctionsList): entities.SanctionsList,
+	string(entities.PEPList):       entities.PEPList,
+	string(entities.WatchList):     entities.WatchList,
+}
+
+// A date of birth as precisely as a list published it. Month and day are 0 when they were not published.
+type listDate struct {
+	year  int
+	month int
+	day   int
+}
+
+// A person on a list
+type listEntry struct {
+	ListType  entities.ScreeningListType
+	Source    string
+	Reference string
+	// the name of the entry followed by its aliases
+	Names        []string
+	DatesOfBirth []listDate
+	// first letters of every name token, entries are only compared with identities that share them
+	initials map[byte]bool
+}
+
+// The entries of every list loaded from SCREENING_LISTS_DIR
+type lists struct {
+	entries []listEntry
+}
+
+var (
+	loadedLists *lists
+	listsMutex  sync.RWMutex
+)
+
+// Returns the loaded lists, loading them on first use
+func currentLists() (*lists, error) {
+	listsMutex.RLock()
+	current := loadedLists
+	listsMutex.RUnlock()
+	if current != nil {
+		return current, nil
+	}
+	return reloadLists()
+}
+
+// Reads every list in SCREENING_LISTS_DIR again and swaps them in. The lists in use are kept when the directory cannot be read.
+// No lists are screened against when SCREENING_LISTS_DIR is not set.
+func ReloadLists() error {
+	_, err := reloadLists()
+	return err
+}
+
+func reloadLists() (*lists, error) {
+	loaded := &lists{entries: []listEntry{}}
+	dir := os.Getenv("SCREENING_LISTS_DIR")
+	if dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			entries, err := readListFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("reading screening list %s: %w", file, err)
+			}
+			loaded.entries = append(loaded.entries, entries...)
+		}
+	}
+	listsMutex.Lock()
+	loadedLists = loaded
+	listsMutex.Unlock()
+	logger.Info("screening lists loaded", logger.LoggerOptions{
+		Key: "entries", Data: len(loaded.entries),
+	})
+	return loaded, nil
+}
+
+func readListFile(path string) ([]listEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))] = i
+	}
+	for _, column := range listColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("the %s column is missing", column)
+		}
+	}
+
+	entries := []listEntry{}
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(column string) string {
+			if i := columns[column]; i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		entry, err := parseListEntry(field)
+		if err != nil {
+			logger.Warning("skipped screening list entry", logger.LoggerOptions{
+				Key: "file", Data: path,
+			}, logger.LoggerOptions{
+				Key: "line", Data: line,
+			}, logger.LoggerOptions{
+				Key: "err", Data: err.Error(),
+			})
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+}
+
+func parseListEntry(field func(column string) string) (*listEntry, error) {
+	listType, ok := listTypes[strings.ToLower(field("list_type"))]
+	if !ok {
+		return nil, fmt.Errorf("unknown list type %q", field("list_type"))
+	}
+	entry := listEntry{
+		ListType:  listType,
+		Source:    field("source"),
+		Reference: field("reference"),
+		Names:     []string{},
+		initials:  map[byte]bool{},
+	}
+	if entry.Source == "" || entry.Reference == "" {
+		return nil, errors.New("the source and reference are required")
+	}
+	for _, name := range append([]string{field("name")}, strings.Split(field("aliases"), "|")...) {
+		tokens := identitymatch_services.NameTokens(name)
+		if len(tokens) == 0 {
+			continue
+		}
+		entry.Names = append(entry.Names, strings.TrimSpace(name))
+		for _, token := range tokens {
+			entry.initials[token[0]] = true
+		}
+	}
+	if len(entry.Names) == 0 {
+		return nil, errors.New("the entry has no name")
+	}
+	for _, date := range strings.Split(field("date_of_birth"), "|") {
+		date = strings.TrimSpace(date)
+		if date == "" {
+			continue
+		}
+		parsed, err := parseListDate(date)
+		if err != nil {
+			return nil, err
+		}
+		entry.DatesOfBirth = append(entry.DatesOfBirth, *parsed)
+	}
+	return &entry, nil
+}
+
+func parseListDate(date string) (*listDate, error) {
+	parts := strings.Split(date, "-")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("invalid date of birth %q", date)
+	}
+	values := []int{0, 0, 0}
+	for i, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date of birth %q", date)
+		}
+		values[i] = value
+	}
+	if values[0] < 1000 || values[1] < 0 || values[1] > 12 || values[2] < 0 || values[2] > 31 {
+		return nil, fmt.Errorf("invalid date of birth %q", date)
+	}
+	return &listDate{year: values[0], month: values[1], day: values[2]}, nil
+}
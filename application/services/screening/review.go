@@ -0,0 +1,143 @@
+package screening_services
+
+import (
+	"errors"
+	"time"
+
+	"gateman.io/application/repository"
+	"gateman.io/application/utils"
+	"gateman.io/entities"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrReviewNotFound = errors.New("screening hit review not found")
+
+// An open or reviewed hit along with the app's review of it
+type HitReview struct {
+	entities.ScreeningHitReview
+	Hit entities.ScreeningHit `json:"hit"`
+}
+
+// Returns the hits of userID that were neither cleared for everyone nor cleared by the app identified by appID
+func UnclearedHits(userID string, appID string) ([]entities.ScreeningHit, error) {
+	hits, err := repository.ScreeningHitRepo().FindMany(map[string]any{
+		"userID": userID,
+		"status": map[string]any{"$ne": entities.ScreeningHitCleared},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(*hits) == 0 {
+		return []entities.ScreeningHit{}, nil
+	}
+	hitIDs := []string{}
+	for _, hit := range *hits {
+		hitIDs = append(hitIDs, hit.ID)
+	}
+	cleared, err := repository.ScreeningHitReviewRepo().FindMany(map[string]any{
+		"appID":  appID,
+		"hitID":  map[string]any{"$in": hitIDs},
+		"status": entities.ScreeningHitCleared,
+	}, options.Find().SetProjection(map[string]any{"hitID": 1}))
+	if err != nil {
+		return nil, err
+	}
+	clearedHits := map[string]bool{}
+	for _, review := range *cleared {
+		clearedHits[review.HitID] = true
+	}
+	uncleared := []entities.ScreeningHit{}
+	for _, hit := range *hits {
+		if !clearedHits[hit.ID] {
+			uncleared = append(uncleared, hit)
+		}
+	}
+	return uncleared, nil
+}
+
+// Opens a review of each hit for the app so the workspace can see who was stopped from signing up and why.
+// Hits the app already reviewed are left as they are.
+func OpenHitReviews(app *entities.Application, hits []entities.ScreeningHit) error {
+	reviewRepo := repository.ScreeningHitReviewRepo()
+	for _, hit := range hits {
+		now := time.Now()
+		_, err := reviewRepo.UpdateManyWithOperator(map[string]interface{}{
+			"hitID": hit.ID,
+			"appID": app.ID,
+		}, map[string]interface{}{
+			"$setOnInsert": map[string]any{
+				"_id":         utils.GenerateUULDString(),
+				"userID":      hit.UserID,
+				"workspaceID": app.WorkspaceID,
+				"status":      entities.ScreeningHitOpen,
+				"reviewedBy":  nil,
+				"reviewNote":  nil,
+				"reviewedAt":  nil,
+				"createdAt":   now,
+				"updatedAt":   now,
+			},
+		}, options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the reviews of the app with their hits, oldest first. Reviews in any status are returned when status is nil.
+func AppHitReviews(appID string, status *entities.ScreeningHitStatus, lastID *string, pageSize int64) ([]HitReview, error) {
+	filter := map[string]interface{}{
+		"appID": appID,
+	}
+	if status != nil {
+		filter["status"] = *status
+	}
+	reviews, err := repository.ScreeningHitReviewRepo().FindManyPaginated(filter, pageSize, lastID, 1)
+	if err != nil {
+		return nil, err
+	}
+	hitIDs := []string{}
+	for _, review := range *reviews {
+		hitIDs = append(hitIDs, review.HitID)
+	}
+	hits := map[string]entities.ScreeningHit{}
+	if len(hitIDs) != 0 {
+		found, err := repository.ScreeningHitRepo().FindMany(map[string]any{
+			"_id": map[string]any{"$in": hitIDs},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, hit := range *found {
+			hits[hit.ID] = hit
+		}
+	}
+	result := []HitReview{}
+	for _, review := range *reviews {
+		result = append(result, HitReview{ScreeningHitReview: review, Hit: hits[review.HitID]})
+	}
+	return result, nil
+}
+
+// Records the app's decision on a hit. A cleared hit no longer stops the user from signing up to the app and a
+// confirmed hit keeps stopping them. A hit can be reviewed again if the decision changes.
+func ReviewHit(appID string, reviewID string, reviewerID string, status entities.ScreeningHitStatus, note *string) error {
+	now := time.Now()
+	updated, err := repository.ScreeningHitReviewRepo().UpdatePartialByFilter(map[string]interface{}{
+		"_id":   reviewID,
+		"appID": appID,
+	}, map[string]any{
+		"status":     status,
+		"reviewedBy": reviewerID,
+		"reviewNote": note,
+		"reviewedAt": now,
+		"updatedAt":  now,
+	})
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrReviewNotFound
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package security_services
+
+import (
+	"encoding/json"
+
+	"gateman.io/application/repository"
+	dataprotection_services "gateman.io/application/services/dataprotection"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/auth"
+	"gateman.io/infrastructure/cryptography"
+	"gateman.io/infrastructure/database"
+	"gateman.io/infrastructure/database/repository/cache"
+	"gateman.io/infrastructure/database/repository/mongo"
+	identity_verification_types "gateman.io/infrastructure/identity_verification/types"
+	"gateman.io/infrastructure/metrics"
+)
+
+const blindIndexMigrationBatchSize = 200
+
+// The identity number fields of users. Only the fixed salt Argon2 hash of a number was stored before blind indexes,
+// numbers are read back from the provider response that was cached under the hash when the response holds the number.
+var identityIndexFields = []struct {
+	field  string
+	domain cryptography.BlindIndexDomain
+	value  func(entities.User) *string
+	number func(cached []byte) string
+}{
+	{"nin", cryptography.NINIndex, func(user entities.User) *string { return user.NIN }, nil},
+	{"bvn", cryptography.BVNIndex, func(user entities.User) *string { return user.BVN }, nil},
+	{"driverID", cryptography.DriverIDIndex, func(user entities.User) *string { return user.DriverID }, func(cached []byte) string {
+		var driverID identity_verification_types.DriversID
+		json.Unmarshal(cached, &driverID)
+		return driverID.LicenseNo
+	}},
+	{"voterID", cryptography.VoterIDIndex, func(user entities.User) *string { return user.VoterID }, func(cached []byte) string {
+		var voterID identity_verification_types.VoterID
+		json.Unmarshal(cached, &voterID)
+		return voterID.VoterIdentificationNumber
+	}},
+}
+
+// blind indexes are 64 hex characters, anything else set on the field is a legacy hash
+func legacyIdentityIndexFilter(field string) map[string]interface{} {
+	return map[string]interface{}{
+		field: map[string]any{"$exists": true, "$ne": nil, "$not": map[string]any{"$regex": "^[0-9a-f]{64}$"}},
+	}
+}
+
+// Moves values indexed under the fixed salt Argon2 hash of device ids and identity numbers to their blind index.
+//
+// The saved tokens of every device of users and workspace members are moved to the blind index of the device id.
+// Sessions can be started on instances still running the old release, so device sessions are only recorded as migrated
+// once a run finds nothing left to move, after which lookups stop falling back to the old keys.
+//
+// Identity numbers in users.nin, bvn, driverID and voterID are re-indexed when the number can be read back from the
+// provider response cached under its hash. Numbers that cannot be recovered are cleared and the user verifies them again,
+// so numbers are only ever looked up by their blind index. A field is recorded as migrated once no legacy hash is left.
+func MigrateBlindIndexes() error {
+	if !cryptography.BlindIndexMigrated(auth.DeviceSessionsMigration) {
+		moved, err := migrateDeviceSessions(repository.UserRepo(), []string{"access", "refresh"}, func(user entities.User) (string, []entities.Device) {
+			return user.ID, user.Devices
+		})
+		if err != nil {
+			return err
+		}
+		movedMemberSessions, err := migrateDeviceSessions(repository.WorkspaceMemberRepo(), []string{"workspace-access", "workspace-refresh"}, func(member entities.WorkspaceMember) (string, []entities.Device) {
+			return member.ID, member.Devices
+		})
+		if err != nil {
+			return err
+		}
+		if moved+movedMemberSessions == 0 {
+			cryptography.MarkBlindIndexMigrated(auth.DeviceSessionsMigration)
+		}
+	}
+
+	userRepo := repository.UserRepo()
+	for _, identityField := range identityIndexFields {
+		field := identityField.field
+		if cryptography.BlindIndexMigrated(dataprotection_services.IdentityIndexMigration(field)) {
+			metrics.LegacyIdentityIndexes.WithLabelValues(field).Set(0)
+			continue
+		}
+		// hashes saved as binary were never used as cache keys so there is nothing to recover them from
+		cleared, err := userRepo.UpdateManyWithOperator(map[string]interface{}{
+			field: map[string]any{"$type": "binData"},
+		}, map[string]interface{}{
+			"$set": map[string]any{field: nil},
+		})
+		if err != nil {
+			return err
+		}
+		if cleared != 0 {
+			metrics.BlindIndexMigrations.WithLabelValues(field, "cleared").Add(float64(cleared))
+		}
+		var lastID *string
+		for {
+			users, err := userRepo.FindManyPaginated(legacyIdentityIndexFilter(field), blindIndexMigrationBatchSize, lastID, 1)
+			if err != nil {
+				return err
+			}
+			for _, user := range *users {
+				lastID = &user.ID
+				if err := reindexIdentityNumber(user, identityField.field, identityField.domain, *identityField.value(user), identityField.number); err != nil {
+					return err
+				}
+			}
+			if len(*users) < blindIndexMigrationBatchSize {
+				break
+			}
+		}
+		legacy, err := userRepo.CountDocs(legacyIdentityIndexFilter(field))
+		if err != nil {
+			return err
+		}
+		metrics.LegacyIdentityIndexes.WithLabelValues(field).Set(float64(legacy))
+		if legacy == 0 {
+			cryptography.MarkBlindIndexMigrated(dataprotection_services.IdentityIndexMigration(field))
+		}
+	}
+	return nil
+}
+
+// Moves the legacy hash in field of user to the blind index of the number it was hashed from, or clears it when the
+// number cannot be recovered or is already linked to another user. The plain provider response cached under the hash is deleted.
+func reindexIdentityNumber(user entities.User, field string, domain cryptography.BlindIndexDomain, legacyHash string, number func([]byte) string) error {
+	var index any
+	if cached := cache.Cache.FindOne(legacyHash); cached != nil && number != nil {
+		// the hash is checked so a response cached under it can never move the user to another number
+		if recovered := number([]byte(*cached)); recovered != "" && cryptography.LegacyFixedSaltHash(recovered) == legacyHash {
+			blindIndex := cryptography.BlindIndex(domain, recovered)
+			linked, err := repository.UserRepo().CountDocs(map[string]interface{}{
+				field: blindIndex,
+				"_id": map[string]any{"$ne": user.ID},
+			})
+			if err != nil {
+				return err
+			}
+			if linked == 0 {
+				index = blindIndex
+			}
+		}
+	}
+	// the filter on the legacy hash stops the update from overwriting a number the user verified in the meantime
+	updated, err := repository.UserRepo().UpdatePartialByFilter(map[string]interface{}{
+		"_id": user.ID,
+		field: legacyHash,
+	}, map[string]interface{}{
+		field: index,
+	})
+	if err != nil {
+		return err
+	}
+	cache.Cache.DeleteOne(legacyHash)
+	if !updated {
+		return nil
+	}
+	if index != nil {
+		metrics.BlindIndexMigrations.WithLabelValues(field, "moved").Inc()
+	} else {
+		metrics.BlindIndexMigrations.WithLabelValues(field, "cleared").Inc()
+	}
+	return nil
+}
+
+// Pages through every document with devices in _id order and moves the sessions saved under each suffix for each device.
+// Returns how many sessions were moved.
+func migrateDeviceSessions[T database.BaseModel](repo *mongo.MongoRepository[T], suffixes []string, devices func(T) (string, []entities.Device)) (int, error) {
+	moved := 0
+	var lastID *string
+	for {
+		docs, err := repo.FindManyPaginated(map[string]interface{}{
+			"devices.0": map[string]any{"$exists": true},
+		}, blindIndexMigrationBatchSize, lastID, 1)
+		if err != nil {
+			return moved, err
+		}
+		for _, doc := range *docs {
+			id, docDevices := devices(doc)
+			lastID = &id
+			for _, device := range docDevices {
+				for _, suffix := range suffixes {
+					if auth.MoveLegacyDeviceSession(device.ID, suffix) {
+						moved++
+					}
+				}
+			}
+		}
+		if len(*docs) < blindIndexMigrationBatchSize {
+			return moved, nil
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package security_services
+
+import (
+	"context"
+
+	"gateman.io/application/repository"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/logger"
+)
+
+// Persists a security event. Failures are logged and never block the request that triggered the event.
+func RecordSecurityEvent(event entities.SecurityEvent) {
+	logger.Warning("security event recorded", logger.LoggerOptions{
+		Key:  "type",
+		Data: event.Type,
+	}, logger.LoggerOptions{
+		Key:  "actorID",
+		Data: event.ActorID,
+	}, logger.LoggerOptions{
+		Key:  "metadata",
+		Data: event.Metadata,
+	})
+	securityEventRepo := repository.SecurityEventRepo()
+	_, err := securityEventRepo.CreateOne(context.TODO(), event)
+	if err != nil {
+		logger.Error("an error occured while saving security event", logger.LoggerOptions{
+			Key:  "err",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key:  "event",
+			Data: event,
+		})
+	}
+}
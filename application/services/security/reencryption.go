@@ -0,0 +1,178 @@
+package security_services
+
+import (
+	"fmt"
+	"regexp"
+
+	"gateman.io/application/repository"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/cryptography"
+	"gateman.io/infrastructure/database"
+	"gateman.io/infrastructure/database/repository/mongo"
+	"gateman.io/infrastructure/logger"
+	"gateman.io/infrastructure/metrics"
+)
+
+const reencryptionBatchSize = 200
+
+// An encrypted value of a document. Values inside an array are addressed by the array and the field within each element.
+type encryptedValue struct {
+	array string
+	field string
+	value string
+}
+
+// Moves every value encrypted at rest to the active key of the keyring.
+// Short lived values in the cache (otp refs, key exchange secrets and pending mfa enrollments) are not swept,
+// they expire long before an old key should be taken out of the keyring.
+func ReencryptStoredData() error {
+	activeKeyID, err := cryptography.ActiveEncryptionKeyID()
+	if err != nil {
+		return err
+	}
+	notActive := map[string]any{
+		"$type": "string",
+		"$ne":   "",
+		"$not":  map[string]any{"$regex": fmt.Sprintf("^g1:%s:", regexp.QuoteMeta(activeKeyID))},
+	}
+	at := func(path string) map[string]any {
+		return map[string]any{path: notActive}
+	}
+	// a plain condition on an array matches when no element is under the active key, any element has to be matched on its own
+	in := func(array string, field string) map[string]any {
+		return map[string]any{array: map[string]any{"$elemMatch": map[string]any{field: notActive}}}
+	}
+	candidates := func(conditions ...map[string]any) func() map[string]interface{} {
+		return func() map[string]interface{} {
+			return map[string]interface{}{"$or": conditions}
+		}
+	}
+
+	err = reencryptCollection(repository.ApplicationRepo(), "applications", candidates(
+		at("appSigningKey"), at("sandBoxAppSigningKey"), at("tokenSigningKey.privateKey"), at("sandboxTokenSigningKey.privateKey"),
+		at("requestSigningSecret"), at("kycCallbackSecret"), in("retiredSigningKeys", "appSigningKey"), in("retiredSigningKeys", "tokenSigningKey.privateKey"),
+	), func(app entities.Application) (string, []encryptedValue) {
+		values := []encryptedValue{
+			{field: "appSigningKey", value: app.AppSigningKey},
+			{field: "sandBoxAppSigningKey", value: app.SandboxAppSigningKey},
+			{field: "requestSigningSecret", value: app.RequestSigningSecret},
+			{field: "kycCallbackSecret", value: app.KYCCallbackSecret},
+		}
+		if app.TokenSigningKey != nil {
+			values = append(values, encryptedValue{field: "tokenSigningKey.privateKey", value: app.TokenSigningKey.PrivateKey})
+		}
+		if app.SandboxTokenSigningKey != nil {
+			values = append(values, encryptedValue{field: "sandboxTokenSigningKey.privateKey", value: app.SandboxTokenSigningKey.PrivateKey})
+		}
+		for _, key := range app.RetiredSigningKeys {
+			values = append(values,
+				encryptedValue{array: "retiredSigningKeys", field: "appSigningKey", value: key.AppSigningKey},
+				encryptedValue{array: "retiredSigningKeys", field: "tokenSigningKey.privateKey", value: key.TokenSigningKey.PrivateKey})
+		}
+		return app.ID, values
+	})
+	if err != nil {
+		return err
+	}
+
+	err = reencryptCollection(repository.WorkspaceRepository(), "workspaces", candidates(in("paymentDetails", "authorizationCode")), func(workspace entities.Workspace) (string, []encryptedValue) {
+		values := []encryptedValue{}
+		for _, card := range workspace.PaymentDetails {
+			values = append(values, encryptedValue{array: "paymentDetails", field: "authorizationCode", value: card.AuthorizationCode})
+		}
+		return workspace.ID, values
+	})
+	if err != nil {
+		return err
+	}
+
+	err = reencryptCollection(repository.AppUserRepo(), "app_users", candidates(at("authenticatorSecret")), func(appUser entities.AppUser) (string, []encryptedValue) {
+		if appUser.AuthenticatorSecret == nil {
+			return appUser.ID, nil
+		}
+		return appUser.ID, []encryptedValue{{field: "authenticatorSecret", value: *appUser.AuthenticatorSecret}}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = reencryptCollection(repository.KYCJobRepo(), "kyc_jobs", candidates(in("items", "encryptedID"), in("items", "data")), func(job entities.KYCJob) (string, []encryptedValue) {
+		values := []encryptedValue{}
+		for _, item := range job.Items {
+			values = append(values, encryptedValue{array: "items", field: "encryptedID", value: item.EncryptedID})
+			if item.Data != nil {
+				values = append(values, encryptedValue{array: "items", field: "data", value: *item.Data})
+			}
+		}
+		return job.ID, values
+	})
+	if err != nil {
+		return err
+	}
+
+	return reencryptCollection(repository.WorkspaceMemberRepo(), "workspace_members", candidates(at("authenticatorSecret")), func(member entities.WorkspaceMember) (string, []encryptedValue) {
+		if member.AuthenticatorSecret == nil {
+			return member.ID, nil
+		}
+		return member.ID, []encryptedValue{{field: "authenticatorSecret", value: *member.AuthenticatorSecret}}
+	})
+}
+
+// Pages through the documents matched by filter in _id order and re-encrypts the values returned by extract.
+// Every value is replaced with a compare-and-set on its old ciphertext so a value changed while the job runs is left alone.
+func reencryptCollection[T database.BaseModel](repo *mongo.MongoRepository[T], collection string, filter func() map[string]interface{}, extract func(T) (string, []encryptedValue)) error {
+	var lastID *string
+	for {
+		docs, err := repo.FindManyPaginated(filter(), reencryptionBatchSize, lastID, 1)
+		if err != nil {
+			return err
+		}
+		for _, doc := range *docs {
+			id, values := extract(doc)
+			lastID = &id
+			for _, value := range values {
+				if !cryptography.NeedsReencryption(value.value) {
+					continue
+				}
+				metrics.ReencryptedValues.WithLabelValues(collection, reencryptValue(repo, collection, id, value)).Inc()
+			}
+		}
+		if len(*docs) < reencryptionBatchSize {
+			return nil
+		}
+	}
+}
+
+func reencryptValue[T database.BaseModel](repo *mongo.MongoRepository[T], collection string, id string, value encryptedValue) string {
+	reencrypted, err := cryptography.ReencryptData(value.value)
+	if err != nil {
+		logger.Error("an error occured while re-encrypting a stored value", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "collection", Data: collection,
+		}, logger.LoggerOptions{
+			Key: "id", Data: id,
+		}, logger.LoggerOptions{
+			Key: "field", Data: value.array + "." + value.field,
+		})
+		return "failed"
+	}
+	filter := map[string]interface{}{"_id": id}
+	path := value.field
+	if value.array == "" {
+		filter[value.field] = value.value
+	} else {
+		filter[value.array] = map[string]any{"$elemMatch": map[string]any{value.field: value.value}}
+		path = value.array + ".$." + value.field
+	}
+	updated, err := repo.UpdateManyWithOperator(filter, map[string]interface{}{
+		"$set": map[string]any{path: *reencrypted},
+	})
+	if err != nil {
+		return "failed"
+	}
+	if updated == 0 {
+		return "skipped"
+	}
+	return "reencrypted"
+}
@@ -9,6 +9,7 @@ import (
 	"gateman.io/application/constants"
 	"gateman.io/application/controller/dto"
 	"gateman.io/application/repository"
+	services "gateman.io/application/services/application"
 	"gateman.io/application/utils"
 	"gateman.io/entities"
 	"gateman.io/infrastructure/cryptography"
@@ -37,14 +38,52 @@ func CreateApplicationUseCase(ctx any, payload *dto.ApplicationDTO, deviceID str
 		apperrors.ClientError(ctx, fmt.Sprintf("You have reached the maximum number of applications a workspace can have. Contact %s to assist in creating more.", constants.SUPPORT_EMAIL), nil, nil, deviceID)
 		return nil, nil, nil, nil, nil, nil
 	}
-	apiKey, _ := cryptography.EncryptData([]byte(utils.GenerateUULDString()), nil)
+	generatedAPIKey, _ := utils.GenerateRandomHexKey(84)
+	apiKey := &generatedAPIKey
 	hashedAPIKey, _ := cryptography.CryptoHahser.HashString(*apiKey, nil)
-	sandboxAPIKey, _ := cryptography.EncryptData([]byte(utils.GenerateUULDString()), nil)
+	generatedSandboxAPIKey, _ := utils.GenerateRandomHexKey(84)
+	sandboxAPIKey := &generatedSandboxAPIKey
 	hashedSandboxAPIKey, _ := cryptography.CryptoHahser.HashString(*sandboxAPIKey, nil)
-	appSigningKey, _ := cryptography.EncryptData([]byte(utils.GenerateUULDString()), nil)
-	encryptedAppSigningKey, _ := cryptography.EncryptData([]byte(*appSigningKey), nil)
-	sandboxAppSigningKey, _ := cryptography.EncryptData([]byte(utils.GenerateUULDString()), nil)
-	encryptedSandboxAppSigningKey, _ := cryptography.EncryptData([]byte(*sandboxAppSigningKey), nil)
+	appSigningKey, encryptedAppSigningKey, err := services.GenerateAppSigningKey()
+	if err != nil {
+		logger.Error("an error occured while generating app signing key for application", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return nil, nil, nil, nil, nil, nil
+	}
+	sandboxAppSigningKey, encryptedSandboxAppSigningKey, err := services.GenerateAppSigningKey()
+	if err != nil {
+		logger.Error("an error occured while generating sandbox app signing key for application", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return nil, nil, nil, nil, nil, nil
+	}
+	signingAlgorithm := cryptography.DefaultSigningAlgorithm
+	if payload.SigningAlgorithm != nil {
+		signingAlgorithm = cryptography.SigningAlgorithm(*payload.SigningAlgorithm)
+	}
+	tokenSigningKey, err := services.GenerateTokenSigningKey(signingAlgorithm)
+	if err != nil {
+		logger.Error("an error occured while generating token signing key for application", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return nil, nil, nil, nil, nil, nil
+	}
+	sandboxTokenSigningKey, err := services.GenerateTokenSigningKey(signingAlgorithm)
+	if err != nil {
+		logger.Error("an error occured while generating sandbox token signing key for application", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return nil, nil, nil, nil, nil, nil
+	}
 	appPriKey := utils.GenerateUULDString()
 	appID := utils.GenerateUULDString()
 	app, err := appRepo.CreateOne(context.TODO(), entities.Application{
@@ -61,6 +100,8 @@ func CreateApplicationUseCase(ctx any, payload *dto.ApplicationDTO, deviceID str
 		RequestedFields:        *payload.RequestedFields,
 		AppSigningKey:          *encryptedAppSigningKey,
 		SandboxAppSigningKey:   *encryptedSandboxAppSigningKey,
+		TokenSigningKey:        tokenSigningKey,
+		SandboxTokenSigningKey: sandboxTokenSigningKey,
 		RefreshTokenTTL:        60 * 60 * 24 * 7, // 7 days
 		AccessTokenTTL:         60 * 60 * 2,      // 2 hours
 		SandboxRefreshTokenTTL: 60 * 60 * 24 * 7, // 7 days
@@ -99,5 +140,5 @@ func CreateApplicationUseCase(ctx any, payload *dto.ApplicationDTO, deviceID str
 		Priority:  "high",
 		ProcessIn: 1,
 	})
-	return app, apiKey, &appID, utils.GetStringPointer(string(*appSigningKey)), utils.GetStringPointer("sandbox-" + *sandboxAPIKey), utils.GetStringPointer("sandbox-" + *sandboxAppSigningKey)
+	return app, apiKey, &appID, appSigningKey, utils.GetStringPointer("sandbox-" + *sandboxAPIKey), sandboxAppSigningKey
 }
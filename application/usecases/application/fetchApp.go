@@ -21,14 +21,25 @@ func FetchAppUseCase(ctx any, appID string, deviceID string, ip string) (*entiti
 	app, err := appRepo.FindOneByFilter(map[string]interface{}{
 		"appID": appID,
 	}, options.FindOne().SetProjection(map[string]any{
-		"name":             1,
-		"verifications":    1,
-		"requestedFields":  1,
-		"localeRestriction": 1,
-		"description":      1,
-		"appImg":           1,
-		"appSigningKey":    1,
-		"workspaceID":      1,
+		"name":                   1,
+		"verifications":          1,
+		"requestedFields":        1,
+		"localeRestriction":      1,
+		"description":            1,
+		"appImg":                 1,
+		"appSigningKey":          1,
+		"sandBoxAppSigningKey":   1,
+		"workspaceID":            1,
+		"appID":                  1,
+		"pinProtected":           1,
+		"requireAppMFA":          1,
+		"rejectScreeningHits":    1,
+		"accessTokenTTL":         1,
+		"refreshTokenTTL":        1,
+		"sandboxAccessTokenTTL":  1,
+		"sandboxRefreshTokenTTL": 1,
+		"tokenSigningKey":        1,
+		"sandboxTokenSigningKey": 1,
 	}))
 	if err != nil {
 		logger.Error("an error occured while fethcing app details", logger.LoggerOptions{
@@ -1,10 +1,183 @@
 package application_usecase
 
-func VerifyAuthTokenUseCase(ctx any, token string, appID string) error {
-	// appRepo := repository.ApplicationRepo()
-	// app, _ := appRepo.FindOneByFilter(map[string]any{
-	// 	"appID": appID,
-	// })
-	// au
+import (
+	"errors"
+	"strings"
+
+	"gateman.io/application/repository"
+	services "gateman.io/application/services/application"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/auth"
+	"gateman.io/infrastructure/cryptography"
+	"gateman.io/infrastructure/logger"
+	"github.com/golang-jwt/jwt"
+)
+
+// The response of a token introspection request as described in RFC 7662
+type TokenIntrospection struct {
+	Active          bool           `json:"active"`
+	Sub             string         `json:"sub,omitempty"`
+	Exp             int64          `json:"exp,omitempty"`
+	Iat             int64          `json:"iat,omitempty"`
+	Iss             string         `json:"iss,omitempty"`
+	Scope           string         `json:"scope,omitempty"`
+	ClientID        string         `json:"client_id,omitempty"`
+	TokenType       string         `json:"token_type,omitempty"`
+	Jti             string         `json:"jti,omitempty"`
+	FamilyID        string         `json:"-"`
+	RequestedFields map[string]any `json:"requested_fields,omitempty"`
+}
+
+// Verifies a token issued to a user of the app identified by appID.
+// An inactive introspection is returned when the token fails any check, an error is only returned when the checks could not be completed.
+func VerifyAuthTokenUseCase(ctx any, token string, appID string, deviceID *string) (*TokenIntrospection, error) {
+	return verifyAppUserToken(token, appID, deviceID, false)
+}
+
+// Verifies a refresh token presented to the token endpoint.
+// Refresh tokens that have already been rotated are still returned as active so the caller can detect the reuse when rotating the family.
+func VerifyRefreshTokenUseCase(ctx any, token string, appID string) (*TokenIntrospection, error) {
+	introspection, err := verifyAppUserToken(token, appID, nil, true)
+	if err != nil || !introspection.Active {
+		return introspection, err
+	}
+	if introspection.TokenType != string(auth.RefreshToken) || introspection.FamilyID == "" {
+		return &TokenIntrospection{Active: false}, nil
+	}
+	return introspection, nil
+}
+
+func verifyAppUserToken(token string, appID string, deviceID *string, allowRotated bool) (*TokenIntrospection, error) {
+	inactive := &TokenIntrospection{Active: false}
+	appRepo := repository.ApplicationRepo()
+	app, err := appRepo.FindOneByFilter(map[string]any{
+		"appID": appID,
+	})
+	if err != nil {
+		logger.Error("an error occured while fetching app for token verification", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "appID", Data: appID,
+		})
+		return nil, err
+	}
+	if app == nil || app.Disabled {
+		return inactive, nil
+	}
+
+	parsedToken, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key := appTokenSigningKeyByID(app, kid)
+		if key == nil {
+			return nil, errors.New("unknown signing key")
+		}
+		// the algorithm is pinned to the key to prevent algorithm substitution
+		if t.Method.Alg() != key.Algorithm {
+			return nil, errors.New("unexpected signing algorithm")
+		}
+		return cryptography.ParsePublicSigningKey(key.PublicKey)
+	})
+	if err != nil || !parsedToken.Valid {
+		logger.Info("app user token failed verification", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "appID", Data: appID,
+		})
+		return inactive, nil
+	}
+	claims, ok := parsedToken.Claims.(jwt.MapClaims)
+	if !ok {
+		return inactive, nil
+	}
+	// exp is validated by jwt.Parse but tokens without one are not accepted
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return inactive, nil
+	}
+	iss, _ := claims["iss"].(string)
+	if iss != strings.ToLower(app.Name) {
+		return inactive, nil
+	}
+	tokenDeviceID, _ := claims["deviceID"].(string)
+	if deviceID != nil && *deviceID != tokenDeviceID {
+		return inactive, nil
+	}
+	userID, _ := claims["sub"].(string)
+	if userID == "" {
+		return inactive, nil
+	}
+	tokenType, _ := claims["tokenType"].(string)
+	// tokens from a revoked family are no longer valid and refresh tokens stop being valid once they are rotated
+	familyID, _ := claims["fam"].(string)
+	tokenID, _ := claims["jti"].(string)
+	if familyID != "" {
+		family := auth.FindRefreshTokenFamily(familyID)
+		if family == nil || family.Revoked {
+			return inactive, nil
+		}
+		if !allowRotated && tokenType == string(auth.RefreshToken) && family.CurrentTokenID != tokenID {
+			return inactive, nil
+		}
+	}
+
+	appUserRepo := repository.AppUserRepo()
+	appUser, err := appUserRepo.FindOneByFilter(map[string]any{
+		"userID": userID,
+		"appID":  app.AppID,
+	})
+	if err != nil {
+		logger.Error("an error occured while fetching app user for token verification", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "userID", Data: userID,
+		})
+		return nil, err
+	}
+	if appUser == nil || appUser.Blocked || appUser.DeletedAt != nil {
+		return inactive, nil
+	}
+
+	userRepo := repository.UserRepo()
+	user, err := userRepo.FindByID(userID)
+	if err != nil {
+		logger.Error("an error occured while fetching user for token verification", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key: "userID", Data: userID,
+		})
+		return nil, err
+	}
+	if user == nil {
+		return inactive, nil
+	}
+
+	// tokens issued outside the OIDC flow are granted every scope the app supports
+	scope, _ := claims["scope"].(string)
+	if scope == "" {
+		scope = strings.Join(services.OIDCSupportedScopes(app), " ")
+	}
+	iat, _ := claims["iat"].(float64)
+	return &TokenIntrospection{
+		Active:          true,
+		Sub:             userID,
+		Exp:             int64(exp),
+		Iat:             int64(iat),
+		Iss:             iss,
+		Scope:           scope,
+		ClientID:        app.AppID,
+		TokenType:       tokenType,
+		Jti:             tokenID,
+		FamilyID:        familyID,
+		RequestedFields: services.RequestedFieldValues(app, user),
+	}, nil
+}
+
+// tokens signed by a retired key keep verifying until the key's overlap window ends
+func appTokenSigningKeyByID(app *entities.Application, kid string) *entities.TokenSigningKey {
+	for _, key := range services.AppVerificationKeys(app) {
+		if key != nil && key.KeyID == kid {
+			return key
+		}
+	}
 	return nil
 }
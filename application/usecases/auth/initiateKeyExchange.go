@@ -4,6 +4,7 @@ import (
 	"crypto/ecdh"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
@@ -35,7 +36,7 @@ func InitiateKeyExchange(ctx any, deviceID string, clientPublicKey *ecdh.PublicK
 	success := cache.Cache.CreateEntry(fmt.Sprintf("%s-key", deviceID), *encryptedSecret, time.Minute*15)
 	if !success {
 		// apperrors.FatalServerError(ctx, nil, deviceID)
-		return nil, nil, err
+		return nil, nil, errors.New("could not save shared secret")
 	}
 	return serverPublicKey.Bytes(), &parsedSharedSecret, nil
 }
@@ -1,12 +1,10 @@
 package auth_usecases
 
 import (
-	"fmt"
 	"os"
 
 	"gateman.io/infrastructure/auth"
 	"gateman.io/infrastructure/cryptography"
-	"gateman.io/infrastructure/database/repository/cache"
 	"gateman.io/infrastructure/logger"
 	"github.com/golang-jwt/jwt"
 )
@@ -74,8 +72,7 @@ func IsUserSignedIn(ctx any, authToken any, intent *string, deviceID string) Use
 	}
 
 	// Validate token in cache
-	deviceIDHash, _ := cryptography.CryptoHahser.HashString(deviceID, []byte(os.Getenv("HASH_FIXED_SALT")))
-	validToken := cache.Cache.FindOne(fmt.Sprintf("%s-access", string(deviceIDHash)))
+	validToken := auth.FindDeviceSession(deviceID, "access")
 	if validToken == nil {
 		result.ErrorMessage = "this session has expired"
 		return result
@@ -0,0 +1,67 @@
+package auth_usecases
+
+import (
+	"fmt"
+	"time"
+
+	"gateman.io/entities"
+	"gateman.io/infrastructure/auth"
+)
+
+// A device the account is currently signed in on
+type Session struct {
+	DeviceID          string    `json:"deviceID"`
+	DeviceName        string    `json:"deviceName"`
+	UserAgent         string    `json:"userAgent"`
+	LastLogin         time.Time `json:"lastLogin"`
+	LastLoginLocation string    `json:"lastLoginLocation"`
+	Current           bool      `json:"current"`
+}
+
+// FetchSessionsUseCase returns the devices with an active session.
+// A device is active while a refresh token family started on it is active or, for tokens issued before families, while its refresh token is saved.
+func FetchSessionsUseCase(devices []entities.Device, ownerID string, ownerType auth.TokenFamilyOwner, currentDeviceID string) []Session {
+	activeDevices := map[string]bool{}
+	for _, family := range auth.FindOwnerRefreshTokenFamilies(ownerID, ownerType) {
+		activeDevices[family.DeviceID] = true
+	}
+	sessions := []Session{}
+	for _, device := range devices {
+		if !activeDevices[device.ID] && auth.FindDeviceSession(device.ID, sessionKeySuffix(ownerType, "refresh")) == nil {
+			continue
+		}
+		sessions = append(sessions, Session{
+			DeviceID:          device.ID,
+			DeviceName:        device.Name,
+			UserAgent:         device.UserAgent,
+			LastLogin:         device.LastLogin,
+			LastLoginLocation: device.LastLoginLocation,
+			Current:           device.ID == currentDeviceID,
+		})
+	}
+	return sessions
+}
+
+// RevokeSessionUseCase signs the owner out of a device.
+// The saved tokens of the device are deleted so issued access tokens stop working and the device's refresh token families are revoked.
+func RevokeSessionUseCase(ctx any, ownerID string, ownerType auth.TokenFamilyOwner, deviceID string, reason string) {
+	auth.SignOutDevice(ctx, deviceID, sessionKeySuffix(ownerType, "access"), reason)
+	auth.SignOutDevice(ctx, deviceID, sessionKeySuffix(ownerType, "refresh"), reason)
+	auth.RevokeOwnerRefreshTokenFamilies(ownerID, ownerType, &deviceID)
+}
+
+// SignOutEverywhereUseCase signs the owner out of every device they have used
+func SignOutEverywhereUseCase(ctx any, ownerID string, ownerType auth.TokenFamilyOwner, devices []entities.Device, reason string) {
+	for _, device := range devices {
+		RevokeSessionUseCase(ctx, ownerID, ownerType, device.ID, reason)
+	}
+	// families started on devices that are no longer saved on the account
+	auth.RevokeOwnerRefreshTokenFamilies(ownerID, ownerType, nil)
+}
+
+func sessionKeySuffix(ownerType auth.TokenFamilyOwner, tokenType string) string {
+	if ownerType == auth.WorkspaceMemberTokenFamily {
+		return fmt.Sprintf("workspace-%s", tokenType)
+	}
+	return tokenType
+}
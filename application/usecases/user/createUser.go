@@ -99,6 +99,7 @@ func CreateUserUseCase(ctx any, payload *dto.CreateUserDTO, deviceID string, use
 			account.Devices = append(account.Devices, entities.Device{
 				ID:        deviceID,
 				Name:      deviceName,
+				UserAgent: userAgent,
 				LastLogin: time.Now(),
 			})
 			_, err := userRepo.UpdateByID(account.ID, account)
@@ -171,6 +172,7 @@ func CreateUserUseCase(ctx any, payload *dto.CreateUserDTO, deviceID string, use
 			account.Devices = append(account.Devices, entities.Device{
 				ID:        deviceID,
 				Name:      deviceName,
+				UserAgent: userAgent,
 				LastLogin: time.Now(),
 			})
 			_, err := userRepo.UpdateByID(account.ID, account)
@@ -196,6 +198,7 @@ func CreateUserUseCase(ctx any, payload *dto.CreateUserDTO, deviceID string, use
 		account.Devices = append(account.Devices, entities.Device{
 			ID:        deviceID,
 			Name:      deviceName,
+			UserAgent: userAgent,
 			LastLogin: time.Now(),
 		})
 		_, err := userRepo.UpdateByID(account.ID, account)
@@ -252,6 +255,7 @@ func CreateUserUseCase(ctx any, payload *dto.CreateUserDTO, deviceID string, use
 		Devices: []entities.Device{{
 			ID:        deviceID,
 			Name:      deviceName,
+			UserAgent: userAgent,
 			LastLogin: time.Now(),
 		}},
 		UserAgent: userAgent,
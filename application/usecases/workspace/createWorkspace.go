@@ -63,6 +63,7 @@ func CreateWorkspaceUseCase(ctx any, payload *dto.CreateWorkspaceDTO, deviceID s
 					{
 						Name:              deviceName,
 						ID:                deviceID,
+						UserAgent:         userAgent,
 						Verified:          false,
 						LastLogin:         time.Now(),
 						LastLoginLocation: fmt.Sprintf("%s, %s - (%f, %f)", strings.ToUpper(ipLookupRes.City), strings.ToUpper(ipLookupRes.CountryCode), ipLookupRes.Longitude, ipLookupRes.Latitude),
@@ -0,0 +1,128 @@
+package workspace_usecases
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	apperrors "gateman.io/application/appErrors"
+	"gateman.io/application/constants"
+	"gateman.io/application/repository"
+	mfa_services "gateman.io/application/services/mfa"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/auth"
+	"gateman.io/infrastructure/database/repository/cache"
+	"gateman.io/infrastructure/logger"
+	server_response "gateman.io/infrastructure/serverResponse"
+)
+
+// the otp intent of the token members use to set up MFA when the workspace policy requires it before they can log in
+const WorkspaceMFASetupIntent = "workspace_mfa_setup"
+
+// Checks the member's second factor during login.
+// Members who must use MFA but have not set it up are sent SET_UP_WORKSPACE_MFA along with a short lived token for the set up routes.
+// Returns true if the member can be logged in. A response has already been sent when it returns false.
+func VerifyWorkspaceMemberMFA(ctx any, member *entities.WorkspaceMember, workspace *entities.Workspace, mfaCode *string, recoveryCode *string, deviceID string) bool {
+	if !member.MFAEnabled {
+		if workspace != nil && workspace.RequireMemberMFA && member.HoldsMFAEnforcedPermission() {
+			token, err := auth.GenerateAuthToken(auth.ClaimsData{
+				Email:     &member.Email,
+				Intent:    WorkspaceMFASetupIntent,
+				IssuedAt:  time.Now().Unix(),
+				ExpiresAt: time.Now().Add(time.Minute * time.Duration(10)).Unix(), //lasts for 10 mins
+			})
+			if err != nil {
+				apperrors.FatalServerError(ctx, err, deviceID)
+				return false
+			}
+			cache.Cache.CreateEntry(fmt.Sprintf("%s-otp-intent", member.Email), WorkspaceMFASetupIntent, time.Minute*10)
+			server_response.Responder.Respond(ctx, http.StatusBadRequest, fmt.Sprintf("%s requires you to set up an authenticator app before logging in", workspace.Name), map[string]any{
+				"otpAccessToken": token,
+			}, nil, &constants.SET_UP_WORKSPACE_MFA, &deviceID)
+			return false
+		}
+		return true
+	}
+	if mfaCode == nil && recoveryCode == nil {
+		apperrors.ClientError(ctx, "Enter the code from your authenticator app", nil, &constants.WORKSPACE_MFA_REQUIRED, deviceID)
+		return false
+	}
+	if mfaCode != nil {
+		valid, err := mfa_services.VerifyCode(member.ID, *member.AuthenticatorSecret, *mfaCode)
+		if errors.Is(err, mfa_services.ErrMFALocked) {
+			apperrors.AuthenticationError(ctx, "Too many incorrect codes. Try again later", deviceID)
+			return false
+		}
+		if err != nil {
+			logger.Error("an error occured while verifying workspace member authenticator code", logger.LoggerOptions{
+				Key: "err", Data: err,
+			}, logger.LoggerOptions{
+				Key:  "memberID",
+				Data: member.ID,
+			})
+			apperrors.UnknownError(ctx, err, nil, deviceID)
+			return false
+		}
+		if !valid {
+			apperrors.AuthenticationError(ctx, "Incorrect authenticator code", deviceID)
+			return false
+		}
+		return true
+	}
+	matchedHash, remainingCodes, err := mfa_services.MatchRecoveryCode(member.ID, member.AccountRecoveryTokens, *recoveryCode)
+	if errors.Is(err, mfa_services.ErrMFALocked) {
+		apperrors.AuthenticationError(ctx, "Too many incorrect codes. Try again later", deviceID)
+		return false
+	}
+	if err != nil {
+		logger.Error("an error occured while matching workspace member recovery code", logger.LoggerOptions{
+			Key: "err", Data: err,
+		}, logger.LoggerOptions{
+			Key:  "memberID",
+			Data: member.ID,
+		})
+		apperrors.UnknownError(ctx, err, nil, deviceID)
+		return false
+	}
+	if matchedHash == nil {
+		apperrors.AuthenticationError(ctx, "Incorrect recovery code", deviceID)
+		return false
+	}
+	// the filter on the used code makes redeeming it atomic when the same code is sent concurrently
+	workspaceMemberRepo := repository.WorkspaceMemberRepo()
+	redeemed, err := workspaceMemberRepo.UpdatePartialByFilter(map[string]any{
+		"_id":                   member.ID,
+		"accountRecoveryTokens": *matchedHash,
+	}, map[string]any{
+		"accountRecoveryTokens": remainingCodes,
+	})
+	if err != nil || !redeemed {
+		apperrors.AuthenticationError(ctx, "Incorrect recovery code", deviceID)
+		return false
+	}
+	return true
+}
+
+// Saves the member's pending TOTP secret if code was generated from it and returns their recovery codes
+func ConfirmWorkspaceMemberMFAEnrollment(member *entities.WorkspaceMember, code string) (recoveryCodes []string, confirmed bool, err error) {
+	encryptedSecret, confirmed, err := mfa_services.ConfirmEnrollment(member.ID, code)
+	if err != nil || !confirmed {
+		return nil, false, err
+	}
+	recoveryCodes, hashedRecoveryCodes, err := mfa_services.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, false, err
+	}
+	workspaceMemberRepo := repository.WorkspaceMemberRepo()
+	_, err = workspaceMemberRepo.UpdatePartialByID(member.ID, map[string]any{
+		"mfaEnabled":            true,
+		"authenticatorSecret":   *encryptedSecret,
+		"accountRecoveryTokens": hashedRecoveryCodes,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	mfa_services.CompleteEnrollment(member.ID, code)
+	return recoveryCodes, true, nil
+}
@@ -8,9 +8,9 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
-	mathRand "math/rand"
 	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"regexp"
 	"strings"
@@ -27,6 +27,14 @@ func GetStringPointer(text string) *string {
 	return &text
 }
 
+// Returns the value of text or an empty string when it is nil
+func GetStringValue(text *string) string {
+	if text == nil {
+		return ""
+	}
+	return *text
+}
+
 func GetBooleanPointer(data bool) *bool {
 	return &data
 }
@@ -65,6 +73,7 @@ func HasItemString(arr *[]string, target string) bool {
 	return false
 }
 
+// Returns true if any of addresses is neither an IP address nor a CIDR range
 func ValidateIPAddresses(addresses []string) bool {
 	invalid := false
 
@@ -72,18 +81,45 @@ func ValidateIPAddresses(addresses []string) bool {
 		// Remove any whitespace
 		addr = strings.TrimSpace(addr)
 
-		// Try parsing as IP address
+		// Try parsing as IP address and then as a CIDR range
 		ip := net.ParseIP(addr)
 
 		if ip == nil {
-			invalid = true
-			break
+			if _, _, err := net.ParseCIDR(addr); err != nil {
+				invalid = true
+				break
+			}
 		}
 	}
 
 	return invalid
 }
 
+// Returns true if ip is one of the IP addresses or is in one of the CIDR ranges of allowlist.
+// IPv4 addresses mapped to IPv6 match their IPv4 entries.
+func IPMatchesAllowlist(ip string, allowlist []string) bool {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, entry := range allowlist {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err == nil && prefix.Masked().Contains(addr) {
+				return true
+			}
+			continue
+		}
+		allowed, err := netip.ParseAddr(entry)
+		if err == nil && allowed.Unmap() == addr {
+			return true
+		}
+	}
+	return false
+}
+
 func MakeStringArrayUnique(arr []string) []string {
 	seen := make(map[string]struct{})
 	for _, str := range arr {
@@ -122,20 +158,23 @@ func GenerateRandomHexKey(length int) (string, error) {
 	return hexString[:length], nil
 }
 
-func GenerateAccountRecoveryCodes(amount int) []string {
-	mathRand.New(mathRand.NewSource(time.Now().UnixNano()))
-
-	// Create a map to store unique codes
+// Generates unique single use recovery codes in the format xxxxx-xxxxx
+func GenerateAccountRecoveryCodes(amount int) ([]string, error) {
 	uniqueCodes := make(map[string]bool)
 	uniqueCodeArr := []string{}
-
-	// Generate 8 unique 6-digit codes
-	for len(uniqueCodes) < amount {
-		code := fmt.Sprintf("%06d", mathRand.Intn(1000000))
+	for len(uniqueCodeArr) < amount {
+		code, err := GenerateRandomHexKey(10)
+		if err != nil {
+			return nil, err
+		}
+		code = fmt.Sprintf("%s-%s", code[:5], code[5:])
+		if uniqueCodes[code] {
+			continue
+		}
 		uniqueCodes[code] = true
 		uniqueCodeArr = append(uniqueCodeArr, code)
 	}
-	return uniqueCodeArr
+	return uniqueCodeArr, nil
 }
 
 func IsBase64Image(input string) bool {
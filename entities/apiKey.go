@@ -0,0 +1,78 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"gateman.io/application/utils"
+)
+
+type APIKeyScope string
+
+var (
+	APIKeyAppRead           APIKeyScope = "app:read"
+	APIKeyTokensIntrospect  APIKeyScope = "tokens:introspect"
+	APIKeyKYCNIN            APIKeyScope = "kyc:nin"
+	APIKeyKYCBVN            APIKeyScope = "kyc:bvn"
+	APIKeyKYCVotersCard     APIKeyScope = "kyc:voters_card"
+	APIKeyKYCDriversLicense APIKeyScope = "kyc:drivers_license"
+	APIKeyBiometricCompare  APIKeyScope = "biometric:compare"
+	APIKeyBiometricLiveness APIKeyScope = "biometric:liveness"
+)
+
+// Returns true for scopes of the KYC api, which production keys can only call once their workspace is verified
+func (scope APIKeyScope) KYC() bool {
+	return strings.HasPrefix(string(scope), "kyc:")
+}
+
+// the scopes an api key can be created with
+var APIKeyScopes = []APIKeyScope{
+	APIKeyAppRead, APIKeyTokensIntrospect, APIKeyKYCNIN, APIKeyKYCBVN, APIKeyKYCVotersCard, APIKeyKYCDriversLicense, APIKeyBiometricCompare, APIKeyBiometricLiveness,
+}
+
+// This represents one of the many api keys an application can call the public api with
+type APIKey struct {
+	AppID       string        `bson:"appID" json:"appID"`
+	WorkspaceID string        `bson:"workspaceID" json:"-"`
+	Label       string        `bson:"label" json:"label"`
+	HashedKey   string        `bson:"hashedKey" json:"-"`
+	Scopes      []APIKeyScope `bson:"scopes" json:"scopes"`
+	Sandbox     bool          `bson:"sandbox" json:"sandbox"`
+	ExpiresAt   *time.Time    `bson:"expiresAt" json:"expiresAt"`
+	LastUsedAt  *time.Time    `bson:"lastUsedAt" json:"lastUsedAt"`
+	LastUsedIP  *string       `bson:"lastUsedIP" json:"lastUsedIP"`
+	RevokedAt   *time.Time    `bson:"revokedAt" json:"revokedAt"`
+	CreatorID   string        `bson:"creatorID" json:"creatorID"`
+	// the key this key was created to replace
+	RotatedFrom *string `bson:"rotatedFrom" json:"rotatedFrom"`
+
+	ID        string    `bson:"_id" json:"id"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+func (model APIKey) ParseModel() any {
+	now := time.Now()
+	if model.CreatedAt.IsZero() {
+		model.CreatedAt = now
+		if model.ID == "" {
+			model.ID = utils.GenerateUULDString()
+		}
+	}
+	model.UpdatedAt = now
+	return &model
+}
+
+// Returns true if the key has not been revoked and has not expired
+func (model *APIKey) Active() bool {
+	return model.RevokedAt == nil && (model.ExpiresAt == nil || model.ExpiresAt.After(time.Now()))
+}
+
+func (model *APIKey) HasScope(scope APIKeyScope) bool {
+	for _, s := range model.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -242,6 +242,33 @@ var ValidationRules map[string]ValidationRule = map[string]ValidationRule{
 	},
 }
 
+// An asymmetric key pair used to sign the tokens issued to an application's users.
+type TokenSigningKey struct {
+	Algorithm  string    `bson:"algorithm" json:"algorithm"`
+	KeyID      string    `bson:"keyID" json:"keyID"`
+	PrivateKey string    `bson:"privateKey" json:"-"` // PEM encoded and encrypted with ENC_KEY
+	PublicKey  string    `bson:"publicKey" json:"publicKey"`
+	Version    uint32    `bson:"version" json:"version"` // 0 for keys created before signing keys were versioned
+	CreatedAt  time.Time `bson:"createdAt" json:"createdAt"`
+}
+
+// A signing key version replaced by a rotation. It keeps verifying tokens until RetiresAt so tokens issued
+// before the rotation stay valid, and its app signing key still decrypts requested fields encrypted before the rotation.
+type RetiredSigningKey struct {
+	Sandbox         bool            `bson:"sandbox" json:"sandbox"`
+	TokenSigningKey TokenSigningKey `bson:"tokenSigningKey" json:"tokenSigningKey"`
+	AppSigningKey   string          `bson:"appSigningKey" json:"-"` // encrypted with ENC_KEY
+	RetiredAt       time.Time       `bson:"retiredAt" json:"retiredAt"`
+	RetiresAt       time.Time       `bson:"retiresAt" json:"retiresAt"`
+}
+
+// How often an app's signing keys are rotated by the scheduled rotation
+type KeyRotationPolicy struct {
+	IntervalDays   uint16    `bson:"intervalDays" json:"intervalDays"`
+	OverlapHours   uint16    `bson:"overlapHours" json:"overlapHours"`
+	NextRotationAt time.Time `bson:"nextRotationAt" json:"nextRotationAt"`
+}
+
 type Application struct {
 	Name                   string               `bson:"name" json:"name"`
 	Disabled               bool                 `bson:"disabled" json:"disabled"`
@@ -256,6 +283,10 @@ type Application struct {
 	AppSigningKey          string               `bson:"appSigningKey" json:"-"`
 	SandboxAppSigningKey   string               `bson:"sandBoxAppSigningKey" json:"-"`
 	SandboxAPIKey          string               `bson:"sandBoxAPIKey" json:"-"`
+	TokenSigningKey        *TokenSigningKey     `bson:"tokenSigningKey" json:"tokenSigningKey"`
+	SandboxTokenSigningKey *TokenSigningKey     `bson:"sandboxTokenSigningKey" json:"sandboxTokenSigningKey"`
+	RetiredSigningKeys     []RetiredSigningKey  `bson:"retiredSigningKeys" json:"-"`
+	SigningKeyRotation     *KeyRotationPolicy   `bson:"signingKeyRotation" json:"signingKeyRotation"`
 	APIKey                 string               `bson:"apiKey" json:"-"`
 	VPN                    bool                 `bson:"vpn" json:"vpn"`
 	RefreshTokenTTL        uint32               `bson:"refreshTokenTTL" json:"refreshTokenTTL"`
@@ -267,7 +298,14 @@ type Application struct {
 	LocaleRestriction      *[]LocaleRestriction `bson:"localeRestriction" json:"localeRestriction"`
 	CustomFields           *[]CustomFormField   `bson:"customFields" json:"customFields"`
 	PaymentCard            *string              `bson:"paymentCard" json:"paymentCard"`
-	WhiteListedIPs         *[]string            `bson:"whiteListedIPs" json:"whiteListedIPs"`
+	WhiteListedIPs         *[]string            `bson:"whiteListedIPs" json:"whiteListedIPs"`               // IPs and CIDR ranges allowed to call the public api with production keys. Empty allows every IP
+	SandboxWhiteListedIPs  *[]string            `bson:"sandboxWhiteListedIPs" json:"sandboxWhiteListedIPs"` // same as WhiteListedIPs for sandbox keys
+	RedirectURIs           *[]string            `bson:"redirectURIs" json:"redirectURIs"`                   // the registered redirect uris of the app when used as an OIDC client
+	RequestSigningSecret   string               `bson:"requestSigningSecret" json:"-"`                      // hex HMAC secret used to sign public api requests, encrypted with ENC_KEY
+	RequireSignedRequests  bool                 `bson:"requireSignedRequests" json:"requireSignedRequests"`
+	RejectScreeningHits    bool                 `bson:"rejectScreeningHits" json:"rejectScreeningHits"` // sign ups of users with a sanctions, PEP or watch list hit that is not cleared are rejected
+	KYCCallbackURL         *string              `bson:"kycCallbackURL" json:"kycCallbackURL"`           // the https url the results of KYC jobs are posted to when they complete
+	KYCCallbackSecret      string               `bson:"kycCallbackSecret" json:"-"`                     // hex HMAC secret KYC job callbacks are signed with, encrypted with the keyring
 
 	ID            string     `bson:"_id" json:"id"`
 	CreatedAt     time.Time  `bson:"createdAt" json:"createdAt"`
@@ -0,0 +1,38 @@
+package entities
+
+import (
+	"time"
+
+	"gateman.io/application/utils"
+)
+
+// The data key a subject's personal data is encrypted with, wrapped by a master key in the keystore.
+// Deleting it makes everything encrypted with it unrecoverable.
+type DataKey struct {
+	SubjectID   string `bson:"subjectID" json:"subjectID"`
+	WrappedKey  string `bson:"wrappedKey" json:"-"`
+	MasterKeyID string `bson:"masterKeyID" json:"masterKeyID"`
+
+	ID        string    `bson:"_id" json:"id"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+func (model DataKey) ParseModel() any {
+	now := time.Now()
+	if model.CreatedAt.IsZero() {
+		model.CreatedAt = now
+		if model.ID == "" {
+			model.ID = utils.GenerateUULDString()
+		}
+	}
+	model.UpdatedAt = now
+	return &model
+}
+
+// A record encrypted field by field with the data key of SubjectID. Fields maps the json name of each field to
+// hex(nonce || ciphertext || tag) of its json value so callers only decrypt the fields they use.
+type ProtectedRecord struct {
+	SubjectID string            `bson:"subjectID" json:"subjectID"`
+	Fields    map[string]string `bson:"fields" json:"fields"`
+}
@@ -7,10 +7,10 @@ import (
 )
 
 type KYCIdentityData struct {
-	UserID string `bson:"userID" json:"userID"`
-	IDHash string `bson:"idHash" json:"idHash"`
-	Name   string `bson:"name" json:"name"`
-	Data   any    `bson:"data" json:"data"`
+	UserID string           `bson:"userID" json:"userID"`
+	IDHash string           `bson:"idHash" json:"idHash"`
+	Name   string           `bson:"name" json:"name"`
+	Data   *ProtectedRecord `bson:"data" json:"-"` // encrypted with the data key of the user
 
 	ID            string     `bson:"_id" json:"id"`
 	CreatedAt     time.Time  `bson:"createdAt" json:"createdAt"`
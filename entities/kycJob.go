@@ -0,0 +1,96 @@
+package entities
+
+import (
+	"time"
+
+	"gateman.io/application/utils"
+)
+
+// The identity checks a KYC job can run, named like the metered lookups they are billed as
+type KYCJobCheck string
+
+const (
+	KYCJobNIN            KYCJobCheck = "nin"
+	KYCJobBVN            KYCJobCheck = "bvn"
+	KYCJobVotersCard     KYCJobCheck = "voters_card"
+	KYCJobDriversLicense KYCJobCheck = "drivers_license"
+)
+
+type KYCJobStatus string
+
+const (
+	KYCJobProcessing KYCJobStatus = "processing"
+	KYCJobCompleted  KYCJobStatus = "completed"
+)
+
+type KYCJobItemStatus string
+
+const (
+	KYCJobItemPending  KYCJobItemStatus = "pending"
+	KYCJobItemFound    KYCJobItemStatus = "found"
+	KYCJobItemNotFound KYCJobItemStatus = "not_found"
+	// the provider could not be reached after every retry
+	KYCJobItemFailed KYCJobItemStatus = "failed"
+)
+
+type KYCCallbackStatus string
+
+const (
+	KYCCallbackPending   KYCCallbackStatus = "pending"
+	KYCCallbackDelivered KYCCallbackStatus = "delivered"
+	// every delivery attempt failed, the results can still be polled
+	KYCCallbackFailed KYCCallbackStatus = "failed"
+)
+
+// One id of a KYC job and the result of looking it up
+type KYCJobItem struct {
+	Index int `bson:"index" json:"index"`
+	// the id with every character but the last 4 masked
+	MaskedID    string           `bson:"maskedID" json:"maskedID"`
+	EncryptedID string           `bson:"encryptedID" json:"-"`
+	Status      KYCJobItemStatus `bson:"status" json:"status"`
+	// the provider record as json, encrypted with the keyring
+	Data        *string    `bson:"data" json:"-"`
+	Error       *string    `bson:"error" json:"error"`
+	CompletedAt *time.Time `bson:"completedAt" json:"completedAt"`
+}
+
+// This represents a batch of identity lookups submitted through the public api.
+// Jobs and their results are deleted once they expire.
+type KYCJob struct {
+	AppID       string       `bson:"appID" json:"appID"`
+	WorkspaceID string       `bson:"workspaceID" json:"-"`
+	Sandbox     bool         `bson:"sandbox" json:"sandbox"`
+	Check       KYCJobCheck  `bson:"check" json:"check"`
+	Reference   *string      `bson:"reference" json:"reference"` // set by the caller to tie the job to their records
+	Status      KYCJobStatus `bson:"status" json:"status"`
+	Items       []KYCJobItem `bson:"items" json:"items"`
+	// items still pending, the job completes when it reaches 0
+	Remaining   int        `bson:"remaining" json:"remaining"`
+	CompletedAt *time.Time `bson:"completedAt" json:"completedAt"`
+	ExpiresAt   time.Time  `bson:"expiresAt" json:"expiresAt"`
+	// the quota reserved for the items when the job was submitted, released for items that could not be looked up
+	QuotaReservation LookupReservation `bson:"quotaReservation" json:"-"`
+
+	// nil when the app had no callback url when the job completed
+	CallbackStatus      *KYCCallbackStatus `bson:"callbackStatus" json:"callbackStatus"`
+	CallbackAttempts    int                `bson:"callbackAttempts" json:"callbackAttempts"`
+	NextCallbackAt      *time.Time         `bson:"nextCallbackAt" json:"-"`
+	CallbackDeliveredAt *time.Time         `bson:"callbackDeliveredAt" json:"callbackDeliveredAt"`
+
+	ID        string    `bson:"_id" json:"id"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+func (model KYCJob) ParseModel() any {
+	now := time.Now()
+	if model.CreatedAt.IsZero() {
+		model.CreatedAt = now
+		if model.ID == "" {
+			model.ID = utils.GenerateUULDString()
+		}
+	}
+	model.UpdatedAt = now
+	return &model
+}
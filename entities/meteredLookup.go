@@ -0,0 +1,133 @@
+package entities
+
+import (
+	"time"
+
+	"gateman.io/application/utils"
+)
+
+// The billable lookups of the public api, named like the KYC job checks for government ids
+type MeteredLookupType string
+
+const (
+	NINLookup            MeteredLookupType = "nin"
+	BVNLookup            MeteredLookupType = "bvn"
+	VotersCardLookup     MeteredLookupType = "voters_card"
+	DriversLicenseLookup MeteredLookupType = "drivers_license"
+	FaceComparisonLookup MeteredLookupType = "face_comparison"
+	LivenessLookup       MeteredLookupType = "liveness"
+	VideoLivenessLookup  MeteredLookupType = "video_liveness"
+)
+
+var MeteredLookupTypes = []MeteredLookupType{
+	NINLookup, BVNLookup, VotersCardLookup, DriversLicenseLookup, FaceComparisonLookup, LivenessLookup, VideoLivenessLookup,
+}
+
+// Lookups share a monthly quota with the other lookups of their category
+type MeteredLookupCategory string
+
+const (
+	GovernmentIDLookups MeteredLookupCategory = "government_id"
+	BiometricLookups    MeteredLookupCategory = "biometric"
+)
+
+func (lookupType MeteredLookupType) Category() MeteredLookupCategory {
+	switch lookupType {
+	case FaceComparisonLookup, LivenessLookup, VideoLivenessLookup:
+		return BiometricLookups
+	}
+	return GovernmentIDLookups
+}
+
+// Types returns the lookup types in category
+func (category MeteredLookupCategory) Types() []MeteredLookupType {
+	types := []MeteredLookupType{}
+	for _, lookupType := range MeteredLookupTypes {
+		if lookupType.Category() == category {
+			types = append(types, lookupType)
+		}
+	}
+	return types
+}
+
+type LookupSource string
+
+const (
+	// answered from our lookup cache without calling a provider
+	CacheLookup    LookupSource = "cache"
+	ProviderLookup LookupSource = "provider"
+)
+
+// This represents one billable lookup made by an app. Lookups are billed to the app whatever their source,
+// the source tells what the lookup cost us.
+type MeteredLookup struct {
+	AppID       string                `bson:"appID" json:"appID"`
+	WorkspaceID string                `bson:"workspaceID" json:"workspaceID"`
+	Type        MeteredLookupType     `bson:"type" json:"type"`
+	Category    MeteredLookupCategory `bson:"category" json:"category"`
+	Source      LookupSource          `bson:"source" json:"source"`
+	Sandbox     bool                  `bson:"sandbox" json:"sandbox"`
+	JobID       *string               `bson:"jobID" json:"jobID"` // set when the lookup was made for a KYC job
+	// the month of the lookup as 2006-01, quotas reset every month
+	Period string               `bson:"period" json:"period"`
+	Plan   SubscriptionPlanName `bson:"plan" json:"plan"`
+	// true when the quota of the plan was used up before the lookup
+	Overage  bool       `bson:"overage" json:"overage"`
+	BilledAt *time.Time `bson:"billedAt" json:"billedAt"` // set on overage lookups once they are added to a charge
+
+	ID        string    `bson:"_id" json:"id"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+func (model MeteredLookup) ParseModel() any {
+	now := time.Now()
+	if model.CreatedAt.IsZero() {
+		model.CreatedAt = now
+		if model.ID == "" {
+			model.ID = utils.GenerateUULDString()
+		}
+	}
+	model.UpdatedAt = now
+	return &model
+}
+
+// Lookups an app reserved against the quota of its plan before making them.
+// Count is 0 when nothing was reserved because the lookups were sandbox ones or the plan does not stop the app.
+type LookupReservation struct {
+	Plan     SubscriptionPlanName  `bson:"plan" json:"-"`
+	Category MeteredLookupCategory `bson:"category" json:"-"`
+	Period   string                `bson:"period" json:"-"`
+	Count    int64                 `bson:"count" json:"-"`
+}
+
+// Counts the lookups of a category an app made in a month. Quotas are reserved and overage is worked out with one
+// atomic update of the counter instead of counting the ledger, so concurrent lookups can not go past a quota.
+// Sandbox lookups are not counted.
+type LookupQuotaCounter struct {
+	AppID    string                `bson:"appID" json:"appID"`
+	Period   string                `bson:"period" json:"period"`
+	Category MeteredLookupCategory `bson:"category" json:"category"`
+	// lookups let through by plans that stop apps at their quota, reserved before they are made
+	Reserved int64 `bson:"reserved" json:"reserved"`
+	// lookups recorded in the ledger, the ones past the quota of the plan are overage
+	Recorded int64 `bson:"recorded" json:"recorded"`
+	// set once the overage of the month has been charged, or found to be nothing, after the month ended
+	OverageSettledAt *time.Time `bson:"overageSettledAt" json:"overageSettledAt"`
+
+	ID        string    `bson:"_id" json:"id"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+func (model LookupQuotaCounter) ParseModel() any {
+	now := time.Now()
+	if model.CreatedAt.IsZero() {
+		model.CreatedAt = now
+		if model.ID == "" {
+			model.ID = utils.GenerateUULDString()
+		}
+	}
+	model.UpdatedAt = now
+	return &model
+}
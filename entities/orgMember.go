@@ -35,6 +35,16 @@ const (
 	SUPER_ACCESS MemberPermissions = "*"
 )
 
+// Members holding any of these permissions must use MFA when their workspace requires it
+var MFAEnforcedPermissions = []MemberPermissions{
+	SUPER_ACCESS,
+	WORKSPACE_BILLING,
+	WORKSPACE_CREATE_APPLICATIONS,
+	WORKSPACE_EDIT_APPLICATIONS,
+	WORKSPACE_DELETE_APPLICATIONS,
+	MEMBER_EDIT_ACCESS,
+}
+
 type WorkspaceMember struct {
 	FirstName     string              `bson:"firstName" json:"firstName"`
 	LastName      string              `bson:"lastName" json:"lastName"`
@@ -49,6 +59,10 @@ type WorkspaceMember struct {
 	UserAgent     string              `bson:"userAgent" json:"userAgent"`
 	Devices       []Device            `bson:"devices" json:"devices"`
 
+	MFAEnabled            bool      `bson:"mfaEnabled" json:"mfaEnabled"`
+	AuthenticatorSecret   *string   `bson:"authenticatorSecret" json:"-"`
+	AccountRecoveryTokens *[]string `bson:"accountRecoveryTokens" json:"-"`
+
 	ID            string     `bson:"_id" json:"id"`
 	CreatedAt     time.Time  `bson:"createdAt" json:"createdAt"`
 	UpdatedAt     time.Time  `bson:"updatedAt" json:"updatedAt"`
@@ -56,6 +70,35 @@ type WorkspaceMember struct {
 	DeletedReason *string    `bson:"deletedReason" json:"deletedReason"`
 }
 
+// Returns true if the member holds a permission that requires MFA under the workspace MFA policy
+func (model WorkspaceMember) HoldsMFAEnforcedPermission() bool {
+	for _, permission := range model.Permissions {
+		for _, enforced := range MFAEnforcedPermissions {
+			if permission == enforced {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Returns true if the member holds every permission the other member holds. SUPER_ACCESS covers every permission.
+func (model WorkspaceMember) HoldsPermissionsOf(other WorkspaceMember) bool {
+	held := map[MemberPermissions]bool{}
+	for _, permission := range model.Permissions {
+		if permission == SUPER_ACCESS {
+			return true
+		}
+		held[permission] = true
+	}
+	for _, permission := range other.Permissions {
+		if !held[permission] {
+			return false
+		}
+	}
+	return true
+}
+
 func (model WorkspaceMember) ParseModel() any {
 	now := time.Now()
 	if model.CreatedAt.IsZero() {
@@ -0,0 +1,72 @@
+package entities
+
+import (
+	"time"
+
+	"gateman.io/application/utils"
+)
+
+type ScreeningListType string
+
+const (
+	SanctionsList ScreeningListType = "sanctions"
+	PEPList       ScreeningListType = "pep"
+	WatchList     ScreeningListType = "watchlist"
+)
+
+type ScreeningHitStatus string
+
+const (
+	// awaiting review
+	ScreeningHitOpen ScreeningHitStatus = "open"
+	// reviewed and found to be somebody else
+	ScreeningHitCleared ScreeningHitStatus = "cleared"
+	// reviewed and found to be the user
+	ScreeningHitConfirmed ScreeningHitStatus = "confirmed"
+)
+
+// What a user was screened after
+type ScreeningTrigger string
+
+const (
+	NINScreening ScreeningTrigger = "nin"
+	BVNScreening ScreeningTrigger = "bvn"
+	Rescreening  ScreeningTrigger = "rescreen"
+)
+
+// This represents a verified user whose identity matched an entry of a sanctions, PEP or watch list.
+// Hits are opened for review. Apps that reject hits review them for themselves in a ScreeningHitReview.
+type ScreeningHit struct {
+	UserID   string            `bson:"userID" json:"userID"`
+	ListType ScreeningListType `bson:"listType" json:"listType"`
+	// the list the entry was published on and its reference there
+	Source    string `bson:"source" json:"source"`
+	Reference string `bson:"reference" json:"reference"`
+	EntryName string `bson:"entryName" json:"entryName"`
+	// the verified name of the user that matched the entry
+	MatchedName string  `bson:"matchedName" json:"matchedName"`
+	NameScore   float64 `bson:"nameScore" json:"nameScore"`
+	// nil when the entry or the user has no date of birth to compare
+	DOBMatch   *bool              `bson:"dobMatch" json:"dobMatch"`
+	Trigger    ScreeningTrigger   `bson:"trigger" json:"trigger"`
+	Status     ScreeningHitStatus `bson:"status" json:"status"`
+	ReviewedBy *string            `bson:"reviewedBy" json:"reviewedBy"`
+	ReviewNote *string            `bson:"reviewNote" json:"reviewNote"`
+	ReviewedAt *time.Time         `bson:"reviewedAt" json:"reviewedAt"`
+
+	ID        string    `bson:"_id" json:"id"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+func (model ScreeningHit) ParseModel() any {
+	now := time.Now()
+	if model.CreatedAt.IsZero() {
+		model.CreatedAt = now
+		if model.ID == "" {
+			model.ID = utils.GenerateUULDString()
+		}
+	}
+	model.UpdatedAt = now
+	return &model
+}
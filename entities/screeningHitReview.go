@@ -0,0 +1,37 @@
+package entities
+
+import (
+	"time"
+
+	"gateman.io/application/utils"
+)
+
+// This represents an app's review of a screening hit. Hits belong to the user so every app that rejects hits reviews
+// them for itself and a hit the app cleared no longer stops the user from signing up to it.
+// Reviews are opened when a user with a hit tries to sign up to the app.
+type ScreeningHitReview struct {
+	HitID       string             `bson:"hitID" json:"hitID"`
+	UserID      string             `bson:"userID" json:"userID"`
+	AppID       string             `bson:"appID" json:"-"`
+	WorkspaceID string             `bson:"workspaceID" json:"-"`
+	Status      ScreeningHitStatus `bson:"status" json:"status"`
+	ReviewedBy  *string            `bson:"reviewedBy" json:"reviewedBy"`
+	ReviewNote  *string            `bson:"reviewNote" json:"reviewNote"`
+	ReviewedAt  *time.Time         `bson:"reviewedAt" json:"reviewedAt"`
+
+	ID        string    `bson:"_id" json:"id"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+func (model ScreeningHitReview) ParseModel() any {
+	now := time.Now()
+	if model.CreatedAt.IsZero() {
+		model.CreatedAt = now
+		if model.ID == "" {
+			model.ID = utils.GenerateUULDString()
+		}
+	}
+	model.UpdatedAt = now
+	return &model
+}
@@ -0,0 +1,43 @@
+package entities
+
+import (
+	"time"
+
+	"gateman.io/application/utils"
+)
+
+type SecurityEventType string
+
+var (
+	RefreshTokenReuseEvent SecurityEventType = "refresh_token_reuse"
+	PasskeyCloneSuspected  SecurityEventType = "passkey_clone_suspected"
+	AppIPNotAllowed        SecurityEventType = "app_ip_not_allowed"
+)
+
+// This represents a security relevant occurrence such as a stolen token being replayed
+type SecurityEvent struct {
+	Type        SecurityEventType `bson:"type" json:"type"`
+	ActorID     *string           `bson:"actorID" json:"actorID"`
+	ActorType   string            `bson:"actorType" json:"actorType"`
+	AppID       *string           `bson:"appID" json:"appID"`
+	WorkspaceID *string           `bson:"workspaceID" json:"workspaceID"`
+	DeviceID    *string           `bson:"deviceID" json:"deviceID"`
+	IPAddress   *string           `bson:"ipAddress" json:"ipAddress"`
+	Metadata    map[string]any    `bson:"metadata" json:"metadata"`
+
+	ID        string    `bson:"_id" json:"id"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+func (model SecurityEvent) ParseModel() any {
+	now := time.Now()
+	if model.CreatedAt.IsZero() {
+		model.CreatedAt = now
+		if model.ID == "" {
+			model.ID = utils.GenerateUULDString()
+		}
+	}
+	model.UpdatedAt = now
+	return &model
+}
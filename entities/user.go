@@ -11,10 +11,27 @@ type Device struct {
 	LastLogin         time.Time `bson:"lastLogin" json:"lastLogin"`
 	LastLoginLocation string    `bson:"lastLoginLocation" json:"lastLoginLocation"`
 	Name              string    `bson:"name" json:"name"`
+	UserAgent         string    `bson:"userAgent" json:"userAgent"`
 	ID                string    `bson:"id" json:"id"`
 	Verified          bool      `bson:"verified" json:"-"`
 }
 
+// A WebAuthn credential (passkey) the user can sign in with instead of face match
+type PasskeyCredential struct {
+	// base64url encoded credential id
+	ID string `bson:"id" json:"id"`
+	// COSE_Key encoded public key
+	PublicKey      []byte     `bson:"publicKey" json:"-"`
+	Algorithm      int64      `bson:"algorithm" json:"-"`
+	SignCount      uint32     `bson:"signCount" json:"-"`
+	AAGUID         string     `bson:"aaguid" json:"aaguid"`
+	Name           string     `bson:"name" json:"name"`
+	Transports     []string   `bson:"transports" json:"transports"`
+	BackupEligible bool       `bson:"backupEligible" json:"backupEligible"`
+	CreatedAt      time.Time  `bson:"createdAt" json:"createdAt"`
+	LastUsedAt     *time.Time `bson:"lastUsedAt" json:"lastUsedAt"`
+}
+
 type PhoneNumber struct {
 	ISOCode     string `bson:"isoCode" json:"isoCode" validate:"iso3166_1_alpha2"` // Two-letter country code (ISO 3166-1 alpha-2)
 	LocalNumber string `bson:"localNumber" json:"localNumber" validate:"len=10"`
@@ -40,28 +57,78 @@ type Address struct {
 	Verified bool    `bson:"verified" json:"verified"`
 }
 
+type IdentityDocumentType string
+
+const (
+	PassportDocument IdentityDocumentType = "passport"
+	NINSlipDocument  IdentityDocumentType = "nin_slip"
+)
+
+// An identity document whose machine readable zone matched the user's name and date of birth
+type IdentityDocument struct {
+	Type         IdentityDocumentType `bson:"type" json:"type"`
+	IssuingState string               `bson:"issuingState" json:"issuingState"`
+	Nationality  string               `bson:"nationality" json:"nationality"`
+	// blind index of the document number
+	DocumentNumber string    `bson:"documentNumber" json:"-"`
+	ExpiresAt      time.Time `bson:"expiresAt" json:"expiresAt"`
+	VerifiedAt     time.Time `bson:"verifiedAt" json:"verifiedAt"`
+}
+
+// The result of comparing the account image of the user with the photo on the government record of an identity number
+type FaceBinding struct {
+	Confidence float64   `bson:"confidence" json:"confidence"`
+	Match      bool      `bson:"match" json:"match"`
+	ComparedAt time.Time `bson:"comparedAt" json:"comparedAt"`
+}
+
+// How an identity record was reconciled with the names and date of birth on the account of the user.
+// The names on the record are not kept, only how each name of the account was found on it.
+type IdentityMatch struct {
+	Match         bool                `bson:"match" json:"match"`
+	NameScore     float64             `bson:"nameScore" json:"nameScore"`
+	NameThreshold float64             `bson:"nameThreshold" json:"nameThreshold"`
+	Names         []IdentityNameMatch `bson:"names" json:"names"`
+	DOBChecked    bool                `bson:"dobChecked" json:"dobChecked"`
+	DOBMatch      bool                `bson:"dobMatch" json:"dobMatch"`
+	Reasons       []string            `bson:"reasons" json:"reasons"`
+	MatchedAt     time.Time           `bson:"matchedAt" json:"matchedAt"`
+}
+
+type IdentityNameMatch struct {
+	Part  string  `bson:"part" json:"part"`
+	Rule  string  `bson:"rule" json:"rule"`
+	Score float64 `bson:"score" json:"score"`
+}
+
 // This represents a user signed up to authone
 type User struct {
-	FirstName       *KYCData[string]    `bson:"firstName" json:"firstName"`
-	LastName        *KYCData[string]    `bson:"lastName" json:"lastName"`
-	MiddleName      *KYCData[string]    `bson:"middleName" json:"middleName"`
-	DOB             *KYCData[time.Time] `bson:"dob" json:"dob"`
-	Gender          *KYCData[string]    `bson:"gender" json:"gender"`
-	Address         *Address            `bson:"address" json:"address"`
-	NIN             *string             `bson:"nin" json:"nin"`
-	BVN             *string             `bson:"bvn" json:"bvn"`
-	VoterID         *string             `bson:"voterID" json:"voterID"`
-	DriverID        *string             `bson:"driverID" json:"driverID"`
-	AllowedOrgs     []string            `bson:"allowedOrgs" json:"allowedOrgs"`
-	Email           *string             `bson:"email" json:"email,omitempty"`
-	Phone           *PhoneNumber        `bson:"phone" json:"phone,omitempty"`
-	Image           string              `bson:"image" json:"image"`
-	UserAgent       string              `bson:"userAgent" json:"userAgent"`
-	Deactivated     bool                `bson:"deactivated" json:"deactivated"`
-	Blocked         bool                `bson:"blocked" json:"-"`
-	BlockedReason   *string             `bson:"blockedReason" json:"blockedReason"`
-	VerifiedAccount bool                `bson:"verifiedAccount" json:"verifiedAccount"`
-	Devices         []Device            `bson:"devices" json:"devices"`
+	FirstName       *KYCData[string]         `bson:"firstName" json:"firstName"`
+	LastName        *KYCData[string]         `bson:"lastName" json:"lastName"`
+	MiddleName      *KYCData[string]         `bson:"middleName" json:"middleName"`
+	DOB             *KYCData[time.Time]      `bson:"dob" json:"dob"`
+	Gender          *KYCData[string]         `bson:"gender" json:"gender"`
+	Address         *Address                 `bson:"address" json:"address"`
+	NIN             *string                  `bson:"nin" json:"nin"`
+	BVN             *string                  `bson:"bvn" json:"bvn"`
+	VoterID         *string                  `bson:"voterID" json:"voterID"`
+	DriverID        *string                  `bson:"driverID" json:"driverID"`
+	IDDocument      *IdentityDocument        `bson:"idDocument" json:"idDocument"`
+	NINFaceBinding  *FaceBinding             `bson:"ninFaceBinding" json:"ninFaceBinding"`
+	BVNFaceBinding  *FaceBinding             `bson:"bvnFaceBinding" json:"bvnFaceBinding"`
+	IdentityMatches map[string]IdentityMatch `bson:"identityMatches,omitempty" json:"identityMatches"` // the latest match of each identity check, keyed by check
+	ScreenedAt      *time.Time               `bson:"screenedAt" json:"-"`                              // when the verified identity was last screened against the sanctions and PEP lists
+	AllowedOrgs     []string                 `bson:"allowedOrgs" json:"allowedOrgs"`
+	Email           *string                  `bson:"email" json:"email,omitempty"`
+	Phone           *PhoneNumber             `bson:"phone" json:"phone,omitempty"`
+	Image           string                   `bson:"image" json:"image"`
+	UserAgent       string                   `bson:"userAgent" json:"userAgent"`
+	Deactivated     bool                     `bson:"deactivated" json:"deactivated"`
+	Blocked         bool                     `bson:"blocked" json:"-"`
+	BlockedReason   *string                  `bson:"blockedReason" json:"blockedReason"`
+	VerifiedAccount bool                     `bson:"verifiedAccount" json:"verifiedAccount"`
+	Devices         []Device                 `bson:"devices" json:"devices"`
+	Passkeys        []PasskeyCredential      `bson:"passkeys" json:"passkeys"`
 
 	ID            string     `bson:"_id" json:"id"`
 	CreatedAt     time.Time  `bson:"createdAt" json:"createdAt"`
@@ -6,6 +6,33 @@ import (
 	"gateman.io/application/utils"
 )
 
+// The documents a workspace can submit for business verification
+type BusinessDocument string
+
+const (
+	CertificateOfIncorporation BusinessDocument = "certificate_of_incorporation"
+	MemorandumOfAssociation    BusinessDocument = "memorandum_of_association"
+	StatusReport               BusinessDocument = "status_report"
+)
+
+// The registry record a workspace was verified against and how its owner was matched to it
+type BusinessVerification struct {
+	RCNumber         string `bson:"rcNumber" json:"rcNumber"`
+	CompanyType      string `bson:"companyType" json:"companyType"`
+	CompanyName      string `bson:"companyName" json:"companyName"`
+	RegistrationDate string `bson:"registrationDate" json:"registrationDate"`
+	Address          string `bson:"address" json:"address"`
+	// name of the director the owner was matched to
+	MatchedDirector string `bson:"matchedDirector" json:"matchedDirector"`
+	// member that submitted the verification, the account their verified identity was taken from and the blind index of its NIN
+	OwnerID     string `bson:"ownerID" json:"ownerID"`
+	OwnerUserID string `bson:"ownerUserID" json:"-"`
+	OwnerNIN    string `bson:"ownerNIN" json:"-"`
+	// file paths of the submitted documents
+	Documents  map[BusinessDocument]string `bson:"documents" json:"documents"`
+	VerifiedAt time.Time                   `bson:"verifiedAt" json:"verifiedAt"`
+}
+
 type Workspace struct {
 	Name               string     `bson:"name" json:"name"`
 	Email              string     `bson:"email" json:"email"`
@@ -15,6 +42,10 @@ type Workspace struct {
 	Sector             string     `bson:"sector" json:"sector"`
 	DefaultPaymentCard string     `bson:"defaultPaymentCard" json:"defaultPaymentCard"`
 	PaymentDetails     []CardInfo `bson:"paymentDetails" json:"paymentDetails"`
+	RequireMemberMFA   bool       `bson:"requireMemberMFA" json:"requireMemberMFA"`
+	// verified workspaces can subscribe to paid plans and call the production KYC api
+	Verified             bool                  `bson:"verified" json:"verified"`
+	BusinessVerification *BusinessVerification `bson:"businessVerification" json:"businessVerification"`
 
 	ID            string     `bson:"_id" json:"id"`
 	CreatedAt     time.Time  `bson:"createdAt" json:"createdAt"`
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"fmt"
+
+	"gateman.io/infrastructure/cryptography"
+	"gateman.io/infrastructure/database/repository/cache"
+	"gateman.io/infrastructure/logger"
+	"gateman.io/infrastructure/metrics"
+)
+
+// The tokens saved for a device are cached under the blind index of the device id followed by a suffix,
+// e.g. <index>-access or <index>-workspace-refresh. Before blind indexes they were cached under the fixed salt Argon2 hash
+// of the device id, lookups fall back to those keys until the blind index migration has moved them all.
+const DeviceSessionsMigration = "device_sessions"
+
+func DeviceSessionKey(deviceID string, suffix string) string {
+	return fmt.Sprintf("%s-%s", cryptography.BlindIndex(cryptography.DeviceIDIndex, deviceID), suffix)
+}
+
+func legacyDeviceSessionKey(deviceID string, suffix string) string {
+	return fmt.Sprintf("%s-%s", cryptography.LegacyFixedSaltHash(deviceID), suffix)
+}
+
+// Returns the entry saved for deviceID under suffix
+func FindDeviceSession(deviceID string, suffix string) *string {
+	entry := cache.Cache.FindOne(DeviceSessionKey(deviceID, suffix))
+	if entry != nil || cryptography.BlindIndexMigrated(DeviceSessionsMigration) {
+		return entry
+	}
+	if !MoveLegacyDeviceSession(deviceID, suffix) {
+		return nil
+	}
+	return cache.Cache.FindOne(DeviceSessionKey(deviceID, suffix))
+}
+
+// Moves the entry saved for deviceID under its legacy key to its blind index, keeping its ttl.
+// An entry already saved under the blind index is newer so the legacy entry is dropped instead.
+// Returns true if an entry was moved.
+func MoveLegacyDeviceSession(deviceID string, suffix string) bool {
+	legacyKey := legacyDeviceSessionKey(deviceID, suffix)
+	if cache.Cache.RenameIfNotExists(legacyKey, DeviceSessionKey(deviceID, suffix)) {
+		metrics.BlindIndexMigrations.WithLabelValues(DeviceSessionsMigration, "moved").Inc()
+		return true
+	}
+	if cache.Cache.DeleteOne(legacyKey) {
+		metrics.BlindIndexMigrations.WithLabelValues(DeviceSessionsMigration, "dropped").Inc()
+	}
+	return false
+}
+
+// Deletes the entry saved for deviceID under suffix. Returns true if an entry was deleted.
+func DeleteDeviceSession(deviceID string, suffix string) bool {
+	deleted := cache.Cache.DeleteOne(DeviceSessionKey(deviceID, suffix))
+	if !cryptography.BlindIndexMigrated(DeviceSessionsMigration) && cache.Cache.DeleteOne(legacyDeviceSessionKey(deviceID, suffix)) {
+		deleted = true
+	}
+	return deleted
+}
+
+// Same as SignOutUser for the tokens saved for deviceID under suffix
+func SignOutDevice(ctx any, deviceID string, suffix string, reason string) {
+	logger.Info("system device signout initiated", logger.LoggerOptions{
+		Key:  "reason",
+		Data: reason,
+	})
+	if !DeleteDeviceSession(deviceID, suffix) {
+		logger.Error("failed to sign out device", logger.LoggerOptions{
+			Key:  "suffix",
+			Data: suffix,
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package auth
+
+import "testing"
+
+// The auth middlewares derive the cache key of the device's saved token on every request.
+// Compare with: go test ./infrastructure/auth -run NONE -bench DeviceSessionKey -benchmem
+const benchmarkDeviceID = "3f0c6a52-93b1-4f0e-8d51-7a2b9e4c1d60"
+
+func BenchmarkDeviceSessionKey(b *testing.B) {
+	b.Setenv("BLIND_INDEX_KEY", "benchmark-blind-index-key")
+	for i := 0; i < b.N; i++ {
+		DeviceSessionKey(benchmarkDeviceID, "access")
+	}
+}
+
+func BenchmarkLegacyDeviceSessionKey(b *testing.B) {
+	b.Setenv("HASH_FIXED_SALT", "benchmark-fixed-salt")
+	for i := 0; i < b.N; i++ {
+		legacyDeviceSessionKey(benchmarkDeviceID, "access")
+	}
+}
@@ -64,8 +64,21 @@ func VerifyOTP(key string, otp string) (string, bool) {
 	return "", true
 }
 
+// Generates a gateman user or workspace member token.
+// Refresh tokens always belong to a family, a new one is started when claimsData does not continue an existing family.
 func GenerateAuthToken(claimsData ClaimsData) (*string, error) {
-	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	if claimsData.TokenType == RefreshToken && claimsData.Family == nil {
+		owner := UserTokenFamily
+		if claimsData.WorkspaceID != nil {
+			owner = WorkspaceMemberTokenFamily
+		}
+		family, err := StartRefreshTokenFamily(claimsData.UserID, owner, claimsData.DeviceID, nil, time.Duration(claimsData.ExpiresAt-claimsData.IssuedAt)*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		claimsData.Family = family
+	}
+	claims := jwt.MapClaims{
 		"iss":             os.Getenv("GATEMAN_ISSUER"),
 		"userID":          claimsData.UserID,
 		"exp":             claimsData.ExpiresAt,
@@ -80,22 +93,67 @@ func GenerateAuthToken(claimsData ClaimsData) (*string, error) {
 		"verifiedAccount": claimsData.VerifiedAccount,
 		"tokenType":       claimsData.TokenType,
 		"workspace":       claimsData.WorkspaceID,
-	}).SignedString([]byte(os.Getenv("JWT_SIGNING_KEY")))
+	}
+	if claimsData.Family != nil {
+		claims["fam"] = claimsData.Family.ID
+		claims["jti"] = claimsData.Family.TokenID
+	}
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(os.Getenv("JWT_SIGNING_KEY")))
 	if err != nil {
 		return nil, err
 	}
 	return &tokenString, nil
 }
 
-func GenerateAppUserToken(claimsData ClaimsData, signingKey string, issuer string) (*string, error) {
-	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+func GenerateAppUserToken(claimsData ClaimsData, signer AppTokenSigner, issuer string) (*string, error) {
+	signingMethod := jwt.GetSigningMethod(signer.Algorithm)
+	if signingMethod == nil {
+		return nil, fmt.Errorf("unsupported signing algorithm %s", signer.Algorithm)
+	}
+	privateKey, err := cryptography.ParsePrivateSigningKey(signer.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{
 		"iss":       issuer,
+		"sub":       claimsData.UserID,
 		"exp":       claimsData.ExpiresAt,
 		"iat":       claimsData.IssuedAt,
 		"deviceID":  claimsData.DeviceID,
 		"userAgent": claimsData.UserAgent,
+		"tokenType": claimsData.TokenType,
 		"payload":   claimsData.Payload,
-	}).SignedString([]byte(signingKey))
+	}
+	if claimsData.Scope != "" {
+		claims["scope"] = claimsData.Scope
+	}
+	if claimsData.Family != nil {
+		claims["fam"] = claimsData.Family.ID
+		if claimsData.TokenType == RefreshToken {
+			claims["jti"] = claimsData.Family.TokenID
+		}
+	}
+	token := jwt.NewWithClaims(signingMethod, claims)
+	token.Header["kid"] = signer.KeyID
+	tokenString, err := token.SignedString(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenString, nil
+}
+
+func GenerateAppIDToken(claims map[string]any, signer AppTokenSigner) (*string, error) {
+	signingMethod := jwt.GetSigningMethod(signer.Algorithm)
+	if signingMethod == nil {
+		return nil, fmt.Errorf("unsupported signing algorithm %s", signer.Algorithm)
+	}
+	privateKey, err := cryptography.ParsePrivateSigningKey(signer.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	token := jwt.NewWithClaims(signingMethod, jwt.MapClaims(claims))
+	token.Header["kid"] = signer.KeyID
+	tokenString, err := token.SignedString(privateKey)
 	if err != nil {
 		return nil, err
 	}
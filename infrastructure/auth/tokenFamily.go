@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gateman.io/application/utils"
+	"gateman.io/infrastructure/database/repository/cache"
+	"gateman.io/infrastructure/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+type TokenFamilyOwner string
+
+var (
+	UserTokenFamily            TokenFamilyOwner = "user"
+	WorkspaceMemberTokenFamily TokenFamilyOwner = "workspace_member"
+	AppUserTokenFamily         TokenFamilyOwner = "app_user"
+)
+
+var ErrRefreshTokenReused = errors.New("refresh token has already been used")
+var ErrRefreshTokenFamilyRevoked = errors.New("refresh token family has been revoked")
+
+const revokedFamilyTTL = time.Hour * 24 * 30
+
+// KEYS: family record, used tokens. ARGV: presented token id, new token id, rotation time, ttl, revoked ttl.
+// SADD marks the presented token as used so only one request can consume it, and the record is only written back
+// while it is still unrevoked.
+var rotateTokenFamilyScript = redis.NewScript(`
+local data = redis.call('GET', KEYS[1])
+if not data then
+	return {'missing', ''}
+end
+local record = cjson.decode(data)
+if record.revoked then
+	return {'revoked', data}
+end
+local consumed = redis.call('SADD', KEYS[2], ARGV[1])
+redis.call('EXPIRE', KEYS[2], ARGV[4])
+if consumed == 0 or record.currentTokenID ~= ARGV[1] then
+	record.revoked = true
+	local revoked = cjson.encode(record)
+	redis.call('SET', KEYS[1], revoked, 'EX', ARGV[5])
+	return {'reused', revoked}
+end
+record.currentTokenID = ARGV[2]
+record.rotatedAt = ARGV[3]
+local rotated = cjson.encode(record)
+redis.call('SET', KEYS[1], rotated, 'EX', ARGV[4])
+return {'rotated', rotated}
+`)
+
+// KEYS: family record. ARGV: revoked ttl.
+var revokeTokenFamilyScript = redis.NewScript(`
+local data = redis.call('GET', KEYS[1])
+if not data then
+	return 0
+end
+local record = cjson.decode(data)
+record.revoked = true
+redis.call('SET', KEYS[1], cjson.encode(record), 'EX', ARGV[1])
+return 1
+`)
+
+// Identifies a refresh token within its family. Every refresh issued from a sign in shares the same family id.
+type RefreshTokenFamily struct {
+	ID      string
+	TokenID string // the jti of the refresh token
+}
+
+// The server side record of a refresh token family
+type TokenFamilyRecord struct {
+	OwnerID        string           `json:"ownerID"`
+	OwnerType      TokenFamilyOwner `json:"ownerType"`
+	DeviceID       string           `json:"deviceID"`
+	AppID          *string          `json:"appID"`
+	CurrentTokenID string           `json:"currentTokenID"`
+	Revoked        bool             `json:"revoked"`
+	CreatedAt      time.Time        `json:"createdAt"`
+	RotatedAt      time.Time        `json:"rotatedAt"`
+}
+
+func tokenFamilyKey(familyID string) string {
+	return fmt.Sprintf("refresh-family:%s", familyID)
+}
+
+func usedTokensKey(familyID string) string {
+	return fmt.Sprintf("refresh-family:%s:used", familyID)
+}
+
+func ownerFamiliesKey(ownerID string, ownerType TokenFamilyOwner) string {
+	return fmt.Sprintf("refresh-families:%s:%s", ownerType, ownerID)
+}
+
+// Starts a new refresh token family and returns the id of its first token.
+func StartRefreshTokenFamily(ownerID string, ownerType TokenFamilyOwner, deviceID string, appID *string, ttl time.Duration) (*RefreshTokenFamily, error) {
+	family := RefreshTokenFamily{
+		ID:      utils.GenerateUULDString(),
+		TokenID: utils.GenerateUULDString(),
+	}
+	now := time.Now()
+	if err := saveTokenFamily(family.ID, TokenFamilyRecord{
+		OwnerID:        ownerID,
+		OwnerType:      ownerType,
+		DeviceID:       deviceID,
+		AppID:          appID,
+		CurrentTokenID: family.TokenID,
+		CreatedAt:      now,
+		RotatedAt:      now,
+	}, ttl); err != nil {
+		return nil, err
+	}
+	cache.Cache.CreateInSet(ownerFamiliesKey(ownerID, ownerType), family.ID, ttl)
+	return &family, nil
+}
+
+// Consumes the refresh token identified by tokenID and returns the id of the token that replaces it.
+// Presenting a token that has already been consumed revokes the whole family and returns ErrRefreshTokenReused
+// along with the family record so the caller can clean up the owner's sessions.
+func RotateRefreshTokenFamily(familyID string, tokenID string, ttl time.Duration) (*RefreshTokenFamily, *TokenFamilyRecord, error) {
+	family := RefreshTokenFamily{
+		ID:      familyID,
+		TokenID: utils.GenerateUULDString(),
+	}
+	// the whole rotation runs as one script so a concurrent revoke or rotation can not be overwritten
+	result, err := cache.Cache.RunScript(rotateTokenFamilyScript,
+		[]string{tokenFamilyKey(familyID), usedTokensKey(familyID)},
+		tokenID, family.TokenID, time.Now().Format(time.RFC3339Nano), int64(ttl.Seconds()), int64(revokedFamilyTTL.Seconds()))
+	if err != nil {
+		return nil, nil, err
+	}
+	reply, ok := result.([]interface{})
+	if !ok || len(reply) != 2 {
+		return nil, nil, errors.New("unexpected reply while rotating refresh token family")
+	}
+	status, _ := reply[0].(string)
+	if status == "missing" {
+		return nil, nil, ErrRefreshTokenFamilyRevoked
+	}
+	data, _ := reply[1].(string)
+	var record TokenFamilyRecord
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		return nil, nil, err
+	}
+	switch status {
+	case "revoked":
+		return nil, &record, ErrRefreshTokenFamilyRevoked
+	case "reused":
+		logger.Warning("refresh token reuse detected", logger.LoggerOptions{
+			Key:  "familyID",
+			Data: familyID,
+		}, logger.LoggerOptions{
+			Key:  "ownerID",
+			Data: record.OwnerID,
+		})
+		return nil, &record, ErrRefreshTokenReused
+	}
+	return &family, &record, nil
+}
+
+// Revokes a family so none of its refresh tokens can be used again.
+func RevokeRefreshTokenFamily(familyID string) {
+	// the record is kept around for a while so late replays are still reported as reuse
+	cache.Cache.RunScript(revokeTokenFamilyScript, []string{tokenFamilyKey(familyID)}, int64(revokedFamilyTTL.Seconds()))
+}
+
+// Returns true if tokenID has already been exchanged for a new refresh token.
+func IsRefreshTokenConsumed(familyID string, tokenID string) bool {
+	return cache.Cache.DoesItemExistInSet(usedTokensKey(familyID), tokenID)
+}
+
+// Returns true if tokens from the family can still be used.
+func IsRefreshTokenFamilyActive(familyID string) bool {
+	record := FindRefreshTokenFamily(familyID)
+	return record != nil && !record.Revoked
+}
+
+// Returns the active families of an owner keyed by family id.
+func FindOwnerRefreshTokenFamilies(ownerID string, ownerType TokenFamilyOwner) map[string]TokenFamilyRecord {
+	families := map[string]TokenFamilyRecord{}
+	familyIDs := cache.Cache.FindSet(ownerFamiliesKey(ownerID, ownerType))
+	if familyIDs == nil {
+		return families
+	}
+	for _, familyID := range *familyIDs {
+		record := FindRefreshTokenFamily(familyID)
+		if record == nil || record.Revoked {
+			continue
+		}
+		families[familyID] = *record
+	}
+	return families
+}
+
+// Revokes every active family of an owner. Only families started on deviceID are revoked when it is provided.
+func RevokeOwnerRefreshTokenFamilies(ownerID string, ownerType TokenFamilyOwner, deviceID *string) {
+	for familyID, record := range FindOwnerRefreshTokenFamilies(ownerID, ownerType) {
+		if deviceID != nil && record.DeviceID != *deviceID {
+			continue
+		}
+		RevokeRefreshTokenFamily(familyID)
+	}
+}
+
+func FindRefreshTokenFamily(familyID string) *TokenFamilyRecord {
+	data := cache.Cache.FindOne(tokenFamilyKey(familyID))
+	if data == nil {
+		return nil
+	}
+	var record TokenFamilyRecord
+	if err := json.Unmarshal([]byte(*data), &record); err != nil {
+		logger.Error("auth module error - error while unmarshalling refresh token family", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		return nil
+	}
+	return &record
+}
+
+func saveTokenFamily(familyID string, record TokenFamilyRecord, ttl time.Duration) error {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if !cache.Cache.CreateEntry(tokenFamilyKey(familyID), payload, ttl) {
+		return errors.New("could not save refresh token family")
+	}
+	return nil
+}
@@ -21,6 +21,8 @@ type ClaimsData struct {
 	WorkspaceID     *string
 	TokenType       TokenType
 	Payload         map[string]any
+	Scope           string // space delimited OIDC scopes granted to the token
+	Family          *RefreshTokenFamily
 }
 
 type InterserviceClaimsData struct {
@@ -29,3 +31,10 @@ type InterserviceClaimsData struct {
 	ExpiresAt   int64
 	IssuedAt    int64
 }
+
+// The key an app user token is signed with
+type AppTokenSigner struct {
+	Algorithm  string
+	KeyID      string // sent in the kid header so clients can pick the right key from the app's JWKS
+	PrivateKey string // PEM encoded PKCS8 private key
+}
@@ -157,3 +157,40 @@ func (g *GatemanFace) GenerateChallenge() (*types.ChallengeResponse, error) {
 
 	return &result, nil
 }
+
+func (g *GatemanFace) ReadMRZ(image *string) (*types.MRZReadResponse, error) {
+	requestBody := types.MRZReadRequest{
+		Image: image,
+	}
+
+	response, statusCode, err := g.Network.Post("/read-mrz", &map[string]string{}, requestBody, nil, false, nil)
+	if err != nil {
+		logger.Error("error reading document mrz", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		return nil, err
+	}
+
+	if statusCode == nil || *statusCode != 200 {
+		logger.Error("document mrz read failed with status code", logger.LoggerOptions{
+			Key:  "status_code",
+			Data: statusCode,
+		})
+		return &types.MRZReadResponse{
+			Success: false,
+			Error:   utils.GetStringPointer("Document MRZ read failed"),
+		}, nil
+	}
+
+	var result types.MRZReadResponse
+	if err := json.Unmarshal(*response, &result); err != nil {
+		logger.Error("error unmarshaling document mrz response", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		return nil, err
+	}
+
+	return &result, nil
+}
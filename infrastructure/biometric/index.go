@@ -9,12 +9,16 @@ import (
 )
 
 func init() {
-	BiometricService = &GatemanFace{
+	gatemanFace := &GatemanFace{
 		Network: &network.NetworkController{
 			BaseUrl: os.Getenv("GATEMAN_FACE_BASE_URL"),
 		},
 		Cache: &cache.Cache,
 	}
+	BiometricService = gatemanFace
+	DocumentReader = gatemanFace
 }
 
 var BiometricService types.BiometricServiceType
+
+var DocumentReader types.DocumentReaderType
@@ -9,6 +9,11 @@ type BiometricServiceType interface {
 	GenerateChallenge() (*ChallengeResponse, error)
 }
 
+// Reads the machine readable zone printed on identity documents
+type DocumentReaderType interface {
+	ReadMRZ(image *string) (*MRZReadResponse, error)
+}
+
 type AnalysisDetails struct {
 	ImageQuality        float64 `json:"image_quality"`
 	LandmarkConsistency float64 `json:"landmark_consistency"`
@@ -132,3 +137,15 @@ type FaceComparisonRequest struct {
 type LivenessCheckRequest struct {
 	Image *string `json:"image"`
 }
+
+// MRZ read request
+type MRZReadRequest struct {
+	Image *string `json:"image"`
+}
+
+// The lines of the machine readable zone found on the document, top to bottom
+type MRZReadResponse struct {
+	Success bool     `json:"success"`
+	Lines   []string `json:"lines"`
+	Error   *string  `json:"error"`
+}
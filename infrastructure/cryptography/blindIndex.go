@@ -0,0 +1,98 @@
+package cryptography
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"gateman.io/infrastructure/database/repository/cache"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Values that are only ever looked up by equality, like device ids, identity numbers and api keys, are stored and cached under their blind index.
+// A blind index is a keyed HMAC-SHA256 of the value. Unlike a password hash it is deterministic and cheap to compute,
+// and without BLIND_INDEX_KEY it cannot be brute forced over the small space of identity numbers.
+type BlindIndexDomain string
+
+// Every domain is indexed with its own key so the index of a value in one domain never matches the same value in another
+const (
+	DeviceIDIndex BlindIndexDomain = "device_id"
+	NINIndex      BlindIndexDomain = "nin"
+	BVNIndex      BlindIndexDomain = "bvn"
+	DriverIDIndex BlindIndexDomain = "driver_id"
+	VoterIDIndex  BlindIndexDomain = "voter_id"
+	// document numbers are indexed with the issuing state since numbers are only unique within a state
+	DocumentNumberIndex BlindIndexDomain = "document_number"
+	// presented api keys are cached under their index once they have been verified against their hash
+	APIKeyIndex BlindIndexDomain = "api_key"
+)
+
+const blindIndexKeyInfo = "gateman blind index v1 "
+
+// domain keys derived from BLIND_INDEX_KEY
+var blindIndexKeys sync.Map
+
+var blindIndexSecret = sync.OnceValues(func() ([]byte, error) {
+	secret := os.Getenv("BLIND_INDEX_KEY")
+	if secret == "" {
+		return nil, errors.New("BLIND_INDEX_KEY is not set")
+	}
+	return []byte(secret), nil
+})
+
+// Checks BLIND_INDEX_KEY when the server starts so it never runs without it.
+// Deployments that indexed values before BLIND_INDEX_KEY was required derived the key from HASH_FIXED_SALT
+// and keep their indexes by setting BLIND_INDEX_KEY to the same value.
+func InitialiseBlindIndex() {
+	if _, err := blindIndexSecret(); err != nil {
+		panic(err)
+	}
+}
+
+// Derives the key of domain with HKDF-SHA256 from BLIND_INDEX_KEY, changing the secret changes every index.
+// There is no fallback secret, values are never indexed with a key that could be guessed.
+func blindIndexKey(domain BlindIndexDomain) []byte {
+	if key, ok := blindIndexKeys.Load(domain); ok {
+		return key.([]byte)
+	}
+	secret, err := blindIndexSecret()
+	if err != nil {
+		panic(err)
+	}
+	key := make([]byte, 32)
+	io.ReadFull(hkdf.New(sha256.New, secret, nil, []byte(blindIndexKeyInfo+string(domain))), key)
+	blindIndexKeys.Store(domain, key)
+	return key
+}
+
+// Returns the hex blind index of value in domain
+func BlindIndex(domain BlindIndexDomain, value string) string {
+	mac := hmac.New(sha256.New, blindIndexKey(domain))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Returns the Argon2 hash with HASH_FIXED_SALT that values were indexed with before blind indexes.
+// It is only computed to find and move values that have not been re-indexed yet.
+func LegacyFixedSaltHash(value string) string {
+	hash, _ := CryptoHahser.HashString(value, []byte(os.Getenv("HASH_FIXED_SALT")))
+	return string(hash)
+}
+
+func blindIndexMigrationKey(name string) string {
+	return fmt.Sprintf("blind-index-migration:%s", name)
+}
+
+// Returns true once nothing indexed under name is left under its legacy hash and the fallback lookups can stop
+func BlindIndexMigrated(name string) bool {
+	return cache.Cache.FindOne(blindIndexMigrationKey(name)) != nil
+}
+
+func MarkBlindIndexMigrated(name string) bool {
+	return cache.Cache.CreateEntry(blindIndexMigrationKey(name), "done", 0)
+}
@@ -41,8 +41,13 @@ func GeneratePublicKey(sessionID string, clientPubKey *ecdh.PublicKey) *ecdh.Pub
 	return serverPubKey
 }
 
+// Decrypts data encrypted by EncryptData. Without keyString the data is opened with the keyring,
+// values written before the keyring existed are still read with ENC_KEY until they are re-encrypted.
 func DecryptData(stringToDecrypt string, keyString *string) ([]byte, error) {
 	if keyString == nil {
+		if isEnvelope(stringToDecrypt) {
+			return openWithKeyring(stringToDecrypt)
+		}
 		keyString = utils.GetStringPointer(os.Getenv("ENC_KEY"))
 	}
 
@@ -75,9 +80,11 @@ func DecryptData(stringToDecrypt string, keyString *string) ([]byte, error) {
 	return plaintext, nil
 }
 
+// Without keyString payload is sealed with the active key of the keyring.
+// An explicit keyString is a key shared with a client, that format is AES-CFB with the IV prefixed because clients decrypt it.
 func EncryptData(payload []byte, keyString *string) (*string, error) {
 	if keyString == nil {
-		keyString = utils.GetStringPointer(os.Getenv("ENC_KEY"))
+		return sealWithKeyring(payload)
 	}
 
 	key, err := hex.DecodeString(*keyString)
@@ -0,0 +1,182 @@
+package cryptography
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Data encrypted at rest is sealed with AES-GCM and stored as
+// g1:<keyID>:hex(nonce || ciphertext || tag)
+// The "g1:<keyID>" header is authenticated as additional data so a ciphertext cannot be moved to another key.
+const envelopeVersion = "g1"
+
+// the id ENC_KEY is known by when ENC_KEYRING is not set
+const defaultEncryptionKeyID = "0"
+
+var (
+	ErrEncryptionKeyNotFound = errors.New("ciphertext was encrypted with a key that is not in the keyring")
+	ErrMalformedCiphertext   = errors.New("ciphertext envelope is malformed")
+)
+
+type encryptionKeyring struct {
+	activeKeyID string
+	keys        map[string][]byte
+}
+
+// The keyring is read from ENC_KEYRING as "id:hexkey,id:hexkey" with ENC_KEY_ID naming the key new data is encrypted with.
+// Deployments that only set ENC_KEY get a keyring holding that one key.
+// Rotating means adding a new key to ENC_KEYRING, pointing ENC_KEY_ID at it and keeping the old keys until the re-encryption job has moved everything over.
+var encryptionKeys = sync.OnceValues(func() (*encryptionKeyring, error) {
+	keyring := encryptionKeyring{keys: map[string][]byte{}}
+	entries := strings.TrimSpace(os.Getenv("ENC_KEYRING"))
+	if entries == "" {
+		key, err := parseEncryptionKey(os.Getenv("ENC_KEY"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ENC_KEY: %w", err)
+		}
+		keyring.activeKeyID = defaultEncryptionKeyID
+		keyring.keys[defaultEncryptionKeyID] = key
+		return &keyring, nil
+	}
+	for _, entry := range strings.Split(entries, ",") {
+		id, hexKey, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found || id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid ENC_KEYRING entry %q", id)
+		}
+		key, err := parseEncryptionKey(hexKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ENC_KEYRING key %s: %w", id, err)
+		}
+		keyring.keys[id] = key
+	}
+	keyring.activeKeyID = os.Getenv("ENC_KEY_ID")
+	if _, ok := keyring.keys[keyring.activeKeyID]; !ok {
+		return nil, fmt.Errorf("ENC_KEY_ID %q is not in ENC_KEYRING", keyring.activeKeyID)
+	}
+	return &keyring, nil
+})
+
+func parseEncryptionKey(hexKey string) ([]byte, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return nil, fmt.Errorf("key must be 16, 24 or 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// Returns the id of the key new data is encrypted with
+func ActiveEncryptionKeyID() (string, error) {
+	keyring, err := encryptionKeys()
+	if err != nil {
+		return "", err
+	}
+	return keyring.activeKeyID, nil
+}
+
+func sealWithKeyring(payload []byte) (*string, error) {
+	keyring, err := encryptionKeys()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(keyring.keys[keyring.activeKeyID])
+	if err != nil {
+		return nil, err
+	}
+	header := envelopeVersion + ":" + keyring.activeKeyID
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(payload)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, payload, []byte(header))
+	envelope := header + ":" + hex.EncodeToString(sealed)
+	return &envelope, nil
+}
+
+func openWithKeyring(envelope string) ([]byte, error) {
+	keyID, sealed, err := parseEnvelope(envelope)
+	if err != nil {
+		return nil, err
+	}
+	keyring, err := encryptionKeys()
+	if err != nil {
+		return nil, err
+	}
+	key, ok := keyring.keys[keyID]
+	if !ok {
+		return nil, ErrEncryptionKeyNotFound
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrMalformedCiphertext
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(envelopeVersion+":"+keyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+	return plaintext, nil
+}
+
+func isEnvelope(ciphertext string) bool {
+	return strings.HasPrefix(ciphertext, envelopeVersion+":")
+}
+
+func parseEnvelope(envelope string) (keyID string, sealed []byte, err error) {
+	parts := strings.SplitN(envelope, ":", 3)
+	if len(parts) != 3 || parts[0] != envelopeVersion || parts[1] == "" {
+		return "", nil, ErrMalformedCiphertext
+	}
+	sealed, err = hex.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, ErrMalformedCiphertext
+	}
+	return parts[1], sealed, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// Returns true if ciphertext was not encrypted with the active key, either because it predates the
+// keyring or because the key it was sealed with has since been rotated out.
+func NeedsReencryption(ciphertext string) bool {
+	if ciphertext == "" {
+		return false
+	}
+	if !isEnvelope(ciphertext) {
+		return true
+	}
+	keyring, err := encryptionKeys()
+	if err != nil {
+		return false
+	}
+	keyID, _, err := parseEnvelope(ciphertext)
+	return err == nil && keyID != keyring.activeKeyID
+}
+
+// Decrypts ciphertext and seals it again with the active key
+func ReencryptData(ciphertext string) (*string, error) {
+	plaintext, err := DecryptData(ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+	return EncryptData(plaintext, nil)
+}
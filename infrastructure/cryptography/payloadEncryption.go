@@ -0,0 +1,71 @@
+package cryptography
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"gateman.io/infrastructure/database/repository/cache"
+	"golang.org/x/crypto/hkdf"
+)
+
+// info used to derive the payload key from the ECDH shared secret. Clients derive the same key with
+// HKDF-SHA256(sharedSecret, salt = none, info = "gateman payload encryption v1") and 32 bytes of output.
+const payloadKeyInfo = "gateman payload encryption v1"
+
+var ErrEncryptionKeyExpired = errors.New("encryption key has expired")
+
+// Returns the AES-256 key used to encrypt payloads exchanged with deviceID.
+// ErrEncryptionKeyExpired is returned when the device has to run the key exchange again.
+func DevicePayloadKey(deviceID string) ([]byte, error) {
+	encryptedSecret := cache.Cache.FindOne(fmt.Sprintf("%s-key", deviceID))
+	if encryptedSecret == nil {
+		return nil, ErrEncryptionKeyExpired
+	}
+	hexSecret, err := DecryptData(*encryptedSecret, nil)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := hex.DecodeString(string(hexSecret))
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, nil, []byte(payloadKeyInfo)), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Encrypts payload with AES-GCM and returns hex(nonce || ciphertext || tag).
+// additionalData is authenticated but not encrypted and has to be given again to open the payload.
+func SealPayload(key []byte, payload []byte, additionalData []byte) (string, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(payload)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return hex.EncodeToString(aead.Seal(nonce, nonce, payload, additionalData)), nil
+}
+
+// Opens a payload sealed with SealPayload
+func OpenPayload(key []byte, sealedPayload string, additionalData []byte) ([]byte, error) {
+	sealed, err := hex.DecodeString(sealedPayload)
+	if err != nil {
+		return nil, ErrMalformedCiphertext
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrMalformedCiphertext
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additionalData)
+}
@@ -0,0 +1,148 @@
+package cryptography
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+type SigningAlgorithm string
+
+const (
+	RS256 SigningAlgorithm = "RS256"
+	ES256 SigningAlgorithm = "ES256"
+	EdDSA SigningAlgorithm = "EdDSA"
+)
+
+// the algorithm used when an application does not pick one
+var DefaultSigningAlgorithm = ES256
+
+var ErrUnsupportedSigningAlgorithm = errors.New("unsupported signing algorithm")
+
+// Generates an asymmetric key pair for the provided algorithm.
+// The private key is returned as a PKCS8 PEM block and the public key as a PKIX PEM block.
+func GenerateSigningKeyPair(alg SigningAlgorithm) (privateKeyPEM string, publicKeyPEM string, err error) {
+	var privateKey crypto.PrivateKey
+	var publicKey crypto.PublicKey
+	switch alg {
+	case RS256:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to generate rsa key: %w", err)
+		}
+		privateKey, publicKey = key, &key.PublicKey
+	case ES256:
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to generate ecdsa key: %w", err)
+		}
+		privateKey, publicKey = key, &key.PublicKey
+	case EdDSA:
+		pub, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to generate ed25519 key: %w", err)
+		}
+		privateKey, publicKey = key, pub
+	default:
+		return "", "", ErrUnsupportedSigningAlgorithm
+	}
+
+	privateKeyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	privateKeyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKeyBytes}))
+	publicKeyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes}))
+	return privateKeyPEM, publicKeyPEM, nil
+}
+
+// Parses a PKCS8 PEM encoded private key generated by GenerateSigningKeyPair.
+func ParsePrivateSigningKey(privateKeyPEM string) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return nil, errors.New("invalid private key pem")
+	}
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
+
+// Parses a PKIX PEM encoded public key generated by GenerateSigningKeyPair.
+func ParsePublicSigningKey(publicKeyPEM string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, errors.New("invalid public key pem")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// Converts a PEM encoded public key to a JSON Web Key (RFC 7517).
+func PublicKeyToJWK(publicKeyPEM string, alg SigningAlgorithm, kid string) (map[string]any, error) {
+	jwk, err := publicJWKMembers(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	jwk["alg"] = string(alg)
+	jwk["use"] = "sig"
+	jwk["kid"] = kid
+	return jwk, nil
+}
+
+// Computes the RFC 7638 thumbprint of a PEM encoded public key. It is used as the key id (kid) of the key.
+func SigningKeyThumbprint(publicKeyPEM string) (string, error) {
+	jwk, err := publicJWKMembers(publicKeyPEM)
+	if err != nil {
+		return "", err
+	}
+	// encoding/json sorts map keys which gives the lexicographic member order the RFC requires
+	canonical, err := json.Marshal(jwk)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// returns only the required members of the JWK for the public key
+func publicJWKMembers(publicKeyPEM string) (map[string]any, error) {
+	publicKey, err := ParsePublicSigningKey(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return map[string]any{
+			"kty": "RSA",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return map[string]any{
+			"kty": "EC",
+			"crv": key.Curve.Params().Name,
+			"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+			"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case ed25519.PublicKey:
+		return map[string]any{
+			"kty": "OKP",
+			"crv": "Ed25519",
+			"x":   base64.RawURLEncoding.EncodeToString(key),
+		}, nil
+	}
+	return nil, ErrUnsupportedSigningAlgorithm
+}
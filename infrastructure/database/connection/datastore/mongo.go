@@ -28,6 +28,14 @@ var (
 	KYCIdentityDataModel    *mongo.Collection
 	HelpCenterModel         *mongo.Collection
 	RequestActivityLogModel *mongo.Collection
+	SecurityEventModel      *mongo.Collection
+	DataKeyModel            *mongo.Collection
+	APIKeyModel             *mongo.Collection
+	ScreeningHitModel       *mongo.Collection
+	ScreeningHitReviewModel *mongo.Collection
+	KYCJobModel             *mongo.Collection
+	MeteredLookupModel      *mongo.Collection
+	LookupQuotaCounterModel *mongo.Collection
 )
 
 type MongoClient struct {
@@ -146,6 +154,10 @@ func (mc *MongoClient) GetCollection(name string) *mongo.Collection {
 
 func setUpIndexes(ctx context.Context, db *mongo.Database) {
 	WorkspaceModel = db.Collection("Workspaces")
+	WorkspaceModel.Indexes().CreateMany(ctx, []mongo.IndexModel{{
+		Keys:    bson.D{{Key: "businessVerification.rcNumber", Value: 1}, {Key: "businessVerification.companyType", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"businessVerification.rcNumber": bson.M{"$exists": true}}),
+	}})
 
 	WorkspaceMemberModel = db.Collection("WorkspaceMembers")
 	WorkspaceMemberModel.Indexes().CreateMany(ctx, []mongo.IndexModel{{
@@ -163,6 +175,9 @@ func setUpIndexes(ctx context.Context, db *mongo.Database) {
 	UserModel.Indexes().CreateMany(ctx, []mongo.IndexModel{{
 		Keys:    bson.D{{Key: "email", Value: 1}},
 		Options: options.Index(),
+	}, {
+		Keys:    bson.D{{Key: "passkeys.id", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"passkeys.id": bson.M{"$exists": true}}),
 	}})
 
 	ApplicationModel = db.Collection("Applications")
@@ -239,5 +254,86 @@ func setUpIndexes(ctx context.Context, db *mongo.Database) {
 		Options: options.Index(),
 	}})
 
+	SecurityEventModel = db.Collection("SecurityEvents")
+	SecurityEventModel.Indexes().CreateMany(ctx, []mongo.IndexModel{{
+		Keys:    bson.D{{Key: "actorID", Value: 1}},
+		Options: options.Index(),
+	}, {
+		Keys:    bson.D{{Key: "appID", Value: 1}},
+		Options: options.Index(),
+	}, {
+		Keys:    bson.D{{Key: "workspaceID", Value: 1}},
+		Options: options.Index(),
+	}})
+
+	APIKeyModel = db.Collection("APIKeys")
+	APIKeyModel.Indexes().CreateMany(ctx, []mongo.IndexModel{{
+		Keys:    bson.D{{Key: "appID", Value: 1}},
+		Options: options.Index(),
+	}, {
+		Keys:    bson.D{{Key: "workspaceID", Value: 1}},
+		Options: options.Index(),
+	}})
+
+	DataKeyModel = db.Collection("DataKeys")
+	DataKeyModel.Indexes().CreateMany(ctx, []mongo.IndexModel{{
+		Keys:    bson.D{{Key: "subjectID", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}})
+
+	ScreeningHitModel = db.Collection("ScreeningHits")
+	ScreeningHitModel.Indexes().CreateMany(ctx, []mongo.IndexModel{{
+		// an entry is only ever recorded once against a user so a cleared hit is not raised again by re-screening
+		Keys:    bson.D{{Key: "userID", Value: 1}, {Key: "source", Value: 1}, {Key: "reference", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}, {
+		Keys:    bson.D{{Key: "status", Value: 1}},
+		Options: options.Index(),
+	}})
+
+	ScreeningHitReviewModel = db.Collection("ScreeningHitReviews")
+	ScreeningHitReviewModel.Indexes().CreateMany(ctx, []mongo.IndexModel{{
+		Keys:    bson.D{{Key: "hitID", Value: 1}, {Key: "appID", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}, {
+		Keys:    bson.D{{Key: "appID", Value: 1}, {Key: "status", Value: 1}},
+		Options: options.Index(),
+	}})
+
+	KYCJobModel = db.Collection("KYCJobs")
+	KYCJobModel.Indexes().CreateMany(ctx, []mongo.IndexModel{{
+		Keys:    bson.D{{Key: "appID", Value: 1}},
+		Options: options.Index(),
+	}, {
+		Keys:    bson.D{{Key: "callbackStatus", Value: 1}, {Key: "nextCallbackAt", Value: 1}},
+		Options: options.Index(),
+	}, {
+		// jobs hold identity records, they are deleted once they expire
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}})
+
+	MeteredLookupModel = db.Collection("MeteredLookups")
+	MeteredLookupModel.Indexes().CreateMany(ctx, []mongo.IndexModel{{
+		Keys:    bson.D{{Key: "appID", Value: 1}, {Key: "period", Value: 1}, {Key: "category", Value: 1}},
+		Options: options.Index(),
+	}, {
+		Keys:    bson.D{{Key: "appID", Value: 1}, {Key: "period", Value: 1}, {Key: "type", Value: 1}, {Key: "source", Value: 1}},
+		Options: options.Index(),
+	}, {
+		Keys:    bson.D{{Key: "appID", Value: 1}, {Key: "overage", Value: 1}, {Key: "billedAt", Value: 1}},
+		Options: options.Index(),
+	}})
+
+	LookupQuotaCounterModel = db.Collection("LookupQuotaCounters")
+	LookupQuotaCounterModel.Indexes().CreateMany(ctx, []mongo.IndexModel{{
+		// a reservation that would go past the quota fails to match and its upsert is stopped here
+		Keys:    bson.D{{Key: "appID", Value: 1}, {Key: "period", Value: 1}, {Key: "category", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}, {
+		Keys:    bson.D{{Key: "overageSettledAt", Value: 1}, {Key: "period", Value: 1}},
+		Options: options.Index(),
+	}})
+
 	logger.Info("mongodb indexes set up successfully")
 }
@@ -45,6 +45,26 @@ func (redisRepo *RedisRepository) CreateEntry(key string, payload interface{}, t
 	return true
 }
 
+// Creates the entry only if key does not exist. Returns false if the key exists or the entry could not be created.
+func (redisRepo *RedisRepository) CreateEntryIfNotExists(key string, payload interface{}, ttl time.Duration) bool {
+	redisRepo.preRequest()
+	ctx := context.Background()
+	created, err := redisRepo.Client.SetNX(ctx, key, payload, ttl).Result()
+	if err != nil {
+		logger.Error("redis error occured while running CreateEntryIfNotExists", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key:  "key",
+			Data: key,
+		})
+		return false
+	}
+
+	logger.Info("redis CreateEntryIfNotExists completed")
+	return created
+}
+
 func (redisRepo *RedisRepository) FindOne(key string) *string {
 	redisRepo.preRequest()
 	ctx := context.Background()
@@ -69,6 +89,31 @@ func (redisRepo *RedisRepository) FindOne(key string) *string {
 	return &result
 }
 
+// reads and deletes key in one step so only one caller can ever get its value
+func (redisRepo *RedisRepository) FindAndDeleteOne(key string) *string {
+	redisRepo.preRequest()
+	ctx := context.Background()
+
+	result, err := redisRepo.Client.GetDel(ctx, key).Result()
+
+	if err != nil {
+		if err.Error() == "redis: nil" {
+			return nil
+		}
+		logger.Error("redis error occured while running FindAndDeleteOne", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key:  "key",
+			Data: key,
+		})
+		return nil
+	}
+
+	logger.Info("redis FindAndDeleteOne completed")
+	return &result
+}
+
 func (redisRepo *RedisRepository) FindOneByteArray(key string) *[]byte {
 	redisRepo.preRequest()
 	ctx := context.Background()
@@ -117,6 +162,29 @@ func (redisRepo *RedisRepository) DeleteOne(key string) bool {
 	return true
 }
 
+// Moves the entry at key to newKey with its ttl. Returns false if key does not exist, newKey already exists or the entry could not be moved.
+func (redisRepo *RedisRepository) RenameIfNotExists(key string, newKey string) bool {
+	redisRepo.preRequest()
+	ctx := context.Background()
+	renamed, err := redisRepo.Client.RenameNX(ctx, key, newKey).Result()
+	if err != nil {
+		if err.Error() == "ERR no such key" {
+			return false
+		}
+		logger.Error("redis error occured while running RenameIfNotExists", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key:  "key",
+			Data: key,
+		})
+		return false
+	}
+
+	logger.Info("redis RenameIfNotExists completed")
+	return renamed
+}
+
 func (redisRepo *RedisRepository) CreateInSortedSet(key string, score float64, member interface{}) int64 {
 	redisRepo.preRequest()
 	ctx := context.Background()
@@ -288,3 +356,23 @@ func (redisRepo *RedisRepository) IncrementField(key string, amount int64) int64
 	logger.Info("redis IncrementField completed")
 	return result.Val()
 }
+
+// Runs a lua script so a read-modify-write on keys happens atomically on the server.
+func (redisRepo *RedisRepository) RunScript(script *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	redisRepo.preRequest()
+	ctx := context.Background()
+
+	result, err := script.Run(ctx, redisRepo.Client, keys, args...).Result()
+	if err != nil && err != redis.Nil {
+		logger.Error("redis error occured while running RunScript", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key:  "keys",
+			Data: keys,
+		})
+		return nil, err
+	}
+	logger.Info("redis RunScript completed")
+	return result, nil
+}
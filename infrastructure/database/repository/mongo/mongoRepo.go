@@ -522,6 +522,33 @@ func (repo *MongoRepository[T]) UpdateManyWithOperator(filter map[string]interfa
 	return affected.ModifiedCount, err
 }
 
+// Applies the update operators in payload to the first document matching filter and returns it.
+// The document is returned as it was before the update unless opts ask for it after. Returns nil when no document matched.
+func (repo *MongoRepository[T]) FindOneAndUpdateWithOperator(filter map[string]interface{}, payload map[string]interface{}, opts ...*options.FindOneAndUpdateOptions) (*T, error) {
+	c, cancel := repo.createCtx()
+
+	defer func() {
+		cancel()
+	}()
+	var result T
+	err := repo.Model.FindOneAndUpdate(c, filter, payload, opts...).Decode(&result)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		logger.Error("mongo error occured while running FindOneAndUpdateWithOperator", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		}, logger.LoggerOptions{
+			Key:  "filter",
+			Data: filter,
+		})
+		return nil, err
+	}
+	logger.Info("FindOneAndUpdateWithOperator complete")
+	return &result, nil
+}
+
 func (repo *MongoRepository[T]) UpdateOrCreateByField(filter map[string]interface{}, payload map[string]interface{}, opts ...*options.UpdateOptions) (bool, error) {
 	c, cancel := repo.createCtx()
 
@@ -53,7 +53,7 @@ func (s *ginServer) Start() {
 	corsConfig := cors.Config{
 		AllowOrigins:     origins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "PATCH"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "x-device-id", "User-Agent", "x-workspace-id", "x-api-key", "x-app-id", "x-app-version"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "x-device-id", "User-Agent", "x-workspace-id", "x-api-key", "x-app-id", "x-app-version", "Authorization", "x-payload-nonce", "x-payload-timestamp"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -72,9 +72,14 @@ func (s *ginServer) Start() {
 			apperrors.ErrorProcessingPayload(ctx, nil)
 			return
 		}
-		it, _ := hex.DecodeString(body["clientPubKey"].(string))
-		clientPubKey, _ := ecdh.P256().NewPublicKey([]byte(it))
 		deviceID := ctx.GetHeader("X-Device-Id")
+		encodedClientPubKey, _ := body["clientPubKey"].(string)
+		it, _ := hex.DecodeString(encodedClientPubKey)
+		clientPubKey, err := ecdh.P256().NewPublicKey([]byte(it))
+		if err != nil || deviceID == "" {
+			apperrors.ErrorProcessingPayload(ctx, &deviceID)
+			return
+		}
 		controller.KeyExchange(&interfaces.ApplicationContext[dto.KeyExchangeDTO]{
 			Ctx: ctx,
 			Body: &dto.KeyExchangeDTO{
@@ -99,16 +104,9 @@ func (s *ginServer) Start() {
 	{
 		publicRouter.AppRouter(publicV1)
 		publicRouter.WebhookRouter(publicV1)
-
-		// In dev mode, allow biometric endpoints without authentication for testing
-		if os.Getenv("APP_ENV") == "dev" {
-			publicRouter.BiometricRouter(publicV1)
-		} else {
-			publicV1.Use(middlewares.AppAuthenticationMiddleware())
-			publicRouter.BiometricRouter(publicV1)
-		}
-
-		publicV1.Use(middlewares.AppAuthenticationMiddleware())
+		publicRouter.OIDCRouter(publicV1)
+		// api keys are authenticated on each route so the scope the route needs can be enforced
+		publicRouter.BiometricRouter(publicV1)
 		publicRouter.KYCRouter(publicV1)
 	}
 
@@ -28,7 +28,14 @@ func (div *DojahIdentityVerification) ImgLivenessCheck(img string) (bool, error)
 			Key:  "error",
 			Data: err,
 		})
-		return false, errors.New("something went wrong while retireving liveness check result from dojah")
+		return false, fmt.Errorf("%w: something went wrong while retireving liveness check result from dojah", identity_verification_types.ErrIdentityProviderUnavailable)
+	}
+	if identity_verification_types.ProviderUnavailableStatus(*statusCode) {
+		logger.Error("request to Dojah for liveness check was unsuccessful", logger.LoggerOptions{
+			Key:  "statusCode",
+			Data: *statusCode,
+		})
+		return false, identity_verification_types.ErrIdentityProviderUnavailable
 	}
 	var dojahResponse identity_verification_types.LivenessCheckResult
 	err = json.Unmarshal(*response, &dojahResponse)
@@ -64,15 +71,15 @@ func (div *DojahIdentityVerification) FetchBVNDetails(bvn string) (*identity_ver
 		"Authorization": div.API_KEY,
 		"AppId":         div.APP_ID,
 	}, nil)
-	var dojahResponse DojahBVNResponse
-	json.Unmarshal(*response, &dojahResponse)
 	if err != nil {
 		logger.Error("error retireving bvn data from dojah", logger.LoggerOptions{
 			Key:  "error",
 			Data: err,
 		})
-		return nil, errors.New("something went wrong while retireving bvn data from dojah")
+		return nil, fmt.Errorf("%w: something went wrong while retireving bvn data from dojah", identity_verification_types.ErrIdentityProviderUnavailable)
 	}
+	var dojahResponse DojahBVNResponse
+	json.Unmarshal(*response, &dojahResponse)
 	if *statusCode != 200 {
 		logger.Error("request to Dojah for BVN fetch was unsuccessful", logger.LoggerOptions{
 			Key:  "statusCode",
@@ -81,6 +88,9 @@ func (div *DojahIdentityVerification) FetchBVNDetails(bvn string) (*identity_ver
 			Key:  "data",
 			Data: dojahResponse,
 		})
+		if identity_verification_types.ProviderUnavailableStatus(*statusCode) {
+			return nil, identity_verification_types.ErrIdentityProviderUnavailable
+		}
 		return nil, errors.New("error retireving bvn")
 	}
 	logger.Info("BVN information retireved by Dojah")
@@ -92,15 +102,15 @@ func (div *DojahIdentityVerification) FetchNINDetails(nin string) (*identity_ver
 		"Authorization": div.API_KEY,
 		"AppId":         div.APP_ID,
 	}, nil)
-	var dojahResponse DojahNINResponse
-	json.Unmarshal(*response, &dojahResponse)
 	if err != nil {
 		logger.Error("error retireving nin data from dojah", logger.LoggerOptions{
 			Key:  "error",
 			Data: err,
 		})
-		return nil, errors.New("something went wrong while retireving nin data from dojah")
+		return nil, fmt.Errorf("%w: something went wrong while retireving nin data from dojah", identity_verification_types.ErrIdentityProviderUnavailable)
 	}
+	var dojahResponse DojahNINResponse
+	json.Unmarshal(*response, &dojahResponse)
 	if *statusCode != 200 {
 		logger.Error("request to Dojah for nin fetch was unsuccessful", logger.LoggerOptions{
 			Key:  "statusCode",
@@ -109,6 +119,9 @@ func (div *DojahIdentityVerification) FetchNINDetails(nin string) (*identity_ver
 			Key:  "data",
 			Data: dojahResponse,
 		})
+		if identity_verification_types.ProviderUnavailableStatus(*statusCode) {
+			return nil, identity_verification_types.ErrIdentityProviderUnavailable
+		}
 		if dojahResponse.Error == "Wrong NIN Inputted" {
 			return nil, errors.New("NIN not found. Crosscheck the number inputed")
 		}
@@ -123,15 +136,15 @@ func (div *DojahIdentityVerification) EmailVerification(email string) (bool, err
 		"Authorization": div.API_KEY,
 		"AppId":         div.APP_ID,
 	}, nil)
-	var dojahResponse DojahEmailVerification
-	json.Unmarshal(*response, &dojahResponse)
 	if err != nil {
 		logger.Error("error verifying email from dojah", logger.LoggerOptions{
 			Key:  "error",
 			Data: err,
 		})
-		return false, errors.New("something went wrong while verifying email from dojah")
+		return false, fmt.Errorf("%w: something went wrong while verifying email from dojah", identity_verification_types.ErrIdentityProviderUnavailable)
 	}
+	var dojahResponse DojahEmailVerification
+	json.Unmarshal(*response, &dojahResponse)
 	if *statusCode != 200 {
 		logger.Error("request to Dojah email verification was unsuccessful", logger.LoggerOptions{
 			Key:  "statusCode",
@@ -140,6 +153,9 @@ func (div *DojahIdentityVerification) EmailVerification(email string) (bool, err
 			Key:  "data",
 			Data: dojahResponse,
 		})
+		if identity_verification_types.ProviderUnavailableStatus(*statusCode) {
+			return false, identity_verification_types.ErrIdentityProviderUnavailable
+		}
 		return false, errors.New("error verifying email")
 	}
 	logger.Info("Email verification successful", logger.LoggerOptions{
@@ -157,15 +173,15 @@ func (div *DojahIdentityVerification) FetchDriverIDDetails(n string) (*identity_
 		"Authorization": div.API_KEY,
 		"AppId":         div.APP_ID,
 	}, nil)
-	var dojahResponse DojahDriversLicenseResponse
-	json.Unmarshal(*response, &dojahResponse)
 	if err != nil {
 		logger.Error("error retireving driver id data from dojah", logger.LoggerOptions{
 			Key:  "error",
 			Data: err,
 		})
-		return nil, errors.New("something went wrong while retireving driver id data from dojah")
+		return nil, fmt.Errorf("%w: something went wrong while retireving driver id data from dojah", identity_verification_types.ErrIdentityProviderUnavailable)
 	}
+	var dojahResponse DojahDriversLicenseResponse
+	json.Unmarshal(*response, &dojahResponse)
 	if *statusCode != 200 {
 		logger.Error("request to Dojah for driver id fetch was unsuccessful", logger.LoggerOptions{
 			Key:  "statusCode",
@@ -174,6 +190,9 @@ func (div *DojahIdentityVerification) FetchDriverIDDetails(n string) (*identity_
 			Key:  "data",
 			Data: dojahResponse,
 		})
+		if identity_verification_types.ProviderUnavailableStatus(*statusCode) {
+			return nil, identity_verification_types.ErrIdentityProviderUnavailable
+		}
 		if dojahResponse.Error == "Wrong driver id Inputted" {
 			return nil, errors.New("Drives License not found. Crosscheck the number inputed")
 		}
@@ -188,15 +207,15 @@ func (div *DojahIdentityVerification) FetchVoterIDDetails(vin string) (*identity
 		"Authorization": div.API_KEY,
 		"AppId":         div.APP_ID,
 	}, nil)
-	var dojahResponse DojahVoterIDResponse
-	json.Unmarshal(*response, &dojahResponse)
 	if err != nil {
 		logger.Error("error retireving voter id data from dojah", logger.LoggerOptions{
 			Key:  "error",
 			Data: err,
 		})
-		return nil, errors.New("something went wrong while retireving voter id data from dojah")
+		return nil, fmt.Errorf("%w: something went wrong while retireving voter id data from dojah", identity_verification_types.ErrIdentityProviderUnavailable)
 	}
+	var dojahResponse DojahVoterIDResponse
+	json.Unmarshal(*response, &dojahResponse)
 	if *statusCode != 200 {
 		logger.Error("request to Dojah for voter id fetch was unsuccessful", logger.LoggerOptions{
 			Key:  "statusCode",
@@ -205,6 +224,9 @@ func (div *DojahIdentityVerification) FetchVoterIDDetails(vin string) (*identity
 			Key:  "data",
 			Data: dojahResponse,
 		})
+		if identity_verification_types.ProviderUnavailableStatus(*statusCode) {
+			return nil, identity_verification_types.ErrIdentityProviderUnavailable
+		}
 		if dojahResponse.Error == "Wrong voter id Inputted" {
 			return nil, errors.New("Voter License not found. Crosscheck the number inputed")
 		}
@@ -213,3 +235,55 @@ func (div *DojahIdentityVerification) FetchVoterIDDetails(vin string) (*identity
 	logger.Info("voter id information retireved by Dojah")
 	return &dojahResponse.Data, nil
 }
+
+func (div *DojahIdentityVerification) FetchCompanyDetails(rcNumber string, companyType identity_verification_types.CompanyType) (*identity_verification_types.CompanyData, error) {
+	response, statusCode, err := div.Network.Get(fmt.Sprintf("/kyb/business/advance?rc_number=%s&company_type=%s", rcNumber, companyType), &map[string]string{
+		"Authorization": div.API_KEY,
+		"AppId":         div.APP_ID,
+	}, nil)
+	if err != nil {
+		logger.Error("error retireving company data from dojah", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		return nil, fmt.Errorf("%w: something went wrong while retireving company data from dojah", identity_verification_types.ErrIdentityProviderUnavailable)
+	}
+	var dojahResponse DojahCompanyResponse
+	json.Unmarshal(*response, &dojahResponse)
+	if *statusCode != 200 {
+		logger.Error("request to Dojah for company fetch was unsuccessful", logger.LoggerOptions{
+			Key:  "statusCode",
+			Data: fmt.Sprintf("%d", *statusCode),
+		}, logger.LoggerOptions{
+			Key:  "error",
+			Data: dojahResponse.Error,
+		})
+		if identity_verification_types.ProviderUnavailableStatus(*statusCode) {
+			return nil, identity_verification_types.ErrIdentityProviderUnavailable
+		}
+		return nil, errors.New("error retireving company")
+	}
+	logger.Info("company information retireved by Dojah")
+	data := dojahResponse.Data
+	directors := []identity_verification_types.CompanyAffiliate{}
+	for _, affiliate := range data.Affiliates {
+		directors = append(directors, identity_verification_types.CompanyAffiliate{
+			FirstName:   affiliate.FirstName,
+			MiddleName:  affiliate.OtherName,
+			LastName:    affiliate.LastName,
+			Position:    affiliate.AffiliateType,
+			DateOfBirth: identity_verification_types.NormaliseProviderDate(affiliate.DateOfBirth, "2006-01-02"),
+		})
+	}
+	return &identity_verification_types.CompanyData{
+		CompanyName:      data.CompanyName,
+		RCNumber:         data.RCNumber,
+		CompanyType:      companyType,
+		Status:           data.Status,
+		RegistrationDate: identity_verification_types.NormaliseProviderDate(data.RegistrationDate, "2006-01-02"),
+		Address:          data.Address,
+		State:            data.State,
+		Email:            data.Email,
+		Directors:        directors,
+	}, nil
+}
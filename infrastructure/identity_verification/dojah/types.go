@@ -21,6 +21,30 @@ type DojahVoterIDResponse struct {
 	Error string                              `json:"error"`
 }
 
+type DojahCompanyResponse struct {
+	Data  DojahCompanyData `json:"entity"`
+	Error string           `json:"error"`
+}
+
+type DojahCompanyData struct {
+	CompanyName      string                  `json:"company_name"`
+	RCNumber         string                  `json:"rc_number"`
+	Status           string                  `json:"company_status"`
+	RegistrationDate string                  `json:"date_of_registration"`
+	Address          string                  `json:"address"`
+	State            string                  `json:"state"`
+	Email            *string                 `json:"email_address"`
+	Affiliates       []DojahCompanyAffiliate `json:"affiliates"`
+}
+
+type DojahCompanyAffiliate struct {
+	FirstName     string  `json:"first_name"`
+	OtherName     *string `json:"other_name"`
+	LastName      string  `json:"last_name"`
+	AffiliateType string  `json:"affiliate_type"`
+	DateOfBirth   string  `json:"date_of_birth"`
+}
+
 type DojahEmailVerification struct {
 	Entity DojahEmailVerificationPayload `json:"entity"`
 }
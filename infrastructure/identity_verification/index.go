@@ -1,21 +1,82 @@
 package identityverification
 
 import (
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	dojah_identity_verification "gateman.io/infrastructure/identity_verification/dojah"
+	prembly_identity_verification "gateman.io/infrastructure/identity_verification/prembly"
 	identity_verification_types "gateman.io/infrastructure/identity_verification/types"
+	"gateman.io/infrastructure/logger"
 	"gateman.io/infrastructure/network"
 )
 
+// how long a provider has to answer before the request fails over to the next one
+const identityProviderTimeout = time.Second * 15
+
 var IdentityVerifier identity_verification_types.IdentityVerifierType
 
+// Providers are tried in the order of IDENTITY_PROVIDERS ("dojah,prembly" by default).
+// IDENTITY_PROVIDER_ROUTES picks the providers of single checks, e.g. "nin=prembly,dojah;voters_card=dojah".
+// Prembly is only registered when PREMBLY_API_KEY is set.
 func InitialiseIdentityVerifier() {
-	IdentityVerifier = &dojah_identity_verification.DojahIdentityVerification{
-		Network: &network.NetworkController{
-			BaseUrl: os.Getenv("DOJAH_BASE_URL"),
+	providers := map[string]identity_verification_types.IdentityVerifierType{
+		"dojah": &dojah_identity_verification.DojahIdentityVerification{
+			Network: &network.NetworkController{
+				BaseUrl:    os.Getenv("DOJAH_BASE_URL"),
+				HttpClient: &http.Client{Timeout: identityProviderTimeout},
+			},
+			API_KEY: os.Getenv("DOJAH_API_KEY"),
+			APP_ID:  os.Getenv("DOJAH_APP_ID"),
 		},
-		API_KEY: os.Getenv("DOJAH_API_KEY"),
-		APP_ID:  os.Getenv("DOJAH_APP_ID"),
 	}
+	if os.Getenv("PREMBLY_API_KEY") != "" {
+		providers["prembly"] = &prembly_identity_verification.PremblyIdentityVerification{
+			Network: &network.NetworkController{
+				BaseUrl:    os.Getenv("PREMBLY_BASE_URL"),
+				HttpClient: &http.Client{Timeout: identityProviderTimeout},
+			},
+			API_KEY: os.Getenv("PREMBLY_API_KEY"),
+			APP_ID:  os.Getenv("PREMBLY_APP_ID"),
+		}
+	}
+
+	priority := os.Getenv("IDENTITY_PROVIDERS")
+	if priority == "" {
+		priority = "dojah,prembly"
+	}
+	router := IdentityVerificationRouter{
+		Routes: map[identity_verification_types.IdentityCheck][]string{},
+	}
+	for _, name := range splitProviderNames(priority) {
+		verifier, ok := providers[name]
+		if !ok {
+			logger.Warning("identity verification provider is not configured", logger.LoggerOptions{
+				Key:  "provider",
+				Data: name,
+			})
+			continue
+		}
+		router.Providers = append(router.Providers, IdentityProvider{Name: name, Verifier: verifier})
+	}
+	for _, route := range strings.Split(os.Getenv("IDENTITY_PROVIDER_ROUTES"), ";") {
+		check, names, found := strings.Cut(strings.TrimSpace(route), "=")
+		if !found {
+			continue
+		}
+		router.Routes[identity_verification_types.IdentityCheck(strings.TrimSpace(check))] = splitProviderNames(names)
+	}
+	IdentityVerifier = &router
+}
+
+func splitProviderNames(names string) []string {
+	split := []string{}
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			split = append(split, name)
+		}
+	}
+	return split
 }
@@ -0,0 +1,199 @@
+package prembly_identity_verification
+
+import (
+	"encoding/json"
+	"fmt"
+
+	identity_verification_types "gateman.io/infrastructure/identity_verification/types"
+	"gateman.io/infrastructure/logger"
+	"gateman.io/infrastructure/network"
+)
+
+// Prembly (Identitypass) responses are normalised into the same structs Dojah responses are parsed into,
+// with dates in the layouts the verification flows parse them with.
+type PremblyIdentityVerification struct {
+	Network *network.NetworkController
+	API_KEY string
+	APP_ID  string
+}
+
+// Runs a verification with body against path and parses the response into premblyResponse.
+// The returned error wraps ErrIdentityProviderUnavailable when Prembly failed rather than the number being invalid.
+func (piv *PremblyIdentityVerification) verify(path string, idName string, body map[string]any, premblyResponse any, status *PremblyResponse) error {
+	response, statusCode, err := piv.Network.Post(path, &map[string]string{
+		"x-api-key": piv.API_KEY,
+		"app-id":    piv.APP_ID,
+	}, body, nil, false, nil)
+	if err != nil {
+		logger.Error(fmt.Sprintf("error retireving %s data from prembly", idName), logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		return fmt.Errorf("%w: something went wrong while retireving %s data from prembly", identity_verification_types.ErrIdentityProviderUnavailable, idName)
+	}
+	if identity_verification_types.ProviderUnavailableStatus(*statusCode) {
+		logger.Error(fmt.Sprintf("request to Prembly for %s fetch was unsuccessful", idName), logger.LoggerOptions{
+			Key:  "statusCode",
+			Data: *statusCode,
+		})
+		return identity_verification_types.ErrIdentityProviderUnavailable
+	}
+	if err := json.Unmarshal(*response, premblyResponse); err != nil {
+		logger.Error(fmt.Sprintf("error parsing %s data from prembly", idName), logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		return fmt.Errorf("%w: something went wrong while parsing %s data from prembly", identity_verification_types.ErrIdentityProviderUnavailable, idName)
+	}
+	if *statusCode != 200 || !status.Status {
+		logger.Error(fmt.Sprintf("request to Prembly for %s fetch was unsuccessful", idName), logger.LoggerOptions{
+			Key:  "statusCode",
+			Data: *statusCode,
+		}, logger.LoggerOptions{
+			Key:  "detail",
+			Data: status.Detail,
+		})
+		return fmt.Errorf("error retireving %s", idName)
+	}
+	logger.Info(fmt.Sprintf("%s information retireved by Prembly", idName))
+	return nil
+}
+
+func (piv *PremblyIdentityVerification) FetchNINDetails(nin string) (*identity_verification_types.NINData, error) {
+	var premblyResponse PremblyNINResponse
+	err := piv.verify("/identitypass/verification/nin_wo_face", "nin", map[string]any{"number": nin}, &premblyResponse, &premblyResponse.PremblyResponse)
+	if err != nil {
+		return nil, err
+	}
+	data := premblyResponse.Data
+	return &identity_verification_types.NINData{
+		FirstName:        data.FirstName,
+		MiddleName:       data.MiddleName,
+		LastName:         data.Surname,
+		PhoneNumber:      data.Telephone,
+		Photo:            data.Photo,
+		Gender:           data.Gender,
+		DateOfBirth:      identity_verification_types.NormaliseProviderDate(data.BirthDate, "2006-01-02"),
+		Email:            data.Email,
+		EmploymentStatus: data.Employment,
+		MaritalStatus:    data.MaritalState,
+		BirthCountry:     data.BirthCountry,
+		BirthLGA:         data.BirthLGA,
+		BirthState:       data.BirthState,
+		EducationalLevel: data.Education,
+		MaidenName:       data.MaidenName,
+		NSpokenLang:      data.SpokenLang,
+		Profession:       data.Profession,
+		Religion:         data.Religion,
+		Address:          data.Address,
+		ResidenceStatus:  data.Status,
+		ResidenceTown:    data.Town,
+		ResidenceLGA:     data.LGA,
+		ResidenceState:   data.State,
+		Signature:        data.Signature,
+		OriginLGA:        data.OriginLGA,
+		OriginPlace:      data.OriginPlace,
+		OriginState:      data.OriginState,
+		Height:           data.Height,
+	}, nil
+}
+
+func (piv *PremblyIdentityVerification) FetchBVNDetails(bvn string) (*identity_verification_types.BVNData, error) {
+	var premblyResponse PremblyBVNResponse
+	err := piv.verify("/identitypass/verification/bvn", "bvn", map[string]any{"number": bvn}, &premblyResponse, &premblyResponse.PremblyResponse)
+	if err != nil {
+		return nil, err
+	}
+	data := premblyResponse.Data
+	return &identity_verification_types.BVNData{
+		FirstName:        data.FirstName,
+		LastName:         data.LastName,
+		MiddleName:       data.MiddleName,
+		Gender:           data.Gender,
+		DateOfBirth:      identity_verification_types.NormaliseProviderDate(data.DateOfBirth, "2006-01-02"),
+		PhoneNumber:      data.PhoneNumber,
+		Image:            data.Image,
+		Email:            data.Email,
+		EnrollmentBank:   data.EnrollmentBank,
+		EnrollmentBranch: data.EnrollmentBranch,
+		LevelOfAccount:   data.LevelOfAccount,
+		LGAOfOrigin:      data.LGAOfOrigin,
+		LGAOfResidence:   data.LGAOfResidence,
+		MaritalStatus:    data.MaritalStatus,
+		NameOnCard:       data.NameOnCard,
+		Nationality:      data.Nationality,
+		RegistrationDate: identity_verification_types.NormaliseProviderDate(data.RegistrationDate, "2006-01-02"),
+		Address:          data.Address,
+		StateOfOrigin:    data.StateOfOrigin,
+		StateOfResidence: data.StateOfResidence,
+		Title:            data.Title,
+		WatchListed:      data.WatchListed,
+	}, nil
+}
+
+func (piv *PremblyIdentityVerification) FetchDriverIDDetails(n string) (*identity_verification_types.DriversID, error) {
+	var premblyResponse PremblyDriversLicenseResponse
+	err := piv.verify("/identitypass/verification/drivers_license", "driver id", map[string]any{"number": n}, &premblyResponse, &premblyResponse.PremblyResponse)
+	if err != nil {
+		return nil, err
+	}
+	data := premblyResponse.Data
+	return &identity_verification_types.DriversID{
+		LicenseNo:    data.LicenseNo,
+		FirstName:    data.FirstName,
+		LastName:     data.LastName,
+		MiddleName:   data.MiddleName,
+		Gender:       data.Gender,
+		IssuedDate:   identity_verification_types.NormaliseProviderDate(data.IssuedDate, "02-01-2006"),
+		ExpiryDate:   identity_verification_types.NormaliseProviderDate(data.ExpiryDate, "02-01-2006"),
+		StateOfIssue: data.StateOfIssue,
+		BirthDate:    identity_verification_types.NormaliseProviderDate(data.BirthDate, "02-01-2006"),
+		Photo:        data.Photo,
+	}, nil
+}
+
+func (piv *PremblyIdentityVerification) FetchCompanyDetails(rcNumber string, companyType identity_verification_types.CompanyType) (*identity_verification_types.CompanyData, error) {
+	var premblyResponse PremblyCompanyResponse
+	err := piv.verify("/identitypass/verification/cac/advance", "company", map[string]any{
+		"rc_number":    rcNumber,
+		"company_type": companyType,
+	}, &premblyResponse, &premblyResponse.PremblyResponse)
+	if err != nil {
+		return nil, err
+	}
+	data := premblyResponse.Data
+	directors := []identity_verification_types.CompanyAffiliate{}
+	for _, director := range data.Directors {
+		directors = append(directors, identity_verification_types.CompanyAffiliate{
+			FirstName:   director.FirstName,
+			MiddleName:  director.OtherName,
+			LastName:    director.Surname,
+			Position:    director.Designation,
+			DateOfBirth: identity_verification_types.NormaliseProviderDate(director.DateOfBirth, "2006-01-02"),
+		})
+	}
+	return &identity_verification_types.CompanyData{
+		CompanyName:      data.CompanyName,
+		RCNumber:         data.RCNumber,
+		CompanyType:      companyType,
+		Status:           data.Status,
+		RegistrationDate: identity_verification_types.NormaliseProviderDate(data.RegistrationDate, "2006-01-02"),
+		Address:          data.Address,
+		State:            data.State,
+		Email:            data.Email,
+		Directors:        directors,
+	}, nil
+}
+
+// Prembly needs the holder's last name and state to look up a voter's card, which the verification flows do not collect
+func (piv *PremblyIdentityVerification) FetchVoterIDDetails(vin string) (*identity_verification_types.VoterID, error) {
+	return nil, identity_verification_types.ErrUnsupportedIdentityCheck
+}
+
+func (piv *PremblyIdentityVerification) EmailVerification(email string) (bool, error) {
+	return false, identity_verification_types.ErrUnsupportedIdentityCheck
+}
+
+func (piv *PremblyIdentityVerification) ImgLivenessCheck(img string) (bool, error) {
+	return false, identity_verification_types.ErrUnsupportedIdentityCheck
+}
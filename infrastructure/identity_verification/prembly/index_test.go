@@ -0,0 +1,98 @@
+package prembly_identity_verification
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	identity_verification_types "gateman.io/infrastructure/identity_verification/types"
+	"gateman.io/infrastructure/network"
+)
+
+func testVerifier(t *testing.T, handler http.HandlerFunc) *PremblyIdentityVerification {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return &PremblyIdentityVerification{
+		Network: &network.NetworkController{BaseUrl: server.URL, HttpClient: server.Client()},
+		API_KEY: "test-api-key",
+		APP_ID:  "test-app-id",
+	}
+}
+
+func respond(statusCode int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		w.Write([]byte(body))
+	}
+}
+
+func TestFetchNINDetails(t *testing.T) {
+	verifier := testVerifier(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/identitypass/verification/nin_wo_face" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "test-api-key" || r.Header.Get("app-id") != "test-app-id" {
+			t.Errorf("missing credentials in headers %v", r.Header)
+		}
+		respond(200, `{"status": true, "detail": "Verification Successful", "nin_data": {"firstname": "Oluwaseun", "surname": "Adebayo", "birthdate": "12-08-1974", "gender": "m"}}`)(w, r)
+	})
+	nin, err := verifier.FetchNINDetails("12345678901")
+	if err != nil {
+		t.Fatalf("FetchNINDetails failed: %v", err)
+	}
+	if nin.FirstName != "Oluwaseun" || nin.LastName != "Adebayo" {
+		t.Errorf("name = %s %s", nin.FirstName, nin.LastName)
+	}
+	if nin.DateOfBirth != "1974-08-12" {
+		t.Errorf("date of birth = %s, want 1974-08-12", nin.DateOfBirth)
+	}
+}
+
+func TestVerifyErrorMapping(t *testing.T) {
+	cases := []struct {
+		name        string
+		handler     http.HandlerFunc
+		unavailable bool
+	}{
+		{"server error", respond(500, `{"status": false}`), true},
+		{"bad gateway without a body", respond(502, ``), true},
+		{"rate limited", respond(429, `{"status": false, "detail": "Too many requests"}`), true},
+		{"malformed response", respond(200, `<html>maintenance</html>`), true},
+		{"invalid number", respond(200, `{"status": false, "detail": "Record not found", "response_code": "01"}`), false},
+		{"rejected request", respond(400, `{"status": false, "detail": "Invalid number"}`), false},
+	}
+	for _, c := range cases {
+		_, err := testVerifier(t, c.handler).FetchNINDetails("12345678901")
+		if err == nil {
+			t.Errorf("%s: expected an error", c.name)
+			continue
+		}
+		if got := errors.Is(err, identity_verification_types.ErrIdentityProviderUnavailable); got != c.unavailable {
+			t.Errorf("%s: err = %v, unavailable = %t, want %t", c.name, err, got, c.unavailable)
+		}
+	}
+}
+
+func TestVerifyUnreachable(t *testing.T) {
+	server := httptest.NewServer(respond(200, `{}`))
+	server.Close()
+	verifier := &PremblyIdentityVerification{Network: &network.NetworkController{BaseUrl: server.URL, HttpClient: &http.Client{}}}
+	if _, err := verifier.FetchBVNDetails("12345678901"); !errors.Is(err, identity_verification_types.ErrIdentityProviderUnavailable) {
+		t.Errorf("err = %v, want %v", err, identity_verification_types.ErrIdentityProviderUnavailable)
+	}
+}
+
+func TestUnsupportedChecks(t *testing.T) {
+	verifier := testVerifier(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+	if _, err := verifier.FetchVoterIDDetails("90F5B1234567890"); !errors.Is(err, identity_verification_types.ErrUnsupportedIdentityCheck) {
+		t.Errorf("voter id: err = %v, want %v", err, identity_verification_types.ErrUnsupportedIdentityCheck)
+	}
+	if _, err := verifier.ImgLivenessCheck("image"); !errors.Is(err, identity_verification_types.ErrUnsupportedIdentityCheck) {
+		t.Errorf("liveness: err = %v, want %v", err, identity_verification_types.ErrUnsupportedIdentityCheck)
+	}
+}
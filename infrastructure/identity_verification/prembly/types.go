@@ -0,0 +1,115 @@
+package prembly_identity_verification
+
+type PremblyResponse struct {
+	Status       bool   `json:"status"`
+	Detail       string `json:"detail"`
+	ResponseCode string `json:"response_code"`
+}
+
+type PremblyNINResponse struct {
+	PremblyResponse
+	Data PremblyNINData `json:"nin_data"`
+}
+
+type PremblyNINData struct {
+	FirstName    string  `json:"firstname"`
+	MiddleName   *string `json:"middlename"`
+	Surname      string  `json:"surname"`
+	MaidenName   *string `json:"maidenname"`
+	Telephone    *string `json:"telephoneno"`
+	Email        *string `json:"email"`
+	Gender       string  `json:"gender"`
+	BirthDate    string  `json:"birthdate"`
+	BirthCountry string  `json:"birthcountry"`
+	BirthLGA     string  `json:"birthlga"`
+	BirthState   string  `json:"birthstate"`
+	Photo        string  `json:"photo"`
+	Signature    string  `json:"signature"`
+	Address      string  `json:"residence_AdressLine1"`
+	Town         string  `json:"residence_Town"`
+	LGA          string  `json:"residence_lga"`
+	State        string  `json:"residence_state"`
+	Status       string  `json:"residencestatus"`
+	Religion     string  `json:"religion"`
+	Profession   *string `json:"profession"`
+	MaritalState string  `json:"maritalstatus"`
+	Employment   string  `json:"emplymentstatus"`
+	Education    string  `json:"educationallevel"`
+	OriginLGA    string  `json:"self_origin_lga"`
+	OriginPlace  string  `json:"self_origin_place"`
+	OriginState  string  `json:"self_origin_state"`
+	Height       string  `json:"heigth"`
+	SpokenLang   string  `json:"nspokenlang"`
+}
+
+type PremblyBVNResponse struct {
+	PremblyResponse
+	Data PremblyBVNData `json:"data"`
+}
+
+type PremblyBVNData struct {
+	FirstName        string  `json:"firstName"`
+	MiddleName       *string `json:"middleName"`
+	LastName         string  `json:"lastName"`
+	DateOfBirth      string  `json:"dateOfBirth"`
+	PhoneNumber      string  `json:"phoneNumber1"`
+	Email            string  `json:"email"`
+	Gender           string  `json:"gender"`
+	Image            string  `json:"base64Image"`
+	EnrollmentBank   string  `json:"enrollmentBank"`
+	EnrollmentBranch string  `json:"enrollmentBranch"`
+	LevelOfAccount   string  `json:"levelOfAccount"`
+	LGAOfOrigin      string  `json:"lgaOfOrigin"`
+	LGAOfResidence   string  `json:"lgaOfResidence"`
+	MaritalStatus    string  `json:"maritalStatus"`
+	NameOnCard       string  `json:"nameOnCard"`
+	Nationality      string  `json:"nationality"`
+	RegistrationDate string  `json:"registrationDate"`
+	Address          string  `json:"residentialAddress"`
+	StateOfOrigin    string  `json:"stateOfOrigin"`
+	StateOfResidence string  `json:"stateOfResidence"`
+	Title            string  `json:"title"`
+	WatchListed      string  `json:"watchListed"`
+}
+
+type PremblyDriversLicenseResponse struct {
+	PremblyResponse
+	Data PremblyDriversLicenseData `json:"data"`
+}
+
+type PremblyDriversLicenseData struct {
+	LicenseNo    string  `json:"licenseNo"`
+	FirstName    string  `json:"firstName"`
+	LastName     string  `json:"lastName"`
+	MiddleName   *string `json:"middleName"`
+	Gender       string  `json:"gender"`
+	IssuedDate   string  `json:"issuedDate"`
+	ExpiryDate   string  `json:"expiryDate"`
+	StateOfIssue string  `json:"stateOfIssue"`
+	BirthDate    string  `json:"birthDate"`
+	Photo        string  `json:"photo"`
+}
+
+type PremblyCompanyResponse struct {
+	PremblyResponse
+	Data PremblyCompanyData `json:"data"`
+}
+
+type PremblyCompanyData struct {
+	CompanyName      string                    `json:"company_name"`
+	RCNumber         string                    `json:"rc_number"`
+	Status           string                    `json:"company_status"`
+	RegistrationDate string                    `json:"registrationDate"`
+	Address          string                    `json:"headOfficeAddress"`
+	State            string                    `json:"state"`
+	Email            *string                   `json:"email_address"`
+	Directors        []PremblyCompanyAffiliate `json:"directors"`
+}
+
+type PremblyCompanyAffiliate struct {
+	FirstName   string  `json:"firstname"`
+	OtherName   *string `json:"otherName"`
+	Surname     string  `json:"surname"`
+	Designation string  `json:"affiliateType"`
+	DateOfBirth string  `json:"dateOfBirth"`
+}
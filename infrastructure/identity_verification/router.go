@@ -0,0 +1,175 @@
+package identityverification
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	identity_verification_types "gateman.io/infrastructure/identity_verification/types"
+	"gateman.io/infrastructure/logger"
+	"gateman.io/infrastructure/metrics"
+)
+
+const (
+	defaultProviderFailureThreshold = 3
+	defaultProviderCooldown         = time.Second * 30
+)
+
+type IdentityProvider struct {
+	Name     string
+	Verifier identity_verification_types.IdentityVerifierType
+}
+
+type providerHealth struct {
+	failures       int
+	unhealthyUntil time.Time
+}
+
+// Routes every check to the providers that can serve it, in order, until one of them answers.
+// A provider only loses the request to the next one when it is unavailable or does not offer the check,
+// an id it reports as invalid is not looked up again elsewhere.
+//
+// A provider that is unavailable FailureThreshold times in a row is marked unhealthy and tried after the healthy ones
+// until Cooldown has passed. Health is tracked per instance.
+type IdentityVerificationRouter struct {
+	// every provider in priority order
+	Providers []IdentityProvider
+	// provider names to try for a check, in order. Checks without a route use the priority order of Providers.
+	Routes           map[identity_verification_types.IdentityCheck][]string
+	FailureThreshold int
+	Cooldown         time.Duration
+
+	mu     sync.Mutex
+	health map[string]*providerHealth
+}
+
+// Returns the providers to try for check, healthy ones first and each group in routing order
+func (router *IdentityVerificationRouter) providersFor(check identity_verification_types.IdentityCheck) []IdentityProvider {
+	candidates := router.Providers
+	if names, ok := router.Routes[check]; ok {
+		candidates = []IdentityProvider{}
+		for _, name := range names {
+			for _, provider := range router.Providers {
+				if provider.Name == name {
+					candidates = append(candidates, provider)
+				}
+			}
+		}
+	}
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	healthy := []IdentityProvider{}
+	unhealthy := []IdentityProvider{}
+	for _, provider := range candidates {
+		if health := router.health[provider.Name]; health != nil && time.Now().Before(health.unhealthyUntil) {
+			unhealthy = append(unhealthy, provider)
+			continue
+		}
+		healthy = append(healthy, provider)
+	}
+	return append(healthy, unhealthy...)
+}
+
+func (router *IdentityVerificationRouter) recordResult(provider string, available bool) {
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	if router.health == nil {
+		router.health = map[string]*providerHealth{}
+	}
+	health := router.health[provider]
+	if health == nil {
+		health = &providerHealth{}
+		router.health[provider] = health
+	}
+	if available {
+		health.failures = 0
+		health.unhealthyUntil = time.Time{}
+		return
+	}
+	health.failures++
+	threshold := router.FailureThreshold
+	if threshold < 1 {
+		threshold = defaultProviderFailureThreshold
+	}
+	if health.failures >= threshold {
+		cooldown := router.Cooldown
+		if cooldown <= 0 {
+			cooldown = defaultProviderCooldown
+		}
+		health.unhealthyUntil = time.Now().Add(cooldown)
+		logger.Warning("identity verification provider marked unhealthy", logger.LoggerOptions{
+			Key:  "provider",
+			Data: provider,
+		}, logger.LoggerOptions{
+			Key:  "failures",
+			Data: health.failures,
+		})
+	}
+}
+
+func routeCheck[T any](router *IdentityVerificationRouter, check identity_verification_types.IdentityCheck, call func(identity_verification_types.IdentityVerifierType) (T, error)) (T, error) {
+	var zero T
+	lastErr := identity_verification_types.ErrUnsupportedIdentityCheck
+	for _, provider := range router.providersFor(check) {
+		result, err := call(provider.Verifier)
+		if errors.Is(err, identity_verification_types.ErrUnsupportedIdentityCheck) {
+			continue
+		}
+		if errors.Is(err, identity_verification_types.ErrIdentityProviderUnavailable) {
+			router.recordResult(provider.Name, false)
+			metrics.IdentityProviderRequests.WithLabelValues(provider.Name, string(check), "unavailable").Inc()
+			lastErr = err
+			continue
+		}
+		router.recordResult(provider.Name, true)
+		if err != nil {
+			metrics.IdentityProviderRequests.WithLabelValues(provider.Name, string(check), "rejected").Inc()
+		} else {
+			metrics.IdentityProviderRequests.WithLabelValues(provider.Name, string(check), "ok").Inc()
+		}
+		return result, err
+	}
+	return zero, lastErr
+}
+
+func (router *IdentityVerificationRouter) FetchNINDetails(nin string) (*identity_verification_types.NINData, error) {
+	return routeCheck(router, identity_verification_types.NINCheck, func(verifier identity_verification_types.IdentityVerifierType) (*identity_verification_types.NINData, error) {
+		return verifier.FetchNINDetails(nin)
+	})
+}
+
+func (router *IdentityVerificationRouter) FetchBVNDetails(bvn string) (*identity_verification_types.BVNData, error) {
+	return routeCheck(router, identity_verification_types.BVNCheck, func(verifier identity_verification_types.IdentityVerifierType) (*identity_verification_types.BVNData, error) {
+		return verifier.FetchBVNDetails(bvn)
+	})
+}
+
+func (router *IdentityVerificationRouter) FetchDriverIDDetails(n string) (*identity_verification_types.DriversID, error) {
+	return routeCheck(router, identity_verification_types.DriversLicenseCheck, func(verifier identity_verification_types.IdentityVerifierType) (*identity_verification_types.DriversID, error) {
+		return verifier.FetchDriverIDDetails(n)
+	})
+}
+
+func (router *IdentityVerificationRouter) FetchVoterIDDetails(vin string) (*identity_verification_types.VoterID, error) {
+	return routeCheck(router, identity_verification_types.VotersCardCheck, func(verifier identity_verification_types.IdentityVerifierType) (*identity_verification_types.VoterID, error) {
+		return verifier.FetchVoterIDDetails(vin)
+	})
+}
+
+func (router *IdentityVerificationRouter) FetchCompanyDetails(rcNumber string, companyType identity_verification_types.CompanyType) (*identity_verification_types.CompanyData, error) {
+	return routeCheck(router, identity_verification_types.CompanyCheck, func(verifier identity_verification_types.IdentityVerifierType) (*identity_verification_types.CompanyData, error) {
+		return verifier.FetchCompanyDetails(rcNumber, companyType)
+	})
+}
+
+func (router *IdentityVerificationRouter) EmailVerification(email string) (bool, error) {
+	return routeCheck(router, identity_verification_types.EmailCheck, func(verifier identity_verification_types.IdentityVerifierType) (bool, error) {
+		return verifier.EmailVerification(email)
+	})
+}
+
+func (router *IdentityVerificationRouter) ImgLivenessCheck(img string) (bool, error) {
+	return routeCheck(router, identity_verification_types.LivenessCheck, func(verifier identity_verification_types.IdentityVerifierType) (bool, error) {
+		return verifier.ImgLivenessCheck(img)
+	})
+}
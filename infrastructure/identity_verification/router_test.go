@@ -0,0 +1,141 @@
+package identityverification
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	prembly_identity_verification "gateman.io/infrastructure/identity_verification/prembly"
+	identity_verification_types "gateman.io/infrastructure/identity_verification/types"
+	"gateman.io/infrastructure/network"
+)
+
+const ninFound = `{"status": true, "nin_data": {"firstname": "Oluwaseun", "surname": "Adebayo", "birthdate": "1974-08-12"}}`
+
+// a provider backed by a test server that answers every request with statusCode and body
+type testProvider struct {
+	requests atomic.Int32
+	provider IdentityProvider
+}
+
+func newTestProvider(t *testing.T, name string, statusCode int, body string) *testProvider {
+	t.Helper()
+	p := &testProvider{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.requests.Add(1)
+		w.WriteHeader(statusCode)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	p.provider = IdentityProvider{
+		Name: name,
+		Verifier: &prembly_identity_verification.PremblyIdentityVerification{
+			Network: &network.NetworkController{BaseUrl: server.URL, HttpClient: server.Client()},
+		},
+	}
+	return p
+}
+
+func TestRouterFailsOverWhenUnavailable(t *testing.T) {
+	primary := newTestProvider(t, "primary", 503, ``)
+	secondary := newTestProvider(t, "secondary", 200, ninFound)
+	router := &IdentityVerificationRouter{Providers: []IdentityProvider{primary.provider, secondary.provider}}
+
+	nin, err := router.FetchNINDetails("12345678901")
+	if err != nil {
+		t.Fatalf("FetchNINDetails failed: %v", err)
+	}
+	if nin.FirstName != "Oluwaseun" {
+		t.Errorf("first name = %s", nin.FirstName)
+	}
+	if primary.requests.Load() != 1 || secondary.requests.Load() != 1 {
+		t.Errorf("requests = %d primary, %d secondary, want 1 each", primary.requests.Load(), secondary.requests.Load())
+	}
+}
+
+func TestRouterDoesNotFailOverInvalidIDs(t *testing.T) {
+	primary := newTestProvider(t, "primary", 200, `{"status": false, "detail": "Record not found"}`)
+	secondary := newTestProvider(t, "secondary", 200, ninFound)
+	router := &IdentityVerificationRouter{Providers: []IdentityProvider{primary.provider, secondary.provider}}
+
+	_, err := router.FetchNINDetails("12345678901")
+	if err == nil || errors.Is(err, identity_verification_types.ErrIdentityProviderUnavailable) {
+		t.Errorf("err = %v, want the rejection of the first provider", err)
+	}
+	if secondary.requests.Load() != 0 {
+		t.Errorf("an invalid id was looked up again on the next provider")
+	}
+}
+
+func TestRouterAllUnavailable(t *testing.T) {
+	primary := newTestProvider(t, "primary", 500, ``)
+	secondary := newTestProvider(t, "secondary", 429, ``)
+	router := &IdentityVerificationRouter{Providers: []IdentityProvider{primary.provider, secondary.provider}}
+
+	if _, err := router.FetchBVNDetails("12345678901"); !errors.Is(err, identity_verification_types.ErrIdentityProviderUnavailable) {
+		t.Errorf("err = %v, want %v", err, identity_verification_types.ErrIdentityProviderUnavailable)
+	}
+}
+
+func TestRouterSkipsUnsupportedChecks(t *testing.T) {
+	provider := newTestProvider(t, "prembly", 200, `{}`)
+	router := &IdentityVerificationRouter{Providers: []IdentityProvider{provider.provider}}
+
+	if _, err := router.FetchVoterIDDetails("90F5B1234567890"); !errors.Is(err, identity_verification_types.ErrUnsupportedIdentityCheck) {
+		t.Errorf("err = %v, want %v", err, identity_verification_types.ErrUnsupportedIdentityCheck)
+	}
+	if health := router.health["prembly"]; health != nil && health.failures != 0 {
+		t.Errorf("an unsupported check was counted as a failure")
+	}
+}
+
+func TestRouterRoutes(t *testing.T) {
+	primary := newTestProvider(t, "primary", 200, ninFound)
+	secondary := newTestProvider(t, "secondary", 200, ninFound)
+	router := &IdentityVerificationRouter{
+		Providers: []IdentityProvider{primary.provider, secondary.provider},
+		Routes:    map[identity_verification_types.IdentityCheck][]string{identity_verification_types.NINCheck: {"secondary"}},
+	}
+
+	if _, err := router.FetchNINDetails("12345678901"); err != nil {
+		t.Fatalf("FetchNINDetails failed: %v", err)
+	}
+	if primary.requests.Load() != 0 || secondary.requests.Load() != 1 {
+		t.Errorf("requests = %d primary, %d secondary, want the routed provider only", primary.requests.Load(), secondary.requests.Load())
+	}
+}
+
+func TestRouterMarksUnhealthyProviders(t *testing.T) {
+	primary := newTestProvider(t, "primary", 503, ``)
+	secondary := newTestProvider(t, "secondary", 200, ninFound)
+	router := &IdentityVerificationRouter{
+		Providers:        []IdentityProvider{primary.provider, secondary.provider},
+		FailureThreshold: 2,
+		Cooldown:         time.Minute,
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := router.FetchNINDetails("12345678901"); err != nil {
+			t.Fatalf("FetchNINDetails failed: %v", err)
+		}
+	}
+	// the third request goes to the healthy provider first
+	if primary.requests.Load() != 2 || secondary.requests.Load() != 3 {
+		t.Errorf("requests = %d primary, %d secondary, want 2 and 3", primary.requests.Load(), secondary.requests.Load())
+	}
+
+	// an unhealthy provider is still tried when no healthy one can serve the check
+	router.health["secondary"] = &providerHealth{failures: 2, unhealthyUntil: time.Now().Add(time.Minute)}
+	if got := router.providersFor(identity_verification_types.NINCheck); len(got) != 2 || got[0].Name != "primary" {
+		t.Errorf("providers = %v, want both in priority order", got)
+	}
+
+	router.health["primary"].unhealthyUntil = time.Now().Add(-time.Second)
+	router.health["secondary"].unhealthyUntil = time.Now().Add(-time.Second)
+	if got := router.providersFor(identity_verification_types.NINCheck); got[0].Name != "primary" {
+		t.Errorf("providers = %v, want the primary first once its cooldown has passed", got)
+	}
+}
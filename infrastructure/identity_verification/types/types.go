@@ -2,14 +2,41 @@ package identity_verification_types
 
 import (
 	"encoding/json"
+	"errors"
 	"time"
 )
 
+var (
+	// Returned when a provider could not be reached or failed on its side. The router tries the next provider.
+	ErrIdentityProviderUnavailable = errors.New("identity verification provider is unavailable")
+	// Returned for checks a provider does not offer. The router tries the next provider without counting it as a failure.
+	ErrUnsupportedIdentityCheck = errors.New("identity verification provider does not support this check")
+)
+
+// The checks providers are chosen for
+type IdentityCheck string
+
+const (
+	NINCheck            IdentityCheck = "nin"
+	BVNCheck            IdentityCheck = "bvn"
+	DriversLicenseCheck IdentityCheck = "drivers_license"
+	VotersCardCheck     IdentityCheck = "voters_card"
+	LivenessCheck       IdentityCheck = "liveness"
+	EmailCheck          IdentityCheck = "email"
+	CompanyCheck        IdentityCheck = "cac"
+)
+
+// Returns true for status codes that mean the provider failed rather than the id being invalid
+func ProviderUnavailableStatus(statusCode int) bool {
+	return statusCode >= 500 || statusCode == 429
+}
+
 type IdentityVerifierType interface {
 	FetchBVNDetails(string) (*BVNData, error)
 	FetchDriverIDDetails(string) (*DriversID, error)
 	FetchVoterIDDetails(string) (*VoterID, error)
 	FetchNINDetails(string) (*NINData, error)
+	FetchCompanyDetails(rcNumber string, companyType CompanyType) (*CompanyData, error)
 	EmailVerification(email string) (bool, error)
 	ImgLivenessCheck(img string) (bool, error)
 }
@@ -140,6 +167,38 @@ func (n *VoterID) UnmarshalBinary(data []byte) error {
 	return json.Unmarshal(data, n) // Deserialize from JSON
 }
 
+// The kinds of entities on the CAC registry
+type CompanyType string
+
+const (
+	LimitedCompany       CompanyType = "RC"
+	BusinessName         CompanyType = "BN"
+	IncorporatedTrustees CompanyType = "IT"
+)
+
+// A company, business name or trust registered with the Corporate Affairs Commission
+type CompanyData struct {
+	CompanyName      string             `json:"company_name"`
+	RCNumber         string             `json:"rc_number"`
+	CompanyType      CompanyType        `json:"company_type"`
+	Status           string             `json:"status"`
+	RegistrationDate string             `json:"registration_date"`
+	Address          string             `json:"address"`
+	State            string             `json:"state"`
+	Email            *string            `json:"email"`
+	Directors        []CompanyAffiliate `json:"directors"`
+}
+
+// A director, proprietor or trustee of a registered entity
+type CompanyAffiliate struct {
+	FirstName  string  `json:"first_name"`
+	MiddleName *string `json:"middle_name"`
+	LastName   string  `json:"last_name"`
+	Position   string  `json:"position"`
+	// in 2006-01-02 when the registry holds it
+	DateOfBirth string `json:"date_of_birth"`
+}
+
 type DriversID struct {
 	UUID         string  `json:"uuid"`
 	LicenseNo    string  `json:"licenseNo"`
@@ -162,3 +221,16 @@ func (n *DriversID) MarshalBinary() ([]byte, error) {
 func (n *DriversID) UnmarshalBinary(data []byte) error {
 	return json.Unmarshal(data, n) // Deserialize from JSON
 }
+
+// Layouts providers return dates in. Day first layouts are tried before month first ones.
+var providerDateLayouts = []string{"2006-01-02", "02-01-2006", "02-Jan-2006", "02/01/2006", "2006/01/02", time.RFC3339}
+
+// Returns date in layout, the layout the verification flows parse the field with. Dates in unknown layouts are returned as they are.
+func NormaliseProviderDate(date string, layout string) string {
+	for _, providerLayout := range providerDateLayouts {
+		if parsed, err := time.Parse(providerLayout, date); err == nil {
+			return parsed.Format(layout)
+		}
+	}
+	return date
+}
@@ -0,0 +1,16 @@
+package keystore
+
+import (
+	"os"
+
+	"gateman.io/infrastructure/keystore/local"
+	"gateman.io/infrastructure/keystore/types"
+)
+
+var KeyStore types.KeyStoreType
+
+func InitialiseKeyStore() {
+	KeyStore = &local.LocalFileKeyStore{
+		Path: os.Getenv("KEYSTORE_FILE"),
+	}
+}
@@ -0,0 +1,83 @@
+package local
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"gateman.io/infrastructure/cryptography"
+)
+
+var ErrMasterKeyNotFound = errors.New("master key not found in keystore")
+
+// A keystore backed by a json file of hex encoded 32 byte master keys:
+// {"activeKeyID": "2", "keys": {"1": "<hex>", "2": "<hex>"}}
+// Master keys never leave the process. Old keys stay in the file for as long as data keys wrapped with them exist.
+type LocalFileKeyStore struct {
+	Path string
+
+	once        sync.Once
+	loadErr     error
+	activeKeyID string
+	keys        map[string][]byte
+}
+
+type keyStoreFile struct {
+	ActiveKeyID string            `json:"activeKeyID"`
+	Keys        map[string]string `json:"keys"`
+}
+
+func (ks *LocalFileKeyStore) load() error {
+	ks.once.Do(func() {
+		content, err := os.ReadFile(ks.Path)
+		if err != nil {
+			ks.loadErr = fmt.Errorf("could not read keystore file: %w", err)
+			return
+		}
+		var file keyStoreFile
+		if err := json.Unmarshal(content, &file); err != nil {
+			ks.loadErr = fmt.Errorf("could not parse keystore file: %w", err)
+			return
+		}
+		ks.keys = map[string][]byte{}
+		for id, hexKey := range file.Keys {
+			key, err := hex.DecodeString(hexKey)
+			if err != nil || len(key) != 32 {
+				ks.loadErr = fmt.Errorf("master key %s must be 32 hex encoded bytes", id)
+				return
+			}
+			ks.keys[id] = key
+		}
+		if _, ok := ks.keys[file.ActiveKeyID]; !ok {
+			ks.loadErr = fmt.Errorf("active master key %q is not in the keystore", file.ActiveKeyID)
+			return
+		}
+		ks.activeKeyID = file.ActiveKeyID
+	})
+	return ks.loadErr
+}
+
+func (ks *LocalFileKeyStore) WrapKey(dataKey []byte) (string, string, error) {
+	if err := ks.load(); err != nil {
+		return "", "", err
+	}
+	wrappedKey, err := cryptography.SealPayload(ks.keys[ks.activeKeyID], dataKey, []byte(ks.activeKeyID))
+	if err != nil {
+		return "", "", err
+	}
+	return wrappedKey, ks.activeKeyID, nil
+}
+
+func (ks *LocalFileKeyStore) UnwrapKey(wrappedKey string, masterKeyID string) ([]byte, error) {
+	if err := ks.load(); err != nil {
+		return nil, err
+	}
+	key, ok := ks.keys[masterKeyID]
+	if !ok {
+		return nil, ErrMasterKeyNotFound
+	}
+	return cryptography.OpenPayload(key, wrappedKey, []byte(masterKeyID))
+}
@@ -0,0 +1,8 @@
+package types
+
+type KeyStoreType interface {
+	// Encrypts dataKey with the active master key and returns it with the id of that master key
+	WrapKey(dataKey []byte) (wrappedKey string, masterKeyID string, err error)
+	// Decrypts a data key wrapped by WrapKey
+	UnwrapKey(wrappedKey string, masterKeyID string) ([]byte, error)
+}
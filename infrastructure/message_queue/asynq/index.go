@@ -5,6 +5,7 @@ import (
 	"os"
 	"time"
 
+	"gateman.io/infrastructure/logger"
 	queue_tasks "gateman.io/infrastructure/message_queue/tasks"
 	mq_types "gateman.io/infrastructure/message_queue/types"
 	"github.com/hibiken/asynq"
@@ -54,6 +55,42 @@ func (aq *AsynqBroker) Start() {
 	mux.HandleFunc(string(queue_tasks.HandleWorkspaceInviteTaskName), queue_tasks.HandleWorkspaceInviteTask)
 	mux.HandleFunc(string(queue_tasks.HandleAppDeletionTaskName), queue_tasks.HandleAppDeletionTask)
 	mux.HandleFunc(string(queue_tasks.HandleSubscriptionAutoRenewal), queue_tasks.HandleSubsciptionAutoRenewalTask)
+	mux.HandleFunc(string(queue_tasks.HandleSigningKeyRotationTaskName), queue_tasks.HandleSigningKeyRotationTask)
+	mux.HandleFunc(string(queue_tasks.HandleReencryptionTaskName), queue_tasks.HandleReencryptionTask)
+	mux.HandleFunc(string(queue_tasks.HandleBlindIndexMigrationTaskName), queue_tasks.HandleBlindIndexMigrationTask)
+	mux.HandleFunc(string(queue_tasks.HandleRescreeningTaskName), queue_tasks.HandleRescreeningTask)
+	mux.HandleFunc(string(queue_tasks.HandleKYCJobLookupTaskName), queue_tasks.HandleKYCJobLookupTask)
+	mux.HandleFunc(string(queue_tasks.HandleKYCCallbackRetryTaskName), queue_tasks.HandleKYCCallbackRetryTask)
+	mux.HandleFunc(string(queue_tasks.HandleLookupOverageBillingTaskName), queue_tasks.HandleLookupOverageBillingTask)
+
+	// periodic tasks. every instance registers them, asynq.Unique stops the duplicates from being queued
+	scheduler := asynq.NewScheduler(redisConnOpt, nil)
+	scheduler.Register("@every 15m", asynq.NewTask(string(queue_tasks.HandleSigningKeyRotationTaskName), nil),
+		asynq.Queue(string(mq_types.Low)),
+		asynq.Unique(time.Minute*10))
+	scheduler.Register("@every 1h", asynq.NewTask(string(queue_tasks.HandleReencryptionTaskName), nil),
+		asynq.Queue(string(mq_types.Low)),
+		asynq.Unique(time.Minute*50))
+	scheduler.Register("@every 1h", asynq.NewTask(string(queue_tasks.HandleBlindIndexMigrationTaskName), nil),
+		asynq.Queue(string(mq_types.Low)),
+		asynq.Unique(time.Minute*50))
+	scheduler.Register("@every 24h", asynq.NewTask(string(queue_tasks.HandleRescreeningTaskName), nil),
+		asynq.Queue(string(mq_types.Low)),
+		asynq.Timeout(time.Hour*6),
+		asynq.Unique(time.Hour*23))
+	scheduler.Register("@every 1m", asynq.NewTask(string(queue_tasks.HandleKYCCallbackRetryTaskName), nil),
+		asynq.Queue(string(mq_types.Low)),
+		asynq.Unique(time.Second*50))
+	scheduler.Register("@every 24h", asynq.NewTask(string(queue_tasks.HandleLookupOverageBillingTaskName), nil),
+		asynq.Queue(string(mq_types.Low)),
+		asynq.Timeout(time.Hour),
+		asynq.Unique(time.Hour*23))
+	if err := scheduler.Start(); err != nil {
+		logger.Error("an error occured while starting the task scheduler", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+	}
 
 	srv.Run(mux)
 }
@@ -0,0 +1,25 @@
+package queue_tasks
+
+import (
+	"context"
+
+	security_services "gateman.io/application/services/security"
+	"gateman.io/infrastructure/logger"
+	mq_types "gateman.io/infrastructure/message_queue/types"
+	"github.com/hibiken/asynq"
+)
+
+var HandleBlindIndexMigrationTaskName mq_types.Queues = "migrate_blind_indexes"
+
+// Runs on a schedule and moves values still indexed under their fixed salt Argon2 hash to their blind index
+func HandleBlindIndexMigrationTask(ctx context.Context, t *asynq.Task) error {
+	err := security_services.MigrateBlindIndexes()
+	if err != nil {
+		logger.Error("an error occured while migrating blind indexes", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		return err
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 
 	"gateman.io/application/repository"
+	services "gateman.io/application/services/application"
 	"gateman.io/infrastructure/logger"
 	mq_types "gateman.io/infrastructure/message_queue/types"
 	"github.com/hibiken/asynq"
@@ -45,5 +46,6 @@ func HandleAppDeletionTask(ctx context.Context, t *asynq.Task) error {
 		"appID":       payload.ID,
 		"workspaceID": payload.WorkspaceID,
 	})
+	services.PurgeAPIKeyCache(app.AppID, services.APIKeyCachePurgeAppDeleted)
 	return nil
 }
@@ -0,0 +1,25 @@
+package queue_tasks
+
+import (
+	"context"
+
+	kycjob_services "gateman.io/application/services/kycjob"
+	"gateman.io/infrastructure/logger"
+	mq_types "gateman.io/infrastructure/message_queue/types"
+	"github.com/hibiken/asynq"
+)
+
+var HandleKYCCallbackRetryTaskName mq_types.Queues = "retry_kyc_callbacks"
+
+// Runs on a schedule and retries the callbacks of completed KYC jobs that could not be delivered
+func HandleKYCCallbackRetryTask(ctx context.Context, t *asynq.Task) error {
+	err := kycjob_services.RetryCallbacks()
+	if err != nil {
+		logger.Error("an error occured while retrying kyc job callbacks", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package queue_tasks
+
+import (
+	"context"
+	"encoding/json"
+
+	kycjob_services "gateman.io/application/services/kycjob"
+	"gateman.io/infrastructure/logger"
+	mq_types "gateman.io/infrastructure/message_queue/types"
+	"github.com/hibiken/asynq"
+)
+
+var HandleKYCJobLookupTaskName mq_types.Queues = "kyc_job_lookup"
+
+type KYCJobLookupPayload struct {
+	JobID string
+	Index int
+	mq_types.BasePayload
+}
+
+// Looks up one id of a KYC job. The lookup is retried while the providers are unavailable and the item is failed on its last retry.
+func HandleKYCJobLookupTask(ctx context.Context, t *asynq.Task) error {
+	var payload KYCJobLookupPayload
+	err := json.Unmarshal(t.Payload(), &payload)
+	if err != nil {
+		logger.Error("an error occured while unmarshalling kyc job lookup queue payload", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		return err
+	}
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	err = kycjob_services.ProcessJobItem(payload.JobID, payload.Index, retried >= maxRetry)
+	if err != nil {
+		logger.Error("an error occured while looking up kyc job item", logger.LoggerOptions{
+			Key:  "payload",
+			Data: payload,
+		}, logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		return err
+	}
+	return nil
+}
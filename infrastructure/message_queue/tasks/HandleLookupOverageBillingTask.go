@@ -0,0 +1,110 @@
+package queue_tasks
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"gateman.io/application/repository"
+	metering_services "gateman.io/application/services/metering"
+	"gateman.io/entities"
+	"gateman.io/infrastructure/cryptography"
+	"gateman.io/infrastructure/logger"
+	mq_types "gateman.io/infrastructure/message_queue/types"
+	"gateman.io/infrastructure/payments"
+	"github.com/hibiken/asynq"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var HandleLookupOverageBillingTaskName mq_types.Queues = "bill_lookup_overage"
+
+// Runs on a schedule and charges apps for the lookups they made past the quotas of their plans in months that have ended,
+// whatever their plan interval and whether or not they renew automatically. An app that could not be charged is tried
+// again on the next run.
+func HandleLookupOverageBillingTask(ctx context.Context, t *asynq.Task) error {
+	appIDs, err := metering_services.AppsWithUnsettledOverage()
+	if err != nil {
+		logger.Error("an error occured while fetching apps with unsettled lookup overage", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		return err
+	}
+	for _, appID := range appIDs {
+		if err := billLookupOverage(appID); err != nil {
+			logger.Error("an error occured while billing lookup overage", logger.LoggerOptions{
+				Key:  "appID",
+				Data: appID,
+			}, logger.LoggerOptions{
+				Key:  "error",
+				Data: err,
+			})
+		}
+	}
+	return nil
+}
+
+func billLookupOverage(appID string) error {
+	overage, err := metering_services.UnbilledOverage(appID)
+	if err != nil {
+		return err
+	}
+	if overage.Total == 0 {
+		return metering_services.SettleOverage(overage)
+	}
+	if overage.Total > math.MaxUint32 {
+		return errors.New("lookup overage is too large to charge")
+	}
+	app, err := repository.ApplicationRepo().FindOneByFilter(map[string]interface{}{
+		"appID": appID,
+	}, options.FindOne().SetProjection(map[string]any{
+		"workspaceID": 1,
+		"paymentCard": 1,
+	}))
+	if err != nil {
+		return err
+	}
+	if app == nil {
+		return errors.New("app does not exist")
+	}
+	if app.PaymentCard == nil {
+		return errors.New("payment card not set on app")
+	}
+	workspace, err := repository.WorkspaceRepository().FindByID(app.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	if workspace == nil {
+		return errors.New("invalid workspace id")
+	}
+	var card *entities.CardInfo
+	for _, savedCard := range workspace.PaymentDetails {
+		if savedCard.ID == *app.PaymentCard {
+			card = &savedCard
+			break
+		}
+	}
+	if card == nil {
+		return errors.New("invalid payment card selected for lookup overage payment")
+	}
+	authCode, err := cryptography.DecryptData(card.AuthorizationCode, nil)
+	if err != nil {
+		return err
+	}
+	charged, err := payments.PaymentProcessor.ChargeCard(string(authCode), workspace.Email, uint32(overage.Total), map[string]any{
+		"workspaceID":   workspace.ID,
+		"appID":         app.ID,
+		"lookupOverage": "true",
+		"overage":       overage,
+	})
+	if err != nil {
+		return err
+	}
+	if charged == nil {
+		return errors.New("lookup overage was not charged")
+	}
+	if err := metering_services.MarkOverageBilled(overage); err != nil {
+		return err
+	}
+	return metering_services.SettleOverage(overage)
+}
@@ -0,0 +1,25 @@
+package queue_tasks
+
+import (
+	"context"
+
+	security_services "gateman.io/application/services/security"
+	"gateman.io/infrastructure/logger"
+	mq_types "gateman.io/infrastructure/message_queue/types"
+	"github.com/hibiken/asynq"
+)
+
+var HandleReencryptionTaskName mq_types.Queues = "reencrypt_stored_data"
+
+// Runs on a schedule and moves data encrypted with a key that has been rotated out to the active key
+func HandleReencryptionTask(ctx context.Context, t *asynq.Task) error {
+	err := security_services.ReencryptStoredData()
+	if err != nil {
+		logger.Error("an error occured while re-encrypting stored data", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package queue_tasks
+
+import (
+	"context"
+
+	screening_services "gateman.io/application/services/screening"
+	"gateman.io/infrastructure/logger"
+	mq_types "gateman.io/infrastructure/message_queue/types"
+	"github.com/hibiken/asynq"
+)
+
+var HandleRescreeningTaskName mq_types.Queues = "rescreen_users"
+
+// Runs on a schedule and screens verified users against the latest sanctions and PEP lists
+func HandleRescreeningTask(ctx context.Context, t *asynq.Task) error {
+	err := screening_services.RescreenUsers()
+	if err != nil {
+		logger.Error("an error occured while re-screening users", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package queue_tasks
+
+import (
+	"context"
+
+	services "gateman.io/application/services/application"
+	"gateman.io/infrastructure/logger"
+	mq_types "gateman.io/infrastructure/message_queue/types"
+	"github.com/hibiken/asynq"
+)
+
+var HandleSigningKeyRotationTaskName mq_types.Queues = "rotate_signing_keys"
+
+// Runs on a schedule and rotates the signing keys of every app whose rotation policy is due
+func HandleSigningKeyRotationTask(ctx context.Context, t *asynq.Task) error {
+	err := services.RotateDueSigningKeys()
+	if err != nil {
+		logger.Error("an error occured while rotating due signing keys", logger.LoggerOptions{
+			Key:  "error",
+			Data: err,
+		})
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Lookups against the api key caches. cache is "api_key" or "app_config", result is "hit" or "miss".
+var APIKeyCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateman_api_key_cache_lookups_total",
+	Help: "Lookups against the api key verification and app config caches",
+}, []string{"cache", "result"})
+
+// Purges of an app's cached api keys and config, labelled with what caused the purge
+var APIKeyCachePurges = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateman_api_key_cache_purges_total",
+	Help: "Purges of an app's cached api keys and config",
+}, []string{"reason"})
+
+// Time taken to authenticate an api key. result is "hit", "miss" or "rejected".
+var APIKeyVerificationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "gateman_api_key_verification_duration_seconds",
+	Help:    "Time taken to authenticate a public api key",
+	Buckets: []float64{0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+}, []string{"result"})
+
+// Values at rest moved to the active encryption key by the re-encryption job. result is "reencrypted", "skipped" or "failed".
+var ReencryptedValues = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateman_reencrypted_values_total",
+	Help: "Encrypted values at rest processed by the re-encryption job",
+}, []string{"collection", "result"})
+
+// Values moved from their fixed salt Argon2 hash to their blind index. index is what was moved, result is "moved" or "dropped".
+var BlindIndexMigrations = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateman_blind_index_migrations_total",
+	Help: "Values moved from their fixed salt Argon2 hash to their blind index",
+}, []string{"index", "result"})
+
+// Users still holding the fixed salt Argon2 hash of an identity number, as counted by the last blind index migration run
+var LegacyIdentityIndexes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "gateman_legacy_identity_indexes",
+	Help: "Users still holding the fixed salt Argon2 hash of an identity number",
+}, []string{"field"})
+
+// Requests to identity verification providers. result is "ok", "rejected" when the provider found the id invalid or "unavailable".
+var IdentityProviderRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateman_identity_provider_requests_total",
+	Help: "Requests routed to identity verification providers",
+}, []string{"provider", "check", "result"})
+
+// Sanctions, PEP and watch list hits recorded for review. trigger is what the user was screened after, "nin", "bvn" or "rescreen".
+var ScreeningHits = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateman_screening_hits_total",
+	Help: "Sanctions, PEP and watch list hits recorded for review",
+}, []string{"list_type", "trigger"})
+
+// Billable lookups of the public api. source is "cache" when the lookup was answered without calling a provider.
+var MeteredLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateman_metered_lookups_total",
+	Help: "Billable lookups made through the public api",
+}, []string{"type", "source"})
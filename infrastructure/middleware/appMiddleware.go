@@ -1,18 +1,54 @@
 package middlewares
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	apperrors "gateman.io/application/appErrors"
 	"gateman.io/application/interfaces"
 	"gateman.io/application/middlewares"
+	"gateman.io/entities"
 	"github.com/gin-gonic/gin"
 )
 
-func AppAuthenticationMiddleware() gin.HandlerFunc {
+// The largest request body read into memory. Biometric comparisons carry two base64 images of up to 64 MiB each.
+const maxRequestBodySize = 2*64<<20 + 1<<20
+
+// Reads the request body, responding and returning false when it cannot be read or is larger than maxRequestBodySize
+func readRequestBody(ctx *gin.Context, deviceID *string) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxRequestBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apperrors.PayloadTooLargeError(ctx, deviceID)
+			return nil, false
+		}
+		apperrors.ErrorProcessingPayload(ctx, deviceID)
+		return nil, false
+	}
+	return body, true
+}
+
+// Authenticates the api key and then the request signature, so apps that make signing mandatory cannot be called with a key alone
+func AppAuthenticationMiddleware(requiredScope entities.APIKeyScope) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		appContext, next := middlewares.AppAuthenticationMiddleware(&interfaces.ApplicationContext[any]{
 			Ctx:      ctx,
 			Header:   ctx.Request.Header,
 			DeviceID: ctx.Request.Header.Get("X-Device-Id"),
-		}, ctx.ClientIP())
+		}, middlewares.ClientIP(ctx), requiredScope)
+		if !next {
+			return
+		}
+		body, ok := readRequestBody(ctx, appContext.GetHeader("X-Device-Id"))
+		if !ok {
+			return
+		}
+		// the body is put back so the route can still bind it
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+		appContext, next = middlewares.AppRequestSignatureMiddleware(appContext, ctx.Request.Method, ctx.Request.URL.EscapedPath(), ctx.Request.URL.RawQuery, body)
 		if next {
 			ctx.Set("AppContext", appContext)
 			ctx.Next()
@@ -1,31 +1,51 @@
 package middlewares
 
 import (
-	"os"
+	"bytes"
+	"io"
+	"path"
 
+	"gateman.io/application/interfaces"
+	"gateman.io/application/middlewares"
+	"gateman.io/application/utils"
 	"github.com/gin-gonic/gin"
 )
 
+// routes that are never encrypted, keyed by method and full path
+var plaintextRoutes = map[string]bool{}
+
+// Opts a route out of payload encryption. Requests to it are handled and answered in plain text even when the device sends encryption headers.
+func PlaintextRoute(router *gin.RouterGroup, method string, relativePath string) {
+	plaintextRoutes[method+" "+path.Join(router.BasePath(), relativePath)] = true
+}
+
+// Decrypts the body of requests sent with X-Payload-Nonce and swaps it in so routes bind the plain body.
+// Requests without the header are passed through untouched and answered in plain text.
 func DecryptPayloadMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		if os.Getenv("APP_ENV") == "dev" {
+		if ctx.GetHeader("X-Payload-Nonce") == "" || plaintextRoutes[ctx.Request.Method+" "+ctx.FullPath()] {
 			ctx.Next()
 			return
 		}
-		// body, err := io.ReadAll(ctx.Request.Body)
-		// if err != nil {
-		// 	ctx.Next()
-		// 	ctx.Set("AppContext", &interfaces.ApplicationContext[any]{
-		// 		Ctx: ctx,
-		// 	})
-		// 	return
-		// }
-		// decryptedBody := middlewares.DecryptPayloadMiddleware(&interfaces.ApplicationContext[string]{
-		// 	Ctx:      ctx,
-		// 	Body:     utils.GetStringPointer(string(body)),
-		// 	DeviceID: ctx.Request.Header.Get("X-Device-Id"),
-		// })
-		// ctx.Set("DecryptedBody", string(decryptedBody))
+		savedCtx := (ctx.MustGet("AppContext")).(*interfaces.ApplicationContext[any])
+		body, ok := readRequestBody(ctx, &savedCtx.DeviceID)
+		if !ok {
+			return
+		}
+		appContext := &interfaces.ApplicationContext[string]{
+			Ctx:      ctx,
+			Body:     utils.GetStringPointer(string(body)),
+			Header:   ctx.Request.Header,
+			DeviceID: ctx.Request.Header.Get("X-Device-Id"),
+		}
+		decryptedBody, next := middlewares.DecryptPayloadMiddleware(appContext, ctx.Request.Method, ctx.Request.URL.EscapedPath())
+		if !next {
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(decryptedBody))
+		ctx.Request.ContentLength = int64(len(decryptedBody))
+		savedCtx.Nonce = appContext.Nonce
+		ctx.Set("AppContext", savedCtx)
 		ctx.Next()
 	}
 }
@@ -12,6 +12,7 @@ func IPAddressMiddleware() gin.HandlerFunc {
 		appContext, next := middlewares.IPAddressMiddleware(&interfaces.ApplicationContext[any]{
 			Ctx:        ctx,
 			Keys:       savedCtx.Keys,
+			Nonce:      savedCtx.Nonce,
 			Header:     ctx.Request.Header,
 			DeviceID:   savedCtx.DeviceID,
 			DeviceName: savedCtx.DeviceName,
@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"gateman.io/application/interfaces"
+	"gateman.io/application/middlewares"
+	"gateman.io/entities"
+	"github.com/gin-gonic/gin"
+)
+
+// Meters a route as a billable lookup of lookupType. It runs after AppAuthenticationMiddleware and records the lookup
+// once the route has answered successfully, the reserved quota is given back when it did not. Routes that answer sandbox requests with mock results set sandboxMocked
+// so those requests are not metered.
+func LookupMeteringMiddleware(lookupType entities.MeteredLookupType, sandboxMocked bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		value, exists := ctx.Get("AppContext")
+		if !exists {
+			// routes left open in dev have no app to meter
+			ctx.Next()
+			return
+		}
+		appContext := value.(*interfaces.ApplicationContext[any])
+		if sandboxMocked && appContext.GetBoolContextData("SandboxEnv") {
+			ctx.Next()
+			return
+		}
+		if _, next := middlewares.LookupQuotaMiddleware(appContext, lookupType); !next {
+			return
+		}
+		ctx.Next()
+		middlewares.RecordMeteredLookup(appContext, lookupType, ctx.Writer.Status() == http.StatusOK)
+	}
+}
@@ -18,6 +18,7 @@ func OTPTokenMiddleware(intent string) gin.HandlerFunc {
 		appContext, next := middlewares.OTPTokenMiddleware(&interfaces.ApplicationContext[any]{
 			Ctx:      ctx,
 			Keys:     savedCtx.Keys,
+			Nonce:    savedCtx.Nonce,
 			DeviceID: savedCtx.DeviceID,
 			Header:   ctx.Request.Header,
 		}, ctx.ClientIP(), intent, accessToken)
@@ -8,6 +8,7 @@ import (
 
 func RefreshTokenMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		savedCtx := (ctx.MustGet("AppContext")).(*interfaces.ApplicationContext[any])
 		workspaceToken := false
 		refreshToken, _ := ctx.Cookie("refreshToken")
 		if refreshToken == "" {
@@ -17,9 +18,10 @@ func RefreshTokenMiddleware() gin.HandlerFunc {
 		appContext, next := middlewares.RefreshTokenMiddleware(&interfaces.ApplicationContext[any]{
 			Ctx:      ctx,
 			Keys:     ctx.Keys,
+			Nonce:    savedCtx.Nonce,
 			Header:   ctx.Request.Header,
 			DeviceID: ctx.Request.Header.Get("X-Device-Id"),
-		}, workspaceToken, refreshToken)
+		}, workspaceToken, refreshToken, ctx.ClientIP())
 		if next {
 			ctx.Set("AppContext", appContext)
 			ctx.Next()
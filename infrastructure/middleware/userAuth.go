@@ -13,6 +13,7 @@ func UserAuthenticationMiddleware(intent *string) gin.HandlerFunc {
 		appContext, next := middlewares.UserAuthenticationMiddleware(&interfaces.ApplicationContext[any]{
 			Ctx:      ctx,
 			Keys:     savedCtx.Keys,
+			Nonce:    savedCtx.Nonce,
 			Header:   ctx.Request.Header,
 			DeviceID: ctx.Request.Header.Get("X-Device-Id"),
 		}, intent, accessToken)
@@ -14,6 +14,7 @@ func WorkspaceAuthenticationMiddleware(intent *string, requiredPermissions *[]en
 		appContext, next := middlewares.WorkspaceAuthenticationMiddleware(&interfaces.ApplicationContext[any]{
 			Ctx:      ctx,
 			Keys:     savedCtx.Keys,
+			Nonce:    savedCtx.Nonce,
 			Header:   ctx.Request.Header,
 			DeviceID: ctx.Request.Header.Get("X-Device-Id"),
 		}, intent, requiredPermissions, accessToken)
@@ -60,6 +60,8 @@ type Metadata struct {
 	Frequency   string `json:"frequency"`
 	Reverse     string `json:"reverse"`
 	AutoRenew   string `json:"autoRenew"`
+	// set on charges for the lookups an app made past the quotas of its plan
+	LookupOverage string `json:"lookupOverage"`
 }
 
 type Log struct {
@@ -7,6 +7,7 @@ import (
 	public_controller "gateman.io/application/controller/devAPI"
 	"gateman.io/application/controller/devAPI/dto"
 	"gateman.io/application/interfaces"
+	"gateman.io/entities"
 	middlewares "gateman.io/infrastructure/middleware"
 	"github.com/gin-gonic/gin"
 )
@@ -14,7 +15,7 @@ import (
 func AppRouter(router *gin.RouterGroup) {
 	appRouter := router.Group("/app")
 	{
-		appRouter.POST("/fetch", middlewares.AppAuthenticationMiddleware(), func(ctx *gin.Context) {
+		appRouter.POST("/fetch", middlewares.AppAuthenticationMiddleware(entities.APIKeyAppRead), func(ctx *gin.Context) {
 			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
 			var body dto.FetchAppDTO
 			if os.Getenv("APP_ENV") != "dev" {
@@ -45,5 +46,32 @@ func AppRouter(router *gin.RouterGroup) {
 				DeviceID: appContext.DeviceID,
 			})
 		})
+
+		appRouter.POST("/token/introspect", middlewares.AppAuthenticationMiddleware(entities.APIKeyTokensIntrospect), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.IntrospectTokenDTO
+			// RFC 7662 clients send the token form encoded so both form and json bodies are accepted
+			if err := ctx.ShouldBind(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			public_controller.APIVerifyAuthToken(&interfaces.ApplicationContext[dto.IntrospectTokenDTO]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				Body:     &body,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		// public keys used to verify the tokens issued to an app's users. this route is intentionally unauthenticated
+		appRouter.GET("/:appID/.well-known/jwks.json", func(ctx *gin.Context) {
+			public_controller.APIFetchAppJWKS(&interfaces.ApplicationContext[any]{
+				Ctx: ctx,
+				Param: map[string]any{
+					"appID": ctx.Param("appID"),
+				},
+				DeviceID: ctx.GetHeader("X-Device-Id"),
+			})
+		})
 	}
 }
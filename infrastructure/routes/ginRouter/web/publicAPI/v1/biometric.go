@@ -8,6 +8,7 @@ import (
 	"gateman.io/application/controller/dto"
 	"gateman.io/application/interfaces"
 	"gateman.io/application/middlewares"
+	"gateman.io/entities"
 
 	appMiddlewares "gateman.io/infrastructure/middleware"
 	"github.com/gin-gonic/gin"
@@ -18,7 +19,7 @@ func BiometricRouter(router *gin.RouterGroup) {
 	// Add activity logging middleware to all biometric routes
 	biometricRouter.Use(middlewares.ActivityLogMiddleware())
 	{
-		biometricRouter.POST("/compare-faces", appMiddlewares.AppAuthenticationMiddleware(), func(ctx *gin.Context) {
+		biometricRouter.POST("/compare-faces", appMiddlewares.AppAuthenticationMiddleware(entities.APIKeyBiometricCompare), appMiddlewares.LookupMeteringMiddleware(entities.FaceComparisonLookup, true), func(ctx *gin.Context) {
 			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
 			var body dto.EnhancedFaceComparisonRequest
 			var deviceID string
@@ -54,7 +55,7 @@ func BiometricRouter(router *gin.RouterGroup) {
 			})
 		})
 
-		biometricRouter.POST("/liveness-check", appMiddlewares.AppAuthenticationMiddleware(), func(ctx *gin.Context) {
+		biometricRouter.POST("/liveness-check", appMiddlewares.AppAuthenticationMiddleware(entities.APIKeyBiometricLiveness), appMiddlewares.LookupMeteringMiddleware(entities.LivenessLookup, true), func(ctx *gin.Context) {
 			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
 			var body dto.LivenessDetectionDTO
 			var deviceID string
@@ -90,7 +91,7 @@ func BiometricRouter(router *gin.RouterGroup) {
 			})
 		})
 
-		biometricRouter.GET("/generate-challenge", func(ctx *gin.Context) {
+		biometricRouter.GET("/generate-challenge", videoLivenessAuthentication(), func(ctx *gin.Context) {
 			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
 			controller.GenerateChallenge(&interfaces.ApplicationContext[any]{
 				Ctx:      ctx,
@@ -99,7 +100,7 @@ func BiometricRouter(router *gin.RouterGroup) {
 		})
 
 		// // Verify video liveness endpoint
-		biometricRouter.POST("/verify-video-liveness", func(ctx *gin.Context) {
+		biometricRouter.POST("/verify-video-liveness", videoLivenessAuthentication(), appMiddlewares.LookupMeteringMiddleware(entities.VideoLivenessLookup, false), func(ctx *gin.Context) {
 			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
 			var body dto.VideoLivenessVerificationRequest
 			if os.Getenv("APP_ENV") != "dev" {
@@ -127,3 +128,13 @@ func BiometricRouter(router *gin.RouterGroup) {
 		})
 	}
 }
+
+// In dev mode, allow the video liveness endpoints without authentication for testing
+func videoLivenessAuthentication() gin.HandlerFunc {
+	if os.Getenv("APP_ENV") == "dev" {
+		return func(ctx *gin.Context) {
+			ctx.Next()
+		}
+	}
+	return appMiddlewares.AppAuthenticationMiddleware(entities.APIKeyBiometricLiveness)
+}
@@ -1,8 +1,11 @@
 package v1
 
 import (
+	apperrors "gateman.io/application/appErrors"
 	public_controller "gateman.io/application/controller/devAPI"
+	"gateman.io/application/controller/devAPI/dto"
 	"gateman.io/application/interfaces"
+	"gateman.io/entities"
 	middlewares "gateman.io/infrastructure/middleware"
 	"github.com/gin-gonic/gin"
 )
@@ -10,20 +13,22 @@ import (
 func KYCRouter(router *gin.RouterGroup) {
 	kycRouter := router.Group("/kyc")
 	{
-		kycRouter.GET("/nin/:id", middlewares.AppAuthenticationMiddleware(), func(ctx *gin.Context) {
+		kycRouter.GET("/nin/:id", middlewares.AppAuthenticationMiddleware(entities.APIKeyKYCNIN), func(ctx *gin.Context) {
 			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
 			public_controller.APIFetchNINDetails(&interfaces.ApplicationContext[any]{
-				Ctx: ctx,
+				Ctx:  ctx,
+				Keys: appContext.Keys,
 				Param: map[string]any{
 					"nin": ctx.Param("id"),
 				},
 				DeviceID: appContext.DeviceID,
 			})
 		})
-		kycRouter.GET("/bvn/:id", middlewares.AppAuthenticationMiddleware(), func(ctx *gin.Context) {
+		kycRouter.GET("/bvn/:id", middlewares.AppAuthenticationMiddleware(entities.APIKeyKYCBVN), func(ctx *gin.Context) {
 			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
 			public_controller.APIFetchBVNDetails(&interfaces.ApplicationContext[any]{
-				Ctx: ctx,
+				Ctx:  ctx,
+				Keys: appContext.Keys,
 				Param: map[string]any{
 					"bvn": ctx.Param("id"),
 				},
@@ -31,10 +36,11 @@ func KYCRouter(router *gin.RouterGroup) {
 			})
 		})
 
-		kycRouter.GET("/voters-card/:id", middlewares.AppAuthenticationMiddleware(), func(ctx *gin.Context) {
+		kycRouter.GET("/voters-card/:id", middlewares.AppAuthenticationMiddleware(entities.APIKeyKYCVotersCard), func(ctx *gin.Context) {
 			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
 			public_controller.APIFetchVotersCardDetails(&interfaces.ApplicationContext[any]{
-				Ctx: ctx,
+				Ctx:  ctx,
+				Keys: appContext.Keys,
 				Param: map[string]any{
 					"votersID": ctx.Param("id"),
 				},
@@ -42,15 +48,60 @@ func KYCRouter(router *gin.RouterGroup) {
 			})
 		})
 
-		kycRouter.GET("/drivers-license/:id", middlewares.AppAuthenticationMiddleware(), func(ctx *gin.Context) {
+		kycRouter.GET("/drivers-license/:id", middlewares.AppAuthenticationMiddleware(entities.APIKeyKYCDriversLicense), func(ctx *gin.Context) {
 			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
 			public_controller.APIFetchDriversLicenseDetails(&interfaces.ApplicationContext[any]{
-				Ctx: ctx,
+				Ctx:  ctx,
+				Keys: appContext.Keys,
 				Param: map[string]any{
 					"driversLicense": ctx.Param("id"),
 				},
 				DeviceID: appContext.DeviceID,
 			})
 		})
+
+		// checks submitted as jobs are looked up on the queue, the results are polled or posted to the callback url of the app
+		jobChecks := []struct {
+			path  string
+			check entities.KYCJobCheck
+			key   entities.APIKeyScope
+		}{
+			{"nin", entities.KYCJobNIN, entities.APIKeyKYCNIN},
+			{"bvn", entities.KYCJobBVN, entities.APIKeyKYCBVN},
+			{"voters-card", entities.KYCJobVotersCard, entities.APIKeyKYCVotersCard},
+			{"drivers-license", entities.KYCJobDriversLicense, entities.APIKeyKYCDriversLicense},
+		}
+		for _, jobCheck := range jobChecks {
+			check := jobCheck.check
+			kycRouter.POST("/jobs/"+jobCheck.path, middlewares.AppAuthenticationMiddleware(jobCheck.key), func(ctx *gin.Context) {
+				appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+				var body dto.SubmitKYCJobDTO
+				if err := ctx.ShouldBindJSON(&body); err != nil {
+					apperrors.ErrorProcessingPayload(ctx, &appContext.DeviceID)
+					return
+				}
+				public_controller.APISubmitKYCJob(&interfaces.ApplicationContext[dto.SubmitKYCJobDTO]{
+					Ctx:  ctx,
+					Keys: appContext.Keys,
+					Param: map[string]any{
+						"check": string(check),
+					},
+					Body:     &body,
+					DeviceID: appContext.DeviceID,
+				})
+			})
+			kycRouter.GET("/jobs/"+jobCheck.path+"/:id", middlewares.AppAuthenticationMiddleware(jobCheck.key), func(ctx *gin.Context) {
+				appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+				public_controller.APIFetchKYCJob(&interfaces.ApplicationContext[any]{
+					Ctx:  ctx,
+					Keys: appContext.Keys,
+					Param: map[string]any{
+						"check": string(check),
+						"id":    ctx.Param("id"),
+					},
+					DeviceID: appContext.DeviceID,
+				})
+			})
+		}
 	}
 }
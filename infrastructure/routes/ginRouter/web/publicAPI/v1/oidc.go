@@ -0,0 +1,73 @@
+package v1
+
+import (
+	apperrors "gateman.io/application/appErrors"
+	public_controller "gateman.io/application/controller/devAPI"
+	"gateman.io/application/controller/devAPI/dto"
+	"gateman.io/application/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+// OIDC endpoints for apps acting as clients. These are authenticated by the protocol itself and not the app api key.
+func OIDCRouter(router *gin.RouterGroup) {
+	oidcRouter := router.Group("/oidc/:appID")
+	{
+		oidcRouter.GET("/.well-known/openid-configuration", func(ctx *gin.Context) {
+			public_controller.APIOIDCDiscovery(&interfaces.ApplicationContext[any]{
+				Ctx: ctx,
+				Param: map[string]any{
+					"appID": ctx.Param("appID"),
+				},
+				DeviceID: ctx.GetHeader("X-Device-Id"),
+			})
+		})
+
+		oidcRouter.GET("/authorize", func(ctx *gin.Context) {
+			var body dto.OIDCAuthorizeDTO
+			if err := ctx.ShouldBindQuery(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, nil)
+				return
+			}
+			public_controller.APIOIDCAuthorize(&interfaces.ApplicationContext[dto.OIDCAuthorizeDTO]{
+				Ctx:  ctx,
+				Body: &body,
+				Param: map[string]any{
+					"appID": ctx.Param("appID"),
+				},
+				DeviceID: ctx.GetHeader("X-Device-Id"),
+			})
+		})
+
+		oidcRouter.POST("/token", func(ctx *gin.Context) {
+			var body dto.OIDCTokenDTO
+			if err := ctx.ShouldBind(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, nil)
+				return
+			}
+			ctx.Header("Cache-Control", "no-store")
+			public_controller.APIOIDCToken(&interfaces.ApplicationContext[dto.OIDCTokenDTO]{
+				Ctx:  ctx,
+				Body: &body,
+				Param: map[string]any{
+					"appID": ctx.Param("appID"),
+				},
+				UserAgent: ctx.Request.UserAgent(),
+				Keys: map[string]any{
+					"ip": ctx.ClientIP(),
+				},
+			})
+		})
+
+		userInfoHandler := func(ctx *gin.Context) {
+			public_controller.APIOIDCUserInfo(&interfaces.ApplicationContext[any]{
+				Ctx:    ctx,
+				Header: ctx.Request.Header,
+				Param: map[string]any{
+					"appID": ctx.Param("appID"),
+				},
+			})
+		}
+		oidcRouter.GET("/userinfo", userInfoHandler)
+		oidcRouter.POST("/userinfo", userInfoHandler)
+	}
+}
@@ -66,6 +66,10 @@ func AppRouter(router *gin.RouterGroup) {
 				Ctx:  ctx,
 				Body: &body,
 				Keys: appContext.Keys,
+				Param: map[string]any{
+					"id": ctx.Param("id"),
+				},
+				DeviceID: appContext.DeviceID,
 			})
 		})
 
@@ -122,6 +126,232 @@ func AppRouter(router *gin.RouterGroup) {
 			})
 		})
 
+		appRouter.POST("/api-keys/:id", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.WORKSPACE_EDIT_APPLICATIONS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.CreateAPIKeyDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.CreateAppAPIKey(&interfaces.ApplicationContext[dto.CreateAPIKeyDTO]{
+				Ctx:  ctx,
+				Body: &body,
+				Keys: appContext.Keys,
+				Param: map[string]any{
+					"id": ctx.Param("id"),
+				},
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		appRouter.GET("/api-keys/:id", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.WORKSPACE_VIEW_APPLICATIONS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.FetchAppAPIKeys(&interfaces.ApplicationContext[any]{
+				Ctx:  ctx,
+				Keys: appContext.Keys,
+				Param: map[string]any{
+					"id": ctx.Param("id"),
+				},
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		appRouter.POST("/api-keys/:id/rotate/:keyID", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.WORKSPACE_EDIT_APPLICATIONS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.RotateAPIKeyDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.RotateAppAPIKey(&interfaces.ApplicationContext[dto.RotateAPIKeyDTO]{
+				Ctx:  ctx,
+				Body: &body,
+				Keys: appContext.Keys,
+				Param: map[string]any{
+					"id":    ctx.Param("id"),
+					"keyID": ctx.Param("keyID"),
+				},
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		appRouter.DELETE("/api-keys/:id/:keyID", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.WORKSPACE_EDIT_APPLICATIONS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.RevokeAppAPIKey(&interfaces.ApplicationContext[any]{
+				Ctx:  ctx,
+				Keys: appContext.Keys,
+				Param: map[string]any{
+					"id":    ctx.Param("id"),
+					"keyID": ctx.Param("keyID"),
+				},
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		appRouter.PATCH("/request-signing-secret/refresh/:id", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.WORKSPACE_EDIT_APPLICATIONS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.RefreshRequestSigningSecret(&interfaces.ApplicationContext[any]{
+				Ctx:  ctx,
+				Keys: appContext.Keys,
+				Param: map[string]any{
+					"id": ctx.Param("id"),
+				},
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		appRouter.PATCH("/request-signing/update/:id", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.WORKSPACE_EDIT_APPLICATIONS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.UpdateRequestSigningDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.UpdateRequestSigningSetting(&interfaces.ApplicationContext[dto.UpdateRequestSigningDTO]{
+				Ctx:  ctx,
+				Body: &body,
+				Keys: appContext.Keys,
+				Param: map[string]any{
+					"id": ctx.Param("id"),
+				},
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		appRouter.PATCH("/screening/update/:id", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.WORKSPACE_EDIT_APPLICATIONS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.UpdateScreeningSettingDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.UpdateScreeningSetting(&interfaces.ApplicationContext[dto.UpdateScreeningSettingDTO]{
+				Ctx:  ctx,
+				Body: &body,
+				Keys: appContext.Keys,
+				Param: map[string]any{
+					"id": ctx.Param("id"),
+				},
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		appRouter.PATCH("/kyc-callback/update/:id", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.WORKSPACE_EDIT_APPLICATIONS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.UpdateKYCCallbackDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.UpdateKYCCallbackURL(&interfaces.ApplicationContext[dto.UpdateKYCCallbackDTO]{
+				Ctx:  ctx,
+				Body: &body,
+				Keys: appContext.Keys,
+				Param: map[string]any{
+					"id": ctx.Param("id"),
+				},
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		appRouter.GET("/lookup-usage/:id", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.WORKSPACE_VIEW_APPLICATIONS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.FetchLookupUsage(&interfaces.ApplicationContext[any]{
+				Ctx:  ctx,
+				Keys: appContext.Keys,
+				Param: map[string]any{
+					"id": ctx.Param("id"),
+				},
+				Query: map[string]any{
+					"period": ctx.Query("period"),
+				},
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		appRouter.GET("/screening/reviews/:id", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.WORKSPACE_VIEW_APPLICATIONS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.FetchScreeningHitReviews(&interfaces.ApplicationContext[any]{
+				Ctx:  ctx,
+				Keys: appContext.Keys,
+				Param: map[string]any{
+					"id": ctx.Param("id"),
+				},
+				Query: map[string]any{
+					"status": ctx.Query("status"),
+					"lastID": ctx.Query("lastID"),
+				},
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		appRouter.PATCH("/screening/reviews/:id/:reviewID", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.WORKSPACE_EDIT_APPLICATIONS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.ReviewScreeningHitDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.ReviewScreeningHit(&interfaces.ApplicationContext[dto.ReviewScreeningHitDTO]{
+				Ctx:  ctx,
+				Body: &body,
+				Keys: appContext.Keys,
+				Param: map[string]any{
+					"id":       ctx.Param("id"),
+					"reviewID": ctx.Param("reviewID"),
+				},
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		appRouter.GET("/signing-keys/:id", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.WORKSPACE_VIEW_APPLICATIONS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.FetchAppSigningKeys(&interfaces.ApplicationContext[any]{
+				Ctx:  ctx,
+				Keys: appContext.Keys,
+				Param: map[string]any{
+					"id": ctx.Param("id"),
+				},
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		appRouter.POST("/signing-keys/:id/rotate", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.WORKSPACE_EDIT_APPLICATIONS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.RotateSigningKeysDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.RotateAppSigningKeys(&interfaces.ApplicationContext[dto.RotateSigningKeysDTO]{
+				Ctx:  ctx,
+				Body: &body,
+				Keys: appContext.Keys,
+				Param: map[string]any{
+					"id": ctx.Param("id"),
+				},
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		appRouter.PATCH("/signing-keys/:id/rotation", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.WORKSPACE_EDIT_APPLICATIONS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.UpdateSigningKeyRotationDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.UpdateSigningKeyRotation(&interfaces.ApplicationContext[dto.UpdateSigningKeyRotationDTO]{
+				Ctx:  ctx,
+				Body: &body,
+				Keys: appContext.Keys,
+				Param: map[string]any{
+					"id": ctx.Param("id"),
+				},
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
 		appRouter.PATCH("/app-signing-key/refresh/:id", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.WORKSPACE_EDIT_APPLICATIONS}, true), func(ctx *gin.Context) {
 			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
 			controller.RefreshAppSigningKey(&interfaces.ApplicationContext[any]{
@@ -225,6 +455,107 @@ func AppRouter(router *gin.RouterGroup) {
 			})
 		})
 
+		appRouter.POST("/oidc/authorize", middlewares.UserAuthenticationMiddleware(nil), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.AuthorizeOIDCRequestDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			appContext.Keys["ip"] = ctx.ClientIP()
+			controller.AuthorizeOIDCRequest(&interfaces.ApplicationContext[dto.AuthorizeOIDCRequestDTO]{
+				Ctx:       ctx,
+				Body:      &body,
+				Keys:      appContext.Keys,
+				Header:    ctx.Request.Header,
+				DeviceID:  appContext.DeviceID,
+				UserAgent: ctx.Request.UserAgent(),
+			})
+		})
+
+		appRouter.POST("/mfa/set-up", middlewares.UserAuthenticationMiddleware(nil), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.SetUpAppMFADTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.SetUpAppMFA(&interfaces.ApplicationContext[dto.SetUpAppMFADTO]{
+				Ctx:      ctx,
+				Body:     &body,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		appRouter.POST("/mfa/confirm", middlewares.UserAuthenticationMiddleware(nil), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.ConfirmAppMFADTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.ConfirmAppMFA(&interfaces.ApplicationContext[dto.ConfirmAppMFADTO]{
+				Ctx:      ctx,
+				Body:     &body,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		appRouter.POST("/mfa/recovery-codes/regenerate", middlewares.UserAuthenticationMiddleware(nil), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.RegenerateRecoveryCodesDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.RegenerateAppRecoveryCodes(&interfaces.ApplicationContext[dto.RegenerateRecoveryCodesDTO]{
+				Ctx:      ctx,
+				Body:     &body,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		appRouter.PATCH("/users/mfa/reset/:id", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{
+			entities.USER_RESTRICT,
+		}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.ResetAppUsersMFADTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.ResetAppUsersMFA(&interfaces.ApplicationContext[dto.ResetAppUsersMFADTO]{
+				Ctx:    ctx,
+				Body:   &body,
+				Keys:   appContext.Keys,
+				Header: ctx.Request.Header,
+				Param: map[string]any{
+					"id": ctx.Param("id"),
+				},
+			})
+		})
+
+		appRouter.PATCH("/redirect-uris/update/:id", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.WORKSPACE_EDIT_APPLICATIONS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.UpdateRedirectURIsDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.UpdateAppRedirectURIs(&interfaces.ApplicationContext[dto.UpdateRedirectURIsDTO]{
+				Ctx:  ctx,
+				Body: &body,
+				Keys: appContext.Keys,
+				Param: map[string]any{
+					"id": ctx.Param("id"),
+				},
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
 		appRouter.POST("/users", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{
 			entities.USER_VIEW,
 		}, true), func(ctx *gin.Context) {
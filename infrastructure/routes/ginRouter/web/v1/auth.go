@@ -72,6 +72,23 @@ func AuthRouter(router *gin.RouterGroup) {
 				}
 			}
 			controller.VeirfyDeviceImage(&interfaces.ApplicationContext[dto.VerifyDeviceDTO]{
+				Ctx:        ctx,
+				DeviceID:   appContext.DeviceID,
+				DeviceName: appContext.DeviceName,
+				UserAgent:  appContext.UserAgent,
+				Keys:       appContext.Keys,
+				Body:       &body,
+			})
+		})
+
+		authRouter.POST("/passkey/login/begin", func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.PasskeyLoginOptionsDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.BeginPasskeyLogin(&interfaces.ApplicationContext[dto.PasskeyLoginOptionsDTO]{
 				Ctx:      ctx,
 				DeviceID: appContext.DeviceID,
 				Keys:     appContext.Keys,
@@ -79,6 +96,24 @@ func AuthRouter(router *gin.RouterGroup) {
 			})
 		})
 
+		authRouter.POST("/passkey/login/finish", func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.PasskeyLoginDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			appContext.Keys["ip"] = ctx.ClientIP()
+			controller.FinishPasskeyLogin(&interfaces.ApplicationContext[dto.PasskeyLoginDTO]{
+				Ctx:        ctx,
+				DeviceID:   appContext.DeviceID,
+				DeviceName: appContext.DeviceName,
+				UserAgent:  appContext.UserAgent,
+				Keys:       appContext.Keys,
+				Body:       &body,
+			})
+		})
+
 		authRouter.GET("/refresh", middlewares.RefreshTokenMiddleware(), func(ctx *gin.Context) {
 			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
 			controller.RefreshToken(&interfaces.ApplicationContext[any]{
@@ -1,12 +1,14 @@
 package routev1
 
 import (
+	"net/http"
 	"os"
 
 	apperrors "gateman.io/application/appErrors"
 	"gateman.io/application/controller"
 	"gateman.io/application/controller/dto"
 	"gateman.io/application/interfaces"
+	middlewares "gateman.io/infrastructure/middleware"
 	"github.com/gin-gonic/gin"
 )
 
@@ -188,6 +190,7 @@ func BiometricRouter(router *gin.RouterGroup) {
 		})
 
 		// System health check endpoint
+		middlewares.PlaintextRoute(biometricRouter, http.MethodGet, "/system-health")
 		biometricRouter.GET("/system-health", func(ctx *gin.Context) {
 			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
 			controller.SystemHealthCheck(&interfaces.ApplicationContext[any]{
@@ -80,6 +80,45 @@ func UserRouter(router *gin.RouterGroup) {
 			})
 		})
 
+		userRouter.POST("/face-binding", middlewares.UserAuthenticationMiddleware(nil), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.BindIdentityFaceDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.BindIdentityFace(&interfaces.ApplicationContext[dto.BindIdentityFaceDTO]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+				Body:     &body,
+			})
+		})
+
+		userRouter.POST("/document/upload-url", middlewares.UserAuthenticationMiddleware(nil), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.RequestIdentityDocumentUploadURL(&interfaces.ApplicationContext[any]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		userRouter.POST("/document/verify", middlewares.UserAuthenticationMiddleware(nil), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.VerifyIdentityDocumentDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.VerifyIdentityDocument(&interfaces.ApplicationContext[dto.VerifyIdentityDocumentDTO]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+				Body:     &body,
+			})
+		})
+
 		userRouter.POST("/verify-nin", middlewares.OTPTokenMiddleware("verify_nin"), func(ctx *gin.Context) {
 			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
 			controller.VerifyNINDetails(&interfaces.ApplicationContext[any]{
@@ -162,5 +201,89 @@ func UserRouter(router *gin.RouterGroup) {
 				DeviceID: appContext.DeviceID,
 			})
 		})
+
+		userRouter.GET("/sessions", middlewares.UserAuthenticationMiddleware(nil), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.FetchUserSessions(&interfaces.ApplicationContext[any]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		userRouter.DELETE("/sessions/:deviceID", middlewares.UserAuthenticationMiddleware(nil), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.RevokeUserSession(&interfaces.ApplicationContext[any]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+				Param: map[string]any{
+					"deviceID": ctx.Param("deviceID"),
+				},
+			})
+		})
+
+		userRouter.DELETE("/kyc-data", middlewares.UserAuthenticationMiddleware(nil), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.EraseKYCData(&interfaces.ApplicationContext[any]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		userRouter.POST("/sessions/sign-out-all", middlewares.UserAuthenticationMiddleware(nil), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.SignOutUserEverywhere(&interfaces.ApplicationContext[any]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		userRouter.GET("/passkeys", middlewares.UserAuthenticationMiddleware(nil), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.FetchPasskeys(&interfaces.ApplicationContext[any]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		userRouter.POST("/passkeys/register/begin", middlewares.UserAuthenticationMiddleware(nil), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.BeginPasskeyRegistration(&interfaces.ApplicationContext[any]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		userRouter.POST("/passkeys/register/finish", middlewares.UserAuthenticationMiddleware(nil), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.RegisterPasskeyDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.FinishPasskeyRegistration(&interfaces.ApplicationContext[dto.RegisterPasskeyDTO]{
+				Ctx:      ctx,
+				Body:     &body,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		userRouter.DELETE("/passkeys/:id", middlewares.UserAuthenticationMiddleware(nil), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.DeletePasskey(&interfaces.ApplicationContext[any]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+				Param: map[string]any{
+					"id": ctx.Param("id"),
+				},
+			})
+		})
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"gateman.io/application/controller"
 	"gateman.io/application/controller/dto"
 	"gateman.io/application/interfaces"
+	workspace_usecases "gateman.io/application/usecases/workspace"
 	"gateman.io/entities"
 	middlewares "gateman.io/infrastructure/middleware"
 	"github.com/gin-gonic/gin"
@@ -83,6 +84,7 @@ func WorkspaceRouter(router *gin.RouterGroup) {
 				Keys:       appContext.Keys,
 				DeviceID:   appContext.DeviceID,
 				DeviceName: appContext.DeviceName,
+				UserAgent:  appContext.UserAgent,
 				Param: map[string]any{
 					"ip": ctx.ClientIP(),
 				},
@@ -110,10 +112,11 @@ func WorkspaceRouter(router *gin.RouterGroup) {
 				}
 			}
 			controller.LoginWorkspaceMember(&interfaces.ApplicationContext[dto.LoginWorkspaceMemberDTO]{
-				Ctx:      ctx,
-				Body:     &body,
-				Keys:     appContext.Keys,
-				DeviceID: appContext.DeviceID,
+				Ctx:       ctx,
+				Body:      &body,
+				Keys:      appContext.Keys,
+				DeviceID:  appContext.DeviceID,
+				UserAgent: appContext.UserAgent,
 				Param: map[string]any{
 					"ip": ctx.ClientIP(),
 				},
@@ -187,5 +190,156 @@ func WorkspaceRouter(router *gin.RouterGroup) {
 				DeviceID: appContext.DeviceID,
 			})
 		})
+
+		workspaceRouter.POST("/kyb/upload-url", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.SUPER_ACCESS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.BusinessDocumentUploadDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.RequestBusinessDocumentUploadURL(&interfaces.ApplicationContext[dto.BusinessDocumentUploadDTO]{
+				Ctx:      ctx,
+				Body:     &body,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		workspaceRouter.POST("/kyb/verify", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.SUPER_ACCESS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.VerifyBusinessDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.VerifyWorkspaceBusiness(&interfaces.ApplicationContext[dto.VerifyBusinessDTO]{
+				Ctx:      ctx,
+				Body:     &body,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		workspaceRouter.GET("/sessions", middlewares.WorkspaceAuthenticationMiddleware(nil, nil, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.FetchWorkspaceMemberSessions(&interfaces.ApplicationContext[any]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		workspaceRouter.DELETE("/sessions/:deviceID", middlewares.WorkspaceAuthenticationMiddleware(nil, nil, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.RevokeWorkspaceMemberSession(&interfaces.ApplicationContext[any]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+				Param: map[string]any{
+					"deviceID": ctx.Param("deviceID"),
+				},
+			})
+		})
+
+		workspaceRouter.POST("/sessions/sign-out-all", middlewares.WorkspaceAuthenticationMiddleware(nil, nil, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.SignOutWorkspaceMemberEverywhere(&interfaces.ApplicationContext[any]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		workspaceRouter.POST("/mfa/set-up", middlewares.WorkspaceAuthenticationMiddleware(nil, nil, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.SetUpWorkspaceMFA(&interfaces.ApplicationContext[any]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		workspaceRouter.POST("/mfa/confirm", middlewares.WorkspaceAuthenticationMiddleware(nil, nil, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.ConfirmWorkspaceMFADTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.ConfirmWorkspaceMFA(&interfaces.ApplicationContext[dto.ConfirmWorkspaceMFADTO]{
+				Ctx:      ctx,
+				Body:     &body,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		// used by members the workspace mfa policy requires to set up mfa before they can log in
+		workspaceRouter.POST("/mfa/enforced/set-up", middlewares.OTPTokenMiddleware(workspace_usecases.WorkspaceMFASetupIntent), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.SetUpWorkspaceMFA(&interfaces.ApplicationContext[any]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		workspaceRouter.POST("/mfa/enforced/confirm", middlewares.OTPTokenMiddleware(workspace_usecases.WorkspaceMFASetupIntent), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.ConfirmWorkspaceMFADTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.ConfirmWorkspaceMFA(&interfaces.ApplicationContext[dto.ConfirmWorkspaceMFADTO]{
+				Ctx:      ctx,
+				Body:     &body,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		workspaceRouter.POST("/mfa/recovery-codes/regenerate", middlewares.WorkspaceAuthenticationMiddleware(nil, nil, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.RegenerateWorkspaceRecoveryCodesDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.RegenerateWorkspaceRecoveryCodes(&interfaces.ApplicationContext[dto.RegenerateWorkspaceRecoveryCodesDTO]{
+				Ctx:      ctx,
+				Body:     &body,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		workspaceRouter.PATCH("/mfa/policy", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.SUPER_ACCESS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			var body dto.UpdateWorkspaceMFAPolicyDTO
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				apperrors.ErrorProcessingPayload(ctx, appContext.GetHeader("X-Device-Id"))
+				return
+			}
+			controller.UpdateWorkspaceMFAPolicy(&interfaces.ApplicationContext[dto.UpdateWorkspaceMFAPolicyDTO]{
+				Ctx:      ctx,
+				Body:     &body,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+			})
+		})
+
+		workspaceRouter.PATCH("/members/mfa/reset/:id", middlewares.WorkspaceAuthenticationMiddleware(nil, &[]entities.MemberPermissions{entities.MEMBER_EDIT_ACCESS}, true), func(ctx *gin.Context) {
+			appContext := ctx.MustGet("AppContext").(*interfaces.ApplicationContext[any])
+			controller.ResetWorkspaceMemberMFA(&interfaces.ApplicationContext[any]{
+				Ctx:      ctx,
+				Keys:     appContext.Keys,
+				DeviceID: appContext.DeviceID,
+				Param: map[string]any{
+					"id": ctx.Param("id"),
+				},
+			})
+		})
 	}
 }
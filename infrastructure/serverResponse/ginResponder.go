@@ -1,32 +1,26 @@
 package server_response
 
 import (
-	// "encoding/json"
-
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"time"
 
 	"gateman.io/application/constants"
+	"gateman.io/application/interfaces"
 	"gateman.io/application/utils"
 	"gateman.io/infrastructure/cryptography"
-	"gateman.io/infrastructure/database/repository/cache"
 	"gateman.io/infrastructure/logger"
 	"github.com/gin-gonic/gin"
 )
 
 type ginResponder struct{}
 
-// Sends an encrypted payload to the client
+// Sends the response to the client. Requests that came in encrypted are answered with {"payload": hex(nonce || ciphertext || tag)},
+// sealed with AES-GCM under the device's payload key and "<request nonce>\n<status code>" as the additional data.
 func (gr ginResponder) Respond(ctx interface{}, code int, message string, payload any, errs []error, responseCode *uint, deviceID *string) {
-	gr.UnEncryptedRespond(ctx, code, message, payload, errs, responseCode)
-	return
-	// if os.Getenv("APP_ENV") == "dev" || deviceID == nil {
-	// 	gr.UnEncryptedRespond(ctx, code, message, payload, errs, responseCode)
-	// 	return
-	// }
 	ginCtx, ok := (ctx).(*gin.Context)
 	if !ok {
 		logger.Error("could not transform *interface{} to gin.Context in serverResponse package", logger.LoggerOptions{
@@ -35,116 +29,52 @@ func (gr ginResponder) Respond(ctx interface{}, code int, message string, payloa
 		})
 		return
 	}
-	ginCtx.Abort()
-
-	if payload != nil {
-		switch p := payload.(type) {
-		case map[string]any:
-			if value, ok := p["accessToken"]; ok && value.(*string) != nil {
-				http.SetCookie(ginCtx.Writer, &http.Cookie{
-					Name:   "accessToken",
-					Value:  *value.(*string),
-					Domain: utils.ExtractDomain(utils.ExtractDomain(os.Getenv("CLIENT_URL"))),
-					// HttpOnly: true,
-					// Secure:   true,
-					Path:     "/",
-					SameSite: http.SameSiteStrictMode,
-					Expires:  time.Now().Add(time.Hour * 1),
-					MaxAge:   3600,
-				})
-			}
-			if value, ok := p["refreshToken"]; ok && value.(*string) != nil {
-				http.SetCookie(ginCtx.Writer, &http.Cookie{
-					Name:   "refreshToken",
-					Value:  *value.(*string),
-					Domain: utils.ExtractDomain(os.Getenv("CLIENT_URL")),
-					// HttpOnly: true,
-					// Secure:   true,
-					Path:     "/api/v1/auth/refresh",
-					SameSite: http.SameSiteStrictMode,
-					Expires:  time.Now().Add(time.Hour * 24 * 183),
-					MaxAge:   15768000,
-				})
-			}
-			if value, ok := p["workspaceAccessToken"]; ok && value.(*string) != nil {
-				http.SetCookie(ginCtx.Writer, &http.Cookie{
-					Name:   "workspaceAccessToken",
-					Value:  *value.(*string),
-					Domain: utils.ExtractDomain(os.Getenv("CLIENT_URL")),
-					// HttpOnly: true,
-					// Secure:   true,
-					Path:     "/",
-					SameSite: http.SameSiteStrictMode,
-					Expires:  time.Now().Add(time.Hour * 1),
-					MaxAge:   3600,
-				})
-			}
-			if value, ok := p["workspaceRefreshToken"]; ok && value.(*string) != nil {
-				http.SetCookie(ginCtx.Writer, &http.Cookie{
-					Name:   "workspaceRefreshToken",
-					Value:  *value.(*string),
-					Domain: utils.ExtractDomain(os.Getenv("CLIENT_URL")),
-					// HttpOnly: true,
-					// Secure:   true,
-					Path:     "/api/v1/auth/workspace/refresh",
-					SameSite: http.SameSiteStrictMode,
-					Expires:  time.Now().Add(time.Hour * 24 * 183),
-					MaxAge:   15768000,
-				})
-			}
-			delete(payload.(map[string]any), "accessToken")
-			delete(payload.(map[string]any), "refreshToken")
-			delete(payload.(map[string]any), "workspaceAccessToken")
-			delete(payload.(map[string]any), "workspaceRefreshToken")
-		}
-	}
-
-	response := map[string]any{
-		"message": message,
-		"body":    payload,
-	}
-	if responseCode != nil {
-		response["responseCode"] = responseCode
-	}
-	if errs != nil {
-		errMsgs := []string{}
-		for _, err := range errs {
-			errMsgs = append(errMsgs, err.Error())
-		}
-		response["errors"] = errMsgs
-	}
-	if deviceID == nil {
-		ginCtx.JSON(code, response)
+	appContext := encryptedRequestContext(ginCtx)
+	if deviceID == nil || appContext == nil {
+		gr.UnEncryptedRespond(ctx, code, message, payload, errs, responseCode)
 		return
 	}
-	jsonResponse, _ := json.Marshal(response)
+	ginCtx.Abort()
 
-	sharedKey := cache.Cache.FindOne(fmt.Sprintf("%s-key", *deviceID))
-	if sharedKey == nil {
-		ginCtx.JSON(401, map[string]any{
+	jsonResponse, _ := json.Marshal(buildResponse(ginCtx, message, payload, errs, responseCode))
+	key, err := cryptography.DevicePayloadKey(appContext.DeviceID)
+	if errors.Is(err, cryptography.ErrEncryptionKeyExpired) {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]any{
 			"responseCode": constants.ENCRYPTION_KEY_EXPIRED,
 			"message":      "encryption key has expired. initiate key exchange protocol again.",
 		})
 		return
 	}
-	decryptedKey, _ := cryptography.DecryptData(*sharedKey, nil)
-	encryptedResponse, err := cryptography.EncryptData(jsonResponse, utils.GetStringPointer(string(decryptedKey)))
+	var sealedResponse string
+	if err == nil {
+		sealedResponse, err = cryptography.SealPayload(key, jsonResponse, []byte(fmt.Sprintf("%s\n%d", *appContext.Nonce, code)))
+	}
 	if err != nil {
 		logger.Error("error encrypting data", logger.LoggerOptions{
 			Key:  "error",
 			Data: err,
 		})
-	}
-	ginCtx.JSON(code, encryptedResponse)
-	ginCtx, ok = (ctx).(*gin.Context)
-	if !ok {
-		logger.Error("could not transform *interface{} to gin.Context in serverResponse package", logger.LoggerOptions{
-			Key:  "payload",
-			Data: ctx,
+		ginCtx.JSON(http.StatusInternalServerError, map[string]any{
+			"message": "response could not be encrypted",
 		})
 		return
 	}
-	ginCtx.Abort()
+	ginCtx.JSON(code, map[string]any{
+		"payload": sealedResponse,
+	})
+}
+
+// Returns the context of the request if it came in encrypted
+func encryptedRequestContext(ginCtx *gin.Context) *interfaces.ApplicationContext[any] {
+	value, exists := ginCtx.Get("AppContext")
+	if !exists {
+		return nil
+	}
+	appContext, ok := value.(*interfaces.ApplicationContext[any])
+	if !ok || appContext.Nonce == nil {
+		return nil
+	}
+	return appContext
 }
 
 func (gr ginResponder) UnEncryptedRespond(ctx interface{}, code int, message string, payload any, errs []error, responseCode *uint) {
@@ -157,7 +87,11 @@ func (gr ginResponder) UnEncryptedRespond(ctx interface{}, code int, message str
 		return
 	}
 	ginCtx.Abort()
+	ginCtx.JSON(code, buildResponse(ginCtx, message, payload, errs, responseCode))
+}
 
+// Moves tokens in payload into cookies and wraps payload in the response envelope
+func buildResponse(ginCtx *gin.Context, message string, payload any, errs []error, responseCode *uint) map[string]any {
 	if payload != nil {
 		secureAccess := os.Getenv("APP_ENV") == "prod"
 		switch p := payload.(type) {
@@ -251,5 +185,31 @@ func (gr ginResponder) UnEncryptedRespond(ctx interface{}, code int, message str
 		response["errors"] = errMsgs
 	}
 
-	ginCtx.JSON(code, response)
+	return response
+}
+
+func (gr ginResponder) RawRespond(ctx interface{}, code int, payload any) {
+	ginCtx, ok := (ctx).(*gin.Context)
+	if !ok {
+		logger.Error("could not transform *interface{} to gin.Context in serverResponse package", logger.LoggerOptions{
+			Key:  "payload",
+			Data: ctx,
+		})
+		return
+	}
+	ginCtx.Abort()
+	ginCtx.JSON(code, payload)
+}
+
+func (gr ginResponder) Redirect(ctx interface{}, location string) {
+	ginCtx, ok := (ctx).(*gin.Context)
+	if !ok {
+		logger.Error("could not transform *interface{} to gin.Context in serverResponse package", logger.LoggerOptions{
+			Key:  "payload",
+			Data: ctx,
+		})
+		return
+	}
+	ginCtx.Abort()
+	ginCtx.Redirect(http.StatusFound, location)
 }
@@ -4,4 +4,8 @@ type serverResponder interface {
 	// Used to send a JSON response to the client.
 	Respond(ctx interface{}, code int, message string, payload any, errs []error, responseCode *uint, deviceID *string)
 	UnEncryptedRespond(ctx interface{}, code int, message string, payload any, errs []error, responseCode *uint)
+	// Used to send a payload as is, for responses whose shape is fixed by a standard (e.g JWKS).
+	RawRespond(ctx interface{}, code int, payload any)
+	// Used to redirect the client to another location.
+	Redirect(ctx interface{}, location string)
 }
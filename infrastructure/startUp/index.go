@@ -1,19 +1,23 @@
 package startup
 
 import (
+	"gateman.io/infrastructure/cryptography"
 	"gateman.io/infrastructure/database"
 	"gateman.io/infrastructure/database/connection/datastore"
 	fileupload "gateman.io/infrastructure/file_upload"
 	identityverification "gateman.io/infrastructure/identity_verification"
+	"gateman.io/infrastructure/keystore"
 	"gateman.io/infrastructure/messaging/sms"
 	"gateman.io/infrastructure/payments"
 )
 
 // Used to start services such as loggers, databases, queues, etc.
 func StartServices() {
+	cryptography.InitialiseBlindIndex()
 	database.SetUpDatabase()
 	fileupload.InitialiseFileUploader()
 	identityverification.InitialiseIdentityVerifier()
+	keystore.InitialiseKeyStore()
 	sms.InitSMSService()
 	payments.InitialisePaymentProcessor()
 }
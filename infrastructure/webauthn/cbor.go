@@ -0,0 +1,140 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var errMalformedCBOR = errors.New("malformed cbor data")
+
+// decodeCBOR decodes the first CBOR item in data and returns it along with the bytes that follow it.
+// Only the definite length encodings authenticators are required to use (CTAP2 canonical CBOR) are supported.
+// Maps decode to map[any]any with int64 or string keys, byte strings to []byte and integers to int64.
+func decodeCBOR(data []byte) (any, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (any, []byte, error) {
+	if len(data) == 0 || depth > 16 {
+		return nil, nil, errMalformedCBOR
+	}
+	majorType := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	if majorType == 7 {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22, 23:
+			return nil, data, nil
+		case 25:
+			return skipCBORBytes(data, 2)
+		case 26:
+			if len(data) < 4 {
+				return nil, nil, errMalformedCBOR
+			}
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), data[4:], nil
+		case 27:
+			if len(data) < 8 {
+				return nil, nil, errMalformedCBOR
+			}
+			return math.Float64frombits(binary.BigEndian.Uint64(data)), data[8:], nil
+		}
+		return nil, nil, errMalformedCBOR
+	}
+
+	argument, data, err := readCBORArgument(info, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch majorType {
+	case 0:
+		if argument > math.MaxInt64 {
+			return nil, nil, errMalformedCBOR
+		}
+		return int64(argument), data, nil
+	case 1:
+		if argument > math.MaxInt64 {
+			return nil, nil, errMalformedCBOR
+		}
+		return -1 - int64(argument), data, nil
+	case 2, 3:
+		if uint64(len(data)) < argument {
+			return nil, nil, errMalformedCBOR
+		}
+		value := data[:argument]
+		if majorType == 3 {
+			return string(value), data[argument:], nil
+		}
+		return append([]byte{}, value...), data[argument:], nil
+	case 4:
+		if argument > uint64(len(data)) {
+			return nil, nil, errMalformedCBOR
+		}
+		items := make([]any, 0, argument)
+		for i := uint64(0); i < argument; i++ {
+			var item any
+			item, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+	case 5:
+		if argument > uint64(len(data)) {
+			return nil, nil, errMalformedCBOR
+		}
+		items := make(map[any]any, argument)
+		for i := uint64(0); i < argument; i++ {
+			var key, value any
+			key, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errMalformedCBOR
+			}
+			value, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items[key] = value
+		}
+		return items, data, nil
+	case 6:
+		// tags carry no meaning for webauthn structures so the tagged item is returned as is
+		return decodeCBORItem(data, depth+1)
+	}
+	return nil, nil, errMalformedCBOR
+}
+
+func readCBORArgument(info byte, data []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24 && len(data) >= 1:
+		return uint64(data[0]), data[1:], nil
+	case info == 25 && len(data) >= 2:
+		return uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26 && len(data) >= 4:
+		return uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27 && len(data) >= 8:
+		return binary.BigEndian.Uint64(data), data[8:], nil
+	}
+	// indefinite lengths (31) and reserved values are rejected
+	return 0, nil, errMalformedCBOR
+}
+
+func skipCBORBytes(data []byte, length int) (any, []byte, error) {
+	if len(data) < length {
+		return nil, nil, errMalformedCBOR
+	}
+	return nil, data[length:], nil
+}
@@ -0,0 +1,101 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// COSE algorithm identifiers of the signature algorithms credentials can be registered with
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+var SupportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+var ErrUnsupportedKey = errors.New("unsupported credential public key")
+
+const (
+	coseKeyType   int64 = 1
+	coseAlgorithm int64 = 3
+	coseCurve     int64 = -1
+	coseX         int64 = -2
+	coseY         int64 = -3
+	coseRSAN      int64 = -1
+	coseRSAE      int64 = -2
+)
+
+type credentialPublicKey struct {
+	algorithm int64
+	key       crypto.PublicKey
+}
+
+// parses a COSE_Key encoded credential public key
+func parseCredentialPublicKey(coseKey []byte) (*credentialPublicKey, error) {
+	decoded, rest, err := decodeCBOR(coseKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, ErrUnsupportedKey
+	}
+	fields, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	keyType, _ := fields[coseKeyType].(int64)
+	algorithm, _ := fields[coseAlgorithm].(int64)
+	switch {
+	case algorithm == AlgES256 && keyType == 2:
+		curve, _ := fields[coseCurve].(int64)
+		x, _ := fields[coseX].([]byte)
+		y, _ := fields[coseY].([]byte)
+		if curve != 1 || len(x) != 32 || len(y) != 32 {
+			return nil, ErrUnsupportedKey
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, ErrUnsupportedKey
+		}
+		return &credentialPublicKey{algorithm: algorithm, key: key}, nil
+	case algorithm == AlgEdDSA && keyType == 1:
+		curve, _ := fields[coseCurve].(int64)
+		x, _ := fields[coseX].([]byte)
+		if curve != 6 || len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedKey
+		}
+		return &credentialPublicKey{algorithm: algorithm, key: ed25519.PublicKey(x)}, nil
+	case algorithm == AlgRS256 && keyType == 3:
+		n, _ := fields[coseRSAN].([]byte)
+		e, _ := fields[coseRSAE].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, ErrUnsupportedKey
+		}
+		return &credentialPublicKey{algorithm: algorithm, key: &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}}, nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+func (pk *credentialPublicKey) verify(data []byte, signature []byte) bool {
+	switch key := pk.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, data, signature)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+	return false
+}
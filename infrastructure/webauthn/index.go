@@ -0,0 +1,186 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Builds the relying party from WEBAUTHN_RP_ID and the comma separated WEBAUTHN_ORIGINS
+func DefaultRelyingParty() RelyingParty {
+	origins := []string{}
+	for _, origin := range strings.Split(os.Getenv("WEBAUTHN_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return RelyingParty{
+		ID:      os.Getenv("WEBAUTHN_RP_ID"),
+		Name:    "Gateman",
+		Origins: origins,
+	}
+}
+
+// Generates a random base64url encoded challenge for a ceremony
+func GenerateChallenge() (string, error) {
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(challenge), nil
+}
+
+// Decodes the base64url values browsers send, with or without padding
+func DecodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+// Verifies the response to navigator.credentials.create() and returns the new credential.
+// User verification is required since passkeys stand in for face match when trusting a device.
+// Attestation statements are not verified, credentials are trusted the same way as with "none" attestation.
+func (rp RelyingParty) VerifyRegistration(challenge string, clientDataJSON []byte, attestationObject []byte) (*Credential, error) {
+	if err := rp.verifyClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+	decoded, _, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return nil, ErrMalformedResponse
+	}
+	attestation, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, ErrMalformedResponse
+	}
+	rawAuthData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, ErrMalformedResponse
+	}
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err = rp.verifyAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+	if authData.credentialPublicKey == nil {
+		return nil, ErrMalformedResponse
+	}
+	publicKey, err := parseCredentialPublicKey(authData.credentialPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Credential{
+		ID:             authData.credentialID,
+		PublicKey:      authData.credentialPublicKey,
+		Algorithm:      publicKey.algorithm,
+		SignCount:      authData.signCount,
+		AAGUID:         authData.aaguid,
+		BackupEligible: authData.flags&flagBackupEligible != 0,
+		BackedUp:       authData.flags&flagBackedUp != 0,
+	}, nil
+}
+
+// Verifies the response to navigator.credentials.get() against a stored credential public key and returns the new signature counter.
+// Authenticators that do not implement a counter always send 0. Any other counter must be higher than storedSignCount,
+// a lower one means the credential may have been cloned and ErrSignCountRegressed is returned.
+func (rp RelyingParty) VerifyAssertion(challenge string, publicKey []byte, storedSignCount uint32, clientDataJSON []byte, rawAuthData []byte, signature []byte) (uint32, error) {
+	if err := rp.verifyClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+	if err = rp.verifyAuthenticatorData(authData); err != nil {
+		return 0, err
+	}
+	key, err := parseCredentialPublicKey(publicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signedData := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if !key.verify(signedData, signature) {
+		return 0, ErrInvalidSignature
+	}
+	if (authData.signCount != 0 || storedSignCount != 0) && authData.signCount <= storedSignCount {
+		return authData.signCount, ErrSignCountRegressed
+	}
+	return authData.signCount, nil
+}
+
+func (rp RelyingParty) verifyClientData(clientDataJSON []byte, ceremony string, challenge string) error {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil {
+		return ErrMalformedResponse
+	}
+	if data.Type != ceremony {
+		return ErrInvalidCeremony
+	}
+	if subtle.ConstantTimeCompare([]byte(strings.TrimRight(data.Challenge, "=")), []byte(challenge)) != 1 {
+		return ErrChallengeMismatch
+	}
+	if data.CrossOrigin || !slices.Contains(rp.Origins, data.Origin) {
+		return ErrOriginNotAllowed
+	}
+	return nil
+}
+
+func (rp RelyingParty) verifyAuthenticatorData(authData *authenticatorData) error {
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if rp.ID == "" || !bytes.Equal(authData.rpIDHash, rpIDHash[:]) {
+		return ErrRelyingPartyID
+	}
+	if authData.flags&flagUserPresent == 0 || authData.flags&flagUserVerified == 0 {
+		return ErrUserNotPresent
+	}
+	return nil
+}
+
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, ErrMalformedResponse
+	}
+	authData := &authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[37:]
+	if authData.flags&flagAttestedCredentialData != 0 {
+		if len(rest) < 18 {
+			return nil, ErrMalformedResponse
+		}
+		authData.aaguid = rest[:16]
+		credentialIDLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if credentialIDLength == 0 || credentialIDLength > 1023 || len(rest) < credentialIDLength {
+			return nil, ErrMalformedResponse
+		}
+		authData.credentialID = rest[:credentialIDLength]
+		rest = rest[credentialIDLength:]
+		_, afterKey, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrMalformedResponse
+		}
+		authData.credentialPublicKey = rest[:len(rest)-len(afterKey)]
+		rest = afterKey
+	}
+	if authData.flags&flagExtensionData != 0 {
+		_, afterExtensions, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrMalformedResponse
+		}
+		rest = afterExtensions
+	}
+	if len(rest) != 0 {
+		return nil, ErrMalformedResponse
+	}
+	return authData, nil
+}
@@ -0,0 +1,231 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const (
+	testOrigin = "https://gateman.io"
+	testRPID   = "gateman.io"
+)
+
+var testRelyingParty = RelyingParty{ID: testRPID, Name: "Gateman", Origins: []string{testOrigin}}
+
+// a software authenticator holding a single ES256 credential
+type testAuthenticator struct {
+	credentialID []byte
+	key          *ecdsa.PrivateKey
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testAuthenticator{credentialID: []byte("test-credential-id"), key: key}
+}
+
+func cborHead(majorType byte, argument uint64) []byte {
+	switch {
+	case argument < 24:
+		return []byte{majorType<<5 | byte(argument)}
+	case argument <= 0xff:
+		return []byte{majorType<<5 | 24, byte(argument)}
+	default:
+		return binary.BigEndian.AppendUint16([]byte{majorType<<5 | 25}, uint16(argument))
+	}
+}
+
+func cborInt(value int64) []byte {
+	if value < 0 {
+		return cborHead(1, uint64(-1-value))
+	}
+	return cborHead(0, uint64(value))
+}
+
+func cborBytes(value []byte) []byte {
+	return append(cborHead(2, uint64(len(value))), value...)
+}
+
+func cborText(value string) []byte {
+	return append(cborHead(3, uint64(len(value))), value...)
+}
+
+// pairs are already encoded keys and values in order
+func cborMap(pairs ...[]byte) []byte {
+	encoded := cborHead(5, uint64(len(pairs)/2))
+	for _, item := range pairs {
+		encoded = append(encoded, item...)
+	}
+	return encoded
+}
+
+func (a *testAuthenticator) coseKey() []byte {
+	x := a.key.PublicKey.X.FillBytes(make([]byte, 32))
+	y := a.key.PublicKey.Y.FillBytes(make([]byte, 32))
+	return cborMap(
+		cborInt(coseKeyType), cborInt(2),
+		cborInt(coseAlgorithm), cborInt(AlgES256),
+		cborInt(coseCurve), cborInt(1),
+		cborInt(coseX), cborBytes(x),
+		cborInt(coseY), cborBytes(y),
+	)
+}
+
+func authData(flags byte, signCount uint32, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, signCount)
+	return append(data, attested...)
+}
+
+func clientDataJSON(t *testing.T, ceremony string, challenge string) []byte {
+	t.Helper()
+	data, err := json.Marshal(clientData{Type: ceremony, Challenge: challenge, Origin: testOrigin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func (a *testAuthenticator) attestationObject() []byte {
+	attested := make([]byte, 16)
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, a.coseKey()...)
+	rawAuthData := authData(flagUserPresent|flagUserVerified|flagAttestedCredentialData, 0, attested)
+	return cborMap(
+		cborText("fmt"), cborText("none"),
+		cborText("attStmt"), cborMap(),
+		cborText("authData"), cborBytes(rawAuthData),
+	)
+}
+
+// returns the authenticator data and signature for an assertion over clientDataJSON
+func (a *testAuthenticator) assert(t *testing.T, signCount uint32, clientDataJSON []byte) ([]byte, []byte) {
+	t.Helper()
+	rawAuthData := authData(flagUserPresent|flagUserVerified, signCount, nil)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, rawAuthData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rawAuthData, signature
+}
+
+func registerTestCredential(t *testing.T, authenticator *testAuthenticator) *Credential {
+	t.Helper()
+	challenge, err := GenerateChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	credential, err := testRelyingParty.VerifyRegistration(challenge, clientDataJSON(t, "webauthn.create", challenge), authenticator.attestationObject())
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+	return credential
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	credential := registerTestCredential(t, authenticator)
+	if string(credential.ID) != string(authenticator.credentialID) {
+		t.Errorf("credential id = %q, want %q", credential.ID, authenticator.credentialID)
+	}
+	if credential.Algorithm != AlgES256 {
+		t.Errorf("algorithm = %d, want %d", credential.Algorithm, AlgES256)
+	}
+
+	challenge, _ := GenerateChallenge()
+	otherChallenge, _ := GenerateChallenge()
+	_, err := testRelyingParty.VerifyRegistration(challenge, clientDataJSON(t, "webauthn.create", otherChallenge), authenticator.attestationObject())
+	if !errors.Is(err, ErrChallengeMismatch) {
+		t.Errorf("mismatched challenge: err = %v, want %v", err, ErrChallengeMismatch)
+	}
+	_, err = testRelyingParty.VerifyRegistration(challenge, clientDataJSON(t, "webauthn.get", challenge), authenticator.attestationObject())
+	if !errors.Is(err, ErrInvalidCeremony) {
+		t.Errorf("wrong ceremony: err = %v, want %v", err, ErrInvalidCeremony)
+	}
+	otherRP := RelyingParty{ID: "evil.example", Origins: []string{testOrigin}}
+	_, err = otherRP.VerifyRegistration(challenge, clientDataJSON(t, "webauthn.create", challenge), authenticator.attestationObject())
+	if !errors.Is(err, ErrRelyingPartyID) {
+		t.Errorf("wrong rp id: err = %v, want %v", err, ErrRelyingPartyID)
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	credential := registerTestCredential(t, authenticator)
+
+	challenge, _ := GenerateChallenge()
+	clientData := clientDataJSON(t, "webauthn.get", challenge)
+	rawAuthData, signature := authenticator.assert(t, 5, clientData)
+	signCount, err := testRelyingParty.VerifyAssertion(challenge, credential.PublicKey, 4, clientData, rawAuthData, signature)
+	if err != nil {
+		t.Fatalf("assertion failed: %v", err)
+	}
+	if signCount != 5 {
+		t.Errorf("sign count = %d, want 5", signCount)
+	}
+
+	// authenticators without a counter always send 0
+	rawAuthData, signature = authenticator.assert(t, 0, clientData)
+	if _, err = testRelyingParty.VerifyAssertion(challenge, credential.PublicKey, 0, clientData, rawAuthData, signature); err != nil {
+		t.Errorf("zero counter assertion failed: %v", err)
+	}
+}
+
+func TestVerifyAssertionBadSignature(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	credential := registerTestCredential(t, authenticator)
+
+	challenge, _ := GenerateChallenge()
+	clientData := clientDataJSON(t, "webauthn.get", challenge)
+	rawAuthData, signature := authenticator.assert(t, 1, clientData)
+
+	tampered := append([]byte{}, rawAuthData...)
+	tampered[36]++
+	if _, err := testRelyingParty.VerifyAssertion(challenge, credential.PublicKey, 0, clientData, tampered, signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("tampered authenticator data: err = %v, want %v", err, ErrInvalidSignature)
+	}
+
+	otherAuthenticator := newTestAuthenticator(t)
+	_, otherSignature := otherAuthenticator.assert(t, 1, clientData)
+	if _, err := testRelyingParty.VerifyAssertion(challenge, credential.PublicKey, 0, clientData, rawAuthData, otherSignature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("signature from another key: err = %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestVerifyAssertionSignCountRegression(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	credential := registerTestCredential(t, authenticator)
+
+	challenge, _ := GenerateChallenge()
+	clientData := clientDataJSON(t, "webauthn.get", challenge)
+	for _, signCount := range []uint32{0, 9, 10} {
+		rawAuthData, signature := authenticator.assert(t, signCount, clientData)
+		if _, err := testRelyingParty.VerifyAssertion(challenge, credential.PublicKey, 10, clientData, rawAuthData, signature); !errors.Is(err, ErrSignCountRegressed) {
+			t.Errorf("sign count %d after 10: err = %v, want %v", signCount, err, ErrSignCountRegressed)
+		}
+	}
+}
+
+func TestDecodeBase64URL(t *testing.T) {
+	value := []byte{0xfb, 0xff, 0x01}
+	for _, encoded := range []string{base64.RawURLEncoding.EncodeToString(value), base64.URLEncoding.EncodeToString(value)} {
+		decoded, err := DecodeBase64URL(encoded)
+		if err != nil || string(decoded) != string(value) {
+			t.Errorf("DecodeBase64URL(%q) = %v, %v", encoded, decoded, err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package webauthn
+
+import "errors"
+
+var (
+	ErrChallengeMismatch  = errors.New("webauthn challenge mismatch")
+	ErrOriginNotAllowed   = errors.New("webauthn origin not allowed")
+	ErrInvalidCeremony    = errors.New("webauthn ceremony type mismatch")
+	ErrRelyingPartyID     = errors.New("webauthn relying party id mismatch")
+	ErrUserNotPresent     = errors.New("webauthn user presence and verification are required")
+	ErrMalformedResponse  = errors.New("malformed webauthn response")
+	ErrInvalidSignature   = errors.New("webauthn signature verification failed")
+	ErrSignCountRegressed = errors.New("webauthn signature counter did not increase")
+)
+
+// The relying party ceremonies are verified for
+type RelyingParty struct {
+	// the domain credentials are scoped to
+	ID   string
+	Name string
+	// the origins the browser is allowed to report in client data, e.g. https://gateman.io
+	Origins []string
+}
+
+// A public key credential created during a registration ceremony
+type Credential struct {
+	ID []byte
+	// the COSE_Key encoded public key as sent by the authenticator
+	PublicKey []byte
+	Algorithm int64
+	SignCount uint32
+	AAGUID    []byte
+	// the credential can be synced across devices (a multi-device passkey)
+	BackupEligible bool
+	BackedUp       bool
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+type authenticatorData struct {
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+	// only present during registration
+	aaguid              []byte
+	credentialID        []byte
+	credentialPublicKey []byte
+}
+
+const (
+	flagUserPresent            byte = 0x01
+	flagUserVerified           byte = 0x04
+	flagBackupEligible         byte = 0x08
+	flagBackedUp               byte = 0x10
+	flagAttestedCredentialData byte = 0x40
+	flagExtensionData          byte = 0x80
+)